@@ -1,15 +1,39 @@
 package main
 
 import (
+	"backend/internal/features/alerts"
+	"backend/internal/features/events"
+	"backend/internal/features/graphql"
+	"backend/internal/features/ingestion"
+	"backend/internal/features/retention"
+	"backend/internal/features/search"
 	"backend/internal/features/stockratings"
+	"backend/internal/features/stream"
+	"backend/internal/features/webhooks"
+	"backend/internal/middleware"
 	"backend/internal/repository"
 	"backend/internal/routes"
+	"backend/internal/rpc"
 	"backend/pkg/db"
+	"backend/pkg/metrics"
+	"backend/pkg/tracing"
+	"cmp"
+	"context"
+	"errors"
 	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
 func main() {
@@ -19,15 +43,107 @@ func main() {
 		log.Fatal("Error loading .env file")
 	}
 
+	// Stop on SIGINT or SIGTERM, so the servers drain and the pending spans are flushed
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Configure tracing, off unless TRACING_EXPORTER is set
+	shutdownTracing, err := tracing.Setup(ctx, "backend-app")
+	if err != nil {
+		log.Fatal("Error configuring tracing: ", err)
+	}
+
 	// DEPENDENCY INJECTION ========================================================================
 	db := db.Get()
+	metrics.RegisterDBPool(db)
 	repo := repository.New(db)
 	service := stockratings.NewService(repo)
 	handler := stockratings.NewHandler(service)
+	searchService := search.NewService(repo)
+	searchHandler := search.NewHandler(searchService)
+	alertsService := alerts.NewService(repo)
+	alertsHandler := alerts.NewHandler(alertsService)
+	webhooksService := webhooks.NewService(repo)
+	webhooksHandler := webhooks.NewHandler(webhooksService)
+	dispatcher := webhooks.NewDispatcher(repo)
+	broker := stream.NewBroker(repo)
+	streamHandler := stream.NewHandler(broker)
+	graphqlService := graphql.NewService(repo)
+	graphqlHandler := graphql.NewHandler(graphqlService)
+	ingestionService := ingestion.NewService(repo)
+	ingestionHandler := ingestion.NewHandler(ingestionService)
+	eventsService := events.NewService(repo)
+	eventsHandler := events.NewHandler(eventsService)
+	cacheSize, err := strconv.Atoi(cmp.Or(os.Getenv("CACHE_SIZE"), "256"))
+	if err != nil {
+		log.Fatal("Invalid CACHE_SIZE: ", err)
+	}
+	cache, err := middleware.NewCache(repo, cacheSize)
+	if err != nil {
+		log.Fatal("Error creating the response cache: ", err)
+	}
+	rateLimits, err := middleware.ParseRateLimits(os.Getenv("RATE_LIMITS"))
+	if err != nil {
+		log.Fatal("Invalid RATE_LIMITS: ", err)
+	}
+	var rateLimitStore middleware.RateLimitStore
+	switch store := cmp.Or(os.Getenv("RATE_LIMIT_STORE"), "memory"); store {
+	case "memory":
+		rateLimitStore = middleware.NewMemoryRateLimitStore()
+	case "postgres":
+		rateLimitStore = middleware.NewPostgresRateLimitStore(repo)
+	default:
+		log.Fatalf("Unknown RATE_LIMIT_STORE: %s (use 'memory' or 'postgres')", store)
+	}
+	rateLimiter := middleware.NewRateLimiter(rateLimits, rateLimitStore, middleware.ParseAPIKeys(os.Getenv("RATE_LIMIT_API_KEYS")))
+	retentionPolicy, err := retention.ParsePolicy(os.Getenv("RETENTION_YEARS"))
+	if err != nil {
+		log.Fatal("Invalid RETENTION_YEARS: ", err)
+	}
+	retentionInterval, err := time.ParseDuration(cmp.Or(os.Getenv("RETENTION_INTERVAL"), "24h"))
+	if err != nil {
+		log.Fatal("Invalid RETENTION_INTERVAL: ", err)
+	}
+	retentionService := retention.NewService(repo, retention.DefaultBatchSize)
+
+	// Send the webhook deliveries queued by the loader
+	go dispatcher.Run(ctx)
+	// Push the ratings changed by each load to the stream subscribers
+	go broker.Run(ctx)
+	// Roll the history past the retention into monthly summaries
+	if retentionPolicy.Enabled() {
+		go retentionService.Run(ctx, retentionPolicy, retentionInterval)
+	}
+
+	// Start the gRPC server, next to the HTTP one
+	grpcServer := rpc.NewServer(rpc.Services{
+		StockRatings:       stockratings.NewGRPCServer(service),
+		StockRatingsStream: stream.NewGRPCServer(broker),
+	})
+	grpcListener, err := net.Listen("tcp", ":"+cmp.Or(os.Getenv("GRPC_PORT"), "5001"))
+	if err != nil {
+		log.Fatal("Error listening for gRPC: ", err)
+	}
+	go func() {
+		if err := grpcServer.Serve(grpcListener); err != nil {
+			log.Fatal("Error serving gRPC: ", err)
+		}
+	}()
 
 	// Start the server
 	router := gin.Default()
+	// Only the listed proxies are trusted with X-Forwarded-For, the rate limit keys on the client IP
+	var trustedProxies []string
+	if proxies := os.Getenv("TRUSTED_PROXIES"); proxies != "" {
+		trustedProxies = strings.Split(proxies, ",")
+	}
+	if err := router.SetTrustedProxies(trustedProxies); err != nil {
+		log.Fatal("Invalid TRUSTED_PROXIES: ", err)
+	}
 	router.Use(cors.Default()) // All origins allowed
+	router.Use(metrics.GinMiddleware())
+	router.Use(otelgin.Middleware("backend-app"))
+	router.Use(rateLimiter.Middleware())
 	// router.Use(cors.New(cors.Config{
 	// 	// AllowOrigins: []string{"http://localhost:5173"},
 	// 	AllowOrigins: []string{"*"},
@@ -46,8 +162,50 @@ func main() {
 	// 	AllowCredentials: false,
 	// 	// MaxAge:           12 * time.Hour,
 	// }))
-	routes.GetRoutes(router, handler)
-	router.Run(":5000")
+	routes.GetRoutes(router, routes.Handlers{
+		StockRatings: handler,
+		Search:       searchHandler,
+		Alerts:       alertsHandler,
+		Webhooks:     webhooksHandler,
+		Stream:       streamHandler,
+		GraphQL:      graphqlHandler,
+		Ingestion:    ingestionHandler,
+		Events:       eventsHandler,
+		Dataset:      gin.HandlersChain{cache.Middleware()},
+	})
+	server := &http.Server{Addr: ":5000", Handler: router}
+	go func() {
+		if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal("Error serving HTTP: ", err)
+		}
+	}()
+
+	// Shut down gracefully once signaled, flushing the spans of the last requests
+	<-ctx.Done()
+	log.Println("Shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Println("Error shutting down HTTP: ", err)
+	}
+	// The RPCs still running past the deadline are cut, a stream may never end on its own
+	grpcStopped := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(grpcStopped)
+	}()
+	select {
+	case <-grpcStopped:
+	case <-shutdownCtx.Done():
+		log.Println("Error shutting down gRPC: ", shutdownCtx.Err())
+		grpcServer.Stop()
+	}
+	// Own deadline, the spans are flushed even when the servers used all of theirs
+	flushCtx, cancelFlush := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelFlush()
+	if err := shutdownTracing(flushCtx); err != nil {
+		log.Println("Error flushing the spans: ", err)
+	}
 
 	// data, err := repo.GetStockRatings(
 	// 	context.Background(),
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"backend/internal/fakeapi"
+	"cmp"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+)
+
+// Fake of the upstream ratings API, for running the loader offline:
+//
+//	go run ./cmd/fakeapi --generate 500 --page-size 20
+//	go run ./cmd/fakeapi --fixtures ratings.json
+//	go run ./cmd/fakeapi --fixtures events.ndjson
+//
+// Point DATA_HOST at http://localhost:8081/ and DATA_TOKEN at the token of --token. Faults are
+// injected while it runs:
+//
+//	curl -X POST 'localhost:8081/faults?kind=429&page=2&retry_after=5s'
+//	curl -X POST 'localhost:8081/faults?kind=truncated&times=3'
+//	curl -X DELETE localhost:8081/faults
+//
+// The kinds are 500, 429, slow (with delay), truncated, unknown_rating and duplicate.
+func main() {
+	port := flag.String("port", cmp.Or(os.Getenv("FAKEAPI_PORT"), "8081"), "port to listen on")
+	token := flag.String("token", cmp.Or(os.Getenv("FAKEAPI_TOKEN"), "token"), "bearer token the requests must carry")
+	fixtures := flag.String("fixtures", "", "JSON file of the events to serve, an array, a page of the API or the ndjson of cmd/seed")
+	generate := flag.Int("generate", 200, "number of events to generate without --fixtures")
+	seed := flag.Uint64("seed", 1, "seed of the generated events")
+	pageSize := flag.Int("page-size", 20, "events per page")
+	flag.Parse()
+
+	events := fakeapi.Generate(*generate, *seed)
+	if *fixtures != "" {
+		var err error
+		events, err = fakeapi.LoadFixtures(*fixtures)
+		if err != nil {
+			log.Fatal("Error loading fixtures: ", err)
+		}
+	}
+	api := fakeapi.New(*token, events, *pageSize)
+
+	mux := http.NewServeMux()
+	mux.Handle("/faults", api.FaultsHandler())
+	mux.Handle("/", api)
+
+	log.Printf("Serving %d events on :%s", len(events), *port)
+	log.Fatal(http.ListenAndServe(":"+*port, mux))
+}
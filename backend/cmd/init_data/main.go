@@ -1,10 +1,16 @@
 package main
 
 import (
+	"backend/internal/features/alerts"
 	"backend/internal/features/stockratings"
+	"backend/internal/features/webhooks"
 	"backend/internal/repository"
 	"backend/pkg/db"
+	"backend/pkg/metrics"
+	"backend/pkg/tracing"
+	"context"
 	"log"
+	"os"
 
 	"github.com/joho/godotenv"
 )
@@ -17,13 +23,33 @@ func main() {
 		log.Fatal("Error loading .env file")
 	}
 
+	// Configure tracing, off unless TRACING_EXPORTER is set
+	shutdownTracing, err := tracing.Setup(context.Background(), "backend-init-data")
+	if err != nil {
+		log.Fatal("Error configuring tracing: ", err)
+	}
+
 	// DEPENDENCY INJECTION ========================================================================
 	db := db.Get()
 	repo := repository.New(db)
 	initializer := stockratings.NewLoaderService(repo)
+	initializer.AddHook(alerts.NewEvaluator(repo))
+	initializer.AddHook(webhooks.NewEnqueuer(repo))
 
 	// INITIALIZE THE DATA =========================================================================
 	err = initializer.InitData()
+
+	// Flush the spans before exiting
+	if err := shutdownTracing(context.Background()); err != nil {
+		log.Println("Error flushing traces", err)
+	}
+
+	// Push the loader metrics, this process ends before any scrape
+	if url := os.Getenv("METRICS_PUSHGATEWAY_URL"); url != "" {
+		if err := metrics.Push(url, "init_data"); err != nil {
+			log.Println("Error pushing metrics", err)
+		}
+	}
 	if err != nil {
 		log.Fatal("Error initializing stock data from API", err)
 	}
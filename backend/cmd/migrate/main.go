@@ -1,22 +1,55 @@
 package main
 
 import (
+	_ "backend/internal/datamigrations"
 	"backend/pkg/db"
-	"log"
+	"errors"
+	"flag"
+	"fmt"
 	"os"
 
 	"github.com/joho/godotenv"
 )
 
+// Exit codes, for the scripts running the migrations
+const (
+	exitFailure = 1
+	exitUsage   = 2
+	exitDirty   = 3
+)
+
+// Run the embedded migrations, from any directory:
+//
+//	go run ./cmd/migrate [--dry-run] <command> [args]
+//
+// With --dry-run the SQL of the migrations the command would run is printed, nothing is applied.
+// The data migrations of internal/datamigrations run after the SQL migration of their version.
 func main() {
-	err := godotenv.Load()
-	if err != nil {
-		log.Fatal("Error loading .env file")
+	dryRun := flag.Bool("dry-run", false, "print the SQL of the pending migrations without running them")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "Usage: go run ./cmd/migrate [--dry-run] <command> [args]")
+		flag.PrintDefaults()
 	}
-	if len(os.Args) < 2 {
-		log.Fatal("Usage: go run ./cmd/migrate [up|down]")
+	flag.Parse()
+
+	// The environment may come from the shell instead of the .env file
+	if err := godotenv.Load(); err != nil && !errors.Is(err, os.ErrNotExist) {
+		fmt.Fprintln(os.Stderr, "Error loading .env file:", err)
+		os.Exit(exitFailure)
 	}
 
-	direction := os.Args[1]
-	db.RunMigrations(direction)
+	err := db.RunMigrations(os.Stdout, flag.Args(), *dryRun)
+	switch {
+	case err == nil:
+	case errors.Is(err, db.ErrMigrationUsage):
+		fmt.Fprintln(os.Stderr, err)
+		flag.Usage()
+		os.Exit(exitUsage)
+	case errors.Is(err, db.ErrDirtyDatabase):
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(exitDirty)
+	default:
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(exitFailure)
+	}
 }
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"backend/internal/features/stockratings"
+	"backend/internal/repository"
+	"backend/pkg/db"
+	"backend/pkg/metrics"
+	"backend/pkg/tracing"
+	"context"
+	"log"
+	"os"
+
+	"github.com/google/uuid"
+	"github.com/joho/godotenv"
+)
+
+// Rebuild the stock ratings from the archived API pages, without calling the API:
+//
+//	go run ./cmd/replay [run-id]
+//
+// The latest successful API run is replayed when no run ID is given.
+func main() {
+
+	// Read environment variables
+	err := godotenv.Load()
+	if err != nil {
+		log.Fatal("Error loading .env file")
+	}
+
+	var runID uuid.UUID
+	if len(os.Args) > 1 {
+		runID, err = uuid.Parse(os.Args[1])
+		if err != nil {
+			log.Fatal("Usage: go run ./cmd/replay [run-id]: ", err)
+		}
+	}
+
+	// Configure tracing, off unless TRACING_EXPORTER is set
+	shutdownTracing, err := tracing.Setup(context.Background(), "backend-replay")
+	if err != nil {
+		log.Fatal("Error configuring tracing: ", err)
+	}
+
+	// DEPENDENCY INJECTION ========================================================================
+	db := db.Get()
+	repo := repository.New(db)
+	initializer := stockratings.NewLoaderService(repo)
+
+	// REPLAY THE ARCHIVE ==========================================================================
+	err = initializer.Replay(runID)
+
+	// Flush the spans before exiting
+	if err := shutdownTracing(context.Background()); err != nil {
+		log.Println("Error flushing traces", err)
+	}
+
+	// Push the loader metrics, this process ends before any scrape
+	if url := os.Getenv("METRICS_PUSHGATEWAY_URL"); url != "" {
+		if err := metrics.Push(url, "replay"); err != nil {
+			log.Println("Error pushing metrics", err)
+		}
+	}
+	if err != nil {
+		log.Fatal("Error replaying the archived stock data", err)
+	}
+}
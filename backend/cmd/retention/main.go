@@ -0,0 +1,72 @@
+package main
+
+import (
+	"backend/internal/features/retention"
+	"backend/internal/repository"
+	"backend/pkg/db"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+// Roll the events of the history past the retention into monthly summaries:
+//
+//	go run ./cmd/retention --dry-run
+//	go run ./cmd/retention --years 3
+//	go run ./cmd/retention --before 2022-01-01
+//
+// The years default to RETENTION_YEARS. The dry run reports the events each month would lose and
+// the tickers it would be summarized for, without changing anything.
+func main() {
+	// The environment may come from the shell instead of the .env file
+	_ = godotenv.Load()
+
+	policy, err := retention.ParsePolicy(os.Getenv("RETENTION_YEARS"))
+	if err != nil {
+		log.Fatal("Invalid RETENTION_YEARS: ", err)
+	}
+	flag.IntVar(&policy.Years, "years", policy.Years, "years of detailed history to keep")
+	before := flag.String("before", "", "roll up the events before this date, YYYY-MM-DD, instead of the years")
+	dryRun := flag.Bool("dry-run", false, "report what would be rolled up without changing anything")
+	batch := flag.Int("batch", retention.DefaultBatchSize, "events rolled up per statement")
+	flag.Parse()
+
+	cutoff := policy.Cutoff(time.Now())
+	switch {
+	case *before != "":
+		cutoff, err = time.Parse(time.DateOnly, *before)
+		if err != nil {
+			log.Fatal("Invalid --before: ", err)
+		}
+	case !policy.Enabled():
+		log.Fatal("No retention: set RETENTION_YEARS, --years or --before")
+	}
+
+	service := retention.NewService(repository.New(db.Get()), int32(*batch))
+	ctx := context.Background()
+
+	months, err := service.Plan(ctx, cutoff)
+	if err != nil {
+		log.Fatal("Error planning the retention: ", err)
+	}
+	var events int64
+	for _, m := range months {
+		fmt.Printf("%s  %8d events  %6d tickers\n", m.Month.Format("2006-01"), m.Events, m.Tickers)
+		events += m.Events
+	}
+	fmt.Printf("%d events before %s in %d months\n", events, cutoff.Format(time.DateOnly), len(months))
+	if *dryRun || events == 0 {
+		return
+	}
+
+	result, err := service.Apply(ctx, cutoff)
+	if err != nil {
+		log.Fatal("Error applying the retention: ", err)
+	}
+	fmt.Printf("✅ Rolled up %d events into %d monthly summaries, in %d batches\n", result.Events, result.Summaries, result.Batches)
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"backend/internal/features/stockratings"
+	"backend/internal/repository"
+	"backend/internal/seed"
+	"backend/pkg/db"
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+// Generate a synthetic dataset of rating events, the same for the same flags:
+//
+//	go run ./cmd/seed --seed 42 --tickers 2000
+//	go run ./cmd/seed --format ndjson --out events.ndjson
+//
+// The db format replaces the stock ratings of DATABASE_URL with the latest event of each ticker and
+// appends every event to their history, as a load of the API would. The ndjson format writes the
+// events as the API sends them, one per line, e.g. to attach them to a bug report and replay them
+// through the loader with cmd/fakeapi --fixtures.
+func main() {
+	opts := seed.DefaultOptions
+	flag.Uint64Var(&opts.Seed, "seed", opts.Seed, "seed of the generator")
+	flag.IntVar(&opts.Tickers, "tickers", opts.Tickers, "number of tickers")
+	flag.IntVar(&opts.EventsPerTicker, "events", opts.EventsPerTicker, "average number of events of a ticker")
+	flag.IntVar(&opts.Days, "days", opts.Days, "days the events are spread over")
+	until := flag.String("until", opts.Until.Format(time.DateOnly), "date of the last event, YYYY-MM-DD")
+	format := flag.String("format", "db", "output: db or ndjson")
+	out := flag.String("out", "-", "file of the ndjson output, - for stdout")
+	flag.Parse()
+
+	var err error
+	opts.Until, err = time.Parse(time.DateOnly, *until)
+	if err != nil {
+		log.Fatal("Invalid --until: ", err)
+	}
+	events := seed.Generate(opts)
+	log.Println("Generated", seed.Describe(events))
+
+	switch *format {
+	case "db":
+		// The environment may come from the shell instead of the .env file
+		_ = godotenv.Load()
+		err = writeDB(context.Background(), repository.New(db.Get()), events)
+	case "ndjson":
+		err = writeNDJSON(*out, events)
+	default:
+		log.Fatalf("Invalid --format %q, use db or ndjson", *format)
+	}
+	if err != nil {
+		log.Fatal("Error writing the dataset: ", err)
+	}
+}
+
+// Events appended to the history per statement, the arrays of a statement are sent at once
+const eventBatchSize = 1000
+
+func writeDB(ctx context.Context, repo repository.Repository, events []stockratings.RawStockEvent) error {
+	rows, err := stockratings.Normalize(events)
+	if err != nil {
+		return err
+	}
+	if err := repo.ClearStockRating(ctx); err != nil {
+		return fmt.Errorf("clearing the stock ratings: %w", err)
+	}
+	latest := seed.Latest(rows)
+	if _, err := repo.AddStockRatings(ctx, latest); err != nil {
+		return fmt.Errorf("inserting the stock ratings: %w", err)
+	}
+	var appended int64
+	for start := 0; start < len(rows); start += eventBatchSize {
+		n, err := repo.AddStockRatingEvents(ctx, stockratings.NewStockRatingEvents(rows[start:min(start+eventBatchSize, len(rows))]))
+		if err != nil {
+			return fmt.Errorf("appending the history: %w", err)
+		}
+		appended += n
+	}
+	// Invalidate the HTTP caches of the previous data
+	version, err := repo.BumpDatasetVersion(ctx)
+	if err != nil {
+		return fmt.Errorf("bumping the dataset version: %w", err)
+	}
+	log.Printf("Inserted %d stock ratings and %d history events, dataset version %d", len(latest), appended, version.Version)
+	return nil
+}
+
+func writeNDJSON(path string, events []stockratings.RawStockEvent) error {
+	var w io.Writer = os.Stdout
+	if path != "-" {
+		f, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	buf := bufio.NewWriter(w)
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	for _, e := range events {
+		if err := enc.Encode(e); err != nil {
+			return err
+		}
+	}
+	return buf.Flush()
+}
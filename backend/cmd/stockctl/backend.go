@@ -0,0 +1,208 @@
+package main
+
+import (
+	"backend/internal/features/ingestion"
+	"backend/internal/features/stockratings"
+	"backend/internal/repository"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// BACKENDS ========================================================================================
+// The commands read the same types through the API or from the database, the responses of the
+// handlers
+
+type listOptions struct {
+	sortBy    string
+	sortOrder string
+	offset    int
+	limit     int
+	ticker    string
+	company   string
+}
+
+type exportOptions struct {
+	listOptions
+	format  string
+	columns string
+}
+
+type backend interface {
+	list(ctx context.Context, opts listOptions) ([]stockratings.GetStockRatingsV2Response, error)
+	show(ctx context.Context, ticker string) (stockratings.GetStockRatingsV2Response, error)
+	export(ctx context.Context, opts exportOptions, w io.Writer) error
+	runs(ctx context.Context, limit int) ([]ingestion.RunResponse, error)
+}
+
+var errNotFound = errors.New("Stock rating not found")
+
+// API ---------------------------------------------------------------------------------------------
+type apiBackend struct {
+	baseURL string
+	client  *http.Client
+}
+
+func newAPIBackend(baseURL string) *apiBackend {
+	return &apiBackend{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		// No timeout for the exports, which stream the whole table
+		client: &http.Client{},
+	}
+}
+
+func (o listOptions) query() url.Values {
+	query := url.Values{}
+	query.Set("sort_by", o.sortBy)
+	query.Set("sort_order", o.sortOrder)
+	query.Set("ticker_like", o.ticker)
+	query.Set("company_like", o.company)
+	return query
+}
+
+// GET a path of the API, returning the body of a successful response. Errors of the API are
+// reported with their message.
+func (b *apiBackend) get(ctx context.Context, path string, query url.Values) (io.ReadCloser, error) {
+	target := b.baseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := b.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode == http.StatusOK {
+		return res.Body, nil
+	}
+	defer res.Body.Close()
+	var apiErr stockratings.ErrorResponse
+	if err := json.NewDecoder(res.Body).Decode(&apiErr); err != nil || apiErr.Error == "" {
+		return nil, fmt.Errorf("%s: %s", target, res.Status)
+	}
+	if res.StatusCode == http.StatusNotFound {
+		return nil, errNotFound
+	}
+	return nil, fmt.Errorf("%s (%s)", apiErr.Error, res.Status)
+}
+
+func (b *apiBackend) getJSON(ctx context.Context, path string, query url.Values, out any) error {
+	body, err := b.get(ctx, path, query)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+	return json.NewDecoder(body).Decode(out)
+}
+
+func (b *apiBackend) list(ctx context.Context, opts listOptions) ([]stockratings.GetStockRatingsV2Response, error) {
+	query := opts.query()
+	query.Set("offset", strconv.Itoa(opts.offset))
+	query.Set("limit", strconv.Itoa(opts.limit))
+	var out stockratings.GetStockRatingsV2ListResponse
+	err := b.getJSON(ctx, "/v2/stock_ratings/", query, &out)
+	return out.Ratings, err
+}
+
+func (b *apiBackend) show(ctx context.Context, ticker string) (stockratings.GetStockRatingsV2Response, error) {
+	var out stockratings.GetStockRatingsV2Response
+	err := b.getJSON(ctx, "/v2/stock_ratings/"+url.PathEscape(ticker), nil, &out)
+	return out, err
+}
+
+func (b *apiBackend) export(ctx context.Context, opts exportOptions, w io.Writer) error {
+	query := opts.query()
+	query.Set("format", opts.format)
+	query.Set("columns", opts.columns)
+	body, err := b.get(ctx, "/v1/stock_ratings/export", query)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+	_, err = io.Copy(w, body)
+	return err
+}
+
+func (b *apiBackend) runs(ctx context.Context, limit int) ([]ingestion.RunResponse, error) {
+	var out ingestion.RunListResponse
+	err := b.getJSON(ctx, "/v1/ingestion/runs/", url.Values{"limit": {strconv.Itoa(limit)}}, &out)
+	return out.Runs, err
+}
+
+// Database ----------------------------------------------------------------------------------------
+// Reads the tables with the queries of the API, for when the API isn't running
+type dbBackend struct {
+	repo *repository.Queries
+}
+
+func (o listOptions) params() repository.GetStockRatingsParams {
+	return repository.GetStockRatingsParams{
+		SortOrder:   o.sortOrder,
+		SortBy:      o.sortBy,
+		Offset:      int32(o.offset),
+		Limit:       int32(o.limit),
+		TickerLike:  o.ticker,
+		CompanyLike: o.company,
+	}
+}
+
+func (b *dbBackend) list(ctx context.Context, opts listOptions) ([]stockratings.GetStockRatingsV2Response, error) {
+	rows, err := b.repo.GetStockRatings(ctx, opts.params())
+	if err != nil {
+		return nil, err
+	}
+	out := make([]stockratings.GetStockRatingsV2Response, len(rows))
+	for i, r := range rows {
+		out[i] = stockratings.NewStockRatingV2Response(r)
+	}
+	return out, nil
+}
+
+func (b *dbBackend) show(ctx context.Context, ticker string) (stockratings.GetStockRatingsV2Response, error) {
+	row, err := b.repo.GetStockRating(ctx, ticker)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return stockratings.GetStockRatingsV2Response{}, errNotFound
+	}
+	if err != nil {
+		return stockratings.GetStockRatingsV2Response{}, err
+	}
+	return stockratings.NewStockRatingV2Response(repository.GetStockRatingsRow(row)), nil
+}
+
+func (b *dbBackend) export(ctx context.Context, opts exportOptions, w io.Writer) error {
+	return stockratings.WriteExport(w, opts.format, opts.columns, func(row func(repository.GetStockRatingsRow) error) error {
+		return b.repo.StreamStockRatings(ctx, opts.params(), row)
+	})
+}
+
+func (b *dbBackend) runs(ctx context.Context, limit int) ([]ingestion.RunResponse, error) {
+	rows, err := b.repo.ListIngestionRuns(ctx, int32(limit))
+	if err != nil {
+		return nil, err
+	}
+	out := make([]ingestion.RunResponse, len(rows))
+	for i, r := range rows {
+		out[i] = ingestion.NewRunResponse(r)
+	}
+	return out, nil
+}
+
+// Format a time in the local zone of the terminal, empty for the zero time
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Local().Format("2006-01-02 15:04:05")
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"backend/internal/repository"
+	"backend/pkg/db"
+	"cmp"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/joho/godotenv"
+)
+
+// Command-line client of the stock ratings, through the HTTP API or straight from the database:
+//
+//	go run ./cmd/stockctl list --sort score --limit 20 --ticker AAP
+//	go run ./cmd/stockctl show AAPL
+//	go run ./cmd/stockctl export --format csv --out ratings.csv
+//	go run ./cmd/stockctl ingest status
+//
+// The API is read from --api or STOCKCTL_API_URL, --direct-db reads the database of DATABASE_URL
+// instead. Results are printed as a table, JSON or CSV with --output.
+const usage = `Usage: stockctl <command> [flags]
+
+Commands:
+  list           List the stock ratings
+  show TICKER    Show the latest rating of a ticker
+  export         Export the stock ratings as csv, ndjson or xlsx
+  ingest status  List the latest ingestion runs
+
+Run stockctl <command> -h for the flags of a command.
+`
+
+// Exit codes, 2 for invalid usage as the flag package does
+const (
+	exitFailure = 1
+	exitUsage   = 2
+)
+
+type command struct {
+	flags    *flag.FlagSet
+	api      string
+	directDB bool
+	output   string
+}
+
+func newCommand(name string) *command {
+	c := &command{flags: flag.NewFlagSet(name, flag.ContinueOnError)}
+	c.flags.StringVar(&c.api, "api", cmp.Or(os.Getenv("STOCKCTL_API_URL"), "http://localhost:5000"), "base URL of the API")
+	c.flags.BoolVar(&c.directDB, "direct-db", false, "read the database of DATABASE_URL instead of the API")
+	c.flags.StringVar(&c.output, "output", "table", "output format: table, json or csv")
+	c.flags.StringVar(&c.output, "o", "table", "shorthand for --output")
+	return c
+}
+
+// Parse the flags, keeping the positional arguments wherever they are
+func (c *command) parse(args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := c.flags.Parse(args); err != nil {
+			return nil, err
+		}
+		args = c.flags.Args()
+		if len(args) == 0 {
+			break
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+	if !slices.Contains(outputs, c.output) {
+		return nil, fmt.Errorf("invalid output %q, use %s", c.output, strings.Join(outputs, ", "))
+	}
+	return positional, nil
+}
+
+func (c *command) backend() backend {
+	if c.directDB {
+		return &dbBackend{repo: repository.New(db.Get())}
+	}
+	return newAPIBackend(c.api)
+}
+
+func (c *command) addListFlags(opts *listOptions) {
+	c.flags.StringVar(&opts.sortBy, "sort", "score", "sort by ticker, company, brokerage, target_delta, at or score")
+	c.flags.StringVar(&opts.sortOrder, "order", "desc", "sort order: asc or desc")
+	c.flags.StringVar(&opts.ticker, "ticker", "", "filter by ticker")
+	c.flags.StringVar(&opts.company, "company", "", "filter by company")
+}
+
+func main() {
+	// The .env file is optional, the API mode only needs the flags
+	_ = godotenv.Load()
+
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(exitUsage)
+	}
+
+	err := run(context.Background(), os.Args[1], os.Args[2:], os.Stdout)
+	var usageErr usageError
+	switch {
+	case err == nil:
+	case errors.Is(err, flag.ErrHelp):
+		os.Exit(exitUsage)
+	case errors.As(err, &usageErr):
+		fmt.Fprintln(os.Stderr, "stockctl:", err)
+		os.Exit(exitUsage)
+	default:
+		fmt.Fprintln(os.Stderr, "stockctl:", err)
+		os.Exit(exitFailure)
+	}
+}
+
+// Errors in the command line, as opposed to the failures of the command
+type usageError struct{ error }
+
+func (e usageError) Unwrap() error { return e.error }
+
+func run(ctx context.Context, name string, args []string, w io.Writer) error {
+	c := newCommand(name)
+	switch name {
+	case "list":
+		var opts listOptions
+		c.addListFlags(&opts)
+		c.flags.IntVar(&opts.limit, "limit", 10, "number of ratings")
+		c.flags.IntVar(&opts.offset, "offset", 0, "number of ratings to skip")
+		if _, err := c.parse(args); err != nil {
+			return usageError{err}
+		}
+		ratings, err := c.backend().list(ctx, opts)
+		if err != nil {
+			return err
+		}
+		return writeRatings(w, c.output, ratings)
+
+	case "show":
+		positional, err := c.parse(args)
+		if err != nil {
+			return usageError{err}
+		}
+		if len(positional) != 1 {
+			return usageError{errors.New("usage: stockctl show TICKER")}
+		}
+		r, err := c.backend().show(ctx, strings.ToUpper(positional[0]))
+		if err != nil {
+			return err
+		}
+		return writeRating(w, c.output, r)
+
+	case "export":
+		var opts exportOptions
+		var out string
+		c.addListFlags(&opts.listOptions)
+		c.flags.StringVar(&opts.format, "format", "csv", "export format: csv, ndjson or xlsx")
+		c.flags.StringVar(&opts.columns, "columns", "", "comma separated columns, all by default")
+		c.flags.StringVar(&out, "out", "", "file to write, stdout by default")
+		if _, err := c.parse(args); err != nil {
+			return usageError{err}
+		}
+		if out == "" {
+			return c.backend().export(ctx, opts, w)
+		}
+		f, err := os.Create(out)
+		if err != nil {
+			return err
+		}
+		if err := c.backend().export(ctx, opts, f); err != nil {
+			f.Close()
+			os.Remove(out)
+			return err
+		}
+		return f.Close()
+
+	case "ingest":
+		var limit int
+		c.flags.IntVar(&limit, "limit", 10, "number of runs")
+		positional, err := c.parse(args)
+		if err != nil {
+			return usageError{err}
+		}
+		if len(positional) != 1 || positional[0] != "status" {
+			return usageError{errors.New("usage: stockctl ingest status")}
+		}
+		runs, err := c.backend().runs(ctx, limit)
+		if err != nil {
+			return err
+		}
+		return writeRuns(w, c.output, runs)
+
+	case "-h", "--help", "help":
+		fmt.Fprint(w, usage)
+		return nil
+
+	default:
+		return usageError{fmt.Errorf("unknown command %q\n\n%s", name, usage)}
+	}
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"backend/internal/features/ingestion"
+	"backend/internal/features/stockratings"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+var aapl = stockratings.GetStockRatingsV2Response{
+	Ticker:         "AAPL",
+	Company:        "Apple Inc.",
+	Brokerage:      "Goldman Sachs",
+	TargetFrom:     "150.00",
+	TargetTo:       "180.00",
+	TargetDelta:    "30.00",
+	TargetDeltaPct: "20.00",
+	Action:         "up",
+	RatingFrom:     "hold",
+	RatingTo:       "buy",
+	At:             time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+	Score:          5000,
+}
+
+// API answering the requests of the commands, recording the query of the last one
+func newAPI(t *testing.T) (*httptest.Server, *url.Values) {
+	t.Helper()
+	var query url.Values
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v2/stock_ratings/", func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		json.NewEncoder(w).Encode(stockratings.GetStockRatingsV2ListResponse{Length: 1, Ratings: []stockratings.GetStockRatingsV2Response{aapl}})
+	})
+	mux.HandleFunc("GET /v2/stock_ratings/{ticker}", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("ticker") != aapl.Ticker {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(stockratings.ErrorResponse{Error: "Stock rating not found"})
+			return
+		}
+		json.NewEncoder(w).Encode(aapl)
+	})
+	mux.HandleFunc("GET /v1/stock_ratings/export", func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		if r.URL.Query().Get("format") == "pdf" {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(stockratings.ErrorResponse{Error: "Invalid format"})
+			return
+		}
+		w.Write([]byte("ticker\nAAPL\n"))
+	})
+	mux.HandleFunc("GET /v1/ingestion/runs/", func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		json.NewEncoder(w).Encode(ingestion.RunListResponse{Length: 1, Runs: []ingestion.RunResponse{{
+			ID: "run-1", Source: "api", Status: "running", StartedAt: aapl.At, Pages: 3,
+		}}})
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server, &query
+}
+
+func TestRunParsesTheArguments(t *testing.T) {
+	api, query := newAPI(t)
+	cases := []struct {
+		name      string
+		args      []string
+		wantQuery url.Values
+	}{
+		{
+			name:      "list with the defaults",
+			args:      []string{"list"},
+			wantQuery: url.Values{"sort_by": {"score"}, "sort_order": {"desc"}, "offset": {"0"}, "limit": {"10"}, "ticker_like": {""}, "company_like": {""}},
+		},
+		{
+			name:      "list with flags",
+			args:      []string{"list", "--sort", "ticker", "--order", "asc", "--limit", "20", "--offset", "5", "--ticker", "AA", "--company", "inc"},
+			wantQuery: url.Values{"sort_by": {"ticker"}, "sort_order": {"asc"}, "offset": {"5"}, "limit": {"20"}, "ticker_like": {"AA"}, "company_like": {"inc"}},
+		},
+		{
+			name:      "export",
+			args:      []string{"export", "--format", "ndjson", "--columns", "ticker,score"},
+			wantQuery: url.Values{"sort_by": {"score"}, "sort_order": {"desc"}, "format": {"ndjson"}, "columns": {"ticker,score"}, "ticker_like": {""}, "company_like": {""}},
+		},
+		{
+			name:      "ingest status with the flags after the subcommand",
+			args:      []string{"ingest", "status", "--limit", "3"},
+			wantQuery: url.Values{"limit": {"3"}},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			*query = nil
+			args := append([]string{"--api", api.URL}, tc.args[1:]...)
+			if err := run(context.Background(), tc.args[0], args, &bytes.Buffer{}); err != nil {
+				t.Fatalf("run(%v) error = %v", tc.args, err)
+			}
+			for key, want := range tc.wantQuery {
+				if got := (*query)[key]; !slices.Equal(got, want) {
+					t.Errorf("run(%v) query %s = %v, want %v", tc.args, key, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestRunRejectsInvalidUsage(t *testing.T) {
+	api, _ := newAPI(t)
+	cases := []struct {
+		name string
+		args []string
+	}{
+		{"unknown command", []string{"delete"}},
+		{"unknown flag", []string{"list", "--nope"}},
+		{"invalid output", []string{"list", "-o", "yaml"}},
+		{"show without a ticker", []string{"show"}},
+		{"show with two tickers", []string{"show", "AAPL", "MSFT"}},
+		{"ingest without status", []string{"ingest"}},
+		{"ingest with another subcommand", []string{"ingest", "start"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			args := append([]string{"--api", api.URL}, tc.args[1:]...)
+			err := run(context.Background(), tc.args[0], args, &bytes.Buffer{})
+			var usageErr usageError
+			if !errors.As(err, &usageErr) {
+				t.Errorf("run(%v) error = %v, want a usage error", tc.args, err)
+			}
+		})
+	}
+	if err := run(context.Background(), "list", []string{"-h"}, &bytes.Buffer{}); !errors.Is(err, flag.ErrHelp) {
+		t.Errorf("run(list -h) error = %v, want %v", err, flag.ErrHelp)
+	}
+}
+
+func TestRunReportsTheErrorsOfTheAPI(t *testing.T) {
+	api, _ := newAPI(t)
+	err := run(context.Background(), "show", []string{"NOPE", "--api", api.URL}, &bytes.Buffer{})
+	if !errors.Is(err, errNotFound) {
+		t.Errorf("run(show NOPE) error = %v, want %v", err, errNotFound)
+	}
+	err = run(context.Background(), "export", []string{"--api", api.URL, "--format", "pdf"}, &bytes.Buffer{})
+	var usageErr usageError
+	if err == nil || errors.As(err, &usageErr) || !strings.Contains(err.Error(), "Invalid format") {
+		t.Errorf("run(export --format pdf) error = %v, want the error of the API", err)
+	}
+}
+
+func TestRunWritesTheOutputFormats(t *testing.T) {
+	api, _ := newAPI(t)
+	output := func(args ...string) string {
+		t.Helper()
+		var buf bytes.Buffer
+		if err := run(context.Background(), args[0], append(args[1:], "--api", api.URL), &buf); err != nil {
+			t.Fatalf("run(%v) error = %v", args, err)
+		}
+		return buf.String()
+	}
+	at := formatTime(aapl.At)
+
+	// The show command takes its ticker in any case, before or after the flags
+	for _, args := range [][]string{{"show", "aapl"}, {"show", "-o", "table", "AAPL"}} {
+		if got := output(args...); !strings.Contains(got, "TICKER            AAPL\n") || !strings.Contains(got, "AT                "+at+"\n") {
+			t.Errorf("run(%v) = %q, want a field per line", args, got)
+		}
+	}
+
+	table := strings.Split(strings.TrimSuffix(output("list"), "\n"), "\n")
+	if len(table) != 2 || !strings.HasPrefix(table[0], "TICKER  COMPANY     BROKERAGE") || !strings.HasPrefix(table[1], "AAPL    Apple Inc.  Goldman Sachs") {
+		t.Errorf("run(list) = %q, want a header and a row", table)
+	}
+
+	records, err := csv.NewReader(strings.NewReader(output("list", "--output", "csv"))).ReadAll()
+	wantRecords := [][]string{
+		{"ticker", "company", "brokerage", "target_from", "target_to", "target_delta_pct", "action", "rating_from", "rating_to", "at", "score"},
+		{"AAPL", "Apple Inc.", "Goldman Sachs", "150.00", "180.00", "20.00", "up", "hold", "buy", at, "5000"},
+	}
+	if err != nil || !slices.EqualFunc(records, wantRecords, slices.Equal) {
+		t.Errorf("run(list --output csv) = %q, %v, want %q", records, err, wantRecords)
+	}
+
+	var ratings []stockratings.GetStockRatingsV2Response
+	if err := json.Unmarshal([]byte(output("list", "-o", "json")), &ratings); err != nil || len(ratings) != 1 || ratings[0] != aapl {
+		t.Errorf("run(list -o json) = %+v, %v, want the ratings of the API", ratings, err)
+	}
+	var r stockratings.GetStockRatingsV2Response
+	if err := json.Unmarshal([]byte(output("show", "AAPL", "-o", "json")), &r); err != nil || r != aapl {
+		t.Errorf("run(show -o json) = %+v, %v, want the rating of the API", r, err)
+	}
+
+	records, err = csv.NewReader(strings.NewReader(output("ingest", "status", "-o", "csv"))).ReadAll()
+	wantRecords = [][]string{
+		{"id", "source", "status", "started_at", "finished_at", "pages", "error"},
+		{"run-1", "api", "running", at, "", "3", ""},
+	}
+	if err != nil || !slices.EqualFunc(records, wantRecords, slices.Equal) {
+		t.Errorf("run(ingest status -o csv) = %q, %v, want %q", records, err, wantRecords)
+	}
+
+	// The export is written as the API sends it
+	if got := output("export"); got != "ticker\nAAPL\n" {
+		t.Errorf("run(export) = %q, want the body of the API", got)
+	}
+}
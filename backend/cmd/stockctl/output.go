@@ -0,0 +1,99 @@
+package main
+
+import (
+	"backend/internal/features/ingestion"
+	"backend/internal/features/stockratings"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// OUTPUT ==========================================================================================
+// Tables for the terminal, JSON and CSV for the scripts
+
+var outputs = []string{"table", "json", "csv"}
+
+var ratingHeader = []string{
+	"TICKER", "COMPANY", "BROKERAGE", "TARGET FROM", "TARGET TO", "TARGET DELTA PCT", "ACTION", "RATING FROM",
+	"RATING TO", "AT", "SCORE",
+}
+
+func ratingRecord(r stockratings.GetStockRatingsV2Response) []string {
+	return []string{
+		r.Ticker, r.Company, r.Brokerage, r.TargetFrom.String(), r.TargetTo.String(),
+		r.TargetDeltaPct.String(), r.Action, r.RatingFrom, r.RatingTo, formatTime(r.At),
+		strconv.Itoa(int(r.Score)),
+	}
+}
+
+var runHeader = []string{"ID", "SOURCE", "STATUS", "STARTED AT", "FINISHED AT", "PAGES", "ERROR"}
+
+func runRecord(r ingestion.RunResponse) []string {
+	record := []string{r.ID, r.Source, r.Status, formatTime(r.StartedAt), "", strconv.FormatInt(r.Pages, 10), ""}
+	if r.FinishedAt != nil {
+		record[4] = formatTime(*r.FinishedAt)
+	}
+	if r.Error != nil {
+		record[6] = *r.Error
+	}
+	return record
+}
+
+// Write the records in the output format, the value itself for JSON
+func write(w io.Writer, output string, value any, header []string, records [][]string) error {
+	switch output {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(value)
+	case "csv":
+		cw := csv.NewWriter(w)
+		// Snake case keys, as in the exports
+		keys := make([]string, len(header))
+		for i, h := range header {
+			keys[i] = strings.ReplaceAll(strings.ToLower(h), " ", "_")
+		}
+		cw.Write(keys)
+		cw.WriteAll(records)
+		return cw.Error()
+	default:
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, strings.Join(header, "\t"))
+		for _, record := range records {
+			fmt.Fprintln(tw, strings.Join(record, "\t"))
+		}
+		return tw.Flush()
+	}
+}
+
+func writeRatings(w io.Writer, output string, ratings []stockratings.GetStockRatingsV2Response) error {
+	records := make([][]string, len(ratings))
+	for i, r := range ratings {
+		records[i] = ratingRecord(r)
+	}
+	return write(w, output, ratings, ratingHeader, records)
+}
+
+// A single rating is shown as a list of fields, easier to read than a one row table
+func writeRating(w io.Writer, output string, r stockratings.GetStockRatingsV2Response) error {
+	if output != "table" {
+		return write(w, output, r, ratingHeader, [][]string{ratingRecord(r)})
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for i, value := range ratingRecord(r) {
+		fmt.Fprintf(tw, "%s\t%s\n", ratingHeader[i], value)
+	}
+	return tw.Flush()
+}
+
+func writeRuns(w io.Writer, output string, runs []ingestion.RunResponse) error {
+	records := make([][]string, len(runs))
+	for i, r := range runs {
+		records[i] = runRecord(r)
+	}
+	return write(w, output, runs, runHeader, records)
+}
@@ -0,0 +1,87 @@
+// Migrations of the data written in Go, registered with db.RegisterDataMigration and run by the
+// migrate command after the SQL migration of their version.
+package datamigrations
+
+import (
+	"backend/internal/features/stockratings"
+	"backend/internal/repository"
+	"backend/pkg/db"
+	"context"
+	"log"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Rows processed by each batch
+const batchSize = 500
+
+func init() {
+	db.RegisterDataMigration(db.DataMigration{
+		Version: 8,
+		Name:    "renormalize_stock_rating",
+		Batch:   renormalizeStockRatings,
+	})
+	// After 0013, the events take their positions in the change feed from stock_rating_event_position
+	db.RegisterDataMigration(db.DataMigration{
+		Version: 13,
+		Name:    "backfill_stock_rating_event",
+		Batch:   backfillStockRatingEvents,
+	})
+}
+
+// Normalize the raw fields of the ratings again, with the tables of the loader, for the rows loaded
+// before their last change. The raw values the loader doesn't know anymore are left as they are.
+func renormalizeStockRatings(ctx context.Context, tx pgx.Tx, cursor string) (string, error) {
+	repo := repository.New(tx)
+	rows, err := repo.ListStockRatingsAfter(ctx, repository.ListStockRatingsAfterParams{After: cursor, Lim: batchSize})
+	if err != nil || len(rows) == 0 {
+		return "", err
+	}
+
+	for _, r := range rows {
+		action, err := stockratings.RawActionToStockAction(r.RawAction)
+		if err != nil {
+			log.Println("Keeping the action of ", r.Ticker, ": ", err)
+			action = r.Action
+		}
+		ratingFrom, err := stockratings.RawRatingToStockRating(r.RawRatingFrom)
+		if err != nil {
+			ratingFrom = r.RatingFrom
+		}
+		ratingTo, err := stockratings.RawRatingToStockRating(r.RawRatingTo)
+		if err != nil {
+			ratingTo = r.RatingTo
+		}
+		if action == r.Action && ratingFrom == r.RatingFrom && ratingTo == r.RatingTo {
+			continue
+		}
+		err = repo.UpdateStockRatingNormalization(ctx, repository.UpdateStockRatingNormalizationParams{
+			Ticker:     r.Ticker,
+			Action:     action,
+			RatingFrom: ratingFrom,
+			RatingTo:   ratingTo,
+		})
+		if err != nil {
+			return "", err
+		}
+	}
+	return rows[len(rows)-1].Ticker, nil
+}
+
+// Split the ratings loaded before the history into its first events, recorded when they were loaded
+func backfillStockRatingEvents(ctx context.Context, tx pgx.Tx, cursor string) (string, error) {
+	repo := repository.New(tx)
+	rows, err := repo.ListStockRatingsAfter(ctx, repository.ListStockRatingsAfterParams{After: cursor, Lim: batchSize})
+	if err != nil || len(rows) == 0 {
+		return "", err
+	}
+
+	tickers := make([]string, len(rows))
+	for i, r := range rows {
+		tickers[i] = r.Ticker
+	}
+	if _, err := repo.BackfillStockRatingEvents(ctx, tickers); err != nil {
+		return "", err
+	}
+	return rows[len(rows)-1].Ticker, nil
+}
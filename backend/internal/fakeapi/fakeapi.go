@@ -0,0 +1,187 @@
+// Fake of the upstream ratings API, serving pages of events from fixtures or a generator, for the
+// tests of the loader and for running it locally with DATA_HOST pointing at cmd/fakeapi. The events
+// are served in pages linked by their next_page cursor as the API does, and faults are injected on
+// demand to reach every error path of the loader.
+package fakeapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// TYPES ===========================================================================================
+// The payloads of the API, kept apart from the types of the loader so they can't drift together
+
+type Event struct {
+	Ticker     string `json:"ticker"`
+	TargetFrom string `json:"target_from"`
+	TargetTo   string `json:"target_to"`
+	Company    string `json:"company"`
+	Action     string `json:"action"`
+	Brokerage  string `json:"brokerage"`
+	RatingFrom string `json:"rating_from"`
+	RatingTo   string `json:"rating_to"`
+	Time       string `json:"time"`
+}
+
+type Page struct {
+	Items    []Event `json:"items"`
+	NextPage string  `json:"next_page"`
+}
+
+// Read the events of a fixture file: an array of events, a page of the API as archived, or the
+// events one per line as cmd/seed writes them
+func LoadFixtures(path string) ([]Event, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var values []json.RawMessage
+	dec := json.NewDecoder(bytes.NewReader(data))
+	for {
+		var value json.RawMessage
+		err := dec.Decode(&value)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("fixtures %s are not JSON: %w", path, err)
+		}
+		values = append(values, value)
+	}
+
+	if len(values) == 1 {
+		var events []Event
+		if err := json.Unmarshal(values[0], &events); err == nil {
+			return events, nil
+		}
+		var page struct {
+			Items *[]Event `json:"items"`
+		}
+		if err := json.Unmarshal(values[0], &page); err == nil && page.Items != nil {
+			return *page.Items, nil
+		}
+	}
+	events := make([]Event, len(values))
+	for i, value := range values {
+		if err := json.Unmarshal(value, &events[i]); err != nil {
+			return nil, fmt.Errorf("fixtures %s are neither events nor a page: %w", path, err)
+		}
+	}
+	return events, nil
+}
+
+// SERVER ==========================================================================================
+
+type Server struct {
+	token    string
+	events   []Event
+	pageSize int
+
+	mu       sync.Mutex
+	faults   []Fault
+	cursors  map[string]int
+	requests int
+}
+
+// Serve the events in pages of pageSize, to the requests bearing the token
+func New(token string, events []Event, pageSize int) *Server {
+	return &Server{
+		token:    token,
+		events:   events,
+		pageSize: max(pageSize, 1),
+		cursors:  map[string]int{},
+	}
+}
+
+// Number of requests received, the rejected ones included
+func (s *Server) Requests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests++
+	s.mu.Unlock()
+
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if r.Header.Get("Authorization") != "Bearer "+s.token {
+		writeError(w, http.StatusUnauthorized, "invalid token")
+		return
+	}
+
+	// Pages are numbered from 0, the first one has no cursor
+	cursor := r.URL.Query().Get("next_page")
+	page, start, ok := s.lookup(cursor)
+	if !ok {
+		writeError(w, http.StatusBadRequest, "unknown next_page")
+		return
+	}
+	end := min(start+s.pageSize, len(s.events))
+	resp := Page{Items: append([]Event{}, s.events[start:end]...)}
+	if end < len(s.events) {
+		resp.NextPage = s.issue(resp.Items[len(resp.Items)-1].Ticker, page+1, end)
+	}
+
+	fault, ok := s.take(page)
+	if !ok {
+		writeJSON(w, resp)
+		return
+	}
+	fault.apply(w, r, resp)
+}
+
+// Page and offset of a cursor
+func (s *Server) lookup(cursor string) (page int, start int, ok bool) {
+	if cursor == "" {
+		return 0, 0, true
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	start, ok = s.cursors[cursor]
+	if !ok {
+		return 0, 0, false
+	}
+	return start / s.pageSize, start, true
+}
+
+// Cursor of the page starting at offset, the ticker of the last event before it as the API does.
+// The page number is added when a ticker ends several pages.
+func (s *Server) issue(ticker string, page int, offset int) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cursor := ticker
+	if start, ok := s.cursors[cursor]; ok && start != offset {
+		cursor = ticker + "~" + strconv.Itoa(page)
+	}
+	s.cursors[cursor] = offset
+	return cursor
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
+
+// Time of the events, as the API formats it
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
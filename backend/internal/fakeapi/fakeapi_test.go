@@ -0,0 +1,203 @@
+package fakeapi
+
+import (
+	"backend/internal/seed"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func get(t *testing.T, srv *httptest.Server, token string, cursor string) (*http.Response, Page) {
+	t.Helper()
+	url := srv.URL
+	if cursor != "" {
+		url += "?next_page=" + cursor
+	}
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatalf("GET %s error = %v", url, err)
+	}
+	defer resp.Body.Close()
+	var page Page
+	if resp.StatusCode == http.StatusOK {
+		if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+			t.Fatalf("GET %s returned invalid JSON: %v", url, err)
+		}
+	}
+	return resp, page
+}
+
+func TestPages(t *testing.T) {
+	events := Generate(5, 1)
+	srv := httptest.NewServer(New("token", events, 2))
+	defer srv.Close()
+
+	if resp, _ := get(t, srv, "wrong", ""); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("GET with a wrong token = %d, want 401", resp.StatusCode)
+	}
+	if resp, _ := get(t, srv, "token", "nope"); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("GET with an unknown cursor = %d, want 400", resp.StatusCode)
+	}
+
+	var served []Event
+	var cursors []string
+	cursor := ""
+	for range 3 {
+		resp, page := get(t, srv, "token", cursor)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("GET page %q = %d, want 200", cursor, resp.StatusCode)
+		}
+		served = append(served, page.Items...)
+		cursor = page.NextPage
+		cursors = append(cursors, cursor)
+	}
+	if !slices.Equal(served, events) {
+		t.Errorf("served events = %v, want %v", served, events)
+	}
+	// Cursors are the ticker of the last event of their previous page
+	if want := []string{"AAAB", "AAAD", ""}; !slices.Equal(cursors, want) {
+		t.Errorf("cursors = %v, want %v", cursors, want)
+	}
+	if got := srv.Config.Handler.(*Server).Requests(); got != 5 {
+		t.Errorf("Requests() = %d, want 5", got)
+	}
+}
+
+func TestFaults(t *testing.T) {
+	s := New("token", Generate(4, 1), 2)
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	s.Inject(Fault{Kind: FaultTooManyRequests, Page: 1, Times: 2, RetryAfter: 1500 * time.Millisecond})
+	s.Inject(Fault{Kind: FaultDuplicate, Page: AnyPage})
+
+	// The fault of the second page doesn't apply to the first one
+	_, first := get(t, srv, "token", "")
+	if len(first.Items) != 3 || first.Items[2] != first.Items[0] {
+		t.Errorf("first page = %v, want its first event served twice", first.Items)
+	}
+	for range 2 {
+		resp, _ := get(t, srv, "token", first.NextPage)
+		if resp.StatusCode != http.StatusTooManyRequests || resp.Header.Get("Retry-After") != "2" {
+			t.Errorf("second page = %d, Retry-After %q, want 429 after 2s", resp.StatusCode, resp.Header.Get("Retry-After"))
+		}
+	}
+	if resp, second := get(t, srv, "token", first.NextPage); resp.StatusCode != http.StatusOK || len(second.Items) != 2 {
+		t.Errorf("second page once the fault cleared = %d, %v, want its 2 events", resp.StatusCode, second.Items)
+	}
+
+	s.Inject(Fault{Kind: FaultUnknownRating, Page: 0})
+	if _, page := get(t, srv, "token", ""); page.Items[0].RatingTo != UnknownRating {
+		t.Errorf("first event rating = %q, want %q", page.Items[0].RatingTo, UnknownRating)
+	}
+}
+
+func TestFaultsHandler(t *testing.T) {
+	s := New("token", nil, 1)
+	h := s.FaultsHandler()
+	cases := []struct {
+		method string
+		query  string
+		status int
+	}{
+		{http.MethodPost, "kind=429&page=2&times=3&retry_after=5s", http.StatusNoContent},
+		{http.MethodPost, "kind=slow&delay=30s", http.StatusNoContent},
+		{http.MethodPost, "kind=teapot", http.StatusBadRequest},
+		{http.MethodPost, "kind=500&page=-1", http.StatusBadRequest},
+		{http.MethodPost, "kind=slow&delay=forever", http.StatusBadRequest},
+		{http.MethodGet, "", http.StatusMethodNotAllowed},
+	}
+	for _, c := range cases {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(c.method, "/faults?"+c.query, nil))
+		if w.Code != c.status {
+			t.Errorf("%s /faults?%s = %d, want %d", c.method, c.query, w.Code, c.status)
+		}
+	}
+	want := []Fault{
+		{Kind: FaultTooManyRequests, Page: 2, Times: 3, RetryAfter: 5 * time.Second},
+		{Kind: FaultSlow, Page: AnyPage, Times: 1, Delay: 30 * time.Second},
+	}
+	if !slices.Equal(s.faults, want) {
+		t.Errorf("injected faults = %+v, want %+v", s.faults, want)
+	}
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodDelete, "/faults", nil))
+	if len(s.faults) != 0 {
+		t.Errorf("faults after DELETE = %+v, want none", s.faults)
+	}
+}
+
+func TestDollars(t *testing.T) {
+	cases := map[float64]string{
+		0.5:        "$0.50",
+		12.345:     "$12.35",
+		999.999:    "$1,000.00",
+		1234567.89: "$1,234,567.89",
+	}
+	for v, want := range cases {
+		if got := dollars(v); got != want {
+			t.Errorf("dollars(%v) = %q, want %q", v, got, want)
+		}
+	}
+}
+
+func TestLoadFixtures(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, data []byte) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatalf("WriteFile(%s) error = %v", path, err)
+		}
+		return path
+	}
+	events := Generate(3, 1)
+	array, _ := json.Marshal(events)
+	page, _ := json.Marshal(Page{Items: events, NextPage: "AAAC"})
+
+	// The events of cmd/seed, one per line
+	opts := seed.DefaultOptions
+	opts.Tickers, opts.EventsPerTicker = 3, 1
+	seeded := seed.Generate(opts)
+	var ndjson bytes.Buffer
+	enc := json.NewEncoder(&ndjson)
+	enc.SetEscapeHTML(false)
+	for _, e := range seeded {
+		enc.Encode(e)
+	}
+
+	for _, tc := range []struct {
+		name string
+		path string
+		want int
+	}{
+		{"array", write("events.json", array), len(events)},
+		{"page", write("page.json", page), len(events)},
+		{"ndjson", write("events.ndjson", ndjson.Bytes()), len(seeded)},
+		{"one line", write("event.ndjson", []byte(`{"ticker":"AAPL","time":"2025-01-02T03:04:05Z"}`+"\n")), 1},
+	} {
+		got, err := LoadFixtures(tc.path)
+		if err != nil || len(got) != tc.want || got[0].Ticker == "" {
+			t.Errorf("LoadFixtures(%s) = %d events, %v, want %d", tc.name, len(got), err, tc.want)
+		}
+	}
+	got, _ := LoadFixtures(filepath.Join(dir, "events.ndjson"))
+	if got[0].Ticker != seeded[0].Ticker || got[0].TargetTo != seeded[0].TargetTo || got[0].Time != seeded[0].Time {
+		t.Errorf("LoadFixtures(ndjson)[0] = %+v, want %+v", got[0], seeded[0])
+	}
+
+	if _, err := LoadFixtures(write("broken.json", []byte(`{"ticker":`))); err == nil {
+		t.Errorf("LoadFixtures(truncated) error = nil, want an error")
+	}
+	if _, err := LoadFixtures(write("numbers.ndjson", []byte("1\n2\n"))); err == nil {
+		t.Errorf("LoadFixtures(numbers) error = nil, want an error")
+	}
+}
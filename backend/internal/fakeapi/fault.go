@@ -0,0 +1,212 @@
+package fakeapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FAULTS ==========================================================================================
+
+type FaultKind int
+
+const (
+	_ FaultKind = iota
+	// 500 Internal Server Error
+	FaultServerError
+	// 429 Too Many Requests, with the Retry-After of the fault
+	FaultTooManyRequests
+	// The page is served after the Delay of the fault, or when the client gives up
+	FaultSlow
+	// The page is cut in the middle of its JSON
+	FaultTruncated
+	// The first event of the page has a rating unknown to the loader
+	FaultUnknownRating
+	// The first event of the page is served twice
+	FaultDuplicate
+)
+
+var faultKinds = []FaultKind{
+	FaultServerError,
+	FaultTooManyRequests,
+	FaultSlow,
+	FaultTruncated,
+	FaultUnknownRating,
+	FaultDuplicate,
+}
+
+// Name of the kind, as ParseFaultKind reads it
+func (k FaultKind) String() string {
+	switch k {
+	case FaultServerError:
+		return "500"
+	case FaultTooManyRequests:
+		return "429"
+	case FaultSlow:
+		return "slow"
+	case FaultTruncated:
+		return "truncated"
+	case FaultUnknownRating:
+		return "unknown_rating"
+	case FaultDuplicate:
+		return "duplicate"
+	default:
+		return "unknown"
+	}
+}
+
+func ParseFaultKind(name string) (FaultKind, error) {
+	for _, k := range faultKinds {
+		if k.String() == name {
+			return k, nil
+		}
+	}
+	var names []string
+	for _, k := range faultKinds {
+		names = append(names, k.String())
+	}
+	return 0, fmt.Errorf("unknown fault %q, use %s", name, strings.Join(names, ", "))
+}
+
+// Rating no brokerage uses, rejected by the normalization of the loader
+const UnknownRating = "Strongly Ambivalent"
+
+// Every page the fault may apply to
+const AnyPage = -1
+
+type Fault struct {
+	Kind FaultKind
+	// Page the fault applies to, numbered from 0, or AnyPage
+	Page int
+	// Requests failing before the fault is cleared, 1 when 0
+	Times int
+	// Retry-After of FaultTooManyRequests
+	RetryAfter time.Duration
+	// Delay of FaultSlow
+	Delay time.Duration
+}
+
+// Fail the next requests of the page of the fault. Faults apply in the order they were injected.
+func (s *Server) Inject(f Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f.Times = max(f.Times, 1)
+	s.faults = append(s.faults, f)
+}
+
+// Clear the faults not applied yet
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = nil
+}
+
+// Take the first fault of the page, clearing it once applied its number of times
+func (s *Server) take(page int) (Fault, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := slices.IndexFunc(s.faults, func(f Fault) bool { return f.Page == AnyPage || f.Page == page })
+	if i < 0 {
+		return Fault{}, false
+	}
+	f := s.faults[i]
+	s.faults[i].Times--
+	if s.faults[i].Times == 0 {
+		s.faults = slices.Delete(s.faults, i, i+1)
+	}
+	return f, true
+}
+
+func (f Fault) apply(w http.ResponseWriter, r *http.Request, resp Page) {
+	switch f.Kind {
+	case FaultServerError:
+		writeError(w, http.StatusInternalServerError, "injected fault")
+	case FaultTooManyRequests:
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(f.RetryAfter.Seconds()))))
+		writeError(w, http.StatusTooManyRequests, "rate limit exceeded")
+	case FaultSlow:
+		select {
+		case <-time.After(f.Delay):
+			writeJSON(w, resp)
+		case <-r.Context().Done():
+		}
+	case FaultTruncated:
+		var body bytes.Buffer
+		json.NewEncoder(&body).Encode(resp)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(body.Bytes()[:body.Len()/2])
+	case FaultUnknownRating:
+		if len(resp.Items) > 0 {
+			resp.Items[0].RatingTo = UnknownRating
+		}
+		writeJSON(w, resp)
+	case FaultDuplicate:
+		if len(resp.Items) > 0 {
+			resp.Items = append(resp.Items, resp.Items[0])
+		}
+		writeJSON(w, resp)
+	default:
+		writeJSON(w, resp)
+	}
+}
+
+// Handler injecting the faults of its requests, for cmd/fakeapi:
+//
+//	POST /faults?kind=429&page=2&times=3&retry_after=5s
+//	POST /faults?kind=slow&delay=30s
+//	DELETE /faults
+func (s *Server) FaultsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			f, err := parseFault(r)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			s.Inject(f)
+			w.WriteHeader(http.StatusNoContent)
+		case http.MethodDelete:
+			s.ClearFaults()
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		}
+	})
+}
+
+func parseFault(r *http.Request) (Fault, error) {
+	q := r.URL.Query()
+	kind, err := ParseFaultKind(q.Get("kind"))
+	if err != nil {
+		return Fault{}, err
+	}
+	f := Fault{Kind: kind, Page: AnyPage}
+	if v := q.Get("page"); v != "" {
+		if f.Page, err = strconv.Atoi(v); err != nil || f.Page < 0 {
+			return Fault{}, fmt.Errorf("invalid page %q", v)
+		}
+	}
+	if v := q.Get("times"); v != "" {
+		if f.Times, err = strconv.Atoi(v); err != nil || f.Times < 1 {
+			return Fault{}, fmt.Errorf("invalid times %q", v)
+		}
+	}
+	if v := q.Get("retry_after"); v != "" {
+		if f.RetryAfter, err = time.ParseDuration(v); err != nil {
+			return Fault{}, fmt.Errorf("invalid retry_after %q", v)
+		}
+	}
+	if v := q.Get("delay"); v != "" {
+		if f.Delay, err = time.ParseDuration(v); err != nil {
+			return Fault{}, fmt.Errorf("invalid delay %q", v)
+		}
+	}
+	return f, nil
+}
@@ -0,0 +1,66 @@
+package fakeapi
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"strconv"
+	"time"
+)
+
+// GENERATOR =======================================================================================
+
+// Raw values as the API sends them, every one known to the normalization of the loader
+var (
+	brokerages = []string{"Goldman Sachs", "Morgan Stanley", "JPMorgan Chase & Co.", "Barclays", "Wells Fargo & Company", "The Goldman Sachs Group", "Raymond James", "Piper Sandler"}
+	suffixes   = []string{"Inc.", "Corp", "Holdings", "Group", "Technologies", "Therapeutics", "Bancorp"}
+	actions    = []string{"target raised by", "upgraded by", "target lowered by", "downgraded by", "target set by", "reiterated by", "initiated by"}
+	ratings    = []string{"Buy", "Strong-Buy", "Outperform", "Overweight", "Hold", "Neutral", "Equal Weight", "Market Perform", "Sell", "Underperform", "Underweight", ""}
+)
+
+// Time of the most recent generated event, the others are spread over the 30 days before it
+var generatedUntil = time.Date(2025, 5, 30, 0, 30, 0, 0, time.UTC)
+
+// Generate n events with distinct tickers, the same for the same seed
+func Generate(n int, seed uint64) []Event {
+	rng := rand.New(rand.NewPCG(seed, seed))
+	events := make([]Event, 0, n)
+	for i := range n {
+		ticker := ticker(i)
+		targetFrom := 1 + rng.Float64()*1500
+		targetTo := targetFrom * (0.7 + rng.Float64()*0.6)
+		events = append(events, Event{
+			Ticker:     ticker,
+			TargetFrom: dollars(targetFrom),
+			TargetTo:   dollars(targetTo),
+			Company:    ticker + " " + suffixes[rng.IntN(len(suffixes))],
+			Action:     actions[rng.IntN(len(actions))],
+			Brokerage:  brokerages[rng.IntN(len(brokerages))],
+			RatingFrom: ratings[rng.IntN(len(ratings))],
+			RatingTo:   ratings[rng.IntN(len(ratings))],
+			Time:       formatTime(generatedUntil.Add(-time.Duration(rng.Int64N(int64(30 * 24 * time.Hour))))),
+		})
+	}
+	return events
+}
+
+// Ticker of the i-th event, AAAA, AAAB, ... in alphabetical order
+func ticker(i int) string {
+	letters := make([]byte, 4)
+	for j := len(letters) - 1; j >= 0; j-- {
+		letters[j] = 'A' + byte(i%26)
+		i /= 26
+	}
+	return string(letters)
+}
+
+// Target as the API writes it, e.g. $1,234.50
+func dollars(v float64) string {
+	cents := int64(v*100 + 0.5)
+	whole := strconv.FormatInt(cents/100, 10)
+	var grouped string
+	for len(whole) > 3 {
+		grouped = "," + whole[len(whole)-3:] + grouped
+		whole = whole[:len(whole)-3]
+	}
+	return fmt.Sprintf("$%s%s.%02d", whole, grouped, cents%100)
+}
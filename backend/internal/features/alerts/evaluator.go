@@ -0,0 +1,145 @@
+package alerts
+
+import (
+	"backend/internal/features/stockratings"
+	"backend/internal/repository"
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// EVALUATOR =======================================================================================
+// Load hook matching the enabled rules against every rating that wasn't in the table before the
+// load, as the webhook enqueuer. The ratings being replaced are kept before the load, to skip the
+// ones reloaded unchanged and to tell when a score crosses a threshold.
+
+type Evaluator struct {
+	repo  repository.Repository
+	rules []repository.AlertRule
+	prior map[string]repository.GetStockRatingScoresRow
+}
+
+func NewEvaluator(r repository.Repository) *Evaluator {
+	return &Evaluator{repo: r}
+}
+
+func (e *Evaluator) BeforeLoad(ctx context.Context, _ uuid.UUID) error {
+	rules, err := e.repo.ListEnabledAlertRules(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read the alert rules: %w", err)
+	}
+	e.rules = rules
+
+	e.prior = map[string]repository.GetStockRatingScoresRow{}
+	if len(rules) == 0 {
+		return nil
+	}
+	scores, err := e.repo.GetStockRatingScores(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read the current scores: %w", err)
+	}
+	for _, r := range scores {
+		e.prior[r.Ticker] = r
+	}
+	return nil
+}
+
+func (e *Evaluator) OnBatch(ctx context.Context, runID uuid.UUID, batch []repository.AddStockRatingsParams) error {
+	var triggered int64
+	for _, r := range batch {
+		prior, hasPrior := e.prior[r.Ticker]
+		if hasPrior && prior.At.Equal(r.At) {
+			continue
+		}
+		ev := event{
+			rating:        r,
+			change:        stockratings.TargetChangePct(r.TargetFrom, r.TargetTo),
+			score:         stockratings.Score(r.TargetFrom, r.TargetTo, r.RatingTo, r.Action),
+			priorScore:    prior.Score,
+			hasPriorScore: hasPrior,
+		}
+
+		for _, rule := range e.rules {
+			reason, ok := match(rule, ev)
+			if !ok {
+				continue
+			}
+			inserted, err := e.repo.AddAlertEvent(ctx, repository.AddAlertEventParams{
+				RuleID:     rule.ID,
+				RunID:      runID,
+				Ticker:     r.Ticker,
+				Company:    r.Company,
+				Brokerage:  r.Brokerage,
+				Action:     r.Action,
+				RatingFrom: r.RatingFrom,
+				RatingTo:   r.RatingTo,
+				TargetFrom: r.TargetFrom,
+				TargetTo:   r.TargetTo,
+				Score:      ev.score,
+				Reason:     reason,
+				At:         r.At,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to record alert: %w", err)
+			}
+			triggered += inserted
+		}
+	}
+	if triggered > 0 {
+		log.Println("Alerts triggered: ", triggered)
+	}
+	return nil
+}
+
+// Matching ----------------------------------------------------------------------------------------
+type event struct {
+	rating        repository.AddStockRatingsParams
+	change        float64
+	score         int32
+	priorScore    int32
+	hasPriorScore bool
+}
+
+// Every condition set on the rule must hold, the reason lists them
+func match(rule repository.AlertRule, ev event) (string, bool) {
+	var reasons []string
+	if len(rule.Tickers) > 0 {
+		if !slices.Contains(rule.Tickers, ev.rating.Ticker) {
+			return "", false
+		}
+		reasons = append(reasons, "on watchlist")
+	}
+	if rule.Action.Valid {
+		if ev.rating.Action != rule.Action.StockActionType {
+			return "", false
+		}
+		reasons = append(reasons, fmt.Sprintf("action %s", ev.rating.Action))
+	}
+	if rule.RatingTo.Valid {
+		if ev.rating.RatingTo != rule.RatingTo.StockRatingType {
+			return "", false
+		}
+		reasons = append(reasons, fmt.Sprintf("rating to %s", ev.rating.RatingTo))
+	}
+	// A negative threshold asks for a target cut at least that large
+	if rule.MinTargetChangePct.Valid {
+		threshold := rule.MinTargetChangePct.Float64
+		if (threshold >= 0 && ev.change < threshold) || (threshold < 0 && ev.change > threshold) {
+			return "", false
+		}
+		reasons = append(reasons, fmt.Sprintf("target changed %+.2f%%", ev.change))
+	}
+	// Crossing in either direction, a ticker seen for the first time crosses nothing
+	if rule.ScoreCrosses.Valid {
+		threshold := rule.ScoreCrosses.Int32
+		if !ev.hasPriorScore || (ev.priorScore < threshold) == (ev.score < threshold) {
+			return "", false
+		}
+		reasons = append(reasons, fmt.Sprintf("score crossed %d (%d to %d)", threshold, ev.priorScore, ev.score))
+	}
+	return strings.Join(reasons, ", "), true
+}
@@ -0,0 +1,153 @@
+package alerts
+
+import (
+	"backend/internal/repository"
+	"backend/internal/repository/memstore"
+	"backend/internal/repository/repositorytest"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestMatch(t *testing.T) {
+	rating := repositorytest.NewStockRating("AAPL", 200, 150, repositorytest.RatedAt)
+	cases := []struct {
+		name       string
+		rule       repository.AlertRule
+		ev         event
+		wantReason string
+		wantMatch  bool
+	}{
+		{
+			name:       "on the watchlist",
+			rule:       repository.AlertRule{Tickers: []string{"MSFT", "AAPL"}},
+			ev:         event{rating: rating},
+			wantReason: "on watchlist",
+			wantMatch:  true,
+		},
+		{
+			name: "off the watchlist",
+			rule: repository.AlertRule{Tickers: []string{"MSFT"}},
+			ev:   event{rating: rating},
+		},
+		{
+			name:       "every condition holds",
+			rule:       repository.AlertRule{Action: repository.NullStockActionType{StockActionType: repository.StockActionTypeUp, Valid: true}, RatingTo: repository.NullStockRatingType{StockRatingType: repository.StockRatingTypeBuy, Valid: true}},
+			ev:         event{rating: rating},
+			wantReason: "action up, rating to buy",
+			wantMatch:  true,
+		},
+		{
+			name: "one condition fails",
+			rule: repository.AlertRule{Action: repository.NullStockActionType{StockActionType: repository.StockActionTypeUp, Valid: true}, RatingTo: repository.NullStockRatingType{StockRatingType: repository.StockRatingTypeSell, Valid: true}},
+			ev:   event{rating: rating},
+		},
+		{
+			name:       "raise above the threshold",
+			rule:       repository.AlertRule{MinTargetChangePct: pgtype.Float8{Float64: 10, Valid: true}},
+			ev:         event{rating: rating, change: 12.5},
+			wantReason: "target changed +12.50%",
+			wantMatch:  true,
+		},
+		{
+			name: "cut below a positive threshold",
+			rule: repository.AlertRule{MinTargetChangePct: pgtype.Float8{Float64: 10, Valid: true}},
+			ev:   event{rating: rating, change: -25},
+		},
+		{
+			name:       "cut beyond a negative threshold",
+			rule:       repository.AlertRule{MinTargetChangePct: pgtype.Float8{Float64: -20, Valid: true}},
+			ev:         event{rating: rating, change: -25},
+			wantReason: "target changed -25.00%",
+			wantMatch:  true,
+		},
+		{
+			name: "cut within a negative threshold",
+			rule: repository.AlertRule{MinTargetChangePct: pgtype.Float8{Float64: -20, Valid: true}},
+			ev:   event{rating: rating, change: -10},
+		},
+		{
+			name: "raise with a negative threshold",
+			rule: repository.AlertRule{MinTargetChangePct: pgtype.Float8{Float64: -20, Valid: true}},
+			ev:   event{rating: rating, change: 25},
+		},
+		{
+			name:       "score crossing upward",
+			rule:       repository.AlertRule{ScoreCrosses: pgtype.Int4{Int32: 3000, Valid: true}},
+			ev:         event{rating: rating, score: 3000, priorScore: 2999, hasPriorScore: true},
+			wantReason: "score crossed 3000 (2999 to 3000)",
+			wantMatch:  true,
+		},
+		{
+			name:       "score crossing downward",
+			rule:       repository.AlertRule{ScoreCrosses: pgtype.Int4{Int32: 3000, Valid: true}},
+			ev:         event{rating: rating, score: -500, priorScore: 5000, hasPriorScore: true},
+			wantReason: "score crossed 3000 (5000 to -500)",
+			wantMatch:  true,
+		},
+		{
+			name: "score staying above",
+			rule: repository.AlertRule{ScoreCrosses: pgtype.Int4{Int32: 3000, Valid: true}},
+			ev:   event{rating: rating, score: 4000, priorScore: 5000, hasPriorScore: true},
+		},
+		{
+			name: "first score of a ticker",
+			rule: repository.AlertRule{ScoreCrosses: pgtype.Int4{Int32: 3000, Valid: true}},
+			ev:   event{rating: rating, score: 5000},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			reason, ok := match(tc.rule, tc.ev)
+			if ok != tc.wantMatch || reason != tc.wantReason {
+				t.Errorf("match() = %q, %v, want %q, %v", reason, ok, tc.wantReason, tc.wantMatch)
+			}
+		})
+	}
+}
+
+func TestEvaluatorSkipsKnownRatings(t *testing.T) {
+	ctx := context.Background()
+	store := memstore.New()
+	day := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	if _, err := store.AddStockRatings(ctx, []repository.AddStockRatingsParams{
+		repositorytest.NewStockRating("AAPL", 100, 120, day),
+		repositorytest.NewStockRating("MSFT", 100, 120, day),
+	}); err != nil {
+		t.Fatalf("AddStockRatings() error = %v", err)
+	}
+	// The rule is created after the ratings were first loaded, reloading them must not alert
+	if _, err := store.CreateAlertRule(ctx, repository.CreateAlertRuleParams{
+		Name:         "Crossing",
+		Tickers:      []string{},
+		ScoreCrosses: pgtype.Int4{Int32: 4000, Valid: true},
+	}); err != nil {
+		t.Fatalf("CreateAlertRule() error = %v", err)
+	}
+
+	runID, err := store.CreateIngestionRun(ctx, repository.IngestionRunSourceApi)
+	if err != nil {
+		t.Fatalf("CreateIngestionRun() error = %v", err)
+	}
+	e := NewEvaluator(store)
+	if err := e.BeforeLoad(ctx, runID); err != nil {
+		t.Fatalf("BeforeLoad() error = %v", err)
+	}
+	batch := []repository.AddStockRatingsParams{
+		repositorytest.NewStockRating("AAPL", 100, 120, day),
+		repositorytest.NewStockRating("MSFT", 100, 50, day.Add(24*time.Hour)),
+	}
+	if err := e.OnBatch(ctx, runID, batch); err != nil {
+		t.Fatalf("OnBatch() error = %v", err)
+	}
+
+	events, err := store.ListAlertEvents(ctx, repository.ListAlertEventsParams{Limit: 10})
+	if err != nil {
+		t.Fatalf("ListAlertEvents() error = %v", err)
+	}
+	if len(events) != 1 || events[0].Ticker != "MSFT" || events[0].Reason != "score crossed 4000 (5000 to -2000)" {
+		t.Errorf("ListAlertEvents() = %+v, want a single crossing of MSFT", events)
+	}
+}
@@ -0,0 +1,211 @@
+package alerts
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type HandlerInterface interface {
+	ListAlerts(c *gin.Context)
+	ListRules(c *gin.Context)
+	CreateRule(c *gin.Context)
+	DeleteRule(c *gin.Context)
+}
+type Handler struct {
+	service ServiceInterface
+}
+
+func NewHandler(s ServiceInterface) *Handler {
+	return &Handler{service: s}
+}
+
+type AlertRuleResponse struct {
+	ID                 string    `json:"id"`
+	Name               string    `json:"name"`
+	Tickers            []string  `json:"tickers"`
+	Action             *string   `json:"action"`
+	RatingTo           *string   `json:"rating_to"`
+	MinTargetChangePct *float64  `json:"min_target_change_pct"`
+	ScoreCrosses       *int32    `json:"score_crosses"`
+	Enabled            bool      `json:"enabled"`
+	CreatedAt          time.Time `json:"created_at"`
+}
+
+type AlertRuleListResponse struct {
+	Length int                 `json:"length"`
+	Rules  []AlertRuleResponse `json:"rules"`
+}
+
+// Conditions left out are ignored, at least one is needed
+type CreateAlertRuleRequest struct {
+	Name               string   `json:"name"`
+	Tickers            []string `json:"tickers"`
+	Action             *string  `json:"action"`
+	RatingTo           *string  `json:"rating_to"`
+	MinTargetChangePct *float64 `json:"min_target_change_pct"`
+	ScoreCrosses       *int32   `json:"score_crosses"`
+}
+
+type AlertResponse struct {
+	ID          string      `json:"id"`
+	RuleID      string      `json:"rule_id"`
+	RuleName    string      `json:"rule_name"`
+	Ticker      string      `json:"ticker"`
+	Company     string      `json:"company"`
+	Brokerage   string      `json:"brokerage"`
+	Action      string      `json:"action"`
+	RatingFrom  string      `json:"rating_from"`
+	RatingTo    string      `json:"rating_to"`
+	TargetFrom  json.Number `json:"target_from"`
+	TargetTo    json.Number `json:"target_to"`
+	Score       int32       `json:"score"`
+	Reason      string      `json:"reason"`
+	At          time.Time   `json:"at"`
+	TriggeredAt time.Time   `json:"triggered_at"`
+}
+
+type AlertListResponse struct {
+	Length int             `json:"length"`
+	Alerts []AlertResponse `json:"alerts"`
+}
+
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+func (h *Handler) ListAlerts(c *gin.Context) {
+	// Validate parameters
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid offset"})
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid limit"})
+		return
+	}
+
+	// Call the service
+	alerts, err := h.service.ListAlerts(c.Request.Context(), ListAlertsInput{
+		ticker: c.Query("ticker"),
+		offset: int32(offset),
+		limit:  int32(limit),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	// Serialize the output
+	resp := make([]AlertResponse, len(alerts))
+	for i, a := range alerts {
+		resp[i] = AlertResponse{
+			ID:          a.id.String(),
+			RuleID:      a.ruleID.String(),
+			RuleName:    a.ruleName,
+			Ticker:      a.ticker,
+			Company:     a.company,
+			Brokerage:   a.brokerage,
+			Action:      a.action,
+			RatingFrom:  a.ratingFrom,
+			RatingTo:    a.ratingTo,
+			TargetFrom:  json.Number(a.targetFrom),
+			TargetTo:    json.Number(a.targetTo),
+			Score:       a.score,
+			Reason:      a.reason,
+			At:          a.at.UTC(),
+			TriggeredAt: a.triggeredAt.UTC(),
+		}
+	}
+	c.JSON(http.StatusOK, AlertListResponse{Length: len(resp), Alerts: resp})
+}
+
+func toRuleResponse(r rule) AlertRuleResponse {
+	tickers := r.tickers
+	if tickers == nil {
+		tickers = []string{}
+	}
+	return AlertRuleResponse{
+		ID:                 r.id.String(),
+		Name:               r.name,
+		Tickers:            tickers,
+		Action:             r.action,
+		RatingTo:           r.ratingTo,
+		MinTargetChangePct: r.minTargetChangePct,
+		ScoreCrosses:       r.scoreCrosses,
+		Enabled:            r.enabled,
+		CreatedAt:          r.createdAt.UTC(),
+	}
+}
+
+func (h *Handler) ListRules(c *gin.Context) {
+	rules, err := h.service.ListRules(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	resp := make([]AlertRuleResponse, len(rules))
+	for i, r := range rules {
+		resp[i] = toRuleResponse(r)
+	}
+	c.JSON(http.StatusOK, AlertRuleListResponse{Length: len(resp), Rules: resp})
+}
+
+func (h *Handler) CreateRule(c *gin.Context) {
+	var req CreateAlertRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid body"})
+		return
+	}
+
+	created, err := h.service.CreateRule(c.Request.Context(), CreateRuleInput{
+		name:               req.Name,
+		tickers:            req.Tickers,
+		action:             req.Action,
+		ratingTo:           req.RatingTo,
+		minTargetChangePct: req.MinTargetChangePct,
+		scoreCrosses:       req.ScoreCrosses,
+	})
+	if errors.Is(err, AlertsErrorInvalidRuleError) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, toRuleResponse(created))
+}
+
+func (h *Handler) DeleteRule(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid id"})
+		return
+	}
+	err = h.service.DeleteRule(c.Request.Context(), id)
+	if errors.Is(err, AlertsErrorRuleNotFound) {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func AddAlertRoutes(rg *gin.RouterGroup, h HandlerInterface) {
+	alerts := rg.Group("/alerts")
+	alerts.GET("/", h.ListAlerts)
+	alerts.GET("/rules/", h.ListRules)
+	alerts.POST("/rules/", h.CreateRule)
+	alerts.DELETE("/rules/:id", h.DeleteRule)
+}
@@ -0,0 +1,282 @@
+package alerts
+
+import (
+	"backend/internal/repository"
+	"backend/pkg/tracing"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// SERVICE =========================================================================================
+
+type ServiceInterface interface {
+	ListAlerts(ctx context.Context, input ListAlertsInput) (ListAlertsOutput, error)
+	ListRules(ctx context.Context) ([]rule, error)
+	CreateRule(ctx context.Context, input CreateRuleInput) (rule, error)
+	DeleteRule(ctx context.Context, id uuid.UUID) error
+}
+type Service struct {
+	repo repository.Repository
+}
+
+func NewService(r repository.Repository) *Service {
+	return &Service{
+		repo: r,
+	}
+}
+
+// Errors ------------------------------------------------------------------------------------------
+type AlertsErrorKind int
+
+const (
+	_ AlertsErrorKind = iota
+	alertsUnexpectedError
+	alertsInvalidRuleError
+	alertsRuleNotFoundError
+)
+
+type AlertsError struct {
+	kind AlertsErrorKind
+	err  error
+}
+
+func (e AlertsError) Error() string {
+	switch e.kind {
+	case alertsUnexpectedError:
+		return fmt.Sprintf("Unexpected error: %s", e.err.Error())
+	case alertsInvalidRuleError:
+		return fmt.Sprintf("Invalid rule: %s", e.err.Error())
+	case alertsRuleNotFoundError:
+		return "Rule not found"
+	default:
+		return "Unknown error"
+	}
+}
+
+func (e AlertsError) From(err error) AlertsError {
+	e1 := e
+	e1.err = err
+	return e1
+}
+func (e AlertsError) Unwrap() error {
+	return e.err
+}
+
+// Errors of the same kind match with errors.Is, whatever their cause
+func (e AlertsError) Is(target error) bool {
+	t, ok := target.(AlertsError)
+	return ok && t.kind == e.kind
+}
+
+var (
+	AlertsErrorUnexpectedError  = AlertsError{kind: alertsUnexpectedError}
+	AlertsErrorInvalidRuleError = AlertsError{kind: alertsInvalidRuleError}
+	AlertsErrorRuleNotFound     = AlertsError{kind: alertsRuleNotFoundError}
+)
+
+// Rules -------------------------------------------------------------------------------------------
+type rule = struct {
+	id                 uuid.UUID
+	name               string
+	tickers            []string
+	action             *string
+	ratingTo           *string
+	minTargetChangePct *float64
+	scoreCrosses       *int32
+	enabled            bool
+	createdAt          time.Time
+}
+
+var actions = []repository.StockActionType{
+	repository.StockActionTypeUp,
+	repository.StockActionTypeDown,
+	repository.StockActionTypeReiterated,
+}
+
+var ratings = []repository.StockRatingType{
+	repository.StockRatingTypeBuy,
+	repository.StockRatingTypeHold,
+	repository.StockRatingTypeSell,
+	repository.StockRatingTypePending,
+}
+
+type CreateRuleInput struct {
+	name               string
+	tickers            []string
+	action             *string
+	ratingTo           *string
+	minTargetChangePct *float64
+	scoreCrosses       *int32
+}
+
+func toRule(r repository.AlertRule) rule {
+	out := rule{
+		id:        r.ID,
+		name:      r.Name,
+		tickers:   r.Tickers,
+		enabled:   r.Enabled,
+		createdAt: r.CreatedAt,
+	}
+	if r.Action.Valid {
+		action := string(r.Action.StockActionType)
+		out.action = &action
+	}
+	if r.RatingTo.Valid {
+		ratingTo := string(r.RatingTo.StockRatingType)
+		out.ratingTo = &ratingTo
+	}
+	if r.MinTargetChangePct.Valid {
+		out.minTargetChangePct = &r.MinTargetChangePct.Float64
+	}
+	if r.ScoreCrosses.Valid {
+		out.scoreCrosses = &r.ScoreCrosses.Int32
+	}
+	return out
+}
+
+func (s *Service) ListRules(ctx context.Context) ([]rule, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "Service.ListRules")
+	defer span.End()
+
+	res, err := s.repo.ListAlertRules(ctx)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return nil, AlertsErrorUnexpectedError.From(err)
+	}
+	var out []rule
+	for _, r := range res {
+		out = append(out, toRule(r))
+	}
+	return out, nil
+}
+
+func (s *Service) CreateRule(ctx context.Context, input CreateRuleInput) (rule, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "Service.CreateRule")
+	defer span.End()
+
+	// Validate the conditions
+	if strings.TrimSpace(input.name) == "" {
+		return rule{}, AlertsErrorInvalidRuleError.From(fmt.Errorf("missing name"))
+	}
+	params := repository.CreateAlertRuleParams{Name: strings.TrimSpace(input.name), Tickers: []string{}}
+	for _, ticker := range input.tickers {
+		params.Tickers = append(params.Tickers, strings.ToUpper(strings.TrimSpace(ticker)))
+	}
+	if input.action != nil {
+		action := repository.StockActionType(*input.action)
+		if !slices.Contains(actions, action) {
+			return rule{}, AlertsErrorInvalidRuleError.From(fmt.Errorf("unknown action: %s", *input.action))
+		}
+		params.Action = repository.NullStockActionType{StockActionType: action, Valid: true}
+	}
+	if input.ratingTo != nil {
+		ratingTo := repository.StockRatingType(*input.ratingTo)
+		if !slices.Contains(ratings, ratingTo) {
+			return rule{}, AlertsErrorInvalidRuleError.From(fmt.Errorf("unknown rating: %s", *input.ratingTo))
+		}
+		params.RatingTo = repository.NullStockRatingType{StockRatingType: ratingTo, Valid: true}
+	}
+	if input.minTargetChangePct != nil {
+		params.MinTargetChangePct = pgtype.Float8{Float64: *input.minTargetChangePct, Valid: true}
+	}
+	if input.scoreCrosses != nil {
+		params.ScoreCrosses = pgtype.Int4{Int32: *input.scoreCrosses, Valid: true}
+	}
+	if len(params.Tickers) == 0 && !params.Action.Valid && !params.RatingTo.Valid &&
+		!params.MinTargetChangePct.Valid && !params.ScoreCrosses.Valid {
+		return rule{}, AlertsErrorInvalidRuleError.From(fmt.Errorf("a rule needs at least one condition"))
+	}
+
+	res, err := s.repo.CreateAlertRule(ctx, params)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return rule{}, AlertsErrorUnexpectedError.From(err)
+	}
+	return toRule(res), nil
+}
+
+func (s *Service) DeleteRule(ctx context.Context, id uuid.UUID) error {
+	ctx, span := tracing.Tracer().Start(ctx, "Service.DeleteRule")
+	defer span.End()
+
+	deleted, err := s.repo.DeleteAlertRule(ctx, id)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return AlertsErrorUnexpectedError.From(err)
+	}
+	if deleted == 0 {
+		return AlertsErrorRuleNotFound
+	}
+	return nil
+}
+
+// Alerts ------------------------------------------------------------------------------------------
+type ListAlertsInput struct {
+	ticker string
+	offset int32
+	limit  int32
+}
+
+type alert = struct {
+	id          uuid.UUID
+	ruleID      uuid.UUID
+	ruleName    string
+	ticker      string
+	company     string
+	brokerage   string
+	action      string
+	ratingFrom  string
+	ratingTo    string
+	targetFrom  string
+	targetTo    string
+	score       int32
+	reason      string
+	at          time.Time
+	triggeredAt time.Time
+}
+type ListAlertsOutput = []alert
+
+// Triggered alerts, newest first
+func (s *Service) ListAlerts(ctx context.Context, input ListAlertsInput) (ListAlertsOutput, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "Service.ListAlerts")
+	defer span.End()
+
+	res, err := s.repo.ListAlertEvents(ctx, repository.ListAlertEventsParams{
+		Ticker: pgtype.Text{String: strings.ToUpper(input.ticker), Valid: input.ticker != ""},
+		Offset: input.offset,
+		Limit:  input.limit,
+	})
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return nil, AlertsErrorUnexpectedError.From(err)
+	}
+
+	var out ListAlertsOutput
+	for _, r := range res {
+		out = append(out, alert{
+			id:          r.ID,
+			ruleID:      r.RuleID,
+			ruleName:    r.RuleName,
+			ticker:      r.Ticker,
+			company:     r.Company,
+			brokerage:   r.Brokerage,
+			action:      string(r.Action),
+			ratingFrom:  string(r.RatingFrom),
+			ratingTo:    string(r.RatingTo),
+			targetFrom:  r.TargetFrom,
+			targetTo:    r.TargetTo,
+			score:       r.Score,
+			reason:      r.Reason,
+			at:          r.At,
+			triggeredAt: r.TriggeredAt,
+		})
+	}
+	return out, nil
+}
@@ -0,0 +1,105 @@
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Events returned at most per request, a consumer reads the rest from the next cursor
+const maxLimit = 1000
+
+type HandlerInterface interface {
+	ListEvents(c *gin.Context)
+}
+type Handler struct {
+	service ServiceInterface
+}
+
+func NewHandler(s ServiceInterface) *Handler {
+	return &Handler{service: s}
+}
+
+type EventResponse struct {
+	Cursor        string      `json:"cursor"`
+	Ticker        string      `json:"ticker"`
+	Company       string      `json:"company"`
+	Brokerage     string      `json:"brokerage"`
+	TargetFrom    json.Number `json:"target_from"`
+	TargetTo      json.Number `json:"target_to"`
+	Action        string      `json:"action"`
+	RawAction     string      `json:"raw_action"`
+	RatingFrom    string      `json:"rating_from"`
+	RawRatingFrom string      `json:"raw_rating_from"`
+	RatingTo      string      `json:"rating_to"`
+	RawRatingTo   string      `json:"raw_rating_to"`
+	At            time.Time   `json:"at"`
+	RecordedAt    time.Time   `json:"recorded_at"`
+}
+
+type EventListResponse struct {
+	Length     int             `json:"length"`
+	Events     []EventResponse `json:"events"`
+	NextCursor string          `json:"next_cursor"`
+	HasMore    bool            `json:"has_more"`
+}
+
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+func (h *Handler) ListEvents(c *gin.Context) {
+	// Validate parameters
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if err != nil || limit < 1 || limit > maxLimit {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid limit, use 1 to 1000"})
+		return
+	}
+
+	// Call the service
+	out, err := h.service.ListEvents(c.Request.Context(), c.Query("since_cursor"), int32(limit))
+	if errors.Is(err, ListEventsErrorInvalidCursor) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	// Serialize the output
+	resp := make([]EventResponse, len(out.events))
+	for i, e := range out.events {
+		resp[i] = EventResponse{
+			Cursor:        e.cursor,
+			Ticker:        e.ticker,
+			Company:       e.company,
+			Brokerage:     e.brokerage,
+			TargetFrom:    json.Number(e.targetFrom),
+			TargetTo:      json.Number(e.targetTo),
+			Action:        e.action,
+			RawAction:     e.rawAction,
+			RatingFrom:    e.ratingFrom,
+			RawRatingFrom: e.rawRatingFrom,
+			RatingTo:      e.ratingTo,
+			RawRatingTo:   e.rawRatingTo,
+			At:            e.at.UTC(),
+			RecordedAt:    e.recordedAt.UTC(),
+		}
+	}
+
+	c.JSON(http.StatusOK, EventListResponse{
+		Length:     len(resp),
+		Events:     resp,
+		NextCursor: out.nextCursor,
+		HasMore:    out.hasMore,
+	})
+}
+
+func AddEventRoutes(rg *gin.RouterGroup, h HandlerInterface) {
+	rg.GET("/events", h.ListEvents)
+}
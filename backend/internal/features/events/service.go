@@ -0,0 +1,167 @@
+package events
+
+import (
+	"backend/internal/repository"
+	"backend/pkg/tracing"
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// SERVICE =========================================================================================
+// Change feed of the history, every recorded event in the order it was recorded. The cursor is the
+// position of the last event read, in the sequence of stock_rating_event and not its time, which a
+// load can bring out of order. The loads, the seed and the backfill can write at once: each takes
+// its positions under the lock of stock_rating_event_position, held until it commits, so a position
+// never becomes visible after a higher one and a reader can't go past it (migration 0013).
+
+type ServiceInterface interface {
+	ListEvents(ctx context.Context, cursor string, limit int32) (ListEventsOutput, error)
+}
+type Service struct {
+	repo repository.Repository
+}
+
+func NewService(r repository.Repository) *Service {
+	return &Service{
+		repo: r,
+	}
+}
+
+// Cursors -----------------------------------------------------------------------------------------
+
+// A position as 16 hex digits, so the cursors also sort as strings. The empty cursor is the start
+// of the feed.
+func encodeCursor(seq int64) string {
+	return fmt.Sprintf("%016x", seq)
+}
+
+func decodeCursor(cursor string) (int64, bool) {
+	if cursor == "" {
+		return 0, true
+	}
+	if len(cursor) != 16 {
+		return 0, false
+	}
+	seq, err := strconv.ParseInt(cursor, 16, 64)
+	return seq, err == nil && seq >= 0
+}
+
+// ListEvents --------------------------------------------------------------------------------------
+type event = struct {
+	cursor        string
+	ticker        string
+	company       string
+	brokerage     string
+	targetFrom    string
+	targetTo      string
+	action        string
+	rawAction     string
+	ratingFrom    string
+	rawRatingFrom string
+	ratingTo      string
+	rawRatingTo   string
+	at            time.Time
+	recordedAt    time.Time
+}
+
+type ListEventsOutput = struct {
+	events []event
+	// Cursor to read the next events from, the one given when there are none yet
+	nextCursor string
+	hasMore    bool
+}
+
+type ListEventsErrorKind int
+
+const (
+	_ ListEventsErrorKind = iota
+	listEventsUnexpectedError
+	listEventsInvalidCursorError
+)
+
+type ListEventsError struct {
+	kind ListEventsErrorKind
+	err  error
+}
+
+func (e ListEventsError) Error() string {
+	switch e.kind {
+	case listEventsUnexpectedError:
+		return fmt.Sprintf("Unexpected error: %s", e.err.Error())
+	case listEventsInvalidCursorError:
+		return "Invalid since_cursor"
+	default:
+		return "Unknown error"
+	}
+}
+
+func (e ListEventsError) From(err error) ListEventsError {
+	e1 := e
+	e1.err = err
+	return e1
+}
+func (e ListEventsError) Unwrap() error {
+	return e.err
+}
+
+// Errors of the same kind match with errors.Is, whatever their cause
+func (e ListEventsError) Is(target error) bool {
+	t, ok := target.(ListEventsError)
+	return ok && t.kind == e.kind
+}
+
+var (
+	ListEventsErrorUnexpectedError = ListEventsError{kind: listEventsUnexpectedError}
+	ListEventsErrorInvalidCursor   = ListEventsError{kind: listEventsInvalidCursorError}
+)
+
+func toEvent(r repository.ListStockRatingEventsAfterRow) event {
+	return event{
+		cursor:        encodeCursor(r.Seq),
+		ticker:        r.Ticker,
+		company:       r.Company,
+		brokerage:     r.Brokerage,
+		targetFrom:    r.TargetFrom,
+		targetTo:      r.TargetTo,
+		action:        string(r.Action),
+		rawAction:     r.RawAction,
+		ratingFrom:    string(r.RatingFrom),
+		rawRatingFrom: r.RawRatingFrom,
+		ratingTo:      string(r.RatingTo),
+		rawRatingTo:   r.RawRatingTo,
+		at:            r.At,
+		recordedAt:    r.RecordedAt,
+	}
+}
+
+// Up to limit events recorded after the cursor
+func (s *Service) ListEvents(ctx context.Context, cursor string, limit int32) (ListEventsOutput, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "Service.ListEvents")
+	defer span.End()
+	span.SetAttributes(attribute.String("since_cursor", cursor), attribute.Int("limit", int(limit)))
+
+	after, ok := decodeCursor(cursor)
+	if !ok {
+		return ListEventsOutput{}, ListEventsErrorInvalidCursor
+	}
+	// One more event tells if there are more
+	res, err := s.repo.ListStockRatingEventsAfter(ctx, repository.ListStockRatingEventsAfterParams{After: after, Lim: limit + 1})
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return ListEventsOutput{}, ListEventsErrorUnexpectedError.From(err)
+	}
+
+	out := ListEventsOutput{nextCursor: cursor, hasMore: len(res) > int(limit)}
+	for _, r := range res[:min(len(res), int(limit))] {
+		out.events = append(out.events, toEvent(r))
+	}
+	if len(out.events) > 0 {
+		out.nextCursor = out.events[len(out.events)-1].cursor
+	}
+	return out, nil
+}
@@ -0,0 +1,85 @@
+package events
+
+import (
+	"backend/internal/features/stockratings"
+	"backend/internal/repository"
+	"backend/internal/repository/memstore"
+	"backend/internal/repository/repositorytest"
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func addEvents(t *testing.T, store *memstore.Store, at time.Time, tickers ...string) {
+	t.Helper()
+	var ratings []repository.AddStockRatingsParams
+	for _, ticker := range tickers {
+		ratings = append(ratings, repositorytest.NewStockRating(ticker, 150, 180, at))
+	}
+	if _, err := store.AddStockRatingEvents(context.Background(), stockratings.NewStockRatingEvents(ratings)); err != nil {
+		t.Fatalf("AddStockRatingEvents() error = %v", err)
+	}
+}
+
+func TestListEventsInRecordedOrder(t *testing.T) {
+	ctx := context.Background()
+	store := memstore.New()
+	at := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	addEvents(t, store, at, "AAPL", "MSFT", "AMZN")
+	s := NewService(store)
+
+	var tickers []string
+	var cursors []string
+	cursor := ""
+	for {
+		out, err := s.ListEvents(ctx, cursor, 2)
+		if err != nil {
+			t.Fatalf("ListEvents(%q) error = %v", cursor, err)
+		}
+		for _, e := range out.events {
+			tickers = append(tickers, e.ticker)
+			cursors = append(cursors, e.cursor)
+		}
+		cursor = out.nextCursor
+		if !out.hasMore {
+			break
+		}
+	}
+	// A load later brings an older event, it comes after the ones already read
+	addEvents(t, store, at.Add(-24*time.Hour), "TSLA")
+	out, err := s.ListEvents(ctx, cursor, 2)
+	if err != nil {
+		t.Fatalf("ListEvents(%q) error = %v", cursor, err)
+	}
+	for _, e := range out.events {
+		tickers = append(tickers, e.ticker)
+		cursors = append(cursors, e.cursor)
+	}
+
+	if want := []string{"AAPL", "MSFT", "AMZN", "TSLA"}; !slices.Equal(tickers, want) {
+		t.Errorf("ListEvents() = %v, want %v", tickers, want)
+	}
+	if !slices.IsSorted(cursors) || len(slices.Compact(slices.Clone(cursors))) != len(cursors) {
+		t.Errorf("ListEvents() cursors = %v, want them increasing", cursors)
+	}
+	if out.hasMore || out.nextCursor != cursors[len(cursors)-1] {
+		t.Errorf("ListEvents() at the end = %+v, want the cursor of the last event and no more", out)
+	}
+
+	// Nothing new, the consumer keeps its cursor
+	out, err = s.ListEvents(ctx, out.nextCursor, 2)
+	if err != nil || len(out.events) != 0 || out.nextCursor != cursors[len(cursors)-1] {
+		t.Errorf("ListEvents() without new events = %+v, %v, want the same cursor", out, err)
+	}
+}
+
+func TestListEventsInvalidCursor(t *testing.T) {
+	s := NewService(memstore.New())
+	for _, cursor := range []string{"abc", "zzzzzzzzzzzzzzzz", "ffffffffffffffff"} {
+		if _, err := s.ListEvents(context.Background(), cursor, 10); !errors.Is(err, ListEventsErrorInvalidCursor) {
+			t.Errorf("ListEvents(%q) error = %v, want %v", cursor, err, ListEventsErrorInvalidCursor)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package graphql
+
+import (
+	_ "embed"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	gql "github.com/graph-gophers/graphql-go"
+)
+
+//go:embed schema.graphql
+var Schema string
+
+// Nesting allowed in a query, enough for ticker -> events -> stock -> events
+const maxDepth = 8
+
+type HandlerInterface interface {
+	GraphQL(c *gin.Context)
+}
+type Handler struct {
+	service ServiceInterface
+	schema  *gql.Schema
+}
+
+func NewHandler(s ServiceInterface) *Handler {
+	return &Handler{
+		service: s,
+		schema: gql.MustParseSchema(Schema, &resolver{service: s},
+			gql.UseStringDescriptions(),
+			gql.MaxDepth(maxDepth),
+		),
+	}
+}
+
+type GraphQLRequest struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+// Queries are accepted as a JSON body, or as query parameters with the variables as JSON. Errors of
+// the query itself are reported in the errors of the response, with a 200 as the GraphQL clients
+// expect.
+func (h *Handler) GraphQL(c *gin.Context) {
+	// Validate parameters
+	var req GraphQLRequest
+	if c.Request.Method == http.MethodGet {
+		req.Query = c.Query("query")
+		req.OperationName = c.Query("operationName")
+		if variables := c.Query("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+				c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid variables"})
+				return
+			}
+		}
+	} else if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request body"})
+		return
+	}
+	if req.Query == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Missing query"})
+		return
+	}
+
+	// Execute it, the loaders batch the nested loads of this request
+	ctx := withLoaders(c.Request.Context(), h.service)
+	c.JSON(http.StatusOK, h.schema.Exec(ctx, req.Query, req.OperationName, req.Variables))
+}
+
+func AddGraphQLRoutes(rg *gin.RouterGroup, h HandlerInterface) {
+	rg.GET("/graphql", h.GraphQL)
+	rg.POST("/graphql", h.GraphQL)
+}
@@ -0,0 +1,114 @@
+package graphql
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// LOADERS =========================================================================================
+// The resolvers of a list run concurrently, each one asking for the nested data of its own item.
+// A loader collects the keys asked within a short window and fetches them with a single query, so
+// a query for 50 tickers with their events costs two queries instead of 51. The loaders live for
+// one request, which also caches the keys fetched by other parts of the same query.
+
+// Time waited after the first key for the others of the batch
+const batchWait = 2 * time.Millisecond
+
+type loader[K comparable, V any] struct {
+	fetch func(ctx context.Context, keys []K) (map[K]V, error)
+
+	mu        sync.Mutex
+	calls     map[K]*call[V]
+	pending   []K
+	scheduled bool
+}
+
+type call[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+}
+
+func newLoader[K comparable, V any](fetch func(ctx context.Context, keys []K) (map[K]V, error)) *loader[K, V] {
+	return &loader[K, V]{fetch: fetch, calls: map[K]*call[V]{}}
+}
+
+// Add keys to the next batch without waiting for them, for the parents that know which keys their
+// children will ask for. The keys then share a batch even when the children are resolved one after
+// the other.
+func (l *loader[K, V]) prime(keys ...K) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, key := range keys {
+		l.enqueue(key)
+	}
+}
+
+// Must be called with the lock held
+func (l *loader[K, V]) enqueue(key K) *call[V] {
+	if c, ok := l.calls[key]; ok {
+		return c
+	}
+	c := &call[V]{done: make(chan struct{})}
+	l.calls[key] = c
+	l.pending = append(l.pending, key)
+	return c
+}
+
+// Value of a key, fetched with the other keys of its batch. A key missing from the fetched values
+// gets the zero value.
+func (l *loader[K, V]) load(ctx context.Context, key K) (V, error) {
+	l.mu.Lock()
+	c := l.enqueue(key)
+	if len(l.pending) > 0 && !l.scheduled {
+		l.scheduled = true
+		time.AfterFunc(batchWait, func() { l.dispatch(ctx) })
+	}
+	l.mu.Unlock()
+
+	select {
+	case <-c.done:
+		return c.value, c.err
+	case <-ctx.Done():
+		var zero V
+		return zero, ctx.Err()
+	}
+}
+
+func (l *loader[K, V]) dispatch(ctx context.Context) {
+	l.mu.Lock()
+	keys := l.pending
+	l.pending = nil
+	l.scheduled = false
+	l.mu.Unlock()
+
+	values, err := l.fetch(ctx, keys)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, key := range keys {
+		c := l.calls[key]
+		c.value, c.err = values[key], err
+		close(c.done)
+	}
+}
+
+// Loaders of a request ----------------------------------------------------------------------------
+type loaders struct {
+	tickerEvents     *loader[string, []rating]
+	brokerageRatings *loader[string, []rating]
+}
+
+type loadersKey struct{}
+
+func withLoaders(ctx context.Context, s ServiceInterface) context.Context {
+	return context.WithValue(ctx, loadersKey{}, &loaders{
+		tickerEvents:     newLoader(s.EventsByTickers),
+		brokerageRatings: newLoader(s.RatingsByBrokerages),
+	})
+}
+
+func loadersFrom(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
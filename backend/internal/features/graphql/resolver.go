@@ -0,0 +1,223 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+
+	gql "github.com/graph-gophers/graphql-go"
+)
+
+// RESOLVERS =======================================================================================
+// The schema types are resolved from the service outputs. The enums of the schema are the database
+// values in upper case.
+
+type resolver struct {
+	service ServiceInterface
+}
+
+type listArgs struct {
+	SortBy      string
+	SortOrder   string
+	Offset      int32
+	Limit       int32
+	TickerLike  string
+	CompanyLike string
+}
+
+type pageArgs struct {
+	Offset int32
+	Limit  int32
+}
+
+var errInvalidPage = errors.New("Invalid page, offset and limit can't be negative")
+
+func (r *resolver) listRatings(ctx context.Context, args listArgs) ([]rating, error) {
+	if args.Offset < 0 || args.Limit < 0 {
+		return nil, errInvalidPage
+	}
+	return r.service.ListRatings(ctx, ListRatingsInput{
+		sortOrder:   strings.ToLower(args.SortOrder),
+		sortBy:      strings.ToLower(args.SortBy),
+		offset:      args.Offset,
+		limit:       args.Limit,
+		tickerLike:  args.TickerLike,
+		companyLike: args.CompanyLike,
+	})
+}
+
+func (r *resolver) Ratings(ctx context.Context, args listArgs) ([]*ratingResolver, error) {
+	ratings, err := r.listRatings(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+	return toRatingResolvers(ratings), nil
+}
+
+func (r *resolver) Tickers(ctx context.Context, args listArgs) ([]*tickerResolver, error) {
+	ratings, err := r.listRatings(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*tickerResolver, len(ratings))
+	tickers := make([]string, len(ratings))
+	for i, rt := range ratings {
+		out[i] = &tickerResolver{ticker: rt.ticker, company: rt.company}
+		tickers[i] = rt.ticker
+	}
+	loadersFrom(ctx).tickerEvents.prime(tickers...)
+	return out, nil
+}
+
+func (r *resolver) Ticker(ctx context.Context, args struct{ Ticker string }) (*tickerResolver, error) {
+	events, err := loadersFrom(ctx).tickerEvents.load(ctx, args.Ticker)
+	if err != nil || len(events) == 0 {
+		return nil, err
+	}
+	return &tickerResolver{ticker: events[0].ticker, company: events[0].company}, nil
+}
+
+func (r *resolver) Brokerages(ctx context.Context, args pageArgs) ([]*brokerageResolver, error) {
+	if args.Offset < 0 || args.Limit < 0 {
+		return nil, errInvalidPage
+	}
+	brokerages, err := r.service.ListBrokerages(ctx, ListBrokeragesInput{offset: args.Offset, limit: args.Limit})
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*brokerageResolver, len(brokerages))
+	names := make([]string, len(brokerages))
+	for i, b := range brokerages {
+		out[i] = &brokerageResolver{brokerage: b}
+		names[i] = b.name
+	}
+	loadersFrom(ctx).brokerageRatings.prime(names...)
+	return out, nil
+}
+
+func (r *resolver) Aggregates(ctx context.Context, args struct {
+	TickerLike  string
+	CompanyLike string
+}) (*aggregatesResolver, error) {
+	out, err := r.service.GetAggregates(ctx, GetAggregatesInput{tickerLike: args.TickerLike, companyLike: args.CompanyLike})
+	if err != nil {
+		return nil, err
+	}
+	return &aggregatesResolver{aggregates: out}, nil
+}
+
+// Ticker ------------------------------------------------------------------------------------------
+type tickerResolver struct {
+	ticker  string
+	company string
+}
+
+func (t *tickerResolver) Ticker() string  { return t.ticker }
+func (t *tickerResolver) Company() string { return t.company }
+
+func (t *tickerResolver) Events(ctx context.Context) ([]*ratingResolver, error) {
+	events, err := loadersFrom(ctx).tickerEvents.load(ctx, t.ticker)
+	if err != nil {
+		return nil, err
+	}
+	return toRatingResolvers(events), nil
+}
+
+func (t *tickerResolver) Latest(ctx context.Context) (*ratingResolver, error) {
+	events, err := loadersFrom(ctx).tickerEvents.load(ctx, t.ticker)
+	if err != nil || len(events) == 0 {
+		return nil, err
+	}
+	return &ratingResolver{rating: events[0]}, nil
+}
+
+// Rating event ------------------------------------------------------------------------------------
+type ratingResolver struct {
+	rating rating
+}
+
+func toRatingResolvers(ratings []rating) []*ratingResolver {
+	out := make([]*ratingResolver, len(ratings))
+	for i, rt := range ratings {
+		out[i] = &ratingResolver{rating: rt}
+	}
+	return out
+}
+
+// Numeric columns are read as text, they are always valid decimals
+func toFloat(s string) float64 {
+	f, _ := strconv.ParseFloat(s, 64)
+	return f
+}
+
+func (r *ratingResolver) Ticker() string          { return r.rating.ticker }
+func (r *ratingResolver) Company() string         { return r.rating.company }
+func (r *ratingResolver) Brokerage() string       { return r.rating.brokerage }
+func (r *ratingResolver) TargetFrom() float64     { return toFloat(r.rating.targetFrom) }
+func (r *ratingResolver) TargetTo() float64       { return toFloat(r.rating.targetTo) }
+func (r *ratingResolver) TargetDelta() float64    { return toFloat(r.rating.targetDelta) }
+func (r *ratingResolver) TargetDeltaPct() float64 { return toFloat(r.rating.targetDeltaPct) }
+func (r *ratingResolver) Action() string          { return strings.ToUpper(r.rating.action) }
+func (r *ratingResolver) RawAction() string       { return r.rating.rawAction }
+func (r *ratingResolver) RatingFrom() string      { return strings.ToUpper(r.rating.ratingFrom) }
+func (r *ratingResolver) RawRatingFrom() string   { return r.rating.rawRatingFrom }
+func (r *ratingResolver) RatingTo() string        { return strings.ToUpper(r.rating.ratingTo) }
+func (r *ratingResolver) RawRatingTo() string     { return r.rating.rawRatingTo }
+func (r *ratingResolver) At() gql.Time            { return gql.Time{Time: r.rating.at} }
+func (r *ratingResolver) Score() int32            { return r.rating.score }
+
+func (r *ratingResolver) Stock() *tickerResolver {
+	return &tickerResolver{ticker: r.rating.ticker, company: r.rating.company}
+}
+
+// Brokerage ---------------------------------------------------------------------------------------
+type brokerageResolver struct {
+	brokerage brokerage
+}
+
+func (b *brokerageResolver) Name() string        { return b.brokerage.name }
+func (b *brokerageResolver) RatingsCount() int32 { return int32(b.brokerage.ratings) }
+func (b *brokerageResolver) Upgrades() int32     { return int32(b.brokerage.upgrades) }
+func (b *brokerageResolver) Downgrades() int32   { return int32(b.brokerage.downgrades) }
+
+func (b *brokerageResolver) Ratings(ctx context.Context) ([]*ratingResolver, error) {
+	ratings, err := loadersFrom(ctx).brokerageRatings.load(ctx, b.brokerage.name)
+	if err != nil {
+		return nil, err
+	}
+	return toRatingResolvers(ratings), nil
+}
+
+// Aggregates --------------------------------------------------------------------------------------
+type aggregatesResolver struct {
+	aggregates GetAggregatesOutput
+}
+
+type countResolver struct {
+	value string
+	count int64
+}
+
+func (c *countResolver) Rating() string { return strings.ToUpper(c.value) }
+func (c *countResolver) Action() string { return strings.ToUpper(c.value) }
+func (c *countResolver) Count() int32   { return int32(c.count) }
+
+// Counts sorted by value, so the responses are stable
+func toCountResolvers(counts map[string]int64) []*countResolver {
+	out := make([]*countResolver, 0, len(counts))
+	for value, count := range counts {
+		out = append(out, &countResolver{value: value, count: count})
+	}
+	slices.SortFunc(out, func(a, b *countResolver) int { return strings.Compare(a.value, b.value) })
+	return out
+}
+
+func (a *aggregatesResolver) Count() int32 { return int32(a.aggregates.count) }
+func (a *aggregatesResolver) ByRating() []*countResolver {
+	return toCountResolvers(a.aggregates.byRating)
+}
+func (a *aggregatesResolver) ByAction() []*countResolver {
+	return toCountResolvers(a.aggregates.byAction)
+}
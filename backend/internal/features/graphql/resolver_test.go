@@ -0,0 +1,150 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// Service answering from memory, with a history of events per ticker, counting the batch loads
+type fakeService struct {
+	ratings          []rating
+	events           []rating
+	tickerBatches    atomic.Int32
+	brokerageBatches atomic.Int32
+}
+
+func (s *fakeService) ListRatings(_ context.Context, input ListRatingsInput) ([]rating, error) {
+	end := min(int(input.offset+input.limit), len(s.ratings))
+	return s.ratings[min(int(input.offset), end):end], nil
+}
+
+func (s *fakeService) EventsByTickers(_ context.Context, tickers []string) (map[string][]rating, error) {
+	s.tickerBatches.Add(1)
+	out := map[string][]rating{}
+	for _, ticker := range tickers {
+		for _, r := range s.events {
+			if r.ticker == ticker {
+				out[ticker] = append(out[ticker], r)
+			}
+		}
+	}
+	return out, nil
+}
+
+func (s *fakeService) RatingsByBrokerages(_ context.Context, brokerages []string) (map[string][]rating, error) {
+	s.brokerageBatches.Add(1)
+	out := map[string][]rating{}
+	for _, brokerage := range brokerages {
+		for _, r := range s.ratings {
+			if r.brokerage == brokerage {
+				out[brokerage] = append(out[brokerage], r)
+			}
+		}
+	}
+	return out, nil
+}
+
+func (s *fakeService) ListBrokerages(_ context.Context, input ListBrokeragesInput) ([]brokerage, error) {
+	counts := map[string]int64{}
+	var out []brokerage
+	for _, r := range s.ratings {
+		if counts[r.brokerage] == 0 {
+			out = append(out, brokerage{name: r.brokerage})
+		}
+		counts[r.brokerage]++
+	}
+	for i := range out {
+		out[i].ratings = counts[out[i].name]
+	}
+	return out[:min(int(input.limit), len(out))], nil
+}
+
+func (s *fakeService) GetAggregates(context.Context, GetAggregatesInput) (GetAggregatesOutput, error) {
+	return GetAggregatesOutput{}, nil
+}
+
+func newFakeService(tickers int) *fakeService {
+	s := &fakeService{}
+	for i := range tickers {
+		s.ratings = append(s.ratings, rating{
+			ticker:     fmt.Sprintf("T%03d", i),
+			company:    fmt.Sprintf("Company %d", i),
+			brokerage:  fmt.Sprintf("Brokerage %d", i%3),
+			targetFrom: "10.00",
+			targetTo:   "12.50",
+			action:     "up",
+			ratingFrom: "hold",
+			ratingTo:   "buy",
+			at:         time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+			score:      4500,
+		})
+		// The rating then the events before it, newest first
+		for j := range i%3 + 1 {
+			r := s.ratings[i]
+			r.at = r.at.Add(-time.Duration(j) * 24 * time.Hour)
+			s.events = append(s.events, r)
+		}
+	}
+	return s
+}
+
+func exec(t *testing.T, s ServiceInterface, query string) map[string]any {
+	t.Helper()
+	h := NewHandler(s)
+	resp := h.schema.Exec(withLoaders(context.Background(), s), query, "", nil)
+	if len(resp.Errors) > 0 {
+		t.Fatalf("query failed: %v", resp.Errors)
+	}
+	var data map[string]any
+	if err := json.Unmarshal(resp.Data, &data); err != nil {
+		t.Fatalf("invalid data: %v", err)
+	}
+	return data
+}
+
+func TestNestedEventsAreBatched(t *testing.T) {
+	s := newFakeService(50)
+	data := exec(t, s, `{ tickers(limit: 50) { ticker events { score stock { latest { ratingTo } } } latest { at } } }`)
+
+	tickers := data["tickers"].([]any)
+	if len(tickers) != 50 {
+		t.Fatalf("got %d tickers, want 50", len(tickers))
+	}
+	for i, ticker := range tickers {
+		if events := ticker.(map[string]any)["events"].([]any); len(events) != i%3+1 {
+			t.Fatalf("got %d events for %v, want its history of %d", len(events), ticker, i%3+1)
+		}
+	}
+	if batches := s.tickerBatches.Load(); batches != 1 {
+		t.Fatalf("loaded the events in %d batches, want 1", batches)
+	}
+}
+
+func TestBrokerageRatingsAreBatched(t *testing.T) {
+	s := newFakeService(30)
+	data := exec(t, s, `{ brokerages(limit: 3) { name ratingsCount ratings { ticker } } }`)
+
+	for _, b := range data["brokerages"].([]any) {
+		b := b.(map[string]any)
+		if ratings := b["ratings"].([]any); float64(len(ratings)) != b["ratingsCount"] {
+			t.Fatalf("got %d ratings for %v, want its count", len(ratings), b["name"])
+		}
+	}
+	if batches := s.brokerageBatches.Load(); batches != 1 {
+		t.Fatalf("loaded the ratings in %d batches, want 1", batches)
+	}
+}
+
+func TestEnumsAreUpperCase(t *testing.T) {
+	s := newFakeService(1)
+	data := exec(t, s, `{ ratings(sortBy: TARGET_DELTA, sortOrder: ASC) { action ratingFrom ratingTo targetTo } }`)
+
+	got := data["ratings"].([]any)[0].(map[string]any)
+	if got["action"] != "UP" || got["ratingFrom"] != "HOLD" || got["ratingTo"] != "BUY" || got["targetTo"] != 12.5 {
+		t.Fatalf("got %v", got)
+	}
+}
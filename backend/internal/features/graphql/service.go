@@ -0,0 +1,269 @@
+package graphql
+
+import (
+	"backend/internal/repository"
+	"backend/pkg/tracing"
+	"context"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// SERVICE =========================================================================================
+
+type ServiceInterface interface {
+	ListRatings(ctx context.Context, input ListRatingsInput) ([]rating, error)
+	EventsByTickers(ctx context.Context, tickers []string) (map[string][]rating, error)
+	RatingsByBrokerages(ctx context.Context, brokerages []string) (map[string][]rating, error)
+	ListBrokerages(ctx context.Context, input ListBrokeragesInput) ([]brokerage, error)
+	GetAggregates(ctx context.Context, input GetAggregatesInput) (GetAggregatesOutput, error)
+}
+type Service struct {
+	repo repository.Repository
+}
+
+func NewService(r repository.Repository) *Service {
+	return &Service{
+		repo: r,
+	}
+}
+
+// Errors ------------------------------------------------------------------------------------------
+type GraphQLErrorKind int
+
+const (
+	_ GraphQLErrorKind = iota
+	graphQLUnexpectedError
+)
+
+type GraphQLError struct {
+	kind GraphQLErrorKind
+	err  error
+}
+
+func (e GraphQLError) Error() string {
+	switch e.kind {
+	case graphQLUnexpectedError:
+		return fmt.Sprintf("Unexpected error: %s", e.err.Error())
+	default:
+		return "Unknown error"
+	}
+}
+
+func (e GraphQLError) From(err error) GraphQLError {
+	e1 := e
+	e1.err = err
+	return e1
+}
+func (e GraphQLError) Unwrap() error {
+	return e.err
+}
+
+// Errors of the same kind match with errors.Is, whatever their cause
+func (e GraphQLError) Is(target error) bool {
+	t, ok := target.(GraphQLError)
+	return ok && t.kind == e.kind
+}
+
+var (
+	GraphQLErrorUnexpectedError = GraphQLError{kind: graphQLUnexpectedError}
+)
+
+// Ratings -----------------------------------------------------------------------------------------
+type rating = struct {
+	ticker         string
+	company        string
+	brokerage      string
+	targetFrom     string
+	targetTo       string
+	targetDelta    string
+	targetDeltaPct string
+	action         string
+	rawAction      string
+	ratingFrom     string
+	rawRatingFrom  string
+	ratingTo       string
+	rawRatingTo    string
+	at             time.Time
+	score          int32
+}
+
+// The batch queries return the columns of the list, their rows convert to its row type
+func toRating(r repository.GetStockRatingsRow) rating {
+	return rating{
+		ticker:         r.Ticker,
+		company:        r.Company,
+		brokerage:      r.Brokerage,
+		targetFrom:     r.TargetFrom,
+		targetTo:       r.TargetTo,
+		targetDelta:    r.TargetDelta,
+		targetDeltaPct: r.TargetDeltaPct,
+		action:         string(r.Action),
+		rawAction:      r.RawAction,
+		ratingFrom:     string(r.RatingFrom),
+		rawRatingFrom:  r.RawRatingFrom,
+		ratingTo:       string(r.RatingTo),
+		rawRatingTo:    r.RawRatingTo,
+		at:             r.At.UTC(),
+		score:          r.Score,
+	}
+}
+
+// Same arguments as the REST list
+type ListRatingsInput struct {
+	sortOrder   string
+	sortBy      string
+	offset      int32
+	limit       int32
+	tickerLike  string
+	companyLike string
+}
+
+func (s *Service) ListRatings(ctx context.Context, input ListRatingsInput) ([]rating, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "Service.ListRatings")
+	defer span.End()
+	span.SetAttributes(
+		attribute.String("sort_by", input.sortBy),
+		attribute.String("sort_order", input.sortOrder),
+		attribute.Int("offset", int(input.offset)),
+		attribute.Int("limit", int(input.limit)),
+	)
+
+	res, err := s.repo.GetStockRatings(ctx, repository.GetStockRatingsParams{
+		SortOrder:   input.sortOrder,
+		SortBy:      input.sortBy,
+		Offset:      input.offset,
+		Limit:       input.limit,
+		TickerLike:  input.tickerLike,
+		CompanyLike: input.companyLike,
+	})
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return nil, GraphQLErrorUnexpectedError.From(err)
+	}
+
+	out := make([]rating, len(res))
+	for i, r := range res {
+		out[i] = toRating(r)
+	}
+	return out, nil
+}
+
+// Rating events of each ticker from the history, newest first. Called by the loaders with the
+// tickers of a whole query.
+func (s *Service) EventsByTickers(ctx context.Context, tickers []string) (map[string][]rating, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "Service.EventsByTickers")
+	defer span.End()
+	span.SetAttributes(attribute.Int("tickers", len(tickers)))
+
+	res, err := s.repo.GetStockRatingEventsByTickers(ctx, tickers)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return nil, GraphQLErrorUnexpectedError.From(err)
+	}
+
+	out := map[string][]rating{}
+	for _, r := range res {
+		out[r.Ticker] = append(out[r.Ticker], toRating(repository.GetStockRatingsRow(r)))
+	}
+	return out, nil
+}
+
+// Ratings of each brokerage, newest first. Called by the loaders with the brokerages of a whole
+// query.
+func (s *Service) RatingsByBrokerages(ctx context.Context, brokerages []string) (map[string][]rating, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "Service.RatingsByBrokerages")
+	defer span.End()
+	span.SetAttributes(attribute.Int("brokerages", len(brokerages)))
+
+	res, err := s.repo.GetStockRatingsByBrokerages(ctx, brokerages)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return nil, GraphQLErrorUnexpectedError.From(err)
+	}
+
+	out := map[string][]rating{}
+	for _, r := range res {
+		out[r.Brokerage] = append(out[r.Brokerage], toRating(repository.GetStockRatingsRow(r)))
+	}
+	return out, nil
+}
+
+// Brokerages --------------------------------------------------------------------------------------
+type brokerage = struct {
+	name       string
+	ratings    int64
+	upgrades   int64
+	downgrades int64
+}
+
+type ListBrokeragesInput struct {
+	offset int32
+	limit  int32
+}
+
+func (s *Service) ListBrokerages(ctx context.Context, input ListBrokeragesInput) ([]brokerage, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "Service.ListBrokerages")
+	defer span.End()
+	span.SetAttributes(
+		attribute.Int("offset", int(input.offset)),
+		attribute.Int("limit", int(input.limit)),
+	)
+
+	res, err := s.repo.GetBrokerages(ctx, repository.GetBrokeragesParams{
+		Offset: input.offset,
+		Limit:  input.limit,
+	})
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return nil, GraphQLErrorUnexpectedError.From(err)
+	}
+
+	out := make([]brokerage, len(res))
+	for i, r := range res {
+		out[i] = brokerage{
+			name:       r.Brokerage,
+			ratings:    r.Ratings,
+			upgrades:   r.Upgrades,
+			downgrades: r.Downgrades,
+		}
+	}
+	return out, nil
+}
+
+// Aggregates --------------------------------------------------------------------------------------
+type GetAggregatesInput struct {
+	tickerLike  string
+	companyLike string
+}
+
+// Counts of the ratings matching the filters, in total and per rating and action
+type GetAggregatesOutput = struct {
+	count    int64
+	byRating map[string]int64
+	byAction map[string]int64
+}
+
+func (s *Service) GetAggregates(ctx context.Context, input GetAggregatesInput) (GetAggregatesOutput, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "Service.GetAggregates")
+	defer span.End()
+
+	res, err := s.repo.GetStockRatingCounts(ctx, repository.GetStockRatingCountsParams{
+		TickerLike:  input.tickerLike,
+		CompanyLike: input.companyLike,
+	})
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return GetAggregatesOutput{}, GraphQLErrorUnexpectedError.From(err)
+	}
+
+	out := GetAggregatesOutput{byRating: map[string]int64{}, byAction: map[string]int64{}}
+	for _, r := range res {
+		out.count += r.Count
+		out.byRating[string(r.RatingTo)] += r.Count
+		out.byAction[string(r.Action)] += r.Count
+	}
+	return out, nil
+}
@@ -0,0 +1,96 @@
+package ingestion
+
+import (
+	"backend/internal/repository"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Runs listed at most, one per load
+const maxLimit = 100
+
+type HandlerInterface interface {
+	ListRuns(c *gin.Context)
+}
+type Handler struct {
+	service ServiceInterface
+}
+
+func NewHandler(s ServiceInterface) *Handler {
+	return &Handler{service: s}
+}
+
+type RunResponse struct {
+	ID         string     `json:"id"`
+	Source     string     `json:"source"`
+	Status     string     `json:"status"`
+	Error      *string    `json:"error"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at"`
+	Pages      int64      `json:"pages"`
+}
+
+type RunListResponse struct {
+	Length int           `json:"length"`
+	Runs   []RunResponse `json:"runs"`
+}
+
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+func toRunResponse(r run) RunResponse {
+	resp := RunResponse{
+		ID:        r.id.String(),
+		Source:    r.source,
+		Status:    r.status,
+		Error:     r.err,
+		StartedAt: r.startedAt.UTC(),
+		Pages:     r.pages,
+	}
+	if r.finishedAt != nil {
+		finishedAt := r.finishedAt.UTC()
+		resp.FinishedAt = &finishedAt
+	}
+	return resp
+}
+
+// Serialize a row of the runs query as in the list, for the clients reading the database
+func NewRunResponse(r repository.ListIngestionRunsRow) RunResponse {
+	return toRunResponse(toRun(r))
+}
+
+func (h *Handler) ListRuns(c *gin.Context) {
+	// Validate parameters
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 || limit > maxLimit {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid limit, use 1 to 100"})
+		return
+	}
+
+	// Call the service
+	runs, err := h.service.ListRuns(c.Request.Context(), int32(limit))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	// Serialize the output
+	resp := make([]RunResponse, len(runs))
+	for i, r := range runs {
+		resp[i] = toRunResponse(r)
+	}
+
+	c.JSON(http.StatusOK, RunListResponse{
+		Length: len(resp),
+		Runs:   resp,
+	})
+}
+
+func AddIngestionRoutes(rg *gin.RouterGroup, h HandlerInterface) {
+	ingestion := rg.Group("/ingestion")
+	ingestion.GET("/runs/", h.ListRuns)
+}
@@ -0,0 +1,109 @@
+package ingestion
+
+import (
+	"backend/internal/repository"
+	"backend/pkg/tracing"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// SERVICE =========================================================================================
+
+type ServiceInterface interface {
+	ListRuns(ctx context.Context, limit int32) ([]run, error)
+}
+type Service struct {
+	repo repository.Repository
+}
+
+func NewService(r repository.Repository) *Service {
+	return &Service{
+		repo: r,
+	}
+}
+
+// ListRuns ----------------------------------------------------------------------------------------
+type run = struct {
+	id         uuid.UUID
+	source     string
+	status     string
+	err        *string
+	startedAt  time.Time
+	finishedAt *time.Time
+	pages      int64
+}
+
+type ListRunsErrorKind int
+
+const (
+	_ ListRunsErrorKind = iota
+	listRunsUnexpectedError
+)
+
+type ListRunsError struct {
+	kind ListRunsErrorKind
+	err  error
+}
+
+func (e ListRunsError) Error() string {
+	switch e.kind {
+	case listRunsUnexpectedError:
+		return fmt.Sprintf("Unexpected error: %s", e.err.Error())
+	default:
+		return "Unknown error"
+	}
+}
+
+func (e ListRunsError) From(err error) ListRunsError {
+	e1 := e
+	e1.err = err
+	return e1
+}
+func (e ListRunsError) Unwrap() error {
+	return e.err
+}
+
+var (
+	ListRunsErrorUnexpectedError = ListRunsError{kind: listRunsUnexpectedError}
+)
+
+func toRun(r repository.ListIngestionRunsRow) run {
+	out := run{
+		id:        r.ID,
+		source:    string(r.Source),
+		status:    string(r.Status),
+		startedAt: r.StartedAt,
+		pages:     r.Pages,
+	}
+	if r.Error.Valid {
+		out.err = &r.Error.String
+	}
+	if r.FinishedAt.Valid {
+		out.finishedAt = &r.FinishedAt.Time
+	}
+	return out
+}
+
+// Most recent ingestion runs, loads from the API and replays of the archive
+func (s *Service) ListRuns(ctx context.Context, limit int32) ([]run, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "Service.ListRuns")
+	defer span.End()
+	span.SetAttributes(attribute.Int("limit", int(limit)))
+
+	res, err := s.repo.ListIngestionRuns(ctx, limit)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return nil, ListRunsErrorUnexpectedError.From(err)
+	}
+
+	out := make([]run, len(res))
+	for i, r := range res {
+		out[i] = toRun(r)
+	}
+	return out, nil
+}
@@ -0,0 +1,158 @@
+package retention
+
+import (
+	"backend/internal/repository"
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+)
+
+// RETENTION =======================================================================================
+// The history of the ratings keeps its events for a number of years, the older ones are rolled
+// into monthly summaries per ticker and deleted. The latest event of each ticker is kept whatever
+// its age, it is the current rating. Loads send the whole history again, the events rolled up are
+// skipped and not recorded a second time.
+//
+// An event is only rolled up once it was also recorded before the cutoff. The change feed serves
+// the events in the order they were recorded, whatever their time, its readers have the whole
+// retention to read one.
+//
+// Each batch deletes its events and writes their summaries in one statement, so an interrupted
+// roll-up resumes where it stopped and replicas running the job at once can't count an event twice.
+
+// Events rolled up per statement
+const DefaultBatchSize = 1000
+
+// Years of detailed history to keep, 0 keeps everything
+type Policy struct {
+	Years int
+}
+
+// Policy of RETENTION_YEARS, empty for none
+func ParsePolicy(years string) (Policy, error) {
+	if years == "" {
+		return Policy{}, nil
+	}
+	n, err := strconv.Atoi(years)
+	if err != nil || n < 0 {
+		return Policy{}, fmt.Errorf("invalid number of years %q", years)
+	}
+	return Policy{Years: n}, nil
+}
+
+func (p Policy) Enabled() bool {
+	return p.Years > 0
+}
+
+// Events before the cutoff are rolled up. It is the first day of a month in UTC so the summaries
+// cover whole months, a month is rolled up once all of it is past the retention.
+func (p Policy) Cutoff(now time.Time) time.Time {
+	now = now.UTC()
+	return time.Date(now.Year()-p.Years, now.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// SERVICE =========================================================================================
+
+type Service struct {
+	repo      repository.Repository
+	batchSize int32
+}
+
+func NewService(r repository.Repository, batchSize int32) *Service {
+	return &Service{
+		repo:      r,
+		batchSize: max(batchSize, 1),
+	}
+}
+
+// Events of a month past the cutoff
+type Month struct {
+	Month   time.Time
+	Events  int64
+	Tickers int64
+}
+
+type Result struct {
+	Events int64
+	// Monthly summaries written or merged into, a month split over batches counts once per batch
+	Summaries int64
+	Batches   int
+}
+
+// What Apply would delete and summarize, without changing anything
+func (s *Service) Plan(ctx context.Context, before time.Time) ([]Month, error) {
+	rows, err := s.repo.GetExpiredStockRatingEvents(ctx, repository.GetExpiredStockRatingEventsParams{
+		Before:         before,
+		RecordedBefore: before,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("reading the expired events: %w", err)
+	}
+	months := make([]Month, len(rows))
+	for i, r := range rows {
+		months[i] = Month{Month: r.Month.Time, Events: r.Events, Tickers: r.Tickers}
+	}
+	return months, nil
+}
+
+// Roll up the events before the cutoff, then bump the dataset version so the cached as_of and diff
+// responses don't serve the deleted history. The batches rolled up before a failure are committed,
+// the version is bumped for them too.
+func (s *Service) Apply(ctx context.Context, before time.Time) (Result, error) {
+	result, err := s.rollUp(ctx, before)
+	if result.Events > 0 {
+		if _, bumpErr := s.repo.BumpDatasetVersion(ctx); bumpErr != nil && err == nil {
+			err = fmt.Errorf("bumping the dataset version: %w", bumpErr)
+		}
+	}
+	return result, err
+}
+
+// Roll up the events before the cutoff, a batch at a time until none is left
+func (s *Service) rollUp(ctx context.Context, before time.Time) (Result, error) {
+	var result Result
+	for {
+		batch, err := s.repo.RollUpStockRatingEvents(ctx, repository.RollUpStockRatingEventsParams{
+			Before:         before,
+			RecordedBefore: before,
+			Lim:            s.batchSize,
+		})
+		if err != nil {
+			return result, fmt.Errorf("rolling up the events before %s, after %d batches: %w", before.Format(time.DateOnly), result.Batches, err)
+		}
+		if batch.Events == 0 {
+			return result, nil
+		}
+		result.Events += batch.Events
+		result.Summaries += batch.Summaries
+		result.Batches++
+		if batch.Events < int64(s.batchSize) {
+			return result, nil
+		}
+	}
+}
+
+// JOB =============================================================================================
+
+// Apply the policy every interval until the context is cancelled. Each replica of the app runs
+// it, a failed run is retried at the next interval.
+func (s *Service) Run(ctx context.Context, policy Policy, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		before := policy.Cutoff(time.Now())
+		result, err := s.Apply(ctx, before)
+		if err != nil {
+			log.Println("Error applying the retention: ", err)
+		} else if result.Events > 0 {
+			log.Printf("Rolled up %d events before %s into %d monthly summaries", result.Events, before.Format(time.DateOnly), result.Summaries)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
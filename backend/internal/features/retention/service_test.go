@@ -0,0 +1,140 @@
+package retention
+
+import (
+	"backend/internal/features/stockratings"
+	"backend/internal/repository"
+	"backend/internal/repository/memstore"
+	"backend/internal/repository/repositorytest"
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestPolicyCutoff(t *testing.T) {
+	now := time.Date(2025, time.June, 17, 22, 30, 0, 0, time.FixedZone("EDT", -4*3600))
+	got := Policy{Years: 2}.Cutoff(now)
+	if want := time.Date(2023, time.June, 1, 0, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("Cutoff() = %v, want %v", got, want)
+	}
+	if p, err := ParsePolicy(""); err != nil || p.Enabled() {
+		t.Errorf("ParsePolicy(\"\") = %+v, %v, want a disabled policy", p, err)
+	}
+	if _, err := ParsePolicy("-1"); err == nil {
+		t.Errorf("ParsePolicy(-1) error = nil, want an error")
+	}
+}
+
+// A store with events of 2 tickers every 10 days of 2023 and 2024, recorded at recordedAt
+func newStore(t *testing.T, recordedAt time.Time) *memstore.Store {
+	t.Helper()
+	var rows []repository.AddStockRatingsParams
+	for _, ticker := range []string{"AAPL", "MSFT"} {
+		for at := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC); at.Year() < 2025; at = at.AddDate(0, 0, 10) {
+			rows = append(rows, repositorytest.NewStockRating(ticker, 100, 110, at))
+		}
+	}
+	store := memstore.New()
+	store.SetClock(func() time.Time { return recordedAt })
+	if _, err := store.AddStockRatingEvents(context.Background(), stockratings.NewStockRatingEvents(rows)); err != nil {
+		t.Fatalf("AddStockRatingEvents() error = %v", err)
+	}
+	store.SetClock(time.Now)
+	return store
+}
+
+// Recorded before every cutoff of the tests, the change feed had the time to serve the events
+var longAgo = time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func TestPlanChangesNothing(t *testing.T) {
+	store := newStore(t, longAgo)
+	s := NewService(store, 7)
+	before := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	months, err := s.Plan(context.Background(), before)
+	if err != nil {
+		t.Fatalf("Plan() error = %v", err)
+	}
+	if len(months) != 12 || months[0].Tickers != 2 {
+		t.Errorf("Plan() = %+v, want the 12 months of 2023 with 2 tickers each", months)
+	}
+	again, _ := s.Plan(context.Background(), before)
+	if !slices.Equal(months, again) {
+		t.Errorf("Plan() again = %+v, want %+v", again, months)
+	}
+}
+
+func TestApplyInBatches(t *testing.T) {
+	before := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	ctx := context.Background()
+
+	// Small batches split the months, their summaries must match those of a single batch
+	batched, whole := newStore(t, longAgo), newStore(t, longAgo)
+	plan, _ := NewService(batched, 7).Plan(ctx, before)
+	var want int64
+	for _, m := range plan {
+		want += m.Events
+	}
+	result, err := NewService(batched, 7).Apply(ctx, before)
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if result.Events != want || result.Batches != int(want+6)/7 {
+		t.Errorf("Apply() = %+v, want %d events in batches of 7", result, want)
+	}
+	if _, err := NewService(whole, 10_000).Apply(ctx, before); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+
+	for _, ticker := range []string{"AAPL", "MSFT"} {
+		got, _ := batched.GetStockRatingMonthly(ctx, ticker)
+		want, _ := whole.GetStockRatingMonthly(ctx, ticker)
+		if len(got) != 12 || !slices.EqualFunc(got, want, sameSummary) {
+			t.Errorf("summaries of %s in batches = %+v, want %+v", ticker, got, want)
+		}
+	}
+	if months, _ := NewService(batched, 7).Plan(ctx, before); len(months) != 0 {
+		t.Errorf("Plan() after Apply() = %+v, want nothing left", months)
+	}
+	// The events after the cutoff are kept, and so is the latest of each ticker, on December 31st
+	if months, _ := NewService(batched, 7).Plan(ctx, before.AddDate(1, 0, 0)); len(months) != 12 || months[11].Events != 6 {
+		t.Errorf("Plan() of 2024 = %+v, want 12 months and 6 of the 8 events of December", months)
+	}
+}
+
+func TestApplyBumpsTheDatasetVersion(t *testing.T) {
+	before := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	ctx := context.Background()
+	store := newStore(t, longAgo)
+	s := NewService(store, 100)
+
+	for i, want := range []int64{2, 2} {
+		if _, err := s.Apply(ctx, before); err != nil {
+			t.Fatalf("Apply() error = %v", err)
+		}
+		// Nothing left to roll up the second time, the cached responses are still valid
+		if version, err := store.GetDatasetVersion(ctx); err != nil || version.Version != want {
+			t.Errorf("dataset version after Apply() #%d = %d, %v, want %d", i, version.Version, err, want)
+		}
+	}
+}
+
+func TestApplyKeepsTheEventsRecordedSinceTheCutoff(t *testing.T) {
+	before := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	ctx := context.Background()
+	store := newStore(t, before.AddDate(0, 0, 1))
+
+	result, err := NewService(store, 100).Apply(ctx, before)
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if result.Events != 0 {
+		t.Errorf("Apply() = %+v, want the events the change feed may not have served kept", result)
+	}
+}
+
+func sameSummary(a, b repository.StockRatingMonthly) bool {
+	return a.Ticker == b.Ticker && a.Month == b.Month && a.Events == b.Events &&
+		a.Upgrades == b.Upgrades && a.Downgrades == b.Downgrades &&
+		a.FirstAt.Equal(b.FirstAt) && a.LastAt.Equal(b.LastAt) && a.LastRatingTo == b.LastRatingTo
+}
@@ -0,0 +1,80 @@
+package search
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Suggestions per type are capped, the endpoint is called on every keystroke
+const maxLimit = 20
+
+type HandlerInterface interface {
+	Search(c *gin.Context)
+}
+type Handler struct {
+	service ServiceInterface
+}
+
+func NewHandler(s ServiceInterface) *Handler {
+	return &Handler{service: s}
+}
+
+type SuggestionResponse struct {
+	Value string  `json:"value"`
+	Rank  float64 `json:"rank"`
+}
+
+type SearchResponse struct {
+	Query      string               `json:"query"`
+	Tickers    []SuggestionResponse `json:"tickers"`
+	Companies  []SuggestionResponse `json:"companies"`
+	Brokerages []SuggestionResponse `json:"brokerages"`
+}
+
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+func (h *Handler) Search(c *gin.Context) {
+	// Validate parameters
+	query := strings.TrimSpace(c.Query("q"))
+	if query == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Missing query"})
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "5"))
+	if err != nil || limit < 1 || limit > maxLimit {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid limit, use 1 to 20"})
+		return
+	}
+
+	// Call the service
+	out, err := h.service.Search(c.Request.Context(), SearchInput{query: query, limit: int32(limit)})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	// Serialize the output
+	c.JSON(http.StatusOK, SearchResponse{
+		Query:      query,
+		Tickers:    toResponse(out.tickers),
+		Companies:  toResponse(out.companies),
+		Brokerages: toResponse(out.brokerages),
+	})
+}
+
+func toResponse(suggestions []suggestion) []SuggestionResponse {
+	resp := make([]SuggestionResponse, len(suggestions))
+	for i, s := range suggestions {
+		resp[i] = SuggestionResponse{Value: s.value, Rank: s.rank}
+	}
+	return resp
+}
+
+func AddSearchRoutes(rg *gin.RouterGroup, h HandlerInterface) {
+	rg.GET("/search", h.Search)
+}
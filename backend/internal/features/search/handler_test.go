@@ -0,0 +1,61 @@
+package search
+
+import (
+	"backend/internal/repository/memstore"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func serve(t *testing.T, s ServiceInterface, target string) *httptest.ResponseRecorder {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	AddSearchRoutes(router.Group("/v1"), NewHandler(s))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+	return w
+}
+
+func TestHandlerSearch(t *testing.T) {
+	w := serve(t, NewService(newStore(t, "AAPL", "MSFT")), "/v1/search?q=+aap+&limit=3")
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET /v1/search = %d %s, want 200", w.Code, w.Body)
+	}
+	var resp SearchResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid response %s: %v", w.Body, err)
+	}
+	if resp.Query != "aap" || len(resp.Tickers) != 1 || resp.Tickers[0].Value != "AAPL" || resp.Brokerages == nil {
+		t.Errorf("GET /v1/search = %+v, want the trimmed query, AAPL and empty arrays", resp)
+	}
+}
+
+func TestHandlerSearchErrors(t *testing.T) {
+	cases := []struct {
+		name    string
+		service ServiceInterface
+		target  string
+		want    int
+	}{
+		{"missing query", NewService(memstore.New()), "/v1/search", http.StatusBadRequest},
+		{"blank query", NewService(memstore.New()), "/v1/search?q=+", http.StatusBadRequest},
+		{"limit too low", NewService(memstore.New()), "/v1/search?q=a&limit=0", http.StatusBadRequest},
+		{"limit too high", NewService(memstore.New()), "/v1/search?q=a&limit=21", http.StatusBadRequest},
+		{"limit not a number", NewService(memstore.New()), "/v1/search?q=a&limit=five", http.StatusBadRequest},
+		{"repository down", NewService(failingRepository{memstore.New(), errors.New("connection reset")}), "/v1/search?q=a", http.StatusInternalServerError},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := serve(t, tc.service, tc.target)
+			var resp ErrorResponse
+			if w.Code != tc.want || json.Unmarshal(w.Body.Bytes(), &resp) != nil || resp.Error == "" {
+				t.Errorf("GET %s = %d %s, want %d with an error", tc.target, w.Code, w.Body, tc.want)
+			}
+		})
+	}
+}
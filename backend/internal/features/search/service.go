@@ -0,0 +1,116 @@
+package search
+
+import (
+	"backend/internal/repository"
+	"backend/pkg/tracing"
+	"context"
+	"fmt"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// SERVICE =========================================================================================
+
+type ServiceInterface interface {
+	Search(ctx context.Context, input SearchInput) (SearchOutput, error)
+}
+type Service struct {
+	repo repository.Repository
+}
+
+func NewService(r repository.Repository) *Service {
+	return &Service{
+		repo: r,
+	}
+}
+
+// Search ------------------------------------------------------------------------------------------
+type SearchInput struct {
+	query string
+	limit int32
+}
+
+type suggestion = struct {
+	value string
+	rank  float64
+}
+
+// Suggestions grouped by the column they matched, best first
+type SearchOutput = struct {
+	tickers    []suggestion
+	companies  []suggestion
+	brokerages []suggestion
+}
+
+type SearchErrorKind int
+
+const (
+	_ SearchErrorKind = iota
+	searchUnexpectedError
+)
+
+type SearchError struct {
+	kind SearchErrorKind
+	err  error
+}
+
+func (e SearchError) Error() string {
+	switch e.kind {
+	case searchUnexpectedError:
+		return fmt.Sprintf("Unexpected error: %s", e.err.Error())
+	default:
+		return "Unknown error"
+	}
+}
+
+func (e SearchError) From(err error) SearchError {
+	e1 := e
+	e1.err = err
+	return e1
+}
+func (e SearchError) Unwrap() error {
+	return e.err
+}
+
+var (
+	SearchErrorUnexpectedError = SearchError{kind: searchUnexpectedError}
+)
+
+// Escape the LIKE wildcards so the query only matches literally as a prefix
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func (s *Service) Search(ctx context.Context, input SearchInput) (SearchOutput, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "Service.Search")
+	defer span.End()
+	span.SetAttributes(
+		attribute.Int("query_length", len(input.query)),
+		attribute.Int("limit", int(input.limit)),
+	)
+
+	res, err := s.repo.Search(ctx, repository.SearchParams{
+		Q:      input.query,
+		Prefix: likeEscaper.Replace(input.query),
+		Limit:  input.limit,
+	})
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return SearchOutput{}, SearchErrorUnexpectedError.From(err)
+	}
+
+	var out SearchOutput
+	for _, r := range res {
+		item := suggestion{value: r.Value, rank: r.Rank}
+		switch r.Kind {
+		case "ticker":
+			out.tickers = append(out.tickers, item)
+		case "company":
+			out.companies = append(out.companies, item)
+		case "brokerage":
+			out.brokerages = append(out.brokerages, item)
+		}
+	}
+	span.SetAttributes(attribute.Int("results", len(res)))
+	return out, nil
+}
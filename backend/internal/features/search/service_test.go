@@ -0,0 +1,77 @@
+package search
+
+import (
+	"backend/internal/repository"
+	"backend/internal/repository/memstore"
+	"backend/internal/repository/repositorytest"
+	"context"
+	"errors"
+	"testing"
+)
+
+func newStore(t *testing.T, tickers ...string) *memstore.Store {
+	t.Helper()
+	var arg []repository.AddStockRatingsParams
+	for _, ticker := range tickers {
+		arg = append(arg, repositorytest.NewStockRating(ticker, 150, 180, repositorytest.RatedAt))
+	}
+	store := memstore.New()
+	if _, err := store.AddStockRatings(context.Background(), arg); err != nil {
+		t.Fatalf("AddStockRatings() error = %v", err)
+	}
+	return store
+}
+
+func TestSearchGroupsByKind(t *testing.T) {
+	s := NewService(newStore(t, "AAPL", "AMZN", "MSFT"))
+
+	out, err := s.Search(context.Background(), SearchInput{query: "gold", limit: 5})
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if len(out.tickers) != 0 || len(out.companies) != 0 || len(out.brokerages) != 1 || out.brokerages[0].value != "Goldman Sachs" {
+		t.Errorf("Search(gold) = %+v, want only the brokerage", out)
+	}
+
+	out, err = s.Search(context.Background(), SearchInput{query: "a", limit: 5})
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if len(out.tickers) != 2 || out.tickers[0].value != "AAPL" || out.tickers[1].value != "AMZN" || len(out.companies) != 2 {
+		t.Errorf("Search(a) = %+v, want the tickers and companies starting with a", out)
+	}
+}
+
+func TestSearchMatchesWildcardsLiterally(t *testing.T) {
+	s := NewService(newStore(t, "AAPL", "AMZN"))
+
+	for _, query := range []string{"a%", "a_", `a\`} {
+		out, err := s.Search(context.Background(), SearchInput{query: query, limit: 5})
+		if err != nil {
+			t.Fatalf("Search(%q) error = %v", query, err)
+		}
+		if len(out.tickers) != 0 || len(out.companies) != 0 {
+			t.Errorf("Search(%q) = %+v, want no match", query, out)
+		}
+	}
+}
+
+// Repository failing the search, the errors of the database can't be reached with memstore
+type failingRepository struct {
+	repository.Repository
+	err error
+}
+
+func (r failingRepository) Search(context.Context, repository.SearchParams) ([]repository.SearchRow, error) {
+	return nil, r.err
+}
+
+func TestSearchUnexpectedError(t *testing.T) {
+	s := NewService(failingRepository{memstore.New(), errors.New("connection reset")})
+
+	_, err := s.Search(context.Background(), SearchInput{query: "gold", limit: 5})
+	var serviceErr SearchError
+	if !errors.As(err, &serviceErr) || serviceErr.kind != searchUnexpectedError {
+		t.Errorf("Search() error = %v, want an unexpected error", err)
+	}
+}
@@ -0,0 +1,132 @@
+package stockratings
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// DIFF ============================================================================================
+// What changed between two points of the history, e.g. between the loads of yesterday and today.
+// The values are typed as in v2.
+
+type StockRatingValuesResponse struct {
+	Brokerage string      `json:"brokerage"`
+	Action    string      `json:"action"`
+	RatingTo  string      `json:"rating_to"`
+	TargetTo  json.Number `json:"target_to"`
+	Score     int32       `json:"score"`
+	At        time.Time   `json:"at"`
+}
+
+type StockRatingChangeResponse struct {
+	Ticker  string `json:"ticker"`
+	Company string `json:"company"`
+	// added, removed or changed
+	Change string `json:"change"`
+	// Compared columns that changed: rating_to, target_to and score
+	Fields []string                   `json:"fields"`
+	Before *StockRatingValuesResponse `json:"before"`
+	After  *StockRatingValuesResponse `json:"after"`
+}
+
+type StockRatingDiffResponse struct {
+	Added   int                         `json:"added"`
+	Removed int                         `json:"removed"`
+	Changed int                         `json:"changed"`
+	Changes []StockRatingChangeResponse `json:"changes"`
+}
+
+const (
+	changeAdded   = "added"
+	changeRemoved = "removed"
+	changeChanged = "changed"
+)
+
+func toValuesResponse(v *ratingValues) *StockRatingValuesResponse {
+	if v == nil {
+		return nil
+	}
+	return &StockRatingValuesResponse{
+		Brokerage: v.brokerage,
+		Action:    v.action,
+		RatingTo:  v.ratingTo,
+		TargetTo:  json.Number(v.targetTo),
+		Score:     v.score,
+		At:        v.at.UTC(),
+	}
+}
+
+// Read a point of the diff, an ingestion run ID or an RFC3339 time, replying 400 when invalid
+func parseDiffPoint(c *gin.Context, name string) (DiffPoint, bool) {
+	param := c.Query(name)
+	if id, err := uuid.Parse(param); err == nil {
+		return DiffPoint{runID: id}, true
+	}
+	at, err := time.Parse(time.RFC3339Nano, param)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid " + name + ", use an RFC3339 time or an ingestion run ID"})
+		return DiffPoint{}, false
+	}
+	return DiffPoint{at: at}, true
+}
+
+func (h *Handler) DiffStockRatings(c *gin.Context) {
+	// Validate parameters
+	from, ok := parseDiffPoint(c, "from")
+	if !ok {
+		return
+	}
+	to, ok := parseDiffPoint(c, "to")
+	if !ok {
+		return
+	}
+
+	// Call the service
+	changes, err := h.service.DiffStockRatings(c.Request.Context(), from, to)
+	if errors.Is(err, DiffStockRatingsErrorRunNotFound) {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if errors.Is(err, DiffStockRatingsErrorInvalidRange) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if errors.Is(err, DiffStockRatingsErrorRunNotFinished) {
+		c.JSON(http.StatusConflict, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	// Serialize the output
+	resp := StockRatingDiffResponse{Changes: make([]StockRatingChangeResponse, len(changes))}
+	for i, ch := range changes {
+		change := changeChanged
+		switch {
+		case ch.before == nil:
+			change = changeAdded
+			resp.Added++
+		case ch.after == nil:
+			change = changeRemoved
+			resp.Removed++
+		default:
+			resp.Changed++
+		}
+		resp.Changes[i] = StockRatingChangeResponse{
+			Ticker:  ch.ticker,
+			Company: ch.company,
+			Change:  change,
+			Fields:  ch.fields,
+			Before:  toValuesResponse(ch.before),
+			After:   toValuesResponse(ch.after),
+		}
+	}
+	c.JSON(http.StatusOK, resp)
+}
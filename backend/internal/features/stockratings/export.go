@@ -0,0 +1,301 @@
+package stockratings
+
+import (
+	"backend/internal/repository"
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xuri/excelize/v2"
+)
+
+// COLUMNS =========================================================================================
+
+type exportColumn struct {
+	name    string
+	numeric bool
+	value   func(r rating) string
+}
+
+var exportColumns = []exportColumn{
+	{name: "ticker", value: func(r rating) string { return r.ticker }},
+	{name: "company", value: func(r rating) string { return r.company }},
+	{name: "brokerage", value: func(r rating) string { return r.brokerage }},
+	{name: "target_from", numeric: true, value: func(r rating) string { return r.targetFrom }},
+	{name: "target_to", numeric: true, value: func(r rating) string { return r.targetTo }},
+	{name: "target_delta", numeric: true, value: func(r rating) string { return r.targetDelta }},
+	{name: "target_delta_pct", numeric: true, value: func(r rating) string { return r.targetDeltaPct }},
+	{name: "action", value: func(r rating) string { return r.action }},
+	{name: "raw_action", value: func(r rating) string { return r.rawAction }},
+	{name: "rating_from", value: func(r rating) string { return r.ratingFrom }},
+	{name: "raw_rating_from", value: func(r rating) string { return r.rawRatingFrom }},
+	{name: "rating_to", value: func(r rating) string { return r.ratingTo }},
+	{name: "raw_rating_to", value: func(r rating) string { return r.rawRatingTo }},
+	{name: "at", value: func(r rating) string { return r.at.Format(time.RFC3339) }},
+	{name: "score", numeric: true, value: func(r rating) string { return strconv.Itoa(int(r.score)) }},
+}
+
+// Columns picked by a comma separated list of names, every column when empty
+func selectExportColumns(names string) ([]exportColumn, error) {
+	if names == "" {
+		return exportColumns, nil
+	}
+	var selected []exportColumn
+	for _, name := range strings.Split(names, ",") {
+		i := slices.IndexFunc(exportColumns, func(col exportColumn) bool { return col.name == strings.TrimSpace(name) })
+		if i < 0 {
+			return nil, fmt.Errorf("unknown column: %s", name)
+		}
+		selected = append(selected, exportColumns[i])
+	}
+	return selected, nil
+}
+
+// WRITERS =========================================================================================
+// Each writer receives the rows one by one and flushes them as it goes, so the export of the whole
+// table runs in constant memory
+
+type exportWriter interface {
+	header() error
+	row(r rating) error
+	close() error
+	discard()
+}
+
+type exportFormat struct {
+	contentType string
+	extension   string
+	new         func(w io.Writer, columns []exportColumn) exportWriter
+}
+
+var exportFormats = map[string]exportFormat{
+	"csv": {
+		contentType: "text/csv; charset=utf-8",
+		extension:   "csv",
+		new: func(w io.Writer, columns []exportColumn) exportWriter {
+			return &csvExportWriter{w: csv.NewWriter(w), columns: columns}
+		},
+	},
+	"ndjson": {
+		contentType: "application/x-ndjson",
+		extension:   "ndjson",
+		new: func(w io.Writer, columns []exportColumn) exportWriter {
+			return &ndjsonExportWriter{w: bufio.NewWriter(w), columns: columns}
+		},
+	},
+	"xlsx": {
+		contentType: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+		extension:   "xlsx",
+		new: func(w io.Writer, columns []exportColumn) exportWriter {
+			return &xlsxExportWriter{w: w, columns: columns}
+		},
+	},
+}
+
+// CSV ---------------------------------------------------------------------------------------------
+type csvExportWriter struct {
+	w       *csv.Writer
+	columns []exportColumn
+	record  []string
+}
+
+func (e *csvExportWriter) header() error {
+	e.record = make([]string, len(e.columns))
+	for i, col := range e.columns {
+		e.record[i] = col.name
+	}
+	return e.w.Write(e.record)
+}
+
+func (e *csvExportWriter) row(r rating) error {
+	for i, col := range e.columns {
+		e.record[i] = col.value(r)
+	}
+	return e.w.Write(e.record)
+}
+
+func (e *csvExportWriter) close() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+func (e *csvExportWriter) discard() {}
+
+// NDJSON ------------------------------------------------------------------------------------------
+type ndjsonExportWriter struct {
+	w       *bufio.Writer
+	columns []exportColumn
+}
+
+func (e *ndjsonExportWriter) header() error {
+	return nil
+}
+
+// One object per line, with the keys in the order of the columns
+func (e *ndjsonExportWriter) row(r rating) error {
+	e.w.WriteByte('{')
+	for i, col := range e.columns {
+		if i > 0 {
+			e.w.WriteByte(',')
+		}
+		key, _ := json.Marshal(col.name)
+		e.w.Write(key)
+		e.w.WriteByte(':')
+		if col.numeric {
+			e.w.WriteString(col.value(r))
+		} else {
+			value, _ := json.Marshal(col.value(r))
+			e.w.Write(value)
+		}
+	}
+	_, err := e.w.WriteString("}\n")
+	return err
+}
+
+func (e *ndjsonExportWriter) close() error {
+	return e.w.Flush()
+}
+
+func (e *ndjsonExportWriter) discard() {}
+
+// XLSX --------------------------------------------------------------------------------------------
+// The excelize stream writer spills the sheet to a temporary file past a small buffer, the
+// workbook is zipped into the response once every row is written
+type xlsxExportWriter struct {
+	w       io.Writer
+	columns []exportColumn
+	file    *excelize.File
+	sheet   *excelize.StreamWriter
+	line    int
+	values  []any
+}
+
+func (e *xlsxExportWriter) header() error {
+	e.file = excelize.NewFile()
+	sheet, err := e.file.NewStreamWriter("Sheet1")
+	if err != nil {
+		return err
+	}
+	e.sheet = sheet
+	e.values = make([]any, len(e.columns))
+	for i, col := range e.columns {
+		e.values[i] = col.name
+	}
+	return e.next()
+}
+
+func (e *xlsxExportWriter) row(r rating) error {
+	for i, col := range e.columns {
+		value := col.value(r)
+		e.values[i] = value
+		if col.numeric {
+			if n, err := strconv.ParseFloat(value, 64); err == nil {
+				e.values[i] = n
+			}
+		}
+	}
+	return e.next()
+}
+
+func (e *xlsxExportWriter) next() error {
+	e.line++
+	cell, err := excelize.CoordinatesToCellName(1, e.line)
+	if err != nil {
+		return err
+	}
+	return e.sheet.SetRow(cell, e.values)
+}
+
+func (e *xlsxExportWriter) close() error {
+	defer e.discard()
+	if err := e.sheet.Flush(); err != nil {
+		return err
+	}
+	return e.file.Write(e.w)
+}
+
+// Remove the temporary files of the workbook
+func (e *xlsxExportWriter) discard() {
+	if e.file != nil {
+		e.file.Close()
+	}
+}
+
+// Write the rows given by stream in one of the export formats, for the exports made outside of a
+// request, e.g. by the command line with the database at hand
+func WriteExport(w io.Writer, format string, columns string, stream func(row func(repository.GetStockRatingsRow) error) error) error {
+	exportFormat, ok := exportFormats[format]
+	if !ok {
+		return fmt.Errorf("invalid format %q, use csv, ndjson or xlsx", format)
+	}
+	selected, err := selectExportColumns(columns)
+	if err != nil {
+		return err
+	}
+	writer := exportFormat.new(w, selected)
+	err = writer.header()
+	if err == nil {
+		err = stream(func(r repository.GetStockRatingsRow) error { return writer.row(toRating(r)) })
+	}
+	if err != nil {
+		writer.discard()
+		return err
+	}
+	return writer.close()
+}
+
+// HANDLER =========================================================================================
+
+// Export every rating matching the filters and sort of the list endpoint, not only one page
+func (h *Handler) ExportStockRatings(c *gin.Context) {
+	// Validate parameters
+	input, ok := parseGetStockRatingsInput(c)
+	if !ok {
+		return
+	}
+	format, ok := exportFormats[c.DefaultQuery("format", "csv")]
+	if !ok {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid format, use csv, ndjson or xlsx"})
+		return
+	}
+	columns, err := selectExportColumns(c.Query("columns"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	// Stream the rows. Once the first bytes are flushed the status can't change anymore, so later
+	// errors only abort the response
+	c.Header("Content-Type", format.contentType)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="stock_ratings.%s"`, format.extension))
+	writer := format.new(c.Writer, columns)
+	err = writer.header()
+	if err == nil {
+		err = h.service.ExportStockRatings(c.Request.Context(), input, writer.row)
+	}
+	if err == nil {
+		err = writer.close()
+	} else {
+		writer.discard()
+	}
+	if err != nil {
+		log.Println("Error exporting stock ratings: ", err)
+		if !c.Writer.Written() {
+			c.Writer.Header().Del("Content-Type")
+			c.Writer.Header().Del("Content-Disposition")
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.Error(err)
+		c.Abort()
+	}
+}
@@ -0,0 +1,116 @@
+package stockratings
+
+import (
+	stockratingsv1 "backend/internal/gen/stockratings/v1"
+	"cmp"
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// GRPC ============================================================================================
+// Same lists as the REST handlers, for the internal services. The messages carry the fields of the
+// v2 response, with the enums of the proto.
+
+type GRPCServer struct {
+	stockratingsv1.UnimplementedStockRatingServiceServer
+	service ServiceInterface
+}
+
+func NewGRPCServer(s ServiceInterface) *GRPCServer {
+	return &GRPCServer{service: s}
+}
+
+var actionsProto = map[string]stockratingsv1.Action{
+	"up":         stockratingsv1.Action_ACTION_UP,
+	"down":       stockratingsv1.Action_ACTION_DOWN,
+	"reiterated": stockratingsv1.Action_ACTION_REITERATED,
+}
+
+var ratingsProto = map[string]stockratingsv1.Rating{
+	"buy":     stockratingsv1.Rating_RATING_BUY,
+	"hold":    stockratingsv1.Rating_RATING_HOLD,
+	"sell":    stockratingsv1.Rating_RATING_SELL,
+	"pending": stockratingsv1.Rating_RATING_PENDING,
+}
+
+// Decimals are always valid, they come from numeric columns
+func toFloat(n string) float64 {
+	f, _ := strconv.ParseFloat(n, 64)
+	return f
+}
+
+// Serialize a rating of the v2 list as a message, for the features pushing ratings
+func NewStockRatingProto(r GetStockRatingsV2Response) *stockratingsv1.StockRating {
+	return &stockratingsv1.StockRating{
+		Ticker:         r.Ticker,
+		Company:        r.Company,
+		Brokerage:      r.Brokerage,
+		TargetFrom:     toFloat(r.TargetFrom.String()),
+		TargetTo:       toFloat(r.TargetTo.String()),
+		TargetDelta:    toFloat(r.TargetDelta.String()),
+		TargetDeltaPct: toFloat(r.TargetDeltaPct.String()),
+		Action:         actionsProto[r.Action],
+		RatingFrom:     ratingsProto[r.RatingFrom],
+		RawRatingFrom:  r.RawRatingFrom,
+		RatingTo:       ratingsProto[r.RatingTo],
+		RawRatingTo:    r.RawRatingTo,
+		At:             timestamppb.New(r.At),
+		Score:          r.Score,
+	}
+}
+
+func (s *GRPCServer) ListStockRatings(ctx context.Context, req *stockratingsv1.ListStockRatingsRequest) (*stockratingsv1.ListStockRatingsResponse, error) {
+	// Validate parameters, with the defaults of the REST list for the fields left empty
+	if req.Offset < 0 || req.Limit < 0 {
+		return nil, status.Error(codes.InvalidArgument, "Invalid page, offset and limit can't be negative")
+	}
+	sortOrder := strings.ToLower(cmp.Or(req.SortOrder, "desc"))
+	if sortOrder != "asc" && sortOrder != "desc" {
+		return nil, status.Error(codes.InvalidArgument, "Invalid sort order, use asc or desc")
+	}
+
+	// Call the service
+	stockRatings, err := s.service.GetStockRatings(ctx, GetStockRatingsInput{
+		sortOrder:   sortOrder,
+		sortBy:      strings.ToLower(cmp.Or(req.SortBy, "score")),
+		offset:      req.Offset,
+		limit:       cmp.Or(req.Limit, 10),
+		tickerLike:  req.TickerLike,
+		companyLike: req.CompanyLike,
+	})
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	// Serialize the output
+	resp := &stockratingsv1.ListStockRatingsResponse{
+		Ratings: make([]*stockratingsv1.StockRating, len(stockRatings)),
+	}
+	for i, r := range stockRatings {
+		resp.Ratings[i] = NewStockRatingProto(toV2Response(r))
+	}
+	return resp, nil
+}
+
+func (s *GRPCServer) GetStockRating(ctx context.Context, req *stockratingsv1.GetStockRatingRequest) (*stockratingsv1.GetStockRatingResponse, error) {
+	if req.Ticker == "" {
+		return nil, status.Error(codes.InvalidArgument, "Missing ticker")
+	}
+
+	r, err := s.service.GetStockRating(ctx, req.Ticker, time.Time{})
+	if errors.Is(err, GetStockRatingErrorNotFound) {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return &stockratingsv1.GetStockRatingResponse{Rating: NewStockRatingProto(toV2Response(r))}, nil
+}
@@ -0,0 +1,120 @@
+package stockratings
+
+import (
+	stockratingsv1 "backend/internal/gen/stockratings/v1"
+	"backend/internal/rpc"
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// Service answering from memory, keeping the last list input
+type fakeService struct {
+	ratings []rating
+	input   GetStockRatingsInput
+}
+
+func (s *fakeService) GetStockRatings(_ context.Context, input GetStockRatingsInput) (GetStockRatingsOutput, error) {
+	s.input = input
+	return s.ratings, nil
+}
+
+func (s *fakeService) ExportStockRatings(context.Context, GetStockRatingsInput, func(rating) error) error {
+	return nil
+}
+
+func (s *fakeService) GetStockRating(_ context.Context, ticker string, _ time.Time) (rating, error) {
+	for _, r := range s.ratings {
+		if r.ticker == ticker {
+			return r, nil
+		}
+	}
+	return rating{}, GetStockRatingErrorNotFound
+}
+
+func (s *fakeService) DiffStockRatings(context.Context, DiffPoint, DiffPoint) (DiffStockRatingsOutput, error) {
+	return nil, nil
+}
+
+func dial(t *testing.T, s ServiceInterface) *grpc.ClientConn {
+	t.Helper()
+	listener := bufconn.Listen(1 << 20)
+	server := rpc.NewServer(rpc.Services{
+		StockRatings:       NewGRPCServer(s),
+		StockRatingsStream: stockratingsv1.UnimplementedStockRatingStreamServiceServer{},
+	})
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestGRPCListAndGet(t *testing.T) {
+	s := &fakeService{ratings: []rating{{
+		ticker:         "AAPL",
+		company:        "Apple Inc.",
+		brokerage:      "Goldman Sachs",
+		targetFrom:     "150.00",
+		targetTo:       "180.00",
+		targetDelta:    "30.00",
+		targetDeltaPct: "20.00",
+		action:         "up",
+		ratingFrom:     "hold",
+		ratingTo:       "buy",
+		at:             time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+		score:          4000,
+	}}}
+	client := stockratingsv1.NewStockRatingServiceClient(dial(t, s))
+	ctx := context.Background()
+
+	list, err := client.ListStockRatings(ctx, &stockratingsv1.ListStockRatingsRequest{TickerLike: "aa"})
+	if err != nil {
+		t.Fatalf("ListStockRatings() failed: %v", err)
+	}
+	if s.input.sortBy != "score" || s.input.sortOrder != "desc" || s.input.limit != 10 || s.input.tickerLike != "aa" {
+		t.Fatalf("got input %+v, want the defaults of the REST list", s.input)
+	}
+	got := list.Ratings[0]
+	if got.Ticker != "AAPL" || got.TargetTo != 180 || got.Action != stockratingsv1.Action_ACTION_UP ||
+		got.RatingTo != stockratingsv1.Rating_RATING_BUY || !got.At.AsTime().Equal(s.ratings[0].at) {
+		t.Fatalf("got %v", got)
+	}
+
+	if _, err := client.GetStockRating(ctx, &stockratingsv1.GetStockRatingRequest{Ticker: "AAPL"}); err != nil {
+		t.Fatalf("GetStockRating() failed: %v", err)
+	}
+	_, err = client.GetStockRating(ctx, &stockratingsv1.GetStockRatingRequest{Ticker: "NOPE"})
+	if status.Code(err) != codes.NotFound {
+		t.Fatalf("GetStockRating() of an unknown ticker = %v, want NotFound", err)
+	}
+	_, err = client.ListStockRatings(ctx, &stockratingsv1.ListStockRatingsRequest{SortOrder: "up"})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("ListStockRatings() with an invalid sort order = %v, want InvalidArgument", err)
+	}
+}
+
+func TestGRPCHealth(t *testing.T) {
+	client := healthpb.NewHealthClient(dial(t, &fakeService{}))
+	for _, service := range []string{"", "stockratings.v1.StockRatingService"} {
+		res, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+		if err != nil || res.Status != healthpb.HealthCheckResponse_SERVING {
+			t.Fatalf("Check(%q) = %v, %v, want SERVING", service, res, err)
+		}
+	}
+}
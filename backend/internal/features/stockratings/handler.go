@@ -4,18 +4,23 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
 type HandlerInterface interface {
 	GetStockRatings(c *gin.Context)
+	GetStockRatingsV2(c *gin.Context)
+	GetStockRatingV2(c *gin.Context)
+	ExportStockRatings(c *gin.Context)
+	DiffStockRatings(c *gin.Context)
 }
 type Handler struct {
 	service ServiceInterface
 }
 
-func NewHandler(s *Service) *Handler {
+func NewHandler(s ServiceInterface) *Handler {
 	return &Handler{service: s}
 }
 
@@ -32,35 +37,71 @@ type GetStockRatingsResponse struct {
 	Score       int32  `json:"score"`
 }
 
-func (h *Handler) GetStockRatings(c *gin.Context) {
-	// Validate parameters
+type GetStockRatingsListResponse struct {
+	Length  int                       `json:"length"`
+	Ratings []GetStockRatingsResponse `json:"ratings"`
+}
+
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+// Read the list parameters shared by the list and export endpoints, replying 400 when invalid
+func parseGetStockRatingsInput(c *gin.Context) (GetStockRatingsInput, bool) {
 	sortOrder := c.DefaultQuery("sort_order", "desc")
 	sortBy := c.DefaultQuery("sort_by", "score")
 	tickerLike := c.DefaultQuery("ticker_like", "")
 	companyLike := c.DefaultQuery("company_like", "")
 	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid offset"})
-		return
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid offset"})
+		return GetStockRatingsInput{}, false
 	}
 	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
-		return
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid limit"})
+		return GetStockRatingsInput{}, false
 	}
-
-	// Call the service
-	stockRatings, err := h.service.GetStockRatings(GetStockRatingsInput{
+	return GetStockRatingsInput{
 		sortOrder:   sortOrder,
 		sortBy:      sortBy,
 		offset:      int32(offset),
 		limit:       int32(limit),
 		tickerLike:  tickerLike,
 		companyLike: companyLike,
-	})
+	}, true
+}
+
+// Read the as_of parameter of the list and detail endpoints, zero when absent, replying 400 when
+// invalid. The export streams the current ratings and doesn't take it.
+func parseAsOf(c *gin.Context) (time.Time, bool) {
+	param := c.Query("as_of")
+	if param == "" {
+		return time.Time{}, true
+	}
+	asOf, err := time.Parse(time.RFC3339Nano, param)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid as_of"})
+		return time.Time{}, false
+	}
+	return asOf, true
+}
+
+func (h *Handler) GetStockRatings(c *gin.Context) {
+	// Validate parameters
+	input, ok := parseGetStockRatingsInput(c)
+	if !ok {
+		return
+	}
+	if input.asOf, ok = parseAsOf(c); !ok {
+		return
+	}
+
+	// Call the service
+	stockRatings, err := h.service.GetStockRatings(c.Request.Context(), input)
 	fmt.Println(stockRatings)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
 		return
 	}
 
@@ -82,13 +123,15 @@ func (h *Handler) GetStockRatings(c *gin.Context) {
 		}
 	}
 
-	c.JSON(200, gin.H{
-		"length":  len(resp),
-		"ratings": resp,
+	c.JSON(200, GetStockRatingsListResponse{
+		Length:  len(resp),
+		Ratings: resp,
 	})
 }
 
 func AddStockRatingRoutes(rg *gin.RouterGroup, h HandlerInterface) {
 	stockRatings := rg.Group("/stock_ratings")
 	stockRatings.GET("/", h.GetStockRatings)
+	stockRatings.GET("/export", h.ExportStockRatings)
+	stockRatings.GET("/diff", h.DiffStockRatings)
 }
@@ -0,0 +1,118 @@
+package stockratings
+
+import (
+	"backend/internal/repository"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// V2 ==============================================================================================
+// Same list as v1, with the targets as JSON numbers (scale of 2 decimals), RFC3339 timestamps and
+// the brokerage and raw ratings that v1 leaves out. v1 stays as is for the old clients.
+
+type GetStockRatingsV2Response struct {
+	Ticker         string      `json:"ticker"`
+	Company        string      `json:"company"`
+	Brokerage      string      `json:"brokerage"`
+	TargetFrom     json.Number `json:"target_from"`
+	TargetTo       json.Number `json:"target_to"`
+	TargetDelta    json.Number `json:"target_delta"`
+	TargetDeltaPct json.Number `json:"target_delta_pct"`
+	Action         string      `json:"action"`
+	RatingFrom     string      `json:"rating_from"`
+	RawRatingFrom  string      `json:"raw_rating_from"`
+	RatingTo       string      `json:"rating_to"`
+	RawRatingTo    string      `json:"raw_rating_to"`
+	At             time.Time   `json:"at"`
+	Score          int32       `json:"score"`
+}
+
+type GetStockRatingsV2ListResponse struct {
+	Length  int                         `json:"length"`
+	Ratings []GetStockRatingsV2Response `json:"ratings"`
+}
+
+func toV2Response(r rating) GetStockRatingsV2Response {
+	return GetStockRatingsV2Response{
+		Ticker:         r.ticker,
+		Company:        r.company,
+		Brokerage:      r.brokerage,
+		TargetFrom:     json.Number(r.targetFrom),
+		TargetTo:       json.Number(r.targetTo),
+		TargetDelta:    json.Number(r.targetDelta),
+		TargetDeltaPct: json.Number(r.targetDeltaPct),
+		Action:         r.action,
+		RatingFrom:     r.ratingFrom,
+		RawRatingFrom:  r.rawRatingFrom,
+		RatingTo:       r.ratingTo,
+		RawRatingTo:    r.rawRatingTo,
+		At:             r.at.UTC(),
+		Score:          r.score,
+	}
+}
+
+// Serialize a row of the list query as in the v2 list, for the features pushing ratings
+func NewStockRatingV2Response(r repository.GetStockRatingsRow) GetStockRatingsV2Response {
+	return toV2Response(toRating(r))
+}
+
+func (h *Handler) GetStockRatingsV2(c *gin.Context) {
+	// Validate parameters
+	input, ok := parseGetStockRatingsInput(c)
+	if !ok {
+		return
+	}
+	if input.asOf, ok = parseAsOf(c); !ok {
+		return
+	}
+
+	// Call the service
+	stockRatings, err := h.service.GetStockRatings(c.Request.Context(), input)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	// Serialize the output
+	resp := make([]GetStockRatingsV2Response, len(stockRatings))
+	for i, r := range stockRatings {
+		resp[i] = toV2Response(r)
+	}
+
+	c.JSON(http.StatusOK, GetStockRatingsV2ListResponse{
+		Length:  len(resp),
+		Ratings: resp,
+	})
+}
+
+func (h *Handler) GetStockRatingV2(c *gin.Context) {
+	// Validate parameters
+	asOf, ok := parseAsOf(c)
+	if !ok {
+		return
+	}
+
+	// Call the service
+	r, err := h.service.GetStockRating(c.Request.Context(), c.Param("ticker"), asOf)
+	if errors.Is(err, GetStockRatingErrorNotFound) {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	// Serialize the output
+	c.JSON(http.StatusOK, toV2Response(r))
+}
+
+func AddStockRatingV2Routes(rg *gin.RouterGroup, h HandlerInterface) {
+	stockRatings := rg.Group("/stock_ratings")
+	stockRatings.GET("/", h.GetStockRatingsV2)
+	stockRatings.GET("/:ticker", h.GetStockRatingV2)
+}
@@ -1,9 +1,13 @@
 package stockratings
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"os"
@@ -12,8 +16,14 @@ import (
 	"time"
 
 	"backend/internal/repository"
+	"backend/pkg/metrics"
+	"backend/pkg/tracing"
 
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
 )
 
 // TYPES ===========================================================================================
@@ -39,10 +49,24 @@ type LoaderService struct {
 	client *http.Client
 	token  string
 	host   string
-	repo   *repository.Queries
+	repo   repository.Repository
+	hooks  []LoadHook
 }
 
-func NewLoaderService(r *repository.Queries) *LoaderService {
+// Notified of the ratings loaded from the API, e.g. to evaluate the alert rules. Replays don't
+// notify the hooks, their events were already seen.
+type LoadHook interface {
+	// Called before the current ratings are cleared
+	BeforeLoad(ctx context.Context, runID uuid.UUID) error
+	// Called with each batch once inserted
+	OnBatch(ctx context.Context, runID uuid.UUID, batch []repository.AddStockRatingsParams) error
+}
+
+func (s *LoaderService) AddHook(h LoadHook) {
+	s.hooks = append(s.hooks, h)
+}
+
+func NewLoaderService(r repository.Repository) *LoaderService {
 	// Get the host and token
 	host := os.Getenv("DATA_HOST")
 	token := os.Getenv("DATA_TOKEN")
@@ -64,8 +88,8 @@ func NewLoaderService(r *repository.Queries) *LoaderService {
 }
 
 // utils ===========================================================================================
-func (s *LoaderService) clearStockRatings() error {
-	err := s.repo.ClearStockRating(context.Background())
+func (s *LoaderService) clearStockRatings(ctx context.Context) error {
+	err := s.repo.ClearStockRating(ctx)
 	if err != nil {
 		return err
 	}
@@ -78,7 +102,7 @@ var sellRating = []string{"Underperform", "Underweight", "Sell", "Negative", "Re
 var pendingRating = []string{""}
 
 // Translate the raw rating to a a normalized rating
-func (s *LoaderService) rawRatingToStockRating(rawRating string) (repository.StockRatingType, error) {
+func RawRatingToStockRating(rawRating string) (repository.StockRatingType, error) {
 	if slices.Contains(buyRating, rawRating) {
 		return repository.StockRatingTypeBuy, nil
 	} else if slices.Contains(holdRating, rawRating) {
@@ -93,12 +117,28 @@ func (s *LoaderService) rawRatingToStockRating(rawRating string) (repository.Sto
 	}
 }
 
+// Raw ratings translated to the rating, e.g. to generate events
+func RawRatings(rating repository.StockRatingType) []string {
+	switch rating {
+	case repository.StockRatingTypeBuy:
+		return slices.Clone(buyRating)
+	case repository.StockRatingTypeHold:
+		return slices.Clone(holdRating)
+	case repository.StockRatingTypeSell:
+		return slices.Clone(sellRating)
+	case repository.StockRatingTypePending:
+		return slices.Clone(pendingRating)
+	default:
+		return nil
+	}
+}
+
 var upAction = []string{"target raised by", "upgraded by"}
 var downAction = []string{"target lowered by", "downgraded by"}
 var reiteratedAction = []string{"target set by", "reiterated by", "initiated by"}
 
 // Translate the raw action to a a normalized action
-func (s *LoaderService) rawActionToStockAction(rawAction string) (repository.StockActionType, error) {
+func RawActionToStockAction(rawAction string) (repository.StockActionType, error) {
 	if slices.Contains(upAction, rawAction) {
 		return repository.StockActionTypeUp, nil
 	} else if slices.Contains(downAction, rawAction) {
@@ -109,7 +149,22 @@ func (s *LoaderService) rawActionToStockAction(rawAction string) (repository.Sto
 		return "", fmt.Errorf("unknown action: %s", rawAction)
 	}
 }
-func (s *LoaderService) rawTargetToStockTarget(rawTarget string) (pgtype.Numeric, error) {
+
+// Raw actions translated to the action
+func RawActions(action repository.StockActionType) []string {
+	switch action {
+	case repository.StockActionTypeUp:
+		return slices.Clone(upAction)
+	case repository.StockActionTypeDown:
+		return slices.Clone(downAction)
+	case repository.StockActionTypeReiterated:
+		return slices.Clone(reiteratedAction)
+	default:
+		return nil
+	}
+}
+
+func RawTargetToStockTarget(rawTarget string) (pgtype.Numeric, error) {
 	// Remove currency symbol, commas and trim spaces
 	clean := strings.ReplaceAll(rawTarget, "$", "")
 	clean = strings.ReplaceAll(clean, ",", "")
@@ -166,8 +221,17 @@ var (
 )
 
 // Method ------------------------------------------------------------------------------------------
-// Get the data from the API
-func (s *LoaderService) getData(cursor string) (APIResponse, error) {
+// Get the data from the API, along with the body as received to archive it
+func (s *LoaderService) getData(ctx context.Context, cursor string) (result APIResponse, body []byte, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "LoaderService.getData")
+	defer func() {
+		span.SetAttributes(attribute.String("cursor", cursor), attribute.Int("items", len(result.Items)))
+		if err != nil {
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}()
+
 	// Config the request
 	var host string
 	if cursor == "" {
@@ -175,7 +239,7 @@ func (s *LoaderService) getData(cursor string) (APIResponse, error) {
 	} else {
 		host = s.host + "?next_page=" + cursor
 	}
-	req, err := http.NewRequest("GET", host, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", host, nil)
 	if err != nil {
 		log.Fatal("Bad request build to API", err)
 	}
@@ -184,23 +248,27 @@ func (s *LoaderService) getData(cursor string) (APIResponse, error) {
 	// Make the request
 	resp, err := s.client.Do(req)
 	if err != nil {
-		return APIResponse{}, APIError.From(err)
+		return APIResponse{}, nil, APIError.From(err)
 	}
 	defer resp.Body.Close()
 
 	// Verify if the request was successful
 	if resp.StatusCode != http.StatusOK {
 		var err = fmt.Errorf("status code: %d", resp.StatusCode)
-		return APIResponse{}, APIError.From(err)
+		return APIResponse{}, nil, APIError.From(err)
 	}
 
 	// Parse the body
-	var result APIResponse
-	err = json.NewDecoder(resp.Body).Decode(&result)
+	body, err = io.ReadAll(resp.Body)
+	if err != nil {
+		return APIResponse{}, nil, APIError.From(err)
+	}
+	err = json.Unmarshal(body, &result)
 	if err != nil {
-		return APIResponse{}, JSONParseError.From(err)
+		return APIResponse{}, nil, JSONParseError.From(err)
 	}
-	return result, nil
+	metrics.LoaderPagesFetched.Inc()
+	return result, body, nil
 }
 
 // InitData ========================================================================================
@@ -217,8 +285,47 @@ const (
 	unknownRatingError
 	unknownActionError
 	unknownTargetError
+	ingestionRunError
+	archivePageError
+	readArchiveError
+	datasetVersionError
+	insertStockRatingEventsError
 )
 
+// Label of the kind, used in the loader_events_rejected metric
+func (k initDataErrorKind) String() string {
+	switch k {
+	case clearStockRatingsError:
+		return "clear_stock_ratings"
+	case dataFetchError:
+		return "data_fetch"
+	case timeParseError:
+		return "time_parse"
+	case insertRawStockRatingsError:
+		return "insert_raw_stock_ratings"
+	case insertStockRatingsError:
+		return "insert_stock_ratings"
+	case unknownRatingError:
+		return "unknown_rating"
+	case unknownActionError:
+		return "unknown_action"
+	case unknownTargetError:
+		return "unknown_target"
+	case ingestionRunError:
+		return "ingestion_run"
+	case archivePageError:
+		return "archive_page"
+	case readArchiveError:
+		return "read_archive"
+	case datasetVersionError:
+		return "dataset_version"
+	case insertStockRatingEventsError:
+		return "insert_stock_rating_events"
+	default:
+		return "unknown"
+	}
+}
+
 type InitDataError struct {
 	kind initDataErrorKind
 	err  error
@@ -242,6 +349,16 @@ func (e InitDataError) Error() string {
 		return fmt.Sprintf("Failed to parse action from API: %s", e.err.Error())
 	case unknownTargetError:
 		return fmt.Sprintf("Failed to parse target from API: %s", e.err.Error())
+	case ingestionRunError:
+		return fmt.Sprintf("Failed to record the ingestion run: %s", e.err.Error())
+	case archivePageError:
+		return fmt.Sprintf("Failed to archive page from API: %s", e.err.Error())
+	case readArchiveError:
+		return fmt.Sprintf("Failed to read archived page: %s", e.err.Error())
+	case datasetVersionError:
+		return fmt.Sprintf("Failed to bump the dataset version: %s", e.err.Error())
+	case insertStockRatingEventsError:
+		return fmt.Sprintf("Failed to append data to the history: %s", e.err.Error())
 	default:
 		return "Unknown error"
 	}
@@ -256,23 +373,56 @@ func (e InitDataError) Unwrap() error {
 	return e.err
 }
 
+// Count the rejected events of the error before returning it
+func (e InitDataError) reject(n int) InitDataError {
+	metrics.LoaderEventsRejected.WithLabelValues(e.kind.String()).Add(float64(n))
+	return e
+}
+
 var (
-	ClearStockRatingsError     = InitDataError{kind: clearStockRatingsError}
-	DataFetchError             = InitDataError{kind: dataFetchError}
-	TimeParseError             = InitDataError{kind: timeParseError}
-	InsertRawStockRatingsError = InitDataError{kind: insertRawStockRatingsError}
-	InsertStockRatingsError    = InitDataError{kind: insertStockRatingsError}
-	UnknownRatingError         = InitDataError{kind: unknownRatingError}
-	UnknownActionError         = InitDataError{kind: unknownActionError}
-	UnknownTargetError         = InitDataError{kind: unknownTargetError}
+	ClearStockRatingsError       = InitDataError{kind: clearStockRatingsError}
+	DataFetchError               = InitDataError{kind: dataFetchError}
+	TimeParseError               = InitDataError{kind: timeParseError}
+	InsertRawStockRatingsError   = InitDataError{kind: insertRawStockRatingsError}
+	InsertStockRatingsError      = InitDataError{kind: insertStockRatingsError}
+	UnknownRatingError           = InitDataError{kind: unknownRatingError}
+	UnknownActionError           = InitDataError{kind: unknownActionError}
+	UnknownTargetError           = InitDataError{kind: unknownTargetError}
+	IngestionRunError            = InitDataError{kind: ingestionRunError}
+	ArchivePageError             = InitDataError{kind: archivePageError}
+	ReadArchiveError             = InitDataError{kind: readArchiveError}
+	DatasetVersionError          = InitDataError{kind: datasetVersionError}
+	InsertStockRatingEventsError = InitDataError{kind: insertStockRatingEventsError}
 )
 
 // Method ------------------------------------------------------------------------------------------
 
-func (s *LoaderService) InitData() error {
+func (s *LoaderService) InitData() (err error) {
+	ctx, span := tracing.Tracer().Start(context.Background(), "LoaderService.InitData")
+	defer func() {
+		if err != nil {
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}()
+
+	// Record the run, its pages are archived under its ID
+	runID, err := s.repo.CreateIngestionRun(ctx, repository.IngestionRunSourceApi)
+	if err != nil {
+		return IngestionRunError.From(err)
+	}
+	defer func() { s.finishRun(ctx, runID, err) }()
+	span.SetAttributes(attribute.String("run_id", runID.String()))
+
+	// A failing hook is logged, the data is loaded anyway
+	for _, hook := range s.hooks {
+		if err := hook.BeforeLoad(ctx, runID); err != nil {
+			log.Println("Error preparing load hook: ", err)
+		}
+	}
 
 	// Clear the current data in db
-	err := s.clearStockRatings()
+	err = s.clearStockRatings(ctx)
 	if err != nil {
 		return ClearStockRatingsError.From(err)
 	}
@@ -282,69 +432,26 @@ func (s *LoaderService) InitData() error {
 	var counter int
 	for {
 		// Get the data
-		resp, err := s.getData(nextPage)
+		resp, body, err := s.getData(ctx, nextPage)
 		if err != nil {
 			return DataFetchError.From(err)
 		}
 
-		// If not void insert it into the database
-		if len(resp.Items) > 0 {
-
-			var parsedStocksRatings []repository.AddStockRatingsParams
-			// Normalize the data
-			for _, rating := range resp.Items {
-				at, err := time.Parse(time.RFC3339Nano, rating.Time)
-				if err != nil {
-					return TimeParseError.From(err)
-				}
-				ratingFrom, err := s.rawRatingToStockRating(rating.RatingFrom)
-				if err != nil {
-					log.Println("Error parsing rating: ", rating)
-					return UnknownRatingError.From(err)
-				}
-				ratingTo, err := s.rawRatingToStockRating(rating.RatingTo)
-				if err != nil {
-					log.Println("Error parsing rating: ", rating)
-					return UnknownRatingError.From(err)
-				}
-				action, err := s.rawActionToStockAction(rating.Action)
-				if err != nil {
-					log.Println("Error parsing rating: ", rating)
-					return UnknownActionError.From(err)
-				}
-				targetFrom, err := s.rawTargetToStockTarget(rating.TargetFrom)
-				if err != nil {
-					log.Println("Error parsing rating: ", rating)
-					return UnknownTargetError.From(err)
-				}
-				targetTo, err := s.rawTargetToStockTarget(rating.TargetTo)
-				if err != nil {
-					log.Println("Error parsing rating: ", rating)
-					return UnknownTargetError.From(err)
-				}
-
-				parsedStocksRatings = append(parsedStocksRatings, repository.AddStockRatingsParams{
-					Ticker:        rating.Ticker,
-					Company:       rating.Company,
-					Brokerage:     rating.Brokerage,
-					TargetFrom:    targetFrom,
-					TargetTo:      targetTo,
-					Action:        action,
-					RawAction:     rating.RatingFrom,
-					RatingFrom:    ratingFrom,
-					RawRatingFrom: rating.RatingFrom,
-					RatingTo:      ratingTo,
-					RawRatingTo:   rating.RatingTo,
-					At:            at,
-				})
-			}
+		// Archive the page as received
+		err = s.archivePage(ctx, runID, int32(counter), nextPage, body)
+		if err != nil {
+			return ArchivePageError.From(err)
+		}
 
-			// Insert it into the db
-			_, err = s.repo.AddStockRatings(context.Background(), parsedStocksRatings)
-			if err != nil {
-				return InsertStockRatingsError.From(err)
+		// If not void insert it into the database
+		batch, err := s.insertPage(ctx, runID, resp.Items)
+		if err != nil {
+			return err
+		}
+		for _, hook := range s.hooks {
+			if err := hook.OnBatch(ctx, runID, batch); err != nil {
+				log.Println("Error in load hook: ", err)
 			}
-
 		}
 
 		nextPage = resp.NextPage
@@ -354,8 +461,250 @@ func (s *LoaderService) InitData() error {
 		log.Println("Chunk ", counter, "length: ", len(resp.Items), "next page: ", nextPage)
 		counter++
 	}
+	err = s.bumpDatasetVersion(ctx)
+	if err != nil {
+		return DatasetVersionError.From(err)
+	}
 	log.Println("Data initialized")
+	metrics.LoaderLastSuccess.SetToCurrentTime()
 
 	return nil
 
 }
+
+// Normalize a page of events and insert it into the database for a run, returning the inserted rows
+func (s *LoaderService) insertPage(ctx context.Context, runID uuid.UUID, items []RawStockEvent) ([]repository.AddStockRatingsParams, error) {
+	if len(items) == 0 {
+		return nil, nil
+	}
+
+	parsedStocksRatings, err := Normalize(items)
+	if err != nil {
+		return nil, err
+	}
+
+	// Insert it into the db
+	inserted, err := s.repo.AddStockRatings(ctx, parsedStocksRatings)
+	if err != nil {
+		return nil, InsertStockRatingsError.From(err).reject(len(parsedStocksRatings))
+	}
+	metrics.LoaderEventsIngested.Add(float64(inserted))
+
+	// Append them to the history
+	_, err = s.repo.AddStockRatingEvents(ctx, NewStockRatingEvents(parsedStocksRatings))
+	if err != nil {
+		return nil, InsertStockRatingEventsError.From(err).reject(len(parsedStocksRatings))
+	}
+
+	// Record the tickers the run loaded, the diff of the history takes the others as removed
+	tickers := make([]string, 0, len(parsedStocksRatings))
+	for _, r := range parsedStocksRatings {
+		tickers = append(tickers, r.Ticker)
+	}
+	err = s.repo.AddIngestionRunTickers(ctx, repository.AddIngestionRunTickersParams{RunID: runID, Tickers: tickers})
+	if err != nil {
+		return nil, IngestionRunError.From(err)
+	}
+	return parsedStocksRatings, nil
+}
+
+// Columns of the rows, as the history takes them
+func NewStockRatingEvents(batch []repository.AddStockRatingsParams) repository.AddStockRatingEventsParams {
+	var events repository.AddStockRatingEventsParams
+	for _, r := range batch {
+		events.Ticker = append(events.Ticker, r.Ticker)
+		events.Company = append(events.Company, r.Company)
+		events.Brokerage = append(events.Brokerage, r.Brokerage)
+		events.TargetFrom = append(events.TargetFrom, r.TargetFrom)
+		events.TargetTo = append(events.TargetTo, r.TargetTo)
+		events.Action = append(events.Action, string(r.Action))
+		events.RawAction = append(events.RawAction, r.RawAction)
+		events.RatingFrom = append(events.RatingFrom, string(r.RatingFrom))
+		events.RawRatingFrom = append(events.RawRatingFrom, r.RawRatingFrom)
+		events.RatingTo = append(events.RatingTo, string(r.RatingTo))
+		events.RawRatingTo = append(events.RawRatingTo, r.RawRatingTo)
+		events.At = append(events.At, r.At)
+	}
+	return events
+}
+
+// Translate the raw events of the API to rows of the database
+func Normalize(items []RawStockEvent) ([]repository.AddStockRatingsParams, error) {
+	var parsedStocksRatings []repository.AddStockRatingsParams
+	for _, rating := range items {
+		at, err := time.Parse(time.RFC3339Nano, rating.Time)
+		if err != nil {
+			return nil, TimeParseError.From(err).reject(1)
+		}
+		ratingFrom, err := RawRatingToStockRating(rating.RatingFrom)
+		if err != nil {
+			log.Println("Error parsing rating: ", rating)
+			return nil, UnknownRatingError.From(err).reject(1)
+		}
+		ratingTo, err := RawRatingToStockRating(rating.RatingTo)
+		if err != nil {
+			log.Println("Error parsing rating: ", rating)
+			return nil, UnknownRatingError.From(err).reject(1)
+		}
+		action, err := RawActionToStockAction(rating.Action)
+		if err != nil {
+			log.Println("Error parsing rating: ", rating)
+			return nil, UnknownActionError.From(err).reject(1)
+		}
+		targetFrom, err := RawTargetToStockTarget(rating.TargetFrom)
+		if err != nil {
+			log.Println("Error parsing rating: ", rating)
+			return nil, UnknownTargetError.From(err).reject(1)
+		}
+		targetTo, err := RawTargetToStockTarget(rating.TargetTo)
+		if err != nil {
+			log.Println("Error parsing rating: ", rating)
+			return nil, UnknownTargetError.From(err).reject(1)
+		}
+
+		parsedStocksRatings = append(parsedStocksRatings, repository.AddStockRatingsParams{
+			Ticker:        rating.Ticker,
+			Company:       rating.Company,
+			Brokerage:     rating.Brokerage,
+			TargetFrom:    targetFrom,
+			TargetTo:      targetTo,
+			Action:        action,
+			RawAction:     rating.Action,
+			RatingFrom:    ratingFrom,
+			RawRatingFrom: rating.RatingFrom,
+			RatingTo:      ratingTo,
+			RawRatingTo:   rating.RatingTo,
+			At:            at,
+		})
+	}
+	return parsedStocksRatings, nil
+}
+
+// Record the outcome of an ingestion run
+func (s *LoaderService) finishRun(ctx context.Context, runID uuid.UUID, err error) {
+	params := repository.FinishIngestionRunParams{ID: runID, Status: repository.IngestionRunStatusSucceeded}
+	if err != nil {
+		params.Status = repository.IngestionRunStatusFailed
+		params.Error = pgtype.Text{String: err.Error(), Valid: true}
+	}
+	if err := s.repo.FinishIngestionRun(ctx, params); err != nil {
+		log.Println("Error recording the end of the ingestion run: ", err)
+	}
+}
+
+// Invalidate the HTTP caches of the data, the app picks up the new version on the next request
+func (s *LoaderService) bumpDatasetVersion(ctx context.Context) error {
+	version, err := s.repo.BumpDatasetVersion(ctx)
+	if err != nil {
+		return err
+	}
+	log.Println("Dataset version ", version.Version)
+	return nil
+}
+
+// ARCHIVE =========================================================================================
+
+// Store a page of the API, gzip compressed, as received
+func (s *LoaderService) archivePage(ctx context.Context, runID uuid.UUID, page int32, cursor string, body []byte) error {
+	var payload bytes.Buffer
+	zw := gzip.NewWriter(&payload)
+	if _, err := zw.Write(body); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	return s.repo.AddRawPage(ctx, repository.AddRawPageParams{
+		RunID:     runID,
+		Page:      page,
+		Cursor:    cursor,
+		FetchedAt: time.Now(),
+		Payload:   payload.Bytes(),
+	})
+}
+
+// Read back an archived page, nil when the run has no such page
+func (s *LoaderService) readArchivedPage(ctx context.Context, runID uuid.UUID, page int32) (*APIResponse, error) {
+	raw, err := s.repo.GetRawPage(ctx, repository.GetRawPageParams{RunID: runID, Page: page})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(raw.Payload))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	var resp APIResponse
+	if err := json.NewDecoder(zr).Decode(&resp); err != nil {
+		return nil, JSONParseError.From(err)
+	}
+	return &resp, nil
+}
+
+// Replay ==========================================================================================
+
+// Rebuild the stock ratings from the pages archived by an ingestion run, without calling the API.
+// Used after fixing the normalization. The latest successful API run is replayed when runID is
+// uuid.Nil.
+func (s *LoaderService) Replay(runID uuid.UUID) (err error) {
+	ctx, span := tracing.Tracer().Start(context.Background(), "LoaderService.Replay")
+	defer func() {
+		if err != nil {
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}()
+
+	// Find the run to replay
+	if runID == uuid.Nil {
+		latest, err := s.repo.GetLatestIngestionRun(ctx)
+		if err != nil {
+			return ReadArchiveError.From(fmt.Errorf("no successful ingestion run to replay: %w", err))
+		}
+		runID = latest.ID
+	}
+	span.SetAttributes(attribute.String("replayed_run_id", runID.String()))
+
+	// Record the replay as a run of its own
+	replayID, err := s.repo.CreateIngestionRun(ctx, repository.IngestionRunSourceReplay)
+	if err != nil {
+		return IngestionRunError.From(err)
+	}
+	defer func() { s.finishRun(ctx, replayID, err) }()
+
+	// Clear the current data in db
+	err = s.clearStockRatings(ctx)
+	if err != nil {
+		return ClearStockRatingsError.From(err)
+	}
+
+	// Normalize and insert the pages in the order they were fetched
+	var page int32
+	for ; ; page++ {
+		resp, err := s.readArchivedPage(ctx, runID, page)
+		if err != nil {
+			return ReadArchiveError.From(err)
+		}
+		if resp == nil {
+			break
+		}
+		_, err = s.insertPage(ctx, replayID, resp.Items)
+		if err != nil {
+			return err
+		}
+	}
+	if page == 0 {
+		return ReadArchiveError.From(fmt.Errorf("run %s has no archived pages", runID))
+	}
+	err = s.bumpDatasetVersion(ctx)
+	if err != nil {
+		return DatasetVersionError.From(err)
+	}
+	log.Println("Replayed ", page, " pages of run ", runID)
+	metrics.LoaderLastSuccess.SetToCurrentTime()
+
+	return nil
+}
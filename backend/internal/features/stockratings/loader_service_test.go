@@ -0,0 +1,134 @@
+package stockratings
+
+import (
+	"backend/internal/fakeapi"
+	"backend/internal/repository"
+	"backend/internal/repository/memstore"
+	"backend/internal/repository/repositorytest"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Loader of the fake API, storing in memory
+func newTestLoader(t *testing.T, api *fakeapi.Server, token string) (*LoaderService, *memstore.Store) {
+	t.Helper()
+	srv := httptest.NewServer(api)
+	t.Cleanup(srv.Close)
+	client := srv.Client()
+	client.Timeout = 200 * time.Millisecond
+	store := memstore.New()
+	return &LoaderService{client: client, token: token, host: srv.URL, repo: store}, store
+}
+
+func TestInitData(t *testing.T) {
+	api := fakeapi.New("token", fakeapi.Generate(5, 1), 2)
+	loader, store := newTestLoader(t, api, "token")
+	ctx := context.Background()
+
+	if err := loader.InitData(); err != nil {
+		t.Fatalf("InitData() error = %v", err)
+	}
+	ratings, err := store.GetStockRatings(ctx, repository.GetStockRatingsParams{SortBy: "ticker", SortOrder: "asc", Limit: 10})
+	if err != nil {
+		t.Fatalf("GetStockRatings() error = %v", err)
+	}
+	if len(ratings) != 5 {
+		t.Errorf("loaded %d ratings, want 5", len(ratings))
+	}
+	runs, err := store.ListIngestionRuns(ctx, 10)
+	if err != nil {
+		t.Fatalf("ListIngestionRuns() error = %v", err)
+	}
+	if len(runs) != 1 || runs[0].Status != repository.IngestionRunStatusSucceeded || runs[0].Pages != 3 {
+		t.Errorf("ingestion runs = %+v, want a succeeded run of 3 pages", runs)
+	}
+	if version, err := store.GetDatasetVersion(ctx); err != nil || version.Version != 2 {
+		t.Errorf("GetDatasetVersion() = %+v, %v, want the version bumped to 2", version, err)
+	}
+
+	// The tickers of the run are recorded, a ticker it did not load is not in the diff to its end
+	if _, err := store.AddStockRatingEvents(ctx, NewStockRatingEvents([]repository.AddStockRatingsParams{repositorytest.NewStockRating("ZZZZ", 1, 2, repositorytest.RatedAt)})); err != nil {
+		t.Fatalf("AddStockRatingEvents() error = %v", err)
+	}
+	diff, err := store.GetStockRatingsDiff(ctx, repository.GetStockRatingsDiffParams{
+		FromAt: pgtype.Timestamptz{Time: time.Unix(0, 0), Valid: true},
+		ToAt:   pgtype.Timestamptz{Time: time.Now(), Valid: true},
+	})
+	if err != nil || len(diff) != 5 {
+		t.Errorf("GetStockRatingsDiff() = %+v, %v, want the 5 tickers of the run added", diff, err)
+	}
+}
+
+func TestInitDataErrors(t *testing.T) {
+	cases := []struct {
+		name  string
+		token string
+		fault fakeapi.Fault
+		kind  initDataErrorKind
+		// Kind of the GetDataError of the fetch errors
+		fetch getDataErrorKind
+	}{
+		{"unauthorized", "wrong", fakeapi.Fault{}, dataFetchError, apiError},
+		{"server error", "token", fakeapi.Fault{Kind: fakeapi.FaultServerError, Page: 1}, dataFetchError, apiError},
+		{"too many requests", "token", fakeapi.Fault{Kind: fakeapi.FaultTooManyRequests, Page: 1, RetryAfter: time.Second}, dataFetchError, apiError},
+		{"slow", "token", fakeapi.Fault{Kind: fakeapi.FaultSlow, Page: 2, Delay: time.Minute}, dataFetchError, apiError},
+		{"truncated", "token", fakeapi.Fault{Kind: fakeapi.FaultTruncated, Page: 0}, dataFetchError, jSONParseError},
+		{"unknown rating", "token", fakeapi.Fault{Kind: fakeapi.FaultUnknownRating, Page: 1}, unknownRatingError, 0},
+		{"duplicate", "token", fakeapi.Fault{Kind: fakeapi.FaultDuplicate, Page: 1}, insertStockRatingsError, 0},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			api := fakeapi.New("token", fakeapi.Generate(5, 1), 2)
+			if c.fault.Kind != 0 {
+				api.Inject(c.fault)
+			}
+			loader, store := newTestLoader(t, api, c.token)
+
+			err := loader.InitData()
+			var initErr InitDataError
+			if !errors.As(err, &initErr) || initErr.kind != c.kind {
+				t.Fatalf("InitData() error = %v, want a %s error", err, c.kind)
+			}
+			var fetchErr GetDataError
+			if c.fetch != 0 && (!errors.As(err, &fetchErr) || fetchErr.kind != c.fetch) {
+				t.Errorf("InitData() error = %v, want a fetch error of kind %d", err, c.fetch)
+			}
+
+			// The failure is recorded on the run, the dataset version is left as it was
+			runs, err := store.ListIngestionRuns(context.Background(), 10)
+			if err != nil {
+				t.Fatalf("ListIngestionRuns() error = %v", err)
+			}
+			if len(runs) != 1 || runs[0].Status != repository.IngestionRunStatusFailed || !runs[0].Error.Valid {
+				t.Errorf("ingestion runs = %+v, want a failed run with its error", runs)
+			}
+			if version, err := store.GetDatasetVersion(context.Background()); err != nil || version.Version != 1 {
+				t.Errorf("GetDatasetVersion() = %+v, %v, want the version left at 1", version, err)
+			}
+		})
+	}
+}
+
+// The fake API refuses the loader's requests without the token of DATA_TOKEN
+func TestInitDataSendsTheToken(t *testing.T) {
+	api := fakeapi.New("secret", nil, 2)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			t.Errorf("Authorization = %q, want the bearer token", r.Header.Get("Authorization"))
+		}
+		api.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+	t.Setenv("DATA_HOST", srv.URL)
+	t.Setenv("DATA_TOKEN", "secret")
+
+	if err := NewLoaderService(memstore.New()).InitData(); err != nil {
+		t.Fatalf("InitData() error = %v", err)
+	}
+}
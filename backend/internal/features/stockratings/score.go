@@ -0,0 +1,74 @@
+package stockratings
+
+import (
+	"backend/internal/repository"
+	"math/big"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// SCORE ===========================================================================================
+// Score column of stock_rating (migration 0009) for the ratings of a load, before they are read
+// back. The targets are NUMERIC, so the expression is evaluated on exact rationals: a float port
+// is off by one whenever the decimal result has no exact binary form, e.g. 25 to 34 would score
+// 3599 instead of 3600.
+
+func ratingWeight(r repository.StockRatingType) int64 {
+	switch r {
+	case repository.StockRatingTypeBuy:
+		return 1
+	case repository.StockRatingTypeSell:
+		return -1
+	default:
+		return 0
+	}
+}
+
+func actionWeight(a repository.StockActionType) int64 {
+	switch a {
+	case repository.StockActionTypeUp:
+		return 1
+	case repository.StockActionTypeDown:
+		return -1
+	default:
+		return 0
+	}
+}
+
+func toRat(n pgtype.Numeric) *big.Rat {
+	r := new(big.Rat)
+	if !n.Valid || n.Int == nil {
+		return r
+	}
+	r.SetInt(n.Int)
+	exp := new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(max(n.Exp, -n.Exp))), nil))
+	if n.Exp > 0 {
+		return r.Mul(r, exp)
+	}
+	return r.Quo(r, exp)
+}
+
+// Change of the target relative to target_from, 0 when there was no previous target as in
+// target_delta_pct
+func targetChange(targetFrom pgtype.Numeric, targetTo pgtype.Numeric) *big.Rat {
+	from, to := toRat(targetFrom), toRat(targetTo)
+	if from.Sign() == 0 {
+		return new(big.Rat)
+	}
+	return new(big.Rat).Quo(new(big.Rat).Sub(to, from), from)
+}
+
+// Relative change of the target in percent, 0 when there was no previous target
+func TargetChangePct(targetFrom pgtype.Numeric, targetTo pgtype.Numeric) float64 {
+	pct, _ := new(big.Rat).Mul(targetChange(targetFrom, targetTo), big.NewRat(100, 1)).Float64()
+	return pct
+}
+
+// TRUNC(10 * change + 2 * rating + action, 3) * 1000
+func Score(targetFrom pgtype.Numeric, targetTo pgtype.Numeric, ratingTo repository.StockRatingType, action repository.StockActionType) int32 {
+	x := new(big.Rat).Mul(targetChange(targetFrom, targetTo), big.NewRat(10, 1))
+	x.Add(x, big.NewRat(2*ratingWeight(ratingTo)+actionWeight(action), 1))
+	x.Mul(x, big.NewRat(1000, 1))
+	// Quo truncates toward zero, as TRUNC
+	return int32(new(big.Int).Quo(x.Num(), x.Denom()).Int64())
+}
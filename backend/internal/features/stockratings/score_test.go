@@ -0,0 +1,60 @@
+package stockratings
+
+import (
+	"backend/internal/repository"
+	"backend/internal/repository/memstore"
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Scores computed by the score column of stock_rating
+func TestScoreMatchesTheColumn(t *testing.T) {
+	cents := func(n int64) pgtype.Numeric { return pgtype.Numeric{Int: big.NewInt(n), Exp: -2, Valid: true} }
+	cases := []struct {
+		targetFrom pgtype.Numeric
+		targetTo   pgtype.Numeric
+		ratingTo   repository.StockRatingType
+		action     repository.StockActionType
+		want       int32
+		wantPct    float64
+	}{
+		{cents(2500), cents(3400), repository.StockRatingTypeHold, repository.StockActionTypeReiterated, 3600, 36},
+		{cents(15000), cents(18000), repository.StockRatingTypeBuy, repository.StockActionTypeUp, 5000, 20},
+		{cents(300), cents(100), repository.StockRatingTypeSell, repository.StockActionTypeDown, -9666, -66.66666666666667},
+		{cents(110), cents(130), repository.StockRatingTypeBuy, repository.StockActionTypeReiterated, 3818, 18.181818181818183},
+		{cents(10000), cents(9999), repository.StockRatingTypePending, repository.StockActionTypeDown, -1001, -0.01},
+		{cents(3333), cents(6666), repository.StockRatingTypeHold, repository.StockActionTypeUp, 11000, 100},
+		{pgtype.Numeric{Int: big.NewInt(12), Exp: 1, Valid: true}, cents(13200), repository.StockRatingTypeBuy, repository.StockActionTypeUp, 4000, 10},
+		{cents(0), cents(100), repository.StockRatingTypeBuy, repository.StockActionTypeUp, 3000, 0},
+	}
+	store := memstore.New()
+	for _, tc := range cases {
+		if got := Score(tc.targetFrom, tc.targetTo, tc.ratingTo, tc.action); got != tc.want {
+			t.Errorf("Score(%v, %v, %s, %s) = %d, want %d", tc.targetFrom.Int, tc.targetTo.Int, tc.ratingTo, tc.action, got, tc.want)
+		}
+		if got := TargetChangePct(tc.targetFrom, tc.targetTo); got != tc.wantPct {
+			t.Errorf("TargetChangePct(%v, %v) = %v, want %v", tc.targetFrom.Int, tc.targetTo.Int, got, tc.wantPct)
+		}
+
+		// The memory store is checked against the database by the conformance suite
+		ticker := "T" + tc.targetFrom.Int.String() + "-" + tc.targetTo.Int.String()
+		if _, err := store.AddStockRatings(context.Background(), []repository.AddStockRatingsParams{{
+			Ticker:     ticker,
+			TargetFrom: tc.targetFrom,
+			TargetTo:   tc.targetTo,
+			Action:     tc.action,
+			RatingFrom: repository.StockRatingTypeHold,
+			RatingTo:   tc.ratingTo,
+			At:         time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+		}}); err != nil {
+			t.Fatalf("AddStockRatings() error = %v", err)
+		}
+		if r, err := store.GetStockRating(context.Background(), ticker); err != nil || r.Score != tc.want {
+			t.Errorf("stored score of %s = %d, %v, want %d", ticker, r.Score, err, tc.want)
+		}
+	}
+}
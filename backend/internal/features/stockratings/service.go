@@ -2,21 +2,32 @@ package stockratings
 
 import (
 	"backend/internal/repository"
+	"backend/pkg/tracing"
 	"context"
+	"errors"
 	"fmt"
 	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
 )
 
 // SERVICE =========================================================================================
 
 type ServiceInterface interface {
-	GetStockRatings(input GetStockRatingsInput) (GetStockRatingsOutput, error)
+	GetStockRatings(ctx context.Context, input GetStockRatingsInput) (GetStockRatingsOutput, error)
+	ExportStockRatings(ctx context.Context, input GetStockRatingsInput, fn func(rating) error) error
+	GetStockRating(ctx context.Context, ticker string, asOf time.Time) (rating, error)
+	DiffStockRatings(ctx context.Context, from DiffPoint, to DiffPoint) (DiffStockRatingsOutput, error)
 }
 type Service struct {
-	repo *repository.Queries
+	repo repository.Repository
 }
 
-func NewService(r *repository.Queries) *Service {
+func NewService(r repository.Repository) *Service {
 	return &Service{
 		repo: r,
 	}
@@ -30,21 +41,26 @@ type GetStockRatingsInput struct {
 	limit       int32
 	tickerLike  string
 	companyLike string
+	// Evaluate the ratings from their history as they were at asOf, the current ones when zero
+	asOf time.Time
 }
 
 type rating = struct {
-	ticker      string
-	company     string
-	brokerage   string
-	targetFrom  string
-	targetTo    string
-	action      string
-	rawAction   string
-	ratingFrom  string
-	ratingTo    string
-	at          time.Time
-	targetDelta string
-	score       int32
+	ticker         string
+	company        string
+	brokerage      string
+	targetFrom     string
+	targetTo       string
+	action         string
+	rawAction      string
+	ratingFrom     string
+	rawRatingFrom  string
+	ratingTo       string
+	rawRatingTo    string
+	at             time.Time
+	targetDelta    string
+	targetDeltaPct string
+	score          int32
 }
 type GetStockRatingsOutput = []rating
 
@@ -82,35 +98,361 @@ var (
 	GetStockRatingsErrorUnexpectedError = GetStockRatingsError{kind: getStockRatingsUnexpectedError}
 )
 
-func (s *Service) GetStockRatings(input GetStockRatingsInput) (GetStockRatingsOutput, error) {
-	res, err := s.repo.GetStockRatings(context.Background(), repository.GetStockRatingsParams{
+func (s *Service) GetStockRatings(ctx context.Context, input GetStockRatingsInput) (GetStockRatingsOutput, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "Service.GetStockRatings")
+	defer span.End()
+	span.SetAttributes(
+		attribute.String("sort_by", input.sortBy),
+		attribute.String("sort_order", input.sortOrder),
+		attribute.Int("offset", int(input.offset)),
+		attribute.Int("limit", int(input.limit)),
+	)
+
+	arg := repository.GetStockRatingsParams{
 		SortOrder:   input.sortOrder,
 		SortBy:      input.sortBy,
 		Offset:      input.offset,
 		Limit:       input.limit,
 		TickerLike:  input.tickerLike,
 		CompanyLike: input.companyLike,
-	})
+	}
+	var res []repository.GetStockRatingsRow
+	var err error
+	if input.asOf.IsZero() {
+		res, err = s.repo.GetStockRatings(ctx, arg)
+	} else {
+		span.SetAttributes(attribute.String("as_of", input.asOf.Format(time.RFC3339Nano)))
+		res, err = s.getStockRatingsAsOf(ctx, arg, input.asOf)
+	}
 	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
 		return nil, GetStockRatingsErrorUnexpectedError.From(err)
 	}
 
 	var out GetStockRatingsOutput
 	for _, r := range res {
-		out = append(out, rating{
-			ticker:      r.Ticker,
-			company:     r.Company,
-			brokerage:   r.Brokerage,
-			targetFrom:  r.TargetFrom,
-			targetTo:    r.TargetTo,
-			action:      string(r.Action),
-			rawAction:   r.RawAction,
-			ratingFrom:  string(r.RatingFrom),
-			ratingTo:    string(r.RatingTo),
-			at:          r.At,
-			targetDelta: r.TargetDelta,
-			score:       r.Score,
-		})
+		out = append(out, toRating(r))
 	}
 	return out, nil
 }
+
+func (s *Service) getStockRatingsAsOf(ctx context.Context, arg repository.GetStockRatingsParams, asOf time.Time) ([]repository.GetStockRatingsRow, error) {
+	rows, err := s.repo.GetStockRatingsAsOf(ctx, repository.GetStockRatingsAsOfParams{
+		SortOrder:   arg.SortOrder,
+		SortBy:      arg.SortBy,
+		Offset:      arg.Offset,
+		Limit:       arg.Limit,
+		TickerLike:  arg.TickerLike,
+		CompanyLike: arg.CompanyLike,
+		AsOf:        asOf,
+	})
+	if err != nil {
+		return nil, err
+	}
+	res := make([]repository.GetStockRatingsRow, len(rows))
+	for i, r := range rows {
+		res[i] = repository.GetStockRatingsRow(r)
+	}
+	return res, nil
+}
+
+func toRating(r repository.GetStockRatingsRow) rating {
+	return rating{
+		ticker:         r.Ticker,
+		company:        r.Company,
+		brokerage:      r.Brokerage,
+		targetFrom:     r.TargetFrom,
+		targetTo:       r.TargetTo,
+		action:         string(r.Action),
+		rawAction:      r.RawAction,
+		ratingFrom:     string(r.RatingFrom),
+		rawRatingFrom:  r.RawRatingFrom,
+		ratingTo:       string(r.RatingTo),
+		rawRatingTo:    r.RawRatingTo,
+		at:             r.At,
+		targetDelta:    r.TargetDelta,
+		targetDeltaPct: r.TargetDeltaPct,
+		score:          r.Score,
+	}
+}
+
+// ExportStockRatings ------------------------------------------------------------------------------
+
+// Stream every rating matching the filters and sort of the input to fn, the offset and limit are
+// ignored
+func (s *Service) ExportStockRatings(ctx context.Context, input GetStockRatingsInput, fn func(rating) error) error {
+	ctx, span := tracing.Tracer().Start(ctx, "Service.ExportStockRatings")
+	defer span.End()
+
+	var count int
+	err := s.repo.StreamStockRatings(ctx, repository.GetStockRatingsParams{
+		SortOrder:   input.sortOrder,
+		SortBy:      input.sortBy,
+		TickerLike:  input.tickerLike,
+		CompanyLike: input.companyLike,
+	}, func(r repository.GetStockRatingsRow) error {
+		count++
+		return fn(toRating(r))
+	})
+	span.SetAttributes(attribute.Int("rows", count))
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return GetStockRatingsErrorUnexpectedError.From(err)
+	}
+	return nil
+}
+
+// GetStockRating ----------------------------------------------------------------------------------
+type GetStockRatingErrorKind int
+
+const (
+	_ GetStockRatingErrorKind = iota
+	getStockRatingUnexpectedError
+	getStockRatingNotFoundError
+)
+
+type GetStockRatingError struct {
+	kind GetStockRatingErrorKind
+	err  error
+}
+
+func (e GetStockRatingError) Error() string {
+	switch e.kind {
+	case getStockRatingUnexpectedError:
+		return fmt.Sprintf("Unexpected error: %s", e.err.Error())
+	case getStockRatingNotFoundError:
+		return "Stock rating not found"
+	default:
+		return "Unknown error"
+	}
+}
+
+func (e GetStockRatingError) From(err error) GetStockRatingError {
+	e1 := e
+	e1.err = err
+	return e1
+}
+func (e GetStockRatingError) Unwrap() error {
+	return e.err
+}
+
+// Errors of the same kind match with errors.Is, whatever their cause
+func (e GetStockRatingError) Is(target error) bool {
+	t, ok := target.(GetStockRatingError)
+	return ok && t.kind == e.kind
+}
+
+var (
+	GetStockRatingErrorUnexpectedError = GetStockRatingError{kind: getStockRatingUnexpectedError}
+	GetStockRatingErrorNotFound        = GetStockRatingError{kind: getStockRatingNotFoundError}
+)
+
+// Rating of a ticker, as it was at asOf when not zero. A ticker without an event by then is not found.
+func (s *Service) GetStockRating(ctx context.Context, ticker string, asOf time.Time) (rating, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "Service.GetStockRating")
+	defer span.End()
+	span.SetAttributes(attribute.String("ticker", ticker))
+
+	var res repository.GetStockRatingRow
+	var err error
+	if asOf.IsZero() {
+		res, err = s.repo.GetStockRating(ctx, ticker)
+	} else {
+		span.SetAttributes(attribute.String("as_of", asOf.Format(time.RFC3339Nano)))
+		var row repository.GetStockRatingAsOfRow
+		row, err = s.repo.GetStockRatingAsOf(ctx, repository.GetStockRatingAsOfParams{Ticker: ticker, AsOf: asOf})
+		res = repository.GetStockRatingRow(row)
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		return rating{}, GetStockRatingErrorNotFound
+	}
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return rating{}, GetStockRatingErrorUnexpectedError.From(err)
+	}
+	return toRating(repository.GetStockRatingsRow(res)), nil
+}
+
+// DiffStockRatings --------------------------------------------------------------------------------
+
+// A point of the history to compare, a time or an ingestion run. A time sees the events at or
+// before it, as the as_of of the list. A run sees the events recorded by its end, so the events it
+// loaded with an older time are its changes and not the ones of the point before it.
+type DiffPoint struct {
+	at    time.Time
+	runID uuid.UUID
+}
+
+// Columns compared by the diff, in the order they are listed
+const (
+	diffFieldRatingTo = "rating_to"
+	diffFieldTargetTo = "target_to"
+	diffFieldScore    = "score"
+)
+
+type ratingValues = struct {
+	brokerage string
+	action    string
+	ratingTo  string
+	targetTo  string
+	score     int32
+	at        time.Time
+}
+
+// A ticker added, removed or changed between the points, before is nil when added and after when
+// removed
+type ratingChange = struct {
+	ticker  string
+	company string
+	fields  []string
+	before  *ratingValues
+	after   *ratingValues
+}
+type DiffStockRatingsOutput = []ratingChange
+
+type DiffStockRatingsErrorKind int
+
+const (
+	_ DiffStockRatingsErrorKind = iota
+	diffStockRatingsUnexpectedError
+	diffStockRatingsRunNotFoundError
+	diffStockRatingsRunNotFinishedError
+	diffStockRatingsInvalidRangeError
+)
+
+type DiffStockRatingsError struct {
+	kind DiffStockRatingsErrorKind
+	err  error
+}
+
+func (e DiffStockRatingsError) Error() string {
+	switch e.kind {
+	case diffStockRatingsUnexpectedError:
+		return fmt.Sprintf("Unexpected error: %s", e.err.Error())
+	case diffStockRatingsRunNotFoundError:
+		return "Ingestion run not found"
+	case diffStockRatingsRunNotFinishedError:
+		return "Ingestion run not finished"
+	case diffStockRatingsInvalidRangeError:
+		return "The from point is after the to point"
+	default:
+		return "Unknown error"
+	}
+}
+
+func (e DiffStockRatingsError) From(err error) DiffStockRatingsError {
+	e1 := e
+	e1.err = err
+	return e1
+}
+func (e DiffStockRatingsError) Unwrap() error {
+	return e.err
+}
+
+// Errors of the same kind match with errors.Is, whatever their cause
+func (e DiffStockRatingsError) Is(target error) bool {
+	t, ok := target.(DiffStockRatingsError)
+	return ok && t.kind == e.kind
+}
+
+var (
+	DiffStockRatingsErrorUnexpectedError = DiffStockRatingsError{kind: diffStockRatingsUnexpectedError}
+	DiffStockRatingsErrorRunNotFound     = DiffStockRatingsError{kind: diffStockRatingsRunNotFoundError}
+	DiffStockRatingsErrorRunNotFinished  = DiffStockRatingsError{kind: diffStockRatingsRunNotFinishedError}
+	DiffStockRatingsErrorInvalidRange    = DiffStockRatingsError{kind: diffStockRatingsInvalidRangeError}
+)
+
+// Bounds of the events seen at a point, the end of a run for a run
+func (s *Service) resolveDiffPoint(ctx context.Context, p DiffPoint) (at pgtype.Timestamptz, recordedAt pgtype.Timestamptz, err error) {
+	if p.runID == uuid.Nil {
+		return pgtype.Timestamptz{Time: p.at, Valid: true}, pgtype.Timestamptz{}, nil
+	}
+	run, err := s.repo.GetIngestionRun(ctx, p.runID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return at, recordedAt, DiffStockRatingsErrorRunNotFound
+	}
+	if err != nil {
+		return at, recordedAt, DiffStockRatingsErrorUnexpectedError.From(err)
+	}
+	if !run.FinishedAt.Valid {
+		return at, recordedAt, DiffStockRatingsErrorRunNotFinished
+	}
+	return pgtype.Timestamptz{}, run.FinishedAt, nil
+}
+
+// Time of a point, the end of the run for a run
+func diffPointTime(at pgtype.Timestamptz, recordedAt pgtype.Timestamptz) time.Time {
+	if at.Valid {
+		return at.Time
+	}
+	return recordedAt.Time
+}
+
+// Tickers added, removed or with another rating, target or score from one point to the other, by
+// ticker. The from point can't be after the to point.
+func (s *Service) DiffStockRatings(ctx context.Context, from DiffPoint, to DiffPoint) (DiffStockRatingsOutput, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "Service.DiffStockRatings")
+	defer span.End()
+
+	var arg repository.GetStockRatingsDiffParams
+	var err error
+	if arg.FromAt, arg.FromRecordedAt, err = s.resolveDiffPoint(ctx, from); err != nil {
+		return nil, err
+	}
+	if arg.ToAt, arg.ToRecordedAt, err = s.resolveDiffPoint(ctx, to); err != nil {
+		return nil, err
+	}
+	if diffPointTime(arg.FromAt, arg.FromRecordedAt).After(diffPointTime(arg.ToAt, arg.ToRecordedAt)) {
+		return nil, DiffStockRatingsErrorInvalidRange
+	}
+
+	res, err := s.repo.GetStockRatingsDiff(ctx, arg)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return nil, DiffStockRatingsErrorUnexpectedError.From(err)
+	}
+	span.SetAttributes(attribute.Int("changes", len(res)))
+
+	var out DiffStockRatingsOutput
+	for _, r := range res {
+		out = append(out, toRatingChange(r))
+	}
+	return out, nil
+}
+
+func toRatingChange(r repository.GetStockRatingsDiffRow) ratingChange {
+	c := ratingChange{ticker: r.Ticker, company: r.Company, fields: []string{}}
+	if r.BeforeAt.Valid {
+		c.before = &ratingValues{
+			brokerage: r.BeforeBrokerage.String,
+			action:    string(r.BeforeAction.StockActionType),
+			ratingTo:  string(r.BeforeRatingTo.StockRatingType),
+			targetTo:  r.BeforeTargetTo.String,
+			score:     r.BeforeScore.Int32,
+			at:        r.BeforeAt.Time,
+		}
+	}
+	if r.AfterAt.Valid {
+		c.after = &ratingValues{
+			brokerage: r.AfterBrokerage.String,
+			action:    string(r.AfterAction.StockActionType),
+			ratingTo:  string(r.AfterRatingTo.StockRatingType),
+			targetTo:  r.AfterTargetTo.String,
+			score:     r.AfterScore.Int32,
+			at:        r.AfterAt.Time,
+		}
+	}
+	if c.before == nil || c.after == nil {
+		return c
+	}
+	if c.before.ratingTo != c.after.ratingTo {
+		c.fields = append(c.fields, diffFieldRatingTo)
+	}
+	if c.before.targetTo != c.after.targetTo {
+		c.fields = append(c.fields, diffFieldTargetTo)
+	}
+	if c.before.score != c.after.score {
+		c.fields = append(c.fields, diffFieldScore)
+	}
+	return c
+}
@@ -0,0 +1,186 @@
+package stockratings
+
+import (
+	"backend/internal/repository"
+	"backend/internal/repository/memstore"
+	"backend/internal/repository/repositorytest"
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestServiceGetStockRating(t *testing.T) {
+	store := memstore.New()
+	if _, err := store.AddStockRatings(context.Background(), []repository.AddStockRatingsParams{
+		repositorytest.NewStockRating("AAPL", 150, 180, repositorytest.RatedAt),
+	}); err != nil {
+		t.Fatalf("AddStockRatings() error = %v", err)
+	}
+	s := NewService(store)
+
+	r, err := s.GetStockRating(context.Background(), "AAPL", time.Time{})
+	if err != nil {
+		t.Fatalf("GetStockRating() error = %v", err)
+	}
+	if r.targetDeltaPct != "20.00" || r.score != 5000 {
+		t.Errorf("GetStockRating() = %+v, want a 20.00%% change scoring 5000", r)
+	}
+	if _, err := s.GetStockRating(context.Background(), "MSFT", time.Time{}); !errors.Is(err, GetStockRatingErrorNotFound) {
+		t.Errorf("GetStockRating(MSFT) error = %v, want %v", err, GetStockRatingErrorNotFound)
+	}
+}
+
+func TestServiceGetStockRatingsAsOf(t *testing.T) {
+	store := memstore.New()
+	first := repositorytest.NewStockRating("AAPL", 150, 180, repositorytest.RatedAt)
+	later := repositorytest.NewStockRating("AAPL", 180, 150, repositorytest.RatedAt)
+	later.Action, later.RatingTo, later.At = repository.StockActionTypeDown, repository.StockRatingTypeHold, first.At.Add(24*time.Hour)
+	other := repositorytest.NewStockRating("MSFT", 400, 440, repositorytest.RatedAt)
+	other.At = later.At
+	events := NewStockRatingEvents([]repository.AddStockRatingsParams{first, later, other})
+	if _, err := store.AddStockRatingEvents(context.Background(), events); err != nil {
+		t.Fatalf("AddStockRatingEvents() error = %v", err)
+	}
+	s := NewService(store)
+
+	// A day before the downgrade only AAPL was rated, with the score it had then
+	out, err := s.GetStockRatings(context.Background(), GetStockRatingsInput{sortBy: "score", sortOrder: "desc", limit: 10, asOf: first.At})
+	if err != nil {
+		t.Fatalf("GetStockRatings() error = %v", err)
+	}
+	if len(out) != 1 || out[0].ticker != "AAPL" || out[0].score != 5000 {
+		t.Errorf("GetStockRatings() as of the first event = %+v, want AAPL scoring 5000", out)
+	}
+	out, err = s.GetStockRatings(context.Background(), GetStockRatingsInput{sortBy: "score", sortOrder: "desc", limit: 10, asOf: later.At})
+	if err != nil {
+		t.Fatalf("GetStockRatings() error = %v", err)
+	}
+	if len(out) != 2 || out[0].ticker != "MSFT" || out[1].ticker != "AAPL" || out[1].ratingTo != "hold" {
+		t.Errorf("GetStockRatings() as of the downgrade = %+v, want MSFT then the downgraded AAPL", out)
+	}
+
+	r, err := s.GetStockRating(context.Background(), "AAPL", first.At.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("GetStockRating() error = %v", err)
+	}
+	if r.targetTo != "180.00" || r.score != 5000 {
+		t.Errorf("GetStockRating() as of the first event = %+v, want its target and score", r)
+	}
+	if _, err := s.GetStockRating(context.Background(), "MSFT", first.At); !errors.Is(err, GetStockRatingErrorNotFound) {
+		t.Errorf("GetStockRating(MSFT) before its first event error = %v, want %v", err, GetStockRatingErrorNotFound)
+	}
+}
+
+func TestServiceDiffStockRatingsBetweenRuns(t *testing.T) {
+	ctx := context.Background()
+	store := memstore.New()
+	// Each call of the clock a second later, the runs end before the next ones record
+	now := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+	store.SetClock(func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	})
+	s := NewService(store)
+	load := func(events ...repository.AddStockRatingsParams) uuid.UUID {
+		t.Helper()
+		id, err := store.CreateIngestionRun(ctx, repository.IngestionRunSourceApi)
+		if err != nil {
+			t.Fatalf("CreateIngestionRun() error = %v", err)
+		}
+		if _, err := store.AddStockRatingEvents(ctx, NewStockRatingEvents(events)); err != nil {
+			t.Fatalf("AddStockRatingEvents() error = %v", err)
+		}
+		var tickers []string
+		for _, e := range events {
+			tickers = append(tickers, e.Ticker)
+		}
+		if err := store.AddIngestionRunTickers(ctx, repository.AddIngestionRunTickersParams{RunID: id, Tickers: tickers}); err != nil {
+			t.Fatalf("AddIngestionRunTickers() error = %v", err)
+		}
+		if err := store.FinishIngestionRun(ctx, repository.FinishIngestionRunParams{ID: id, Status: repository.IngestionRunStatusSucceeded}); err != nil {
+			t.Fatalf("FinishIngestionRun() error = %v", err)
+		}
+		return id
+	}
+
+	first := repositorytest.NewStockRating("AAPL", 150, 180, repositorytest.RatedAt)
+	yesterday := load(first)
+	downgrade := repositorytest.NewStockRating("AAPL", 180, 150, repositorytest.RatedAt)
+	downgrade.Action, downgrade.RatingTo, downgrade.At = repository.StockActionTypeDown, repository.StockRatingTypeHold, first.At.Add(time.Hour)
+	// Published before the first rating, only loaded today: a change of today
+	late := repositorytest.NewStockRating("MSFT", 400, 440, repositorytest.RatedAt)
+	late.At = first.At.Add(-time.Hour)
+	today := load(downgrade, late)
+
+	out, err := s.DiffStockRatings(ctx, DiffPoint{runID: yesterday}, DiffPoint{runID: today})
+	if err != nil {
+		t.Fatalf("DiffStockRatings() error = %v", err)
+	}
+	if len(out) != 2 {
+		t.Fatalf("DiffStockRatings() = %+v, want AAPL and MSFT", out)
+	}
+	if aapl := out[0]; aapl.ticker != "AAPL" || aapl.before.ratingTo != "buy" || aapl.after.ratingTo != "hold" ||
+		!slices.Equal(aapl.fields, []string{diffFieldRatingTo, diffFieldTargetTo, diffFieldScore}) {
+		t.Errorf("DiffStockRatings() of AAPL = %+v, want the downgrade", aapl)
+	}
+	if msft := out[1]; msft.ticker != "MSFT" || msft.before != nil || msft.after == nil || msft.after.targetTo != "440.00" {
+		t.Errorf("DiffStockRatings() of MSFT = %+v, want it added", msft)
+	}
+
+	// By time the late rating was already there
+	out, err = s.DiffStockRatings(ctx, DiffPoint{at: first.At}, DiffPoint{runID: today})
+	if err != nil {
+		t.Fatalf("DiffStockRatings() error = %v", err)
+	}
+	if len(out) != 1 || out[0].ticker != "AAPL" {
+		t.Errorf("DiffStockRatings() from the time of the first rating = %+v, want AAPL only", out)
+	}
+
+	// Dropped upstream, MSFT keeps its last event but is not loaded anymore
+	tomorrow := load(downgrade)
+	out, err = s.DiffStockRatings(ctx, DiffPoint{runID: today}, DiffPoint{runID: tomorrow})
+	if err != nil {
+		t.Fatalf("DiffStockRatings() error = %v", err)
+	}
+	if len(out) != 1 || out[0].ticker != "MSFT" || out[0].before == nil || out[0].after != nil {
+		t.Errorf("DiffStockRatings() after MSFT was dropped = %+v, want it removed", out)
+	}
+	if _, err := s.DiffStockRatings(ctx, DiffPoint{runID: today}, DiffPoint{runID: yesterday}); !errors.Is(err, DiffStockRatingsErrorInvalidRange) {
+		t.Errorf("DiffStockRatings() backward error = %v, want %v", err, DiffStockRatingsErrorInvalidRange)
+	}
+
+	running, err := store.CreateIngestionRun(ctx, repository.IngestionRunSourceApi)
+	if err != nil {
+		t.Fatalf("CreateIngestionRun() error = %v", err)
+	}
+	if _, err := s.DiffStockRatings(ctx, DiffPoint{runID: today}, DiffPoint{runID: running}); !errors.Is(err, DiffStockRatingsErrorRunNotFinished) {
+		t.Errorf("DiffStockRatings() to a running run error = %v, want %v", err, DiffStockRatingsErrorRunNotFinished)
+	}
+	if _, err := s.DiffStockRatings(ctx, DiffPoint{runID: uuid.New()}, DiffPoint{runID: today}); !errors.Is(err, DiffStockRatingsErrorRunNotFound) {
+		t.Errorf("DiffStockRatings() from an unknown run error = %v, want %v", err, DiffStockRatingsErrorRunNotFound)
+	}
+}
+
+// Repository failing the list, the errors of the database can't be reached with valid ratings
+type failingRepository struct {
+	repository.Repository
+	err error
+}
+
+func (r failingRepository) GetStockRatings(ctx context.Context, arg repository.GetStockRatingsParams) ([]repository.GetStockRatingsRow, error) {
+	return nil, r.err
+}
+
+func TestServiceGetStockRatingsUnexpectedError(t *testing.T) {
+	s := NewService(failingRepository{memstore.New(), errors.New("connection reset")})
+
+	_, err := s.GetStockRatings(context.Background(), GetStockRatingsInput{sortBy: "ticker", sortOrder: "asc", limit: 10})
+	var serviceErr GetStockRatingsError
+	if !errors.As(err, &serviceErr) || serviceErr.kind != getStockRatingsUnexpectedError {
+		t.Errorf("GetStockRatings() error = %v, want an unexpected error", err)
+	}
+}
@@ -0,0 +1,233 @@
+package stream
+
+import (
+	"backend/internal/features/stockratings"
+	"backend/internal/repository"
+	"context"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// BROKER ==========================================================================================
+// Watches the dataset version and, when a load bumps it, diffs the ratings against the snapshot of
+// the previous version. Each created, changed or removed rating becomes an event pushed to the
+// subscribers, and kept in a ring buffer so a client reconnecting with the ID of the last event it
+// saw gets what it missed.
+
+const (
+	pollInterval = 5 * time.Second
+	historySize  = 4096
+	// Events a subscriber can fall behind before it is dropped, it then resumes from its last ID
+	subscriberBuffer = 256
+)
+
+const (
+	EventCreated = "created"
+	EventChanged = "changed"
+	EventRemoved = "removed"
+)
+
+type Event struct {
+	ID     uint64                                 `json:"id"`
+	Type   string                                 `json:"type"`
+	Rating stockratings.GetStockRatingsV2Response `json:"rating"`
+}
+
+// Subscription filters, the ticker_like and company_like of the list
+type Filter struct {
+	TickerLike  string
+	CompanyLike string
+}
+
+func (f Filter) match(e Event) bool {
+	return containsFold(e.Rating.Ticker, f.TickerLike) && containsFold(e.Rating.Company, f.CompanyLike)
+}
+
+func containsFold(s string, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
+
+type Subscription struct {
+	Events <-chan Event
+	events chan Event
+	filter Filter
+}
+
+type Broker struct {
+	repo repository.Repository
+
+	mu          sync.Mutex
+	version     int64
+	snapshot    map[string]repository.GetStockRatingsRow
+	lastID      uint64
+	history     []Event
+	subscribers map[*Subscription]struct{}
+	// Set once Run returns, the subscriptions are then closed as they come
+	closed bool
+}
+
+func NewBroker(r repository.Repository) *Broker {
+	return &Broker{
+		repo: r,
+		// IDs start from the clock, so the IDs of a previous process are never taken for newer ones
+		lastID:      uint64(time.Now().UnixMicro()),
+		subscribers: map[*Subscription]struct{}{},
+	}
+}
+
+// Watch the dataset until the context is cancelled, then close the subscriptions so the streams
+// return before the servers shut down, their requests aren't cancelled by a graceful shutdown
+func (b *Broker) Run(ctx context.Context) {
+	defer b.close()
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		if err := b.poll(ctx); err != nil {
+			log.Println("Error polling the ratings to stream: ", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (b *Broker) poll(ctx context.Context) error {
+	version, err := b.repo.GetDatasetVersion(ctx)
+	if err != nil {
+		return err
+	}
+	b.mu.Lock()
+	unchanged := b.snapshot != nil && version.Version == b.version
+	b.mu.Unlock()
+	if unchanged {
+		return nil
+	}
+
+	// Read every rating, in a stable order so the events of a load come sorted by ticker
+	snapshot := map[string]repository.GetStockRatingsRow{}
+	var tickers []string
+	err = b.repo.StreamStockRatings(ctx, repository.GetStockRatingsParams{
+		SortOrder: "asc",
+		SortBy:    "ticker",
+	}, func(r repository.GetStockRatingsRow) error {
+		r.At = r.At.UTC()
+		snapshot[r.Ticker] = r
+		tickers = append(tickers, r.Ticker)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	previous := b.snapshot
+	b.snapshot = snapshot
+	b.version = version.Version
+	// The first snapshot is the starting point, there is nothing to compare it to
+	if previous == nil {
+		return nil
+	}
+	for _, ticker := range tickers {
+		current := snapshot[ticker]
+		old, ok := previous[ticker]
+		switch {
+		case !ok:
+			b.publish(EventCreated, current)
+		case old != current:
+			b.publish(EventChanged, current)
+		}
+	}
+	for ticker, old := range previous {
+		if _, ok := snapshot[ticker]; !ok {
+			b.publish(EventRemoved, old)
+		}
+	}
+	return nil
+}
+
+func (b *Broker) close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	for sub := range b.subscribers {
+		delete(b.subscribers, sub)
+		close(sub.events)
+	}
+}
+
+// Whether the broker stopped, a closed subscription was then ended by the shutdown rather than
+// dropped for being too slow
+func (b *Broker) Closed() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.closed
+}
+
+// Must be called with the lock held
+func (b *Broker) publish(eventType string, r repository.GetStockRatingsRow) {
+	b.lastID++
+	event := Event{ID: b.lastID, Type: eventType, Rating: stockratings.NewStockRatingV2Response(r)}
+	b.history = append(b.history, event)
+	if len(b.history) > historySize {
+		b.history = b.history[len(b.history)-historySize:]
+	}
+	for sub := range b.subscribers {
+		if !sub.filter.match(event) {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+			// Too slow, drop it rather than block the others
+			delete(b.subscribers, sub)
+			close(sub.events)
+		}
+	}
+}
+
+// Subscribe to the events matching the filter. With a lastID, the missed events kept in the history
+// are returned first; resumed is false when some of them were already dropped from the history and
+// the client has to reload the list instead.
+func (b *Broker) Subscribe(filter Filter, lastID *uint64) (sub *Subscription, backlog []Event, resumed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	events := make(chan Event, subscriberBuffer)
+	sub = &Subscription{Events: events, events: events, filter: filter}
+	if b.closed {
+		close(events)
+		return sub, nil, true
+	}
+	b.subscribers[sub] = struct{}{}
+
+	if lastID == nil {
+		return sub, nil, true
+	}
+	oldest := b.lastID + 1
+	if len(b.history) > 0 {
+		oldest = b.history[0].ID
+	}
+	if *lastID+1 < oldest || *lastID > b.lastID {
+		return sub, nil, false
+	}
+	for _, event := range b.history {
+		if event.ID > *lastID && filter.match(event) {
+			backlog = append(backlog, event)
+		}
+	}
+	return sub, backlog, true
+}
+
+func (b *Broker) Unsubscribe(sub *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.subscribers[sub]; ok {
+		delete(b.subscribers, sub)
+		close(sub.events)
+	}
+}
@@ -0,0 +1,191 @@
+package stream
+
+import (
+	"backend/internal/repository"
+	"backend/internal/repository/memstore"
+	"backend/internal/repository/repositorytest"
+	"context"
+	"slices"
+	"testing"
+)
+
+// Replace the ratings as a load does, with a target per ticker, and bump the version
+func load(t *testing.T, store *memstore.Store, targets map[string]int64) {
+	t.Helper()
+	ctx := context.Background()
+	var arg []repository.AddStockRatingsParams
+	for ticker, target := range targets {
+		arg = append(arg, repositorytest.NewStockRating(ticker, 100, target, repositorytest.RatedAt))
+	}
+	if err := store.ClearStockRating(ctx); err != nil {
+		t.Fatalf("ClearStockRating() error = %v", err)
+	}
+	if _, err := store.AddStockRatings(ctx, arg); err != nil {
+		t.Fatalf("AddStockRatings() error = %v", err)
+	}
+	if _, err := store.BumpDatasetVersion(ctx); err != nil {
+		t.Fatalf("BumpDatasetVersion() error = %v", err)
+	}
+}
+
+func poll(t *testing.T, b *Broker) {
+	t.Helper()
+	if err := b.poll(context.Background()); err != nil {
+		t.Fatalf("poll() error = %v", err)
+	}
+}
+
+// Events waiting on the subscription, as type:ticker
+func received(sub *Subscription) []string {
+	var out []string
+	for {
+		select {
+		case e, ok := <-sub.Events:
+			if !ok {
+				return append(out, "closed")
+			}
+			out = append(out, e.Type+":"+e.Rating.Ticker)
+		default:
+			return out
+		}
+	}
+}
+
+func describe(events []Event) []string {
+	var out []string
+	for _, e := range events {
+		out = append(out, e.Type+":"+e.Rating.Ticker)
+	}
+	return out
+}
+
+func TestBrokerPublishesTheChangesOfALoad(t *testing.T) {
+	store := memstore.New()
+	load(t, store, map[string]int64{"AAPL": 110, "MSFT": 120})
+	b := NewBroker(store)
+	poll(t, b)
+	all, _, _ := b.Subscribe(Filter{}, nil)
+	filtered, _, _ := b.Subscribe(Filter{TickerLike: "aa", CompanyLike: "INC"}, nil)
+
+	// The first snapshot publishes nothing, and neither does a poll without a new version
+	poll(t, b)
+	if got := received(all); len(got) != 0 {
+		t.Fatalf("events before a load = %v, want none", got)
+	}
+
+	load(t, store, map[string]int64{"AAPL": 110, "MSFT": 130, "AMZN": 140})
+	poll(t, b)
+	load(t, store, map[string]int64{"MSFT": 130, "AMZN": 140})
+	poll(t, b)
+	if got, want := received(all), []string{"created:AMZN", "changed:MSFT", "removed:AAPL"}; !slices.Equal(got, want) {
+		t.Errorf("events = %v, want %v", got, want)
+	}
+	if got, want := received(filtered), []string{"removed:AAPL"}; !slices.Equal(got, want) {
+		t.Errorf("events matching the filter = %v, want %v", got, want)
+	}
+
+	b.Unsubscribe(all)
+	if got := received(all); !slices.Equal(got, []string{"closed"}) {
+		t.Errorf("events after unsubscribing = %v, want the channel closed", got)
+	}
+}
+
+func TestBrokerResumesFromTheLastEventID(t *testing.T) {
+	store := memstore.New()
+	load(t, store, map[string]int64{"AAPL": 110})
+	b := NewBroker(store)
+	poll(t, b)
+	load(t, store, map[string]int64{"AAPL": 120, "AMZN": 130, "MSFT": 140})
+	poll(t, b)
+	first := b.history[0].ID
+
+	_, backlog, resumed := b.Subscribe(Filter{}, &first)
+	if got, want := describe(backlog), []string{"created:AMZN", "created:MSFT"}; !resumed || !slices.Equal(got, want) {
+		t.Errorf("Subscribe(%d) = %v, %v, want %v resumed", first, got, resumed, want)
+	}
+	_, backlog, resumed = b.Subscribe(Filter{TickerLike: "ms"}, &first)
+	if got, want := describe(backlog), []string{"created:MSFT"}; !resumed || !slices.Equal(got, want) {
+		t.Errorf("Subscribe(%d) with a filter = %v, %v, want %v resumed", first, got, resumed, want)
+	}
+	last := b.lastID
+	if _, backlog, resumed = b.Subscribe(Filter{}, &last); !resumed || len(backlog) != 0 {
+		t.Errorf("Subscribe(%d) up to date = %v, %v, want nothing missed", last, describe(backlog), resumed)
+	}
+}
+
+func TestBrokerResetsUnknownEventIDs(t *testing.T) {
+	store := memstore.New()
+	load(t, store, map[string]int64{"AAPL": 110})
+	b := NewBroker(store)
+	poll(t, b)
+	load(t, store, map[string]int64{"AAPL": 120})
+	poll(t, b)
+
+	// Dropped from the history, or from a newer process after a restart
+	for _, lastID := range []uint64{b.history[0].ID - 2, b.lastID + 1} {
+		sub, backlog, resumed := b.Subscribe(Filter{}, &lastID)
+		if resumed || len(backlog) != 0 || sub == nil {
+			t.Errorf("Subscribe(%d) = %v, %v, want a subscription asked to reload the list", lastID, describe(backlog), resumed)
+		}
+	}
+}
+
+func TestBrokerDropsSlowSubscribers(t *testing.T) {
+	store := memstore.New()
+	load(t, store, map[string]int64{"AAPL": 110})
+	b := NewBroker(store)
+	poll(t, b)
+	slow, _, _ := b.Subscribe(Filter{}, nil)
+	other, _, _ := b.Subscribe(Filter{TickerLike: "MSFT"}, nil)
+
+	snapshot := b.snapshot["AAPL"]
+	b.mu.Lock()
+	for range subscriberBuffer + 1 {
+		b.publish(EventChanged, snapshot)
+	}
+	b.mu.Unlock()
+
+	got := received(slow)
+	if len(got) != subscriberBuffer+1 || got[len(got)-1] != "closed" {
+		t.Errorf("slow subscriber got %d events, want %d then the channel closed", len(got), subscriberBuffer)
+	}
+	if _, ok := b.subscribers[other]; !ok || len(b.subscribers) != 1 {
+		t.Errorf("subscribers = %v, want only the one not matching the events left", b.subscribers)
+	}
+	// Resuming from the last event it got, it misses nothing
+	lastID := b.lastID - 1
+	if _, backlog, resumed := b.Subscribe(Filter{}, &lastID); !resumed || len(backlog) != 1 {
+		t.Errorf("Subscribe(%d) after being dropped = %d events, %v, want the last one", lastID, len(backlog), resumed)
+	}
+}
+
+func TestBrokerClosesTheSubscriptionsWhenStopped(t *testing.T) {
+	store := memstore.New()
+	load(t, store, map[string]int64{"AAPL": 110})
+	b := NewBroker(store)
+	sub, _, _ := b.Subscribe(Filter{}, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		b.Run(ctx)
+	}()
+	cancel()
+	<-done
+
+	if got := received(sub); !slices.Equal(got, []string{"closed"}) {
+		t.Errorf("subscription after Run returned = %v, want closed", got)
+	}
+	if !b.Closed() {
+		t.Errorf("Closed() = false, want true")
+	}
+	// Subscribing while shutting down ends at once
+	late, backlog, _ := b.Subscribe(Filter{}, nil)
+	if got := received(late); !slices.Equal(got, []string{"closed"}) || len(backlog) != 0 {
+		t.Errorf("Subscribe() after Run returned = %v, %v, want closed", got, describe(backlog))
+	}
+	if len(b.subscribers) != 0 {
+		t.Errorf("subscribers = %v, want none", b.subscribers)
+	}
+}
@@ -0,0 +1,70 @@
+package stream
+
+import (
+	"backend/internal/features/stockratings"
+	stockratingsv1 "backend/internal/gen/stockratings/v1"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// GRPC ============================================================================================
+// The events of the SSE and WebSocket endpoints as a server stream
+
+type GRPCServer struct {
+	stockratingsv1.UnimplementedStockRatingStreamServiceServer
+	broker *Broker
+}
+
+func NewGRPCServer(b *Broker) *GRPCServer {
+	return &GRPCServer{broker: b}
+}
+
+var eventTypesProto = map[string]stockratingsv1.StockRatingEvent_Type{
+	EventCreated: stockratingsv1.StockRatingEvent_TYPE_CREATED,
+	EventChanged: stockratingsv1.StockRatingEvent_TYPE_CHANGED,
+	EventRemoved: stockratingsv1.StockRatingEvent_TYPE_REMOVED,
+}
+
+func toEventProto(e Event) *stockratingsv1.StockRatingEvent {
+	return &stockratingsv1.StockRatingEvent{
+		Id:     e.ID,
+		Type:   eventTypesProto[e.Type],
+		Rating: stockratings.NewStockRatingProto(e.Rating),
+	}
+}
+
+func (s *GRPCServer) WatchStockRatings(req *stockratingsv1.WatchStockRatingsRequest, srv grpc.ServerStreamingServer[stockratingsv1.StockRatingEvent]) error {
+	filter := Filter{TickerLike: req.TickerLike, CompanyLike: req.CompanyLike}
+	sub, backlog, resumed := s.broker.Subscribe(filter, req.LastEventId)
+	defer s.broker.Unsubscribe(sub)
+
+	if !resumed {
+		if err := srv.Send(&stockratingsv1.StockRatingEvent{Type: stockratingsv1.StockRatingEvent_TYPE_RESET}); err != nil {
+			return err
+		}
+	}
+	for _, event := range backlog {
+		if err := srv.Send(toEventProto(event)); err != nil {
+			return err
+		}
+	}
+
+	for {
+		select {
+		case <-srv.Context().Done():
+			return nil
+		case event, ok := <-sub.Events:
+			if !ok {
+				if s.broker.Closed() {
+					return status.Error(codes.Unavailable, "Shutting down, resume from the last event id")
+				}
+				return status.Error(codes.ResourceExhausted, "Too slow, resume from the last event id")
+			}
+			if err := srv.Send(toEventProto(event)); err != nil {
+				return err
+			}
+		}
+	}
+}
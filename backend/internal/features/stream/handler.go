@@ -0,0 +1,194 @@
+package stream
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+// Comment or ping sent to idle clients, so proxies don't close the connection
+const heartbeatInterval = 15 * time.Second
+
+type HandlerInterface interface {
+	StreamSSE(c *gin.Context)
+	StreamWebSocket(c *gin.Context)
+}
+type Handler struct {
+	broker   *Broker
+	upgrader websocket.Upgrader
+}
+
+func NewHandler(b *Broker) *Handler {
+	return &Handler{
+		broker: b,
+		// The API allows every origin, as the CORS configuration of the app
+		upgrader: websocket.Upgrader{CheckOrigin: func(*http.Request) bool { return true }},
+	}
+}
+
+// Sent instead of the missed events when they are no longer kept, the client must reload the list
+type ResetMessage struct {
+	Type string `json:"type"`
+}
+
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+// Read the filters and the ID to resume from, the Last-Event-ID header set by EventSource or the
+// last_event_id parameter
+func parseSubscription(c *gin.Context) (Filter, *uint64, bool) {
+	filter := Filter{
+		TickerLike:  c.Query("ticker_like"),
+		CompanyLike: c.Query("company_like"),
+	}
+	raw := c.GetHeader("Last-Event-ID")
+	if raw == "" {
+		raw = c.Query("last_event_id")
+	}
+	if raw == "" {
+		return filter, nil, true
+	}
+	lastID, err := strconv.ParseUint(raw, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid last event id"})
+		return Filter{}, nil, false
+	}
+	return filter, &lastID, true
+}
+
+// SSE ---------------------------------------------------------------------------------------------
+
+func (h *Handler) StreamSSE(c *gin.Context) {
+	filter, lastID, ok := parseSubscription(c)
+	if !ok {
+		return
+	}
+	sub, backlog, resumed := h.broker.Subscribe(filter, lastID)
+	defer h.broker.Unsubscribe(sub)
+
+	header := c.Writer.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	header.Set("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	if !resumed {
+		writeSSE(c, "", "reset", ResetMessage{Type: "reset"})
+	}
+	for _, event := range backlog {
+		writeSSE(c, strconv.FormatUint(event.ID, 10), event.Type, event)
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case event, ok := <-sub.Events:
+			// Dropped for being too slow or ended by the shutdown, the client reconnects with its
+			// last ID
+			if !ok {
+				return
+			}
+			writeSSE(c, strconv.FormatUint(event.ID, 10), event.Type, event)
+		case <-heartbeat.C:
+			fmt.Fprint(c.Writer, ": ping\n\n")
+		}
+		c.Writer.Flush()
+	}
+}
+
+func writeSSE(c *gin.Context, id string, eventType string, data any) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		log.Println("Error serializing stream event: ", err)
+		return
+	}
+	if id != "" {
+		fmt.Fprintf(c.Writer, "id: %s\n", id)
+	}
+	fmt.Fprintf(c.Writer, "event: %s\ndata: %s\n\n", eventType, payload)
+}
+
+// WebSocket ---------------------------------------------------------------------------------------
+// Same events as JSON text messages, the type inside the message. The connection is read only
+// to notice when the client leaves.
+
+func (h *Handler) StreamWebSocket(c *gin.Context) {
+	filter, lastID, ok := parseSubscription(c)
+	if !ok {
+		return
+	}
+	conn, err := h.upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// The upgrader already replied
+		log.Println("Error upgrading stream to WebSocket: ", err)
+		return
+	}
+	defer conn.Close()
+
+	sub, backlog, resumed := h.broker.Subscribe(filter, lastID)
+	defer h.broker.Unsubscribe(sub)
+
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	if !resumed {
+		if err := conn.WriteJSON(ResetMessage{Type: "reset"}); err != nil {
+			return
+		}
+	}
+	for _, event := range backlog {
+		if err := conn.WriteJSON(event); err != nil {
+			return
+		}
+	}
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-closed:
+			return
+		case event, ok := <-sub.Events:
+			if !ok {
+				message := websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "too slow, resume from the last event id")
+				if h.broker.Closed() {
+					message = websocket.FormatCloseMessage(websocket.CloseGoingAway, "shutting down, resume from the last event id")
+				}
+				conn.WriteControl(websocket.CloseMessage, message, time.Now().Add(time.Second))
+				return
+			}
+			if err := conn.WriteJSON(event); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(time.Second)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func AddStreamRoutes(rg *gin.RouterGroup, h HandlerInterface) {
+	stream := rg.Group("/stream")
+	stream.GET("", h.StreamSSE)
+	stream.GET("/ws", h.StreamWebSocket)
+}
@@ -0,0 +1,178 @@
+package webhooks
+
+import (
+	"backend/internal/repository"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// DISPATCHER ======================================================================================
+// Sends the queued deliveries. Each replica of the app runs one, the deliveries are leased when
+// claimed so a delivery isn't sent by two of them at once. Failed deliveries are retried with an
+// exponential backoff until maxAttempts, then left failed until replayed.
+//
+// A claimed batch is sent concurrently and cut at sendDeadline, well within the lease: the outcome
+// of an attempt recorded after the lease ran out is dropped, the delivery may be claimed again.
+
+const (
+	pollInterval = 5 * time.Second
+	claimBatch   = 50
+	// INTERVAL of ClaimWebhookDeliveries
+	claimLease      = time.Minute
+	sendConcurrency = 25
+	sendTimeout     = 10 * time.Second
+	// The whole batch takes at most claimBatch / sendConcurrency * sendTimeout = 20s
+	sendDeadline = claimLease / 2
+	maxAttempts  = 8
+	firstBackoff = 10 * time.Second
+	maxBackoff   = time.Hour
+)
+
+type Dispatcher struct {
+	repo   repository.Repository
+	client *http.Client
+}
+
+func NewDispatcher(r repository.Repository) *Dispatcher {
+	return &Dispatcher{
+		repo:   r,
+		client: &http.Client{Timeout: sendTimeout},
+	}
+}
+
+// Deliver until the context is cancelled
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		// Keep claiming while full batches come back
+		for {
+			sent, err := d.dispatch(ctx)
+			if err != nil {
+				log.Println("Error dispatching webhooks: ", err)
+				break
+			}
+			if sent < claimBatch {
+				break
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (d *Dispatcher) dispatch(ctx context.Context) (int, error) {
+	deliveries, err := d.repo.ClaimWebhookDeliveries(ctx, claimBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	sendCtx, cancel := context.WithTimeout(ctx, sendDeadline)
+	defer cancel()
+	errs := make([]error, len(deliveries))
+	slots := make(chan struct{}, sendConcurrency)
+	var wg sync.WaitGroup
+	for i, delivery := range deliveries {
+		slots <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() { <-slots; wg.Done() }()
+			start := time.Now()
+			status, err := Send(sendCtx, d.client, delivery.Url, delivery.Secret, delivery.ID, delivery.EventType, delivery.Payload)
+			errs[i] = d.record(ctx, delivery, status, err, time.Since(start))
+		}()
+	}
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		return 0, err
+	}
+	return len(deliveries), nil
+}
+
+// Log the attempt and schedule the next one when it failed
+func (d *Dispatcher) record(ctx context.Context, delivery repository.ClaimWebhookDeliveriesRow, status int, sendErr error, duration time.Duration) error {
+	responseStatus := pgtype.Int4{Int32: int32(status), Valid: status != 0}
+	var lastError pgtype.Text
+	if sendErr != nil {
+		lastError = pgtype.Text{String: sendErr.Error(), Valid: true}
+	}
+	err := d.repo.AddWebhookDeliveryAttempt(ctx, repository.AddWebhookDeliveryAttemptParams{
+		DeliveryID:     delivery.ID,
+		Attempt:        delivery.Attempts,
+		ResponseStatus: responseStatus,
+		Error:          lastError,
+		DurationMs:     int32(duration.Milliseconds()),
+	})
+	if err != nil {
+		return err
+	}
+
+	params := repository.FinishWebhookDeliveryAttemptParams{
+		ID:             delivery.ID,
+		Status:         repository.WebhookDeliveryStatusSucceeded,
+		NextAttemptAt:  time.Now(),
+		ResponseStatus: responseStatus,
+		LastError:      lastError,
+		Attempts:       delivery.Attempts,
+	}
+	if sendErr != nil {
+		params.Status = repository.WebhookDeliveryStatusPending
+		params.NextAttemptAt = time.Now().Add(backoff(int(delivery.Attempts)))
+		if delivery.Attempts >= maxAttempts {
+			params.Status = repository.WebhookDeliveryStatusFailed
+		}
+	}
+	finished, err := d.repo.FinishWebhookDeliveryAttempt(ctx, params)
+	if err != nil {
+		return err
+	}
+	if finished == 0 {
+		log.Printf("Lease of webhook delivery %s lost at attempt %d, its outcome is dropped", delivery.ID, delivery.Attempts)
+	}
+	return nil
+}
+
+// Wait before the attempt following the given one: 10s, 20s, 40s... up to an hour
+func backoff(attempt int) time.Duration {
+	wait := firstBackoff
+	for i := 1; i < attempt && wait < maxBackoff; i++ {
+		wait *= 2
+	}
+	return min(wait, maxBackoff)
+}
+
+// Post a signed payload, any status but 2xx is an error. The status is 0 when no response came.
+func Send(ctx context.Context, client *http.Client, url string, secret string, deliveryID uuid.UUID, eventType string, payload []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(SignatureHeader, Sign(secret, time.Now(), payload))
+	req.Header.Set(EventHeader, eventType)
+	req.Header.Set(DeliveryHeader, deliveryID.String())
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("status code: %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
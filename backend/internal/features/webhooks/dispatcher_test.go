@@ -0,0 +1,162 @@
+package webhooks
+
+import (
+	"backend/internal/repository"
+	"backend/internal/repository/memstore"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestSendSignsTheDelivery(t *testing.T) {
+	secret := "whsec_test"
+	payload := []byte(`{"type":"rating.down","ticker":"TSLA"}`)
+	deliveryID := uuid.New()
+
+	received := make(chan error, 1)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Header.Get(EventHeader) != "rating.down" || r.Header.Get(DeliveryHeader) != deliveryID.String() {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+		received <- Verify(secret, r.Header.Get(SignatureHeader), body, time.Minute)
+	}))
+	defer receiver.Close()
+
+	status, err := Send(context.Background(), receiver.Client(), receiver.URL, secret, deliveryID, "rating.down", payload)
+	if err != nil || status != http.StatusOK {
+		t.Fatalf("Send() = %d, %v, want 200", status, err)
+	}
+	if err := <-received; err != nil {
+		t.Fatalf("receiver rejected the signature: %v", err)
+	}
+}
+
+func TestSendFailsOnErrorStatus(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer receiver.Close()
+
+	status, err := Send(context.Background(), receiver.Client(), receiver.URL, "whsec_test", uuid.New(), "rating.up", []byte(`{}`))
+	if err == nil || status != http.StatusServiceUnavailable {
+		t.Fatalf("Send() = %d, %v, want 503 and an error", status, err)
+	}
+
+	receiver.Close()
+	status, err = Send(context.Background(), receiver.Client(), receiver.URL, "whsec_test", uuid.New(), "rating.up", []byte(`{}`))
+	if err == nil || status != 0 {
+		t.Fatalf("Send() to a closed receiver = %d, %v, want 0 and an error", status, err)
+	}
+}
+
+func TestVerifyRejectsTampering(t *testing.T) {
+	body := []byte(`{"ticker":"AAPL"}`)
+	header := Sign("whsec_test", time.Now(), body)
+
+	if err := Verify("whsec_test", header, body, time.Minute); err != nil {
+		t.Fatalf("valid signature rejected: %v", err)
+	}
+	if err := Verify("whsec_other", header, body, time.Minute); err == nil {
+		t.Fatal("signature with another secret accepted")
+	}
+	if err := Verify("whsec_test", header, []byte(`{"ticker":"TSLA"}`), time.Minute); err == nil {
+		t.Fatal("signature of another body accepted")
+	}
+	old := Sign("whsec_test", time.Now().Add(-time.Hour), body)
+	if err := Verify("whsec_test", old, body, time.Minute); err == nil {
+		t.Fatal("old signature accepted")
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	cases := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, 10 * time.Second},
+		{2, 20 * time.Second},
+		{4, 80 * time.Second},
+		{20, time.Hour},
+	}
+	for _, tc := range cases {
+		if got := backoff(tc.attempt); got != tc.want {
+			t.Errorf("backoff(%d) = %v, want %v", tc.attempt, got, tc.want)
+		}
+	}
+}
+
+func queueDeliveries(t *testing.T, store *memstore.Store, url string, n int) repository.Webhook {
+	t.Helper()
+	ctx := context.Background()
+	webhook, err := store.CreateWebhook(ctx, repository.CreateWebhookParams{Url: url, Secret: "whsec_test", EventTypes: []string{}})
+	if err != nil {
+		t.Fatalf("CreateWebhook() error = %v", err)
+	}
+	for i := range n {
+		if _, err := store.AddWebhookDelivery(ctx, repository.AddWebhookDeliveryParams{
+			WebhookID: webhook.ID, EventType: "rating.up", EventKey: fmt.Sprint(i), Payload: []byte(`{}`),
+		}); err != nil {
+			t.Fatalf("AddWebhookDelivery() error = %v", err)
+		}
+	}
+	return webhook
+}
+
+func TestDispatchSendsConcurrently(t *testing.T) {
+	// The receiver answers once every delivery of the batch arrived, sent one by one they would
+	// time out
+	const n = 5
+	var arrived sync.WaitGroup
+	arrived.Add(n)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		arrived.Done()
+		arrived.Wait()
+	}))
+	defer receiver.Close()
+	store := memstore.New()
+	webhook := queueDeliveries(t, store, receiver.URL, n)
+
+	d := NewDispatcher(store)
+	d.client.Timeout = 5 * time.Second
+	if sent, err := d.dispatch(context.Background()); err != nil || sent != n {
+		t.Fatalf("dispatch() = %d, %v, want %d", sent, err, n)
+	}
+	succeeded := repository.NullWebhookDeliveryStatus{WebhookDeliveryStatus: repository.WebhookDeliveryStatusSucceeded, Valid: true}
+	deliveries, err := store.ListWebhookDeliveries(context.Background(), repository.ListWebhookDeliveriesParams{WebhookID: webhook.ID, Status: succeeded, Limit: 10})
+	if err != nil || len(deliveries) != n {
+		t.Errorf("ListWebhookDeliveries(succeeded) = %d deliveries, %v, want %d", len(deliveries), err, n)
+	}
+}
+
+func TestRecordDropsTheOutcomeOfALostLease(t *testing.T) {
+	ctx := context.Background()
+	store := memstore.New()
+	webhook := queueDeliveries(t, store, "http://127.0.0.1:0", 1)
+	claimed, err := store.ClaimWebhookDeliveries(ctx, claimBatch)
+	if err != nil || len(claimed) != 1 {
+		t.Fatalf("ClaimWebhookDeliveries() = %+v, %v, want the delivery", claimed, err)
+	}
+
+	// The lease ran out and another dispatcher claimed the delivery and is sending it
+	store.SetClock(func() time.Time { return time.Now().Add(claimLease) })
+	if again, err := store.ClaimWebhookDeliveries(ctx, claimBatch); err != nil || len(again) != 1 {
+		t.Fatalf("ClaimWebhookDeliveries() after the lease = %+v, %v, want the delivery", again, err)
+	}
+	d := NewDispatcher(store)
+	if err := d.record(ctx, claimed[0], http.StatusOK, nil, time.Second); err != nil {
+		t.Fatalf("record() error = %v", err)
+	}
+
+	deliveries, err := store.ListWebhookDeliveries(ctx, repository.ListWebhookDeliveriesParams{WebhookID: webhook.ID, Limit: 10})
+	if err != nil || len(deliveries) != 1 || deliveries[0].Status != repository.WebhookDeliveryStatusPending || deliveries[0].Attempts != 2 {
+		t.Errorf("ListWebhookDeliveries() = %+v, %v, want the delivery still pending at its second attempt", deliveries, err)
+	}
+}
@@ -0,0 +1,130 @@
+package webhooks
+
+import (
+	"backend/internal/features/stockratings"
+	"backend/internal/repository"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ENQUEUER ========================================================================================
+// Load hook queuing a delivery per subscribed webhook for every rating that wasn't in the table
+// before the load. The dispatcher of the app sends them.
+
+// Event types, one per analyst action
+var EventTypes = []string{"rating.up", "rating.down", "rating.reiterated"}
+
+// Body of the deliveries
+type Event struct {
+	Type       string      `json:"type"`
+	Ticker     string      `json:"ticker"`
+	Company    string      `json:"company"`
+	Brokerage  string      `json:"brokerage"`
+	Action     string      `json:"action"`
+	RatingFrom string      `json:"rating_from"`
+	RatingTo   string      `json:"rating_to"`
+	TargetFrom json.Number `json:"target_from"`
+	TargetTo   json.Number `json:"target_to"`
+	Score      int32       `json:"score"`
+	At         time.Time   `json:"at"`
+}
+
+type Enqueuer struct {
+	repo     repository.Repository
+	webhooks []repository.Webhook
+	known    map[string]time.Time
+}
+
+func NewEnqueuer(r repository.Repository) *Enqueuer {
+	return &Enqueuer{repo: r}
+}
+
+func (e *Enqueuer) BeforeLoad(ctx context.Context, _ uuid.UUID) error {
+	webhooks, err := e.repo.ListEnabledWebhooks(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read the webhooks: %w", err)
+	}
+	e.webhooks = webhooks
+
+	e.known = map[string]time.Time{}
+	if len(webhooks) == 0 {
+		return nil
+	}
+	times, err := e.repo.GetStockRatingTimes(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read the current ratings: %w", err)
+	}
+	for _, t := range times {
+		e.known[t.Ticker] = t.At
+	}
+	return nil
+}
+
+func (e *Enqueuer) OnBatch(ctx context.Context, _ uuid.UUID, batch []repository.AddStockRatingsParams) error {
+	var queued int64
+	for _, r := range batch {
+		if at, ok := e.known[r.Ticker]; ok && at.Equal(r.At) {
+			continue
+		}
+		event, err := newEvent(r)
+		if err != nil {
+			return err
+		}
+		payload, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+
+		// The key makes the queue idempotent, a rating reloaded later isn't delivered twice
+		key := fmt.Sprintf("%s@%s", r.Ticker, r.At.UTC().Format(time.RFC3339Nano))
+		for _, webhook := range e.webhooks {
+			if len(webhook.EventTypes) > 0 && !slices.Contains(webhook.EventTypes, event.Type) {
+				continue
+			}
+			inserted, err := e.repo.AddWebhookDelivery(ctx, repository.AddWebhookDeliveryParams{
+				WebhookID: webhook.ID,
+				EventType: event.Type,
+				EventKey:  key,
+				Payload:   payload,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to queue webhook delivery: %w", err)
+			}
+			queued += inserted
+		}
+	}
+	if queued > 0 {
+		log.Println("Webhook deliveries queued: ", queued)
+	}
+	return nil
+}
+
+func newEvent(r repository.AddStockRatingsParams) (Event, error) {
+	targetFrom, err := r.TargetFrom.Float64Value()
+	if err != nil {
+		return Event{}, err
+	}
+	targetTo, err := r.TargetTo.Float64Value()
+	if err != nil {
+		return Event{}, err
+	}
+	return Event{
+		Type:       "rating." + string(r.Action),
+		Ticker:     r.Ticker,
+		Company:    r.Company,
+		Brokerage:  r.Brokerage,
+		Action:     string(r.Action),
+		RatingFrom: string(r.RatingFrom),
+		RatingTo:   string(r.RatingTo),
+		TargetFrom: json.Number(fmt.Sprintf("%.2f", targetFrom.Float64)),
+		TargetTo:   json.Number(fmt.Sprintf("%.2f", targetTo.Float64)),
+		Score:      stockratings.Score(r.TargetFrom, r.TargetTo, r.RatingTo, r.Action),
+		At:         r.At.UTC(),
+	}, nil
+}
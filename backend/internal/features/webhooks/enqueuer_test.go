@@ -0,0 +1,136 @@
+package webhooks
+
+import (
+	"backend/internal/repository"
+	"backend/internal/repository/memstore"
+	"backend/internal/repository/repositorytest"
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Rating of the ticker with the given action
+func rating(ticker string, action repository.StockActionType, at time.Time) repository.AddStockRatingsParams {
+	r := repositorytest.NewStockRating(ticker, 150, 180, at)
+	r.Action = action
+	return r
+}
+
+func createWebhook(t *testing.T, store *memstore.Store, eventTypes ...string) repository.Webhook {
+	t.Helper()
+	webhook, err := store.CreateWebhook(context.Background(), repository.CreateWebhookParams{
+		Url: "https://example.com/hook", Secret: "whsec_test", EventTypes: append([]string{}, eventTypes...),
+	})
+	if err != nil {
+		t.Fatalf("CreateWebhook() error = %v", err)
+	}
+	return webhook
+}
+
+// Event keys of the deliveries queued for the webhook
+func queued(t *testing.T, store *memstore.Store, webhook repository.Webhook) []string {
+	t.Helper()
+	deliveries, err := store.ListWebhookDeliveries(context.Background(), repository.ListWebhookDeliveriesParams{WebhookID: webhook.ID, Limit: 100})
+	if err != nil {
+		t.Fatalf("ListWebhookDeliveries() error = %v", err)
+	}
+	var keys []string
+	for _, d := range deliveries {
+		keys = append(keys, d.EventKey)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// Run a load of the batches through the enqueuer
+func enqueue(t *testing.T, store *memstore.Store, batches ...[]repository.AddStockRatingsParams) {
+	t.Helper()
+	ctx := context.Background()
+	e := NewEnqueuer(store)
+	runID := uuid.New()
+	if err := e.BeforeLoad(ctx, runID); err != nil {
+		t.Fatalf("BeforeLoad() error = %v", err)
+	}
+	for _, batch := range batches {
+		if err := e.OnBatch(ctx, runID, batch); err != nil {
+			t.Fatalf("OnBatch() error = %v", err)
+		}
+	}
+}
+
+func TestEnqueuerFiltersTheEventTypes(t *testing.T) {
+	store := memstore.New()
+	all := createWebhook(t, store)
+	ups := createWebhook(t, store, "rating.up")
+	downs := createWebhook(t, store, "rating.down", "rating.reiterated")
+
+	enqueue(t, store, []repository.AddStockRatingsParams{
+		rating("AAPL", repository.StockActionTypeUp, repositorytest.RatedAt),
+		rating("MSFT", repository.StockActionTypeDown, repositorytest.RatedAt),
+	})
+
+	cases := []struct {
+		name    string
+		webhook repository.Webhook
+		want    []string
+	}{
+		{"every event", all, []string{"AAPL@2025-01-02T03:04:05Z", "MSFT@2025-01-02T03:04:05Z"}},
+		{"rating.up", ups, []string{"AAPL@2025-01-02T03:04:05Z"}},
+		{"rating.down and rating.reiterated", downs, []string{"MSFT@2025-01-02T03:04:05Z"}},
+	}
+	for _, tc := range cases {
+		if got := queued(t, store, tc.webhook); !slices.Equal(got, tc.want) {
+			t.Errorf("deliveries of the webhook subscribed to %s = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestEnqueuerQueuesARatingOnce(t *testing.T) {
+	store := memstore.New()
+	webhook := createWebhook(t, store)
+	batch := []repository.AddStockRatingsParams{rating("AAPL", repository.StockActionTypeUp, repositorytest.RatedAt)}
+
+	// Sent again in the same load, then in the next one before the table has it
+	enqueue(t, store, batch, batch)
+	enqueue(t, store, batch)
+
+	if got, want := queued(t, store, webhook), []string{"AAPL@2025-01-02T03:04:05Z"}; !slices.Equal(got, want) {
+		t.Errorf("deliveries = %v, want %v", got, want)
+	}
+}
+
+func TestEnqueuerSkipsTheRatingsKnownBeforeTheLoad(t *testing.T) {
+	store := memstore.New()
+	webhook := createWebhook(t, store)
+	if _, err := store.AddStockRatings(context.Background(), []repository.AddStockRatingsParams{
+		rating("AAPL", repository.StockActionTypeUp, repositorytest.RatedAt),
+		rating("MSFT", repository.StockActionTypeUp, repositorytest.RatedAt),
+	}); err != nil {
+		t.Fatalf("AddStockRatings() error = %v", err)
+	}
+
+	// AAPL unchanged, MSFT rated again and TSLA new
+	later := repositorytest.RatedAt.Add(time.Hour)
+	enqueue(t, store, []repository.AddStockRatingsParams{
+		rating("AAPL", repository.StockActionTypeUp, repositorytest.RatedAt),
+		rating("MSFT", repository.StockActionTypeDown, later),
+		rating("TSLA", repository.StockActionTypeUp, repositorytest.RatedAt),
+	})
+
+	want := []string{"MSFT@2025-01-02T04:04:05Z", "TSLA@2025-01-02T03:04:05Z"}
+	if got := queued(t, store, webhook); !slices.Equal(got, want) {
+		t.Errorf("deliveries = %v, want %v", got, want)
+	}
+}
+
+func TestEnqueuerWithoutWebhooks(t *testing.T) {
+	store := memstore.New()
+	enqueue(t, store, []repository.AddStockRatingsParams{rating("AAPL", repository.StockActionTypeUp, repositorytest.RatedAt)})
+	webhook := createWebhook(t, store)
+	if got := queued(t, store, webhook); len(got) != 0 {
+		t.Errorf("deliveries = %v, want none for a webhook created after the load", got)
+	}
+}
@@ -0,0 +1,233 @@
+package webhooks
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Deliveries returned at most per request
+const maxLimit = 1000
+
+type HandlerInterface interface {
+	CreateWebhook(c *gin.Context)
+	ListWebhooks(c *gin.Context)
+	DeleteWebhook(c *gin.Context)
+	ListDeliveries(c *gin.Context)
+	ReplayDelivery(c *gin.Context)
+}
+type Handler struct {
+	service ServiceInterface
+}
+
+func NewHandler(s ServiceInterface) *Handler {
+	return &Handler{service: s}
+}
+
+type WebhookResponse struct {
+	ID         string    `json:"id"`
+	URL        string    `json:"url"`
+	EventTypes []string  `json:"event_types"`
+	Enabled    bool      `json:"enabled"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// The secret is only shown once, when the webhook is created
+type CreatedWebhookResponse struct {
+	ID         string    `json:"id"`
+	URL        string    `json:"url"`
+	EventTypes []string  `json:"event_types"`
+	Enabled    bool      `json:"enabled"`
+	CreatedAt  time.Time `json:"created_at"`
+	Secret     string    `json:"secret"`
+}
+
+type WebhookListResponse struct {
+	Length   int               `json:"length"`
+	Webhooks []WebhookResponse `json:"webhooks"`
+}
+
+// Event types left out subscribe to every event
+type CreateWebhookRequest struct {
+	URL        string   `json:"url"`
+	EventTypes []string `json:"event_types"`
+}
+
+type DeliveryResponse struct {
+	ID             string    `json:"id"`
+	EventType      string    `json:"event_type"`
+	EventKey       string    `json:"event_key"`
+	Event          Event     `json:"event"`
+	Status         string    `json:"status"`
+	Attempts       int32     `json:"attempts"`
+	NextAttemptAt  time.Time `json:"next_attempt_at"`
+	ResponseStatus *int32    `json:"response_status"`
+	LastError      *string   `json:"last_error"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+type DeliveryListResponse struct {
+	Length     int                `json:"length"`
+	Deliveries []DeliveryResponse `json:"deliveries"`
+}
+
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+func (h *Handler) CreateWebhook(c *gin.Context) {
+	var req CreateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid body"})
+		return
+	}
+
+	w, err := h.service.CreateWebhook(c.Request.Context(), CreateWebhookInput{url: req.URL, eventTypes: req.EventTypes})
+	if errors.Is(err, WebhooksErrorInvalidWebhookError) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, CreatedWebhookResponse{
+		ID:         w.id.String(),
+		URL:        w.url,
+		EventTypes: w.eventTypes,
+		Enabled:    w.enabled,
+		CreatedAt:  w.createdAt.UTC(),
+		Secret:     w.secret,
+	})
+}
+
+func (h *Handler) ListWebhooks(c *gin.Context) {
+	webhooks, err := h.service.ListWebhooks(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	resp := make([]WebhookResponse, len(webhooks))
+	for i, w := range webhooks {
+		eventTypes := w.eventTypes
+		if eventTypes == nil {
+			eventTypes = []string{}
+		}
+		resp[i] = WebhookResponse{
+			ID:         w.id.String(),
+			URL:        w.url,
+			EventTypes: eventTypes,
+			Enabled:    w.enabled,
+			CreatedAt:  w.createdAt.UTC(),
+		}
+	}
+	c.JSON(http.StatusOK, WebhookListResponse{Length: len(resp), Webhooks: resp})
+}
+
+func (h *Handler) DeleteWebhook(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid id"})
+		return
+	}
+	err = h.service.DeleteWebhook(c.Request.Context(), id)
+	if errors.Is(err, WebhooksErrorNotFound) {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (h *Handler) ListDeliveries(c *gin.Context) {
+	// Validate parameters
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid id"})
+		return
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 || offset > math.MaxInt32 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid offset"})
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit < 1 || limit > maxLimit {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid limit, use 1 to 1000"})
+		return
+	}
+
+	// Call the service
+	deliveries, err := h.service.ListDeliveries(c.Request.Context(), ListDeliveriesInput{
+		webhookID: id,
+		status:    c.Query("status"),
+		offset:    int32(offset),
+		limit:     int32(limit),
+	})
+	if errors.Is(err, WebhooksErrorInvalidWebhookError) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	// Serialize the output
+	resp := make([]DeliveryResponse, len(deliveries))
+	for i, d := range deliveries {
+		resp[i] = DeliveryResponse{
+			ID:             d.id.String(),
+			EventType:      d.eventType,
+			EventKey:       d.eventKey,
+			Event:          d.event,
+			Status:         d.status,
+			Attempts:       d.attempts,
+			NextAttemptAt:  d.nextAttemptAt.UTC(),
+			ResponseStatus: d.responseStatus,
+			LastError:      d.lastError,
+			CreatedAt:      d.createdAt.UTC(),
+		}
+	}
+	c.JSON(http.StatusOK, DeliveryListResponse{Length: len(resp), Deliveries: resp})
+}
+
+func (h *Handler) ReplayDelivery(c *gin.Context) {
+	webhookID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid id"})
+		return
+	}
+	deliveryID, err := uuid.Parse(c.Param("delivery_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid delivery id"})
+		return
+	}
+	err = h.service.ReplayDelivery(c.Request.Context(), webhookID, deliveryID)
+	if errors.Is(err, WebhooksErrorNotFound) {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	c.Status(http.StatusAccepted)
+}
+
+func AddWebhookRoutes(rg *gin.RouterGroup, h HandlerInterface) {
+	webhooks := rg.Group("/webhooks")
+	webhooks.GET("/", h.ListWebhooks)
+	webhooks.POST("/", h.CreateWebhook)
+	webhooks.DELETE("/:id", h.DeleteWebhook)
+	webhooks.GET("/:id/deliveries/", h.ListDeliveries)
+	webhooks.POST("/:id/deliveries/:delivery_id/replay", h.ReplayDelivery)
+}
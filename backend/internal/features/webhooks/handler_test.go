@@ -0,0 +1,101 @@
+package webhooks
+
+import (
+	"backend/internal/repository"
+	"backend/internal/repository/memstore"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func serve(t *testing.T, store *memstore.Store, method string, target string) *httptest.ResponseRecorder {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	AddWebhookRoutes(router.Group("/v1"), NewHandler(NewService(store)))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(method, target, nil))
+	return w
+}
+
+func TestHandlerReplayDelivery(t *testing.T) {
+	ctx := context.Background()
+	store := memstore.New()
+	webhook := queueDeliveries(t, store, "http://127.0.0.1:0", 1)
+	claimed, err := store.ClaimWebhookDeliveries(ctx, claimBatch)
+	if err != nil || len(claimed) != 1 {
+		t.Fatalf("ClaimWebhookDeliveries() = %+v, %v, want the delivery", claimed, err)
+	}
+	// Its first attempt failed, the next one is backed off
+	if err := NewDispatcher(store).record(ctx, claimed[0], http.StatusGone, errors.New("410 Gone"), time.Second); err != nil {
+		t.Fatalf("record() error = %v", err)
+	}
+	before, err := store.ListWebhookDeliveries(ctx, repository.ListWebhookDeliveriesParams{WebhookID: webhook.ID, Limit: 10})
+	if err != nil || len(before) != 1 || before[0].Attempts != 1 || !before[0].NextAttemptAt.After(time.Now()) {
+		t.Fatalf("ListWebhookDeliveries() = %+v, %v, want the delivery backed off after an attempt", before, err)
+	}
+
+	target := "/v1/webhooks/" + webhook.ID.String() + "/deliveries/" + claimed[0].ID.String() + "/replay"
+	if w := serve(t, store, http.MethodPost, target); w.Code != http.StatusAccepted {
+		t.Fatalf("POST %s = %d %s, want 202", target, w.Code, w.Body)
+	}
+	deliveries, err := store.ListWebhookDeliveries(ctx, repository.ListWebhookDeliveriesParams{WebhookID: webhook.ID, Limit: 10})
+	if err != nil || len(deliveries) != 1 || deliveries[0].Status != repository.WebhookDeliveryStatusPending || deliveries[0].Attempts != 0 || deliveries[0].NextAttemptAt.After(time.Now()) {
+		t.Errorf("ListWebhookDeliveries() = %+v, %v, want the delivery due again without attempts", deliveries, err)
+	}
+}
+
+func TestHandlerReplayDeliveryErrors(t *testing.T) {
+	store := memstore.New()
+	webhook := queueDeliveries(t, store, "http://127.0.0.1:0", 1)
+	other := queueDeliveries(t, store, "http://127.0.0.1:0", 1)
+	deliveries, err := store.ListWebhookDeliveries(context.Background(), repository.ListWebhookDeliveriesParams{WebhookID: other.ID, Limit: 10})
+	if err != nil || len(deliveries) != 1 {
+		t.Fatalf("ListWebhookDeliveries() = %+v, %v, want the delivery of the other webhook", deliveries, err)
+	}
+
+	cases := []struct {
+		name   string
+		target string
+		want   int
+	}{
+		{"invalid id", "/v1/webhooks/nope/deliveries/" + uuid.NewString() + "/replay", http.StatusBadRequest},
+		{"invalid delivery id", "/v1/webhooks/" + webhook.ID.String() + "/deliveries/nope/replay", http.StatusBadRequest},
+		{"unknown delivery", "/v1/webhooks/" + webhook.ID.String() + "/deliveries/" + uuid.NewString() + "/replay", http.StatusNotFound},
+		{"delivery of another webhook", "/v1/webhooks/" + webhook.ID.String() + "/deliveries/" + deliveries[0].ID.String() + "/replay", http.StatusNotFound},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := serve(t, store, http.MethodPost, tc.target)
+			var resp ErrorResponse
+			if w.Code != tc.want || json.Unmarshal(w.Body.Bytes(), &resp) != nil || resp.Error == "" {
+				t.Errorf("POST %s = %d %s, want %d with an error", tc.target, w.Code, w.Body, tc.want)
+			}
+		})
+	}
+}
+
+func TestHandlerListDeliveriesErrors(t *testing.T) {
+	store := memstore.New()
+	webhook := queueDeliveries(t, store, "http://127.0.0.1:0", 1)
+	deliveries := "/v1/webhooks/" + webhook.ID.String() + "/deliveries/"
+
+	for _, query := range []string{"limit=0", "limit=-1", "limit=1001", "limit=ten", "offset=-1", "offset=4294967296", "status=lost"} {
+		t.Run(query, func(t *testing.T) {
+			w := serve(t, store, http.MethodGet, deliveries+"?"+query)
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("GET %s?%s = %d %s, want 400", deliveries, query, w.Code, w.Body)
+			}
+		})
+	}
+	if w := serve(t, store, http.MethodGet, deliveries+"?limit=1000"); w.Code != http.StatusOK {
+		t.Errorf("GET %s?limit=1000 = %d %s, want 200", deliveries, w.Code, w.Body)
+	}
+}
@@ -0,0 +1,270 @@
+package webhooks
+
+import (
+	"backend/internal/repository"
+	"backend/pkg/tracing"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// SERVICE =========================================================================================
+
+type ServiceInterface interface {
+	CreateWebhook(ctx context.Context, input CreateWebhookInput) (webhook, error)
+	ListWebhooks(ctx context.Context) ([]webhook, error)
+	DeleteWebhook(ctx context.Context, id uuid.UUID) error
+	ListDeliveries(ctx context.Context, input ListDeliveriesInput) ([]delivery, error)
+	ReplayDelivery(ctx context.Context, webhookID uuid.UUID, deliveryID uuid.UUID) error
+}
+type Service struct {
+	repo repository.Repository
+}
+
+func NewService(r repository.Repository) *Service {
+	return &Service{
+		repo: r,
+	}
+}
+
+// Errors ------------------------------------------------------------------------------------------
+type WebhooksErrorKind int
+
+const (
+	_ WebhooksErrorKind = iota
+	webhooksUnexpectedError
+	webhooksInvalidWebhookError
+	webhooksNotFoundError
+)
+
+type WebhooksError struct {
+	kind WebhooksErrorKind
+	err  error
+}
+
+func (e WebhooksError) Error() string {
+	switch e.kind {
+	case webhooksUnexpectedError:
+		return fmt.Sprintf("Unexpected error: %s", e.err.Error())
+	case webhooksInvalidWebhookError:
+		return fmt.Sprintf("Invalid webhook: %s", e.err.Error())
+	case webhooksNotFoundError:
+		return "Not found"
+	default:
+		return "Unknown error"
+	}
+}
+
+func (e WebhooksError) From(err error) WebhooksError {
+	e1 := e
+	e1.err = err
+	return e1
+}
+func (e WebhooksError) Unwrap() error {
+	return e.err
+}
+
+// Errors of the same kind match with errors.Is, whatever their cause
+func (e WebhooksError) Is(target error) bool {
+	t, ok := target.(WebhooksError)
+	return ok && t.kind == e.kind
+}
+
+var (
+	WebhooksErrorUnexpectedError     = WebhooksError{kind: webhooksUnexpectedError}
+	WebhooksErrorInvalidWebhookError = WebhooksError{kind: webhooksInvalidWebhookError}
+	WebhooksErrorNotFound            = WebhooksError{kind: webhooksNotFoundError}
+)
+
+// Webhooks ----------------------------------------------------------------------------------------
+type webhook = struct {
+	id         uuid.UUID
+	url        string
+	secret     string
+	eventTypes []string
+	enabled    bool
+	createdAt  time.Time
+}
+
+type CreateWebhookInput struct {
+	url        string
+	eventTypes []string
+}
+
+func toWebhook(w repository.Webhook) webhook {
+	return webhook{
+		id:         w.ID,
+		url:        w.Url,
+		secret:     w.Secret,
+		eventTypes: w.EventTypes,
+		enabled:    w.Enabled,
+		createdAt:  w.CreatedAt,
+	}
+}
+
+// Register a URL, the secret signing its deliveries is generated here
+func (s *Service) CreateWebhook(ctx context.Context, input CreateWebhookInput) (webhook, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "Service.CreateWebhook")
+	defer span.End()
+
+	// Validate the subscription
+	target, err := url.Parse(input.url)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return webhook{}, WebhooksErrorInvalidWebhookError.From(fmt.Errorf("url must be an absolute http(s) URL"))
+	}
+	eventTypes := []string{}
+	for _, eventType := range input.eventTypes {
+		if !slices.Contains(EventTypes, eventType) {
+			return webhook{}, WebhooksErrorInvalidWebhookError.From(fmt.Errorf("unknown event type: %s", eventType))
+		}
+		eventTypes = append(eventTypes, eventType)
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return webhook{}, WebhooksErrorUnexpectedError.From(err)
+	}
+	res, err := s.repo.CreateWebhook(ctx, repository.CreateWebhookParams{
+		Url:        target.String(),
+		Secret:     "whsec_" + hex.EncodeToString(secret),
+		EventTypes: eventTypes,
+	})
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return webhook{}, WebhooksErrorUnexpectedError.From(err)
+	}
+	return toWebhook(res), nil
+}
+
+func (s *Service) ListWebhooks(ctx context.Context) ([]webhook, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "Service.ListWebhooks")
+	defer span.End()
+
+	res, err := s.repo.ListWebhooks(ctx)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return nil, WebhooksErrorUnexpectedError.From(err)
+	}
+	var out []webhook
+	for _, w := range res {
+		out = append(out, toWebhook(w))
+	}
+	return out, nil
+}
+
+func (s *Service) DeleteWebhook(ctx context.Context, id uuid.UUID) error {
+	ctx, span := tracing.Tracer().Start(ctx, "Service.DeleteWebhook")
+	defer span.End()
+
+	deleted, err := s.repo.DeleteWebhook(ctx, id)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return WebhooksErrorUnexpectedError.From(err)
+	}
+	if deleted == 0 {
+		return WebhooksErrorNotFound
+	}
+	return nil
+}
+
+// Deliveries --------------------------------------------------------------------------------------
+type ListDeliveriesInput struct {
+	webhookID uuid.UUID
+	status    string
+	offset    int32
+	limit     int32
+}
+
+type delivery = struct {
+	id             uuid.UUID
+	eventType      string
+	eventKey       string
+	event          Event
+	status         string
+	attempts       int32
+	nextAttemptAt  time.Time
+	responseStatus *int32
+	lastError      *string
+	createdAt      time.Time
+}
+
+// Delivery log of a webhook, newest first
+func (s *Service) ListDeliveries(ctx context.Context, input ListDeliveriesInput) ([]delivery, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "Service.ListDeliveries")
+	defer span.End()
+
+	params := repository.ListWebhookDeliveriesParams{
+		WebhookID: input.webhookID,
+		Offset:    input.offset,
+		Limit:     input.limit,
+	}
+	if input.status != "" {
+		status := repository.WebhookDeliveryStatus(input.status)
+		if !slices.Contains(deliveryStatuses, status) {
+			return nil, WebhooksErrorInvalidWebhookError.From(fmt.Errorf("unknown status: %s", input.status))
+		}
+		params.Status = repository.NullWebhookDeliveryStatus{WebhookDeliveryStatus: status, Valid: true}
+	}
+	res, err := s.repo.ListWebhookDeliveries(ctx, params)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return nil, WebhooksErrorUnexpectedError.From(err)
+	}
+
+	var out []delivery
+	for _, d := range res {
+		item := delivery{
+			id:            d.ID,
+			eventType:     d.EventType,
+			eventKey:      d.EventKey,
+			status:        string(d.Status),
+			attempts:      d.Attempts,
+			nextAttemptAt: d.NextAttemptAt,
+			createdAt:     d.CreatedAt,
+		}
+		if err := json.Unmarshal(d.Payload, &item.event); err != nil {
+			return nil, WebhooksErrorUnexpectedError.From(err)
+		}
+		if d.ResponseStatus.Valid {
+			item.responseStatus = &d.ResponseStatus.Int32
+		}
+		if d.LastError.Valid {
+			item.lastError = &d.LastError.String
+		}
+		out = append(out, item)
+	}
+	return out, nil
+}
+
+var deliveryStatuses = []repository.WebhookDeliveryStatus{
+	repository.WebhookDeliveryStatusPending,
+	repository.WebhookDeliveryStatusSucceeded,
+	repository.WebhookDeliveryStatusFailed,
+}
+
+// Queue a delivery again, whatever its status, with a fresh count of attempts
+func (s *Service) ReplayDelivery(ctx context.Context, webhookID uuid.UUID, deliveryID uuid.UUID) error {
+	ctx, span := tracing.Tracer().Start(ctx, "Service.ReplayDelivery")
+	defer span.End()
+
+	replayed, err := s.repo.ReplayWebhookDelivery(ctx, repository.ReplayWebhookDeliveryParams{
+		ID:        deliveryID,
+		WebhookID: webhookID,
+	})
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return WebhooksErrorUnexpectedError.From(err)
+	}
+	if replayed == 0 {
+		return WebhooksErrorNotFound
+	}
+	return nil
+}
@@ -0,0 +1,826 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.9
+// 	protoc        (unknown)
+// source: stockratings/v1/stock_ratings.proto
+
+package stockratingsv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Action int32
+
+const (
+	Action_ACTION_UNSPECIFIED Action = 0
+	Action_ACTION_UP          Action = 1
+	Action_ACTION_DOWN        Action = 2
+	Action_ACTION_REITERATED  Action = 3
+)
+
+// Enum value maps for Action.
+var (
+	Action_name = map[int32]string{
+		0: "ACTION_UNSPECIFIED",
+		1: "ACTION_UP",
+		2: "ACTION_DOWN",
+		3: "ACTION_REITERATED",
+	}
+	Action_value = map[string]int32{
+		"ACTION_UNSPECIFIED": 0,
+		"ACTION_UP":          1,
+		"ACTION_DOWN":        2,
+		"ACTION_REITERATED":  3,
+	}
+)
+
+func (x Action) Enum() *Action {
+	p := new(Action)
+	*p = x
+	return p
+}
+
+func (x Action) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Action) Descriptor() protoreflect.EnumDescriptor {
+	return file_stockratings_v1_stock_ratings_proto_enumTypes[0].Descriptor()
+}
+
+func (Action) Type() protoreflect.EnumType {
+	return &file_stockratings_v1_stock_ratings_proto_enumTypes[0]
+}
+
+func (x Action) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Action.Descriptor instead.
+func (Action) EnumDescriptor() ([]byte, []int) {
+	return file_stockratings_v1_stock_ratings_proto_rawDescGZIP(), []int{0}
+}
+
+type Rating int32
+
+const (
+	Rating_RATING_UNSPECIFIED Rating = 0
+	Rating_RATING_BUY         Rating = 1
+	Rating_RATING_HOLD        Rating = 2
+	Rating_RATING_SELL        Rating = 3
+	Rating_RATING_PENDING     Rating = 4
+)
+
+// Enum value maps for Rating.
+var (
+	Rating_name = map[int32]string{
+		0: "RATING_UNSPECIFIED",
+		1: "RATING_BUY",
+		2: "RATING_HOLD",
+		3: "RATING_SELL",
+		4: "RATING_PENDING",
+	}
+	Rating_value = map[string]int32{
+		"RATING_UNSPECIFIED": 0,
+		"RATING_BUY":         1,
+		"RATING_HOLD":        2,
+		"RATING_SELL":        3,
+		"RATING_PENDING":     4,
+	}
+)
+
+func (x Rating) Enum() *Rating {
+	p := new(Rating)
+	*p = x
+	return p
+}
+
+func (x Rating) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Rating) Descriptor() protoreflect.EnumDescriptor {
+	return file_stockratings_v1_stock_ratings_proto_enumTypes[1].Descriptor()
+}
+
+func (Rating) Type() protoreflect.EnumType {
+	return &file_stockratings_v1_stock_ratings_proto_enumTypes[1]
+}
+
+func (x Rating) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Rating.Descriptor instead.
+func (Rating) EnumDescriptor() ([]byte, []int) {
+	return file_stockratings_v1_stock_ratings_proto_rawDescGZIP(), []int{1}
+}
+
+type StockRatingEvent_Type int32
+
+const (
+	StockRatingEvent_TYPE_UNSPECIFIED StockRatingEvent_Type = 0
+	StockRatingEvent_TYPE_CREATED     StockRatingEvent_Type = 1
+	StockRatingEvent_TYPE_CHANGED     StockRatingEvent_Type = 2
+	StockRatingEvent_TYPE_REMOVED     StockRatingEvent_Type = 3
+	// The missed events are no longer kept, the list must be reloaded
+	StockRatingEvent_TYPE_RESET StockRatingEvent_Type = 4
+)
+
+// Enum value maps for StockRatingEvent_Type.
+var (
+	StockRatingEvent_Type_name = map[int32]string{
+		0: "TYPE_UNSPECIFIED",
+		1: "TYPE_CREATED",
+		2: "TYPE_CHANGED",
+		3: "TYPE_REMOVED",
+		4: "TYPE_RESET",
+	}
+	StockRatingEvent_Type_value = map[string]int32{
+		"TYPE_UNSPECIFIED": 0,
+		"TYPE_CREATED":     1,
+		"TYPE_CHANGED":     2,
+		"TYPE_REMOVED":     3,
+		"TYPE_RESET":       4,
+	}
+)
+
+func (x StockRatingEvent_Type) Enum() *StockRatingEvent_Type {
+	p := new(StockRatingEvent_Type)
+	*p = x
+	return p
+}
+
+func (x StockRatingEvent_Type) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (StockRatingEvent_Type) Descriptor() protoreflect.EnumDescriptor {
+	return file_stockratings_v1_stock_ratings_proto_enumTypes[2].Descriptor()
+}
+
+func (StockRatingEvent_Type) Type() protoreflect.EnumType {
+	return &file_stockratings_v1_stock_ratings_proto_enumTypes[2]
+}
+
+func (x StockRatingEvent_Type) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use StockRatingEvent_Type.Descriptor instead.
+func (StockRatingEvent_Type) EnumDescriptor() ([]byte, []int) {
+	return file_stockratings_v1_stock_ratings_proto_rawDescGZIP(), []int{6, 0}
+}
+
+type StockRating struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Ticker    string                 `protobuf:"bytes,1,opt,name=ticker,proto3" json:"ticker,omitempty"`
+	Company   string                 `protobuf:"bytes,2,opt,name=company,proto3" json:"company,omitempty"`
+	Brokerage string                 `protobuf:"bytes,3,opt,name=brokerage,proto3" json:"brokerage,omitempty"`
+	// Targets with 2 decimals
+	TargetFrom     float64                `protobuf:"fixed64,4,opt,name=target_from,json=targetFrom,proto3" json:"target_from,omitempty"`
+	TargetTo       float64                `protobuf:"fixed64,5,opt,name=target_to,json=targetTo,proto3" json:"target_to,omitempty"`
+	TargetDelta    float64                `protobuf:"fixed64,6,opt,name=target_delta,json=targetDelta,proto3" json:"target_delta,omitempty"`
+	TargetDeltaPct float64                `protobuf:"fixed64,7,opt,name=target_delta_pct,json=targetDeltaPct,proto3" json:"target_delta_pct,omitempty"`
+	Action         Action                 `protobuf:"varint,8,opt,name=action,proto3,enum=stockratings.v1.Action" json:"action,omitempty"`
+	RatingFrom     Rating                 `protobuf:"varint,9,opt,name=rating_from,json=ratingFrom,proto3,enum=stockratings.v1.Rating" json:"rating_from,omitempty"`
+	RawRatingFrom  string                 `protobuf:"bytes,10,opt,name=raw_rating_from,json=rawRatingFrom,proto3" json:"raw_rating_from,omitempty"`
+	RatingTo       Rating                 `protobuf:"varint,11,opt,name=rating_to,json=ratingTo,proto3,enum=stockratings.v1.Rating" json:"rating_to,omitempty"`
+	RawRatingTo    string                 `protobuf:"bytes,12,opt,name=raw_rating_to,json=rawRatingTo,proto3" json:"raw_rating_to,omitempty"`
+	At             *timestamppb.Timestamp `protobuf:"bytes,13,opt,name=at,proto3" json:"at,omitempty"`
+	Score          int32                  `protobuf:"varint,14,opt,name=score,proto3" json:"score,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *StockRating) Reset() {
+	*x = StockRating{}
+	mi := &file_stockratings_v1_stock_ratings_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StockRating) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StockRating) ProtoMessage() {}
+
+func (x *StockRating) ProtoReflect() protoreflect.Message {
+	mi := &file_stockratings_v1_stock_ratings_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StockRating.ProtoReflect.Descriptor instead.
+func (*StockRating) Descriptor() ([]byte, []int) {
+	return file_stockratings_v1_stock_ratings_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *StockRating) GetTicker() string {
+	if x != nil {
+		return x.Ticker
+	}
+	return ""
+}
+
+func (x *StockRating) GetCompany() string {
+	if x != nil {
+		return x.Company
+	}
+	return ""
+}
+
+func (x *StockRating) GetBrokerage() string {
+	if x != nil {
+		return x.Brokerage
+	}
+	return ""
+}
+
+func (x *StockRating) GetTargetFrom() float64 {
+	if x != nil {
+		return x.TargetFrom
+	}
+	return 0
+}
+
+func (x *StockRating) GetTargetTo() float64 {
+	if x != nil {
+		return x.TargetTo
+	}
+	return 0
+}
+
+func (x *StockRating) GetTargetDelta() float64 {
+	if x != nil {
+		return x.TargetDelta
+	}
+	return 0
+}
+
+func (x *StockRating) GetTargetDeltaPct() float64 {
+	if x != nil {
+		return x.TargetDeltaPct
+	}
+	return 0
+}
+
+func (x *StockRating) GetAction() Action {
+	if x != nil {
+		return x.Action
+	}
+	return Action_ACTION_UNSPECIFIED
+}
+
+func (x *StockRating) GetRatingFrom() Rating {
+	if x != nil {
+		return x.RatingFrom
+	}
+	return Rating_RATING_UNSPECIFIED
+}
+
+func (x *StockRating) GetRawRatingFrom() string {
+	if x != nil {
+		return x.RawRatingFrom
+	}
+	return ""
+}
+
+func (x *StockRating) GetRatingTo() Rating {
+	if x != nil {
+		return x.RatingTo
+	}
+	return Rating_RATING_UNSPECIFIED
+}
+
+func (x *StockRating) GetRawRatingTo() string {
+	if x != nil {
+		return x.RawRatingTo
+	}
+	return ""
+}
+
+func (x *StockRating) GetAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.At
+	}
+	return nil
+}
+
+func (x *StockRating) GetScore() int32 {
+	if x != nil {
+		return x.Score
+	}
+	return 0
+}
+
+type ListStockRatingsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// score, target_from, target_to, target_delta, ticker, company, brokerage, action, rating_from
+	// or rating_to, score when empty
+	SortBy string `protobuf:"bytes,1,opt,name=sort_by,json=sortBy,proto3" json:"sort_by,omitempty"`
+	// asc or desc, desc when empty
+	SortOrder string `protobuf:"bytes,2,opt,name=sort_order,json=sortOrder,proto3" json:"sort_order,omitempty"`
+	Offset    int32  `protobuf:"varint,3,opt,name=offset,proto3" json:"offset,omitempty"`
+	// 10 when 0
+	Limit int32 `protobuf:"varint,4,opt,name=limit,proto3" json:"limit,omitempty"`
+	// Case insensitive substrings
+	TickerLike    string `protobuf:"bytes,5,opt,name=ticker_like,json=tickerLike,proto3" json:"ticker_like,omitempty"`
+	CompanyLike   string `protobuf:"bytes,6,opt,name=company_like,json=companyLike,proto3" json:"company_like,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListStockRatingsRequest) Reset() {
+	*x = ListStockRatingsRequest{}
+	mi := &file_stockratings_v1_stock_ratings_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListStockRatingsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListStockRatingsRequest) ProtoMessage() {}
+
+func (x *ListStockRatingsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_stockratings_v1_stock_ratings_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListStockRatingsRequest.ProtoReflect.Descriptor instead.
+func (*ListStockRatingsRequest) Descriptor() ([]byte, []int) {
+	return file_stockratings_v1_stock_ratings_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ListStockRatingsRequest) GetSortBy() string {
+	if x != nil {
+		return x.SortBy
+	}
+	return ""
+}
+
+func (x *ListStockRatingsRequest) GetSortOrder() string {
+	if x != nil {
+		return x.SortOrder
+	}
+	return ""
+}
+
+func (x *ListStockRatingsRequest) GetOffset() int32 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *ListStockRatingsRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *ListStockRatingsRequest) GetTickerLike() string {
+	if x != nil {
+		return x.TickerLike
+	}
+	return ""
+}
+
+func (x *ListStockRatingsRequest) GetCompanyLike() string {
+	if x != nil {
+		return x.CompanyLike
+	}
+	return ""
+}
+
+type ListStockRatingsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ratings       []*StockRating         `protobuf:"bytes,1,rep,name=ratings,proto3" json:"ratings,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListStockRatingsResponse) Reset() {
+	*x = ListStockRatingsResponse{}
+	mi := &file_stockratings_v1_stock_ratings_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListStockRatingsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListStockRatingsResponse) ProtoMessage() {}
+
+func (x *ListStockRatingsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_stockratings_v1_stock_ratings_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListStockRatingsResponse.ProtoReflect.Descriptor instead.
+func (*ListStockRatingsResponse) Descriptor() ([]byte, []int) {
+	return file_stockratings_v1_stock_ratings_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ListStockRatingsResponse) GetRatings() []*StockRating {
+	if x != nil {
+		return x.Ratings
+	}
+	return nil
+}
+
+type GetStockRatingRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ticker        string                 `protobuf:"bytes,1,opt,name=ticker,proto3" json:"ticker,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetStockRatingRequest) Reset() {
+	*x = GetStockRatingRequest{}
+	mi := &file_stockratings_v1_stock_ratings_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetStockRatingRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetStockRatingRequest) ProtoMessage() {}
+
+func (x *GetStockRatingRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_stockratings_v1_stock_ratings_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetStockRatingRequest.ProtoReflect.Descriptor instead.
+func (*GetStockRatingRequest) Descriptor() ([]byte, []int) {
+	return file_stockratings_v1_stock_ratings_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GetStockRatingRequest) GetTicker() string {
+	if x != nil {
+		return x.Ticker
+	}
+	return ""
+}
+
+type GetStockRatingResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Rating        *StockRating           `protobuf:"bytes,1,opt,name=rating,proto3" json:"rating,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetStockRatingResponse) Reset() {
+	*x = GetStockRatingResponse{}
+	mi := &file_stockratings_v1_stock_ratings_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetStockRatingResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetStockRatingResponse) ProtoMessage() {}
+
+func (x *GetStockRatingResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_stockratings_v1_stock_ratings_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetStockRatingResponse.ProtoReflect.Descriptor instead.
+func (*GetStockRatingResponse) Descriptor() ([]byte, []int) {
+	return file_stockratings_v1_stock_ratings_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *GetStockRatingResponse) GetRating() *StockRating {
+	if x != nil {
+		return x.Rating
+	}
+	return nil
+}
+
+type WatchStockRatingsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Case insensitive substrings
+	TickerLike  string `protobuf:"bytes,1,opt,name=ticker_like,json=tickerLike,proto3" json:"ticker_like,omitempty"`
+	CompanyLike string `protobuf:"bytes,2,opt,name=company_like,json=companyLike,proto3" json:"company_like,omitempty"`
+	// Resume after this event, with the events still kept
+	LastEventId   *uint64 `protobuf:"varint,3,opt,name=last_event_id,json=lastEventId,proto3,oneof" json:"last_event_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchStockRatingsRequest) Reset() {
+	*x = WatchStockRatingsRequest{}
+	mi := &file_stockratings_v1_stock_ratings_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchStockRatingsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchStockRatingsRequest) ProtoMessage() {}
+
+func (x *WatchStockRatingsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_stockratings_v1_stock_ratings_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchStockRatingsRequest.ProtoReflect.Descriptor instead.
+func (*WatchStockRatingsRequest) Descriptor() ([]byte, []int) {
+	return file_stockratings_v1_stock_ratings_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *WatchStockRatingsRequest) GetTickerLike() string {
+	if x != nil {
+		return x.TickerLike
+	}
+	return ""
+}
+
+func (x *WatchStockRatingsRequest) GetCompanyLike() string {
+	if x != nil {
+		return x.CompanyLike
+	}
+	return ""
+}
+
+func (x *WatchStockRatingsRequest) GetLastEventId() uint64 {
+	if x != nil && x.LastEventId != nil {
+		return *x.LastEventId
+	}
+	return 0
+}
+
+type StockRatingEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Type          StockRatingEvent_Type  `protobuf:"varint,2,opt,name=type,proto3,enum=stockratings.v1.StockRatingEvent_Type" json:"type,omitempty"`
+	Rating        *StockRating           `protobuf:"bytes,3,opt,name=rating,proto3" json:"rating,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StockRatingEvent) Reset() {
+	*x = StockRatingEvent{}
+	mi := &file_stockratings_v1_stock_ratings_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StockRatingEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StockRatingEvent) ProtoMessage() {}
+
+func (x *StockRatingEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_stockratings_v1_stock_ratings_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StockRatingEvent.ProtoReflect.Descriptor instead.
+func (*StockRatingEvent) Descriptor() ([]byte, []int) {
+	return file_stockratings_v1_stock_ratings_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *StockRatingEvent) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *StockRatingEvent) GetType() StockRatingEvent_Type {
+	if x != nil {
+		return x.Type
+	}
+	return StockRatingEvent_TYPE_UNSPECIFIED
+}
+
+func (x *StockRatingEvent) GetRating() *StockRating {
+	if x != nil {
+		return x.Rating
+	}
+	return nil
+}
+
+var File_stockratings_v1_stock_ratings_proto protoreflect.FileDescriptor
+
+const file_stockratings_v1_stock_ratings_proto_rawDesc = "" +
+	"\n" +
+	"#stockratings/v1/stock_ratings.proto\x12\x0fstockratings.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\x97\x04\n" +
+	"\vStockRating\x12\x16\n" +
+	"\x06ticker\x18\x01 \x01(\tR\x06ticker\x12\x18\n" +
+	"\acompany\x18\x02 \x01(\tR\acompany\x12\x1c\n" +
+	"\tbrokerage\x18\x03 \x01(\tR\tbrokerage\x12\x1f\n" +
+	"\vtarget_from\x18\x04 \x01(\x01R\n" +
+	"targetFrom\x12\x1b\n" +
+	"\ttarget_to\x18\x05 \x01(\x01R\btargetTo\x12!\n" +
+	"\ftarget_delta\x18\x06 \x01(\x01R\vtargetDelta\x12(\n" +
+	"\x10target_delta_pct\x18\a \x01(\x01R\x0etargetDeltaPct\x12/\n" +
+	"\x06action\x18\b \x01(\x0e2\x17.stockratings.v1.ActionR\x06action\x128\n" +
+	"\vrating_from\x18\t \x01(\x0e2\x17.stockratings.v1.RatingR\n" +
+	"ratingFrom\x12&\n" +
+	"\x0fraw_rating_from\x18\n" +
+	" \x01(\tR\rrawRatingFrom\x124\n" +
+	"\trating_to\x18\v \x01(\x0e2\x17.stockratings.v1.RatingR\bratingTo\x12\"\n" +
+	"\rraw_rating_to\x18\f \x01(\tR\vrawRatingTo\x12*\n" +
+	"\x02at\x18\r \x01(\v2\x1a.google.protobuf.TimestampR\x02at\x12\x14\n" +
+	"\x05score\x18\x0e \x01(\x05R\x05score\"\xc3\x01\n" +
+	"\x17ListStockRatingsRequest\x12\x17\n" +
+	"\asort_by\x18\x01 \x01(\tR\x06sortBy\x12\x1d\n" +
+	"\n" +
+	"sort_order\x18\x02 \x01(\tR\tsortOrder\x12\x16\n" +
+	"\x06offset\x18\x03 \x01(\x05R\x06offset\x12\x14\n" +
+	"\x05limit\x18\x04 \x01(\x05R\x05limit\x12\x1f\n" +
+	"\vticker_like\x18\x05 \x01(\tR\n" +
+	"tickerLike\x12!\n" +
+	"\fcompany_like\x18\x06 \x01(\tR\vcompanyLike\"R\n" +
+	"\x18ListStockRatingsResponse\x126\n" +
+	"\aratings\x18\x01 \x03(\v2\x1c.stockratings.v1.StockRatingR\aratings\"/\n" +
+	"\x15GetStockRatingRequest\x12\x16\n" +
+	"\x06ticker\x18\x01 \x01(\tR\x06ticker\"N\n" +
+	"\x16GetStockRatingResponse\x124\n" +
+	"\x06rating\x18\x01 \x01(\v2\x1c.stockratings.v1.StockRatingR\x06rating\"\x99\x01\n" +
+	"\x18WatchStockRatingsRequest\x12\x1f\n" +
+	"\vticker_like\x18\x01 \x01(\tR\n" +
+	"tickerLike\x12!\n" +
+	"\fcompany_like\x18\x02 \x01(\tR\vcompanyLike\x12'\n" +
+	"\rlast_event_id\x18\x03 \x01(\x04H\x00R\vlastEventId\x88\x01\x01B\x10\n" +
+	"\x0e_last_event_id\"\xf8\x01\n" +
+	"\x10StockRatingEvent\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x12:\n" +
+	"\x04type\x18\x02 \x01(\x0e2&.stockratings.v1.StockRatingEvent.TypeR\x04type\x124\n" +
+	"\x06rating\x18\x03 \x01(\v2\x1c.stockratings.v1.StockRatingR\x06rating\"b\n" +
+	"\x04Type\x12\x14\n" +
+	"\x10TYPE_UNSPECIFIED\x10\x00\x12\x10\n" +
+	"\fTYPE_CREATED\x10\x01\x12\x10\n" +
+	"\fTYPE_CHANGED\x10\x02\x12\x10\n" +
+	"\fTYPE_REMOVED\x10\x03\x12\x0e\n" +
+	"\n" +
+	"TYPE_RESET\x10\x04*W\n" +
+	"\x06Action\x12\x16\n" +
+	"\x12ACTION_UNSPECIFIED\x10\x00\x12\r\n" +
+	"\tACTION_UP\x10\x01\x12\x0f\n" +
+	"\vACTION_DOWN\x10\x02\x12\x15\n" +
+	"\x11ACTION_REITERATED\x10\x03*f\n" +
+	"\x06Rating\x12\x16\n" +
+	"\x12RATING_UNSPECIFIED\x10\x00\x12\x0e\n" +
+	"\n" +
+	"RATING_BUY\x10\x01\x12\x0f\n" +
+	"\vRATING_HOLD\x10\x02\x12\x0f\n" +
+	"\vRATING_SELL\x10\x03\x12\x12\n" +
+	"\x0eRATING_PENDING\x10\x042\xe0\x01\n" +
+	"\x12StockRatingService\x12g\n" +
+	"\x10ListStockRatings\x12(.stockratings.v1.ListStockRatingsRequest\x1a).stockratings.v1.ListStockRatingsResponse\x12a\n" +
+	"\x0eGetStockRating\x12&.stockratings.v1.GetStockRatingRequest\x1a'.stockratings.v1.GetStockRatingResponse2\x7f\n" +
+	"\x18StockRatingStreamService\x12c\n" +
+	"\x11WatchStockRatings\x12).stockratings.v1.WatchStockRatingsRequest\x1a!.stockratings.v1.StockRatingEvent0\x01B5Z3backend/internal/gen/stockratings/v1;stockratingsv1b\x06proto3"
+
+var (
+	file_stockratings_v1_stock_ratings_proto_rawDescOnce sync.Once
+	file_stockratings_v1_stock_ratings_proto_rawDescData []byte
+)
+
+func file_stockratings_v1_stock_ratings_proto_rawDescGZIP() []byte {
+	file_stockratings_v1_stock_ratings_proto_rawDescOnce.Do(func() {
+		file_stockratings_v1_stock_ratings_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_stockratings_v1_stock_ratings_proto_rawDesc), len(file_stockratings_v1_stock_ratings_proto_rawDesc)))
+	})
+	return file_stockratings_v1_stock_ratings_proto_rawDescData
+}
+
+var file_stockratings_v1_stock_ratings_proto_enumTypes = make([]protoimpl.EnumInfo, 3)
+var file_stockratings_v1_stock_ratings_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_stockratings_v1_stock_ratings_proto_goTypes = []any{
+	(Action)(0),                      // 0: stockratings.v1.Action
+	(Rating)(0),                      // 1: stockratings.v1.Rating
+	(StockRatingEvent_Type)(0),       // 2: stockratings.v1.StockRatingEvent.Type
+	(*StockRating)(nil),              // 3: stockratings.v1.StockRating
+	(*ListStockRatingsRequest)(nil),  // 4: stockratings.v1.ListStockRatingsRequest
+	(*ListStockRatingsResponse)(nil), // 5: stockratings.v1.ListStockRatingsResponse
+	(*GetStockRatingRequest)(nil),    // 6: stockratings.v1.GetStockRatingRequest
+	(*GetStockRatingResponse)(nil),   // 7: stockratings.v1.GetStockRatingResponse
+	(*WatchStockRatingsRequest)(nil), // 8: stockratings.v1.WatchStockRatingsRequest
+	(*StockRatingEvent)(nil),         // 9: stockratings.v1.StockRatingEvent
+	(*timestamppb.Timestamp)(nil),    // 10: google.protobuf.Timestamp
+}
+var file_stockratings_v1_stock_ratings_proto_depIdxs = []int32{
+	0,  // 0: stockratings.v1.StockRating.action:type_name -> stockratings.v1.Action
+	1,  // 1: stockratings.v1.StockRating.rating_from:type_name -> stockratings.v1.Rating
+	1,  // 2: stockratings.v1.StockRating.rating_to:type_name -> stockratings.v1.Rating
+	10, // 3: stockratings.v1.StockRating.at:type_name -> google.protobuf.Timestamp
+	3,  // 4: stockratings.v1.ListStockRatingsResponse.ratings:type_name -> stockratings.v1.StockRating
+	3,  // 5: stockratings.v1.GetStockRatingResponse.rating:type_name -> stockratings.v1.StockRating
+	2,  // 6: stockratings.v1.StockRatingEvent.type:type_name -> stockratings.v1.StockRatingEvent.Type
+	3,  // 7: stockratings.v1.StockRatingEvent.rating:type_name -> stockratings.v1.StockRating
+	4,  // 8: stockratings.v1.StockRatingService.ListStockRatings:input_type -> stockratings.v1.ListStockRatingsRequest
+	6,  // 9: stockratings.v1.StockRatingService.GetStockRating:input_type -> stockratings.v1.GetStockRatingRequest
+	8,  // 10: stockratings.v1.StockRatingStreamService.WatchStockRatings:input_type -> stockratings.v1.WatchStockRatingsRequest
+	5,  // 11: stockratings.v1.StockRatingService.ListStockRatings:output_type -> stockratings.v1.ListStockRatingsResponse
+	7,  // 12: stockratings.v1.StockRatingService.GetStockRating:output_type -> stockratings.v1.GetStockRatingResponse
+	9,  // 13: stockratings.v1.StockRatingStreamService.WatchStockRatings:output_type -> stockratings.v1.StockRatingEvent
+	11, // [11:14] is the sub-list for method output_type
+	8,  // [8:11] is the sub-list for method input_type
+	8,  // [8:8] is the sub-list for extension type_name
+	8,  // [8:8] is the sub-list for extension extendee
+	0,  // [0:8] is the sub-list for field type_name
+}
+
+func init() { file_stockratings_v1_stock_ratings_proto_init() }
+func file_stockratings_v1_stock_ratings_proto_init() {
+	if File_stockratings_v1_stock_ratings_proto != nil {
+		return
+	}
+	file_stockratings_v1_stock_ratings_proto_msgTypes[5].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_stockratings_v1_stock_ratings_proto_rawDesc), len(file_stockratings_v1_stock_ratings_proto_rawDesc)),
+			NumEnums:      3,
+			NumMessages:   7,
+			NumExtensions: 0,
+			NumServices:   2,
+		},
+		GoTypes:           file_stockratings_v1_stock_ratings_proto_goTypes,
+		DependencyIndexes: file_stockratings_v1_stock_ratings_proto_depIdxs,
+		EnumInfos:         file_stockratings_v1_stock_ratings_proto_enumTypes,
+		MessageInfos:      file_stockratings_v1_stock_ratings_proto_msgTypes,
+	}.Build()
+	File_stockratings_v1_stock_ratings_proto = out.File
+	file_stockratings_v1_stock_ratings_proto_goTypes = nil
+	file_stockratings_v1_stock_ratings_proto_depIdxs = nil
+}
@@ -0,0 +1,277 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: stockratings/v1/stock_ratings.proto
+
+package stockratingsv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	StockRatingService_ListStockRatings_FullMethodName = "/stockratings.v1.StockRatingService/ListStockRatings"
+	StockRatingService_GetStockRating_FullMethodName   = "/stockratings.v1.StockRatingService/GetStockRating"
+)
+
+// StockRatingServiceClient is the client API for StockRatingService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Ratings of the dataset, the same lists as the REST API
+type StockRatingServiceClient interface {
+	// Ratings with the filters, sort and page of GET /v2/stock_ratings/
+	ListStockRatings(ctx context.Context, in *ListStockRatingsRequest, opts ...grpc.CallOption) (*ListStockRatingsResponse, error)
+	// Rating of a ticker, NOT_FOUND when the ticker has none
+	GetStockRating(ctx context.Context, in *GetStockRatingRequest, opts ...grpc.CallOption) (*GetStockRatingResponse, error)
+}
+
+type stockRatingServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewStockRatingServiceClient(cc grpc.ClientConnInterface) StockRatingServiceClient {
+	return &stockRatingServiceClient{cc}
+}
+
+func (c *stockRatingServiceClient) ListStockRatings(ctx context.Context, in *ListStockRatingsRequest, opts ...grpc.CallOption) (*ListStockRatingsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListStockRatingsResponse)
+	err := c.cc.Invoke(ctx, StockRatingService_ListStockRatings_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *stockRatingServiceClient) GetStockRating(ctx context.Context, in *GetStockRatingRequest, opts ...grpc.CallOption) (*GetStockRatingResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetStockRatingResponse)
+	err := c.cc.Invoke(ctx, StockRatingService_GetStockRating_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// StockRatingServiceServer is the server API for StockRatingService service.
+// All implementations must embed UnimplementedStockRatingServiceServer
+// for forward compatibility.
+//
+// Ratings of the dataset, the same lists as the REST API
+type StockRatingServiceServer interface {
+	// Ratings with the filters, sort and page of GET /v2/stock_ratings/
+	ListStockRatings(context.Context, *ListStockRatingsRequest) (*ListStockRatingsResponse, error)
+	// Rating of a ticker, NOT_FOUND when the ticker has none
+	GetStockRating(context.Context, *GetStockRatingRequest) (*GetStockRatingResponse, error)
+	mustEmbedUnimplementedStockRatingServiceServer()
+}
+
+// UnimplementedStockRatingServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedStockRatingServiceServer struct{}
+
+func (UnimplementedStockRatingServiceServer) ListStockRatings(context.Context, *ListStockRatingsRequest) (*ListStockRatingsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListStockRatings not implemented")
+}
+func (UnimplementedStockRatingServiceServer) GetStockRating(context.Context, *GetStockRatingRequest) (*GetStockRatingResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetStockRating not implemented")
+}
+func (UnimplementedStockRatingServiceServer) mustEmbedUnimplementedStockRatingServiceServer() {}
+func (UnimplementedStockRatingServiceServer) testEmbeddedByValue()                            {}
+
+// UnsafeStockRatingServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to StockRatingServiceServer will
+// result in compilation errors.
+type UnsafeStockRatingServiceServer interface {
+	mustEmbedUnimplementedStockRatingServiceServer()
+}
+
+func RegisterStockRatingServiceServer(s grpc.ServiceRegistrar, srv StockRatingServiceServer) {
+	// If the following call pancis, it indicates UnimplementedStockRatingServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&StockRatingService_ServiceDesc, srv)
+}
+
+func _StockRatingService_ListStockRatings_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListStockRatingsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StockRatingServiceServer).ListStockRatings(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StockRatingService_ListStockRatings_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StockRatingServiceServer).ListStockRatings(ctx, req.(*ListStockRatingsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StockRatingService_GetStockRating_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetStockRatingRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StockRatingServiceServer).GetStockRating(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StockRatingService_GetStockRating_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StockRatingServiceServer).GetStockRating(ctx, req.(*GetStockRatingRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// StockRatingService_ServiceDesc is the grpc.ServiceDesc for StockRatingService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var StockRatingService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "stockratings.v1.StockRatingService",
+	HandlerType: (*StockRatingServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListStockRatings",
+			Handler:    _StockRatingService_ListStockRatings_Handler,
+		},
+		{
+			MethodName: "GetStockRating",
+			Handler:    _StockRatingService_GetStockRating_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "stockratings/v1/stock_ratings.proto",
+}
+
+const (
+	StockRatingStreamService_WatchStockRatings_FullMethodName = "/stockratings.v1.StockRatingStreamService/WatchStockRatings"
+)
+
+// StockRatingStreamServiceClient is the client API for StockRatingStreamService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Ratings created, changed or removed by the loads, as GET /v1/stream
+type StockRatingStreamServiceClient interface {
+	WatchStockRatings(ctx context.Context, in *WatchStockRatingsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[StockRatingEvent], error)
+}
+
+type stockRatingStreamServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewStockRatingStreamServiceClient(cc grpc.ClientConnInterface) StockRatingStreamServiceClient {
+	return &stockRatingStreamServiceClient{cc}
+}
+
+func (c *stockRatingStreamServiceClient) WatchStockRatings(ctx context.Context, in *WatchStockRatingsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[StockRatingEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &StockRatingStreamService_ServiceDesc.Streams[0], StockRatingStreamService_WatchStockRatings_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchStockRatingsRequest, StockRatingEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type StockRatingStreamService_WatchStockRatingsClient = grpc.ServerStreamingClient[StockRatingEvent]
+
+// StockRatingStreamServiceServer is the server API for StockRatingStreamService service.
+// All implementations must embed UnimplementedStockRatingStreamServiceServer
+// for forward compatibility.
+//
+// Ratings created, changed or removed by the loads, as GET /v1/stream
+type StockRatingStreamServiceServer interface {
+	WatchStockRatings(*WatchStockRatingsRequest, grpc.ServerStreamingServer[StockRatingEvent]) error
+	mustEmbedUnimplementedStockRatingStreamServiceServer()
+}
+
+// UnimplementedStockRatingStreamServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedStockRatingStreamServiceServer struct{}
+
+func (UnimplementedStockRatingStreamServiceServer) WatchStockRatings(*WatchStockRatingsRequest, grpc.ServerStreamingServer[StockRatingEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchStockRatings not implemented")
+}
+func (UnimplementedStockRatingStreamServiceServer) mustEmbedUnimplementedStockRatingStreamServiceServer() {
+}
+func (UnimplementedStockRatingStreamServiceServer) testEmbeddedByValue() {}
+
+// UnsafeStockRatingStreamServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to StockRatingStreamServiceServer will
+// result in compilation errors.
+type UnsafeStockRatingStreamServiceServer interface {
+	mustEmbedUnimplementedStockRatingStreamServiceServer()
+}
+
+func RegisterStockRatingStreamServiceServer(s grpc.ServiceRegistrar, srv StockRatingStreamServiceServer) {
+	// If the following call pancis, it indicates UnimplementedStockRatingStreamServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&StockRatingStreamService_ServiceDesc, srv)
+}
+
+func _StockRatingStreamService_WatchStockRatings_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchStockRatingsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(StockRatingStreamServiceServer).WatchStockRatings(m, &grpc.GenericServerStream[WatchStockRatingsRequest, StockRatingEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type StockRatingStreamService_WatchStockRatingsServer = grpc.ServerStreamingServer[StockRatingEvent]
+
+// StockRatingStreamService_ServiceDesc is the grpc.ServiceDesc for StockRatingStreamService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var StockRatingStreamService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "stockratings.v1.StockRatingStreamService",
+	HandlerType: (*StockRatingStreamServiceServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchStockRatings",
+			Handler:       _StockRatingStreamService_WatchStockRatings_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "stockratings/v1/stock_ratings.proto",
+}
@@ -0,0 +1,194 @@
+package middleware
+
+import (
+	"backend/internal/repository"
+	"bytes"
+	"context"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	lru "github.com/hashicorp/golang-lru/v2"
+)
+
+// CACHE ===========================================================================================
+// The data only changes when a load bumps the dataset version, so the responses of the read routes
+// are identified by that version and the query. Clients revalidate with If-None-Match or
+// If-Modified-Since and get a 304, while the rendered bodies are kept in an LRU until the version
+// changes.
+
+// How long the version read from the database is trusted before asking again
+const versionTTL = time.Second
+
+// Bodies larger than this are streamed without being kept, the exports would evict everything else
+const maxCachedBody = 1 << 20
+
+type cachedResponse struct {
+	version int64
+	// Headers set by the handler, e.g. the Content-Disposition of the exports
+	header http.Header
+	body   []byte
+}
+
+type Cache struct {
+	repo      repository.Repository
+	responses *lru.Cache[string, cachedResponse]
+
+	mu        sync.Mutex
+	version   repository.GetDatasetVersionRow
+	checkedAt time.Time
+}
+
+// Keep up to size rendered responses, only conditional requests are served when size is 0
+func NewCache(r repository.Repository, size int) (*Cache, error) {
+	c := &Cache{repo: r}
+	if size > 0 {
+		responses, err := lru.New[string, cachedResponse](size)
+		if err != nil {
+			return nil, err
+		}
+		c.responses = responses
+	}
+	return c, nil
+}
+
+// Current dataset version, the stored responses are dropped when it changes
+func (c *Cache) currentVersion(ctx context.Context) (repository.GetDatasetVersionRow, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if time.Since(c.checkedAt) < versionTTL {
+		return c.version, nil
+	}
+	version, err := c.repo.GetDatasetVersion(ctx)
+	if err != nil {
+		return repository.GetDatasetVersionRow{}, err
+	}
+	if version.Version != c.version.Version && c.responses != nil {
+		c.responses.Purge()
+	}
+	c.version = version
+	c.checkedAt = time.Now()
+	return version, nil
+}
+
+// Method, path and query with the parameters sorted, so equivalent requests share an entry. The
+// empty values are kept, the handlers don't always take them as missing.
+func cacheKey(r *http.Request) string {
+	return r.Method + " " + r.URL.Path + "?" + r.URL.Query().Encode()
+}
+
+func etag(version int64, key string) string {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	return fmt.Sprintf(`"%d-%x"`, version, h.Sum64())
+}
+
+// Whether the client already has the current representation
+func notModified(r *http.Request, tag string, modified time.Time) bool {
+	if match := r.Header.Get("If-None-Match"); match != "" {
+		for _, candidate := range strings.Split(match, ",") {
+			candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+			if candidate == tag || candidate == "*" {
+				return true
+			}
+		}
+		return false
+	}
+	if since, err := http.ParseTime(r.Header.Get("If-Modified-Since")); err == nil {
+		return !modified.Truncate(time.Second).After(since)
+	}
+	return false
+}
+
+func (c *Cache) Middleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if ctx.Request.Method != http.MethodGet {
+			ctx.Next()
+			return
+		}
+
+		// Without a version the response can't be identified, serve it uncached
+		version, err := c.currentVersion(ctx.Request.Context())
+		if err != nil {
+			log.Println("Error reading the dataset version: ", err)
+			ctx.Next()
+			return
+		}
+
+		key := cacheKey(ctx.Request)
+		tag := etag(version.Version, key)
+		header := ctx.Writer.Header()
+		header.Set("ETag", tag)
+		header.Set("Last-Modified", version.UpdatedAt.UTC().Format(http.TimeFormat))
+		header.Set("Cache-Control", "no-cache")
+		if notModified(ctx.Request, tag, version.UpdatedAt) {
+			ctx.AbortWithStatus(http.StatusNotModified)
+			return
+		}
+		if c.responses == nil {
+			ctx.Next()
+			return
+		}
+
+		// Serve the rendered body when it was built from the same version
+		if cached, ok := c.responses.Get(key); ok && cached.version == version.Version {
+			for name, values := range cached.header {
+				header[name] = values
+			}
+			header.Set("X-Cache", "HIT")
+			ctx.Data(http.StatusOK, header.Get("Content-Type"), cached.body)
+			ctx.Abort()
+			return
+		}
+		header.Set("X-Cache", "MISS")
+
+		// Render it, keeping a copy of successful responses. A handler failing after writing, e.g. an
+		// export cut mid-stream, still answers 200 but reports it in ctx.Errors or by aborting
+		writer := &teeWriter{ResponseWriter: ctx.Writer}
+		ctx.Writer = writer
+		ctx.Next()
+		if writer.Status() == http.StatusOK && !writer.overflow && len(ctx.Errors) == 0 && !ctx.IsAborted() {
+			stored := writer.Header().Clone()
+			stored.Del("X-Cache")
+			c.responses.Add(key, cachedResponse{
+				version: version.Version,
+				header:  stored,
+				body:    writer.body.Bytes(),
+			})
+		}
+	}
+}
+
+// Writes through to the client while keeping a copy of the body, up to maxCachedBody
+type teeWriter struct {
+	gin.ResponseWriter
+	body     bytes.Buffer
+	overflow bool
+}
+
+func (w *teeWriter) Write(data []byte) (int, error) {
+	w.keep(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *teeWriter) WriteString(s string) (int, error) {
+	w.keep([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *teeWriter) keep(data []byte) {
+	if w.overflow {
+		return
+	}
+	if w.body.Len()+len(data) > maxCachedBody {
+		w.overflow = true
+		w.body = bytes.Buffer{}
+		return
+	}
+	w.body.Write(data)
+}
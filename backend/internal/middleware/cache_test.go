@@ -0,0 +1,139 @@
+package middleware
+
+import (
+	"backend/internal/repository/memstore"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Router behind the cache counting the renders of its routes
+func newCachedRouter(t *testing.T, cache *Cache) (*gin.Engine, *int) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	renders := 0
+	router := gin.New()
+	router.Use(cache.Middleware())
+	router.GET("/export", func(c *gin.Context) {
+		renders++
+		c.Header("Content-Disposition", `attachment; filename="stock_ratings.csv"`)
+		c.Data(http.StatusOK, "text/csv", []byte("ticker\nAAPL\n"))
+	})
+	router.GET("/failing", func(c *gin.Context) {
+		renders++
+		c.Data(http.StatusOK, "text/csv", []byte("ticker\n"))
+		c.Error(errors.New("connection reset"))
+		c.Abort()
+	})
+	return router, &renders
+}
+
+func get(router *gin.Engine, target string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestCacheMissThenHit(t *testing.T) {
+	cache, err := NewCache(memstore.New(), 8)
+	if err != nil {
+		t.Fatalf("NewCache() error = %v", err)
+	}
+	router, renders := newCachedRouter(t, cache)
+
+	miss := get(router, "/export?sort_by=score&limit=10", nil)
+	hit := get(router, "/export?limit=10&sort_by=score", nil)
+	if miss.Header().Get("X-Cache") != "MISS" || hit.Header().Get("X-Cache") != "HIT" || *renders != 1 {
+		t.Fatalf("X-Cache = %q then %q after %d renders, want MISS then HIT after 1", miss.Header().Get("X-Cache"), hit.Header().Get("X-Cache"), *renders)
+	}
+	for _, name := range []string{"Content-Type", "Content-Disposition", "ETag", "Last-Modified"} {
+		if got, want := hit.Header().Get(name), miss.Header().Get(name); got != want {
+			t.Errorf("HIT %s = %q, want %q", name, got, want)
+		}
+	}
+	if hit.Body.String() != miss.Body.String() {
+		t.Errorf("HIT body = %q, want %q", hit.Body, miss.Body)
+	}
+}
+
+func TestCacheKeepsEmptyParameters(t *testing.T) {
+	cache, err := NewCache(memstore.New(), 8)
+	if err != nil {
+		t.Fatalf("NewCache() error = %v", err)
+	}
+	router, renders := newCachedRouter(t, cache)
+
+	get(router, "/export", nil)
+	for _, target := range []string{"/export?sort_by=", "/export?limit="} {
+		if w := get(router, target, nil); w.Header().Get("X-Cache") != "MISS" {
+			t.Errorf("GET %s X-Cache = %q, want MISS, the handler may reject it", target, w.Header().Get("X-Cache"))
+		}
+	}
+	if *renders != 3 {
+		t.Errorf("renders = %d, want 3", *renders)
+	}
+}
+
+func TestCacheNotModified(t *testing.T) {
+	cache, err := NewCache(memstore.New(), 8)
+	if err != nil {
+		t.Fatalf("NewCache() error = %v", err)
+	}
+	router, renders := newCachedRouter(t, cache)
+
+	tag := get(router, "/export", nil).Header().Get("ETag")
+	w := get(router, "/export", http.Header{"If-None-Match": {"W/" + tag}})
+	if w.Code != http.StatusNotModified || w.Body.Len() != 0 || *renders != 1 {
+		t.Errorf("revalidation = %d %q after %d renders, want 304 without rendering again", w.Code, w.Body, *renders)
+	}
+	w = get(router, "/export", http.Header{"If-None-Match": {`"0-0"`}})
+	if w.Code != http.StatusOK {
+		t.Errorf("revalidation of another tag = %d, want 200", w.Code)
+	}
+}
+
+func TestCacheDropsStaleVersions(t *testing.T) {
+	store := memstore.New()
+	cache, err := NewCache(store, 8)
+	if err != nil {
+		t.Fatalf("NewCache() error = %v", err)
+	}
+	router, renders := newCachedRouter(t, cache)
+
+	before := get(router, "/export", nil)
+	if _, err := store.BumpDatasetVersion(context.Background()); err != nil {
+		t.Fatalf("BumpDatasetVersion() error = %v", err)
+	}
+	// Skip the TTL of the version
+	cache.checkedAt = time.Time{}
+	after := get(router, "/export", http.Header{"If-None-Match": {before.Header().Get("ETag")}})
+	if after.Code != http.StatusOK || after.Header().Get("X-Cache") != "MISS" || *renders != 2 {
+		t.Errorf("after a load = %d X-Cache %q after %d renders, want a 200 MISS rendered again", after.Code, after.Header().Get("X-Cache"), *renders)
+	}
+	if after.Header().Get("ETag") == before.Header().Get("ETag") {
+		t.Errorf("ETag = %s after a load, want it to change", after.Header().Get("ETag"))
+	}
+}
+
+func TestCacheSkipsFailedResponses(t *testing.T) {
+	cache, err := NewCache(memstore.New(), 8)
+	if err != nil {
+		t.Fatalf("NewCache() error = %v", err)
+	}
+	router, renders := newCachedRouter(t, cache)
+
+	get(router, "/failing", nil)
+	w := get(router, "/failing", nil)
+	if w.Header().Get("X-Cache") != "MISS" || *renders != 2 {
+		t.Errorf("X-Cache = %q after %d renders, want the failed response rendered again", w.Header().Get("X-Cache"), *renders)
+	}
+}
@@ -0,0 +1,156 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RATE LIMIT ======================================================================================
+// Token buckets per client and route. Each bucket holds up to Burst tokens and refills at Rate
+// tokens per second, a request takes one token or is answered with a 429.
+
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+// Interval between two tokens
+func (l RateLimit) interval() time.Duration {
+	return time.Duration(float64(time.Second) / l.Rate)
+}
+
+// Time to refill an empty bucket
+func (l RateLimit) window() time.Duration {
+	return time.Duration(l.Burst) * l.interval()
+}
+
+// Key of the limit applied to the routes without one of their own
+const defaultRoute = "*"
+
+// Parse the limits per route, a comma separated list of route=rate:burst where the route is the
+// gin path and * the default, e.g. "*=10:20,/v1/stock_ratings/export=0.2:2". A rate of 0 disables
+// the limit of the route.
+func ParseRateLimits(config string) (map[string]RateLimit, error) {
+	limits := map[string]RateLimit{}
+	for _, entry := range strings.Split(config, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		route, value, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid rate limit %q, use route=rate:burst", entry)
+		}
+		rate, burst, ok := strings.Cut(value, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid rate limit %q, use route=rate:burst", entry)
+		}
+		var limit RateLimit
+		var err error
+		limit.Rate, err = strconv.ParseFloat(rate, 64)
+		if err != nil || limit.Rate < 0 {
+			return nil, fmt.Errorf("invalid rate in %q", entry)
+		}
+		limit.Burst, err = strconv.Atoi(burst)
+		if err != nil || limit.Burst < 1 {
+			return nil, fmt.Errorf("invalid burst in %q", entry)
+		}
+		limits[route] = limit
+	}
+	return limits, nil
+}
+
+// Parse the API keys given their own buckets, a comma separated list
+func ParseAPIKeys(config string) []string {
+	var keys []string
+	for _, key := range strings.Split(config, ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+type RateLimiter struct {
+	limits map[string]RateLimit
+	store  RateLimitStore
+	// Hashes of the known API keys
+	apiKeys map[string]bool
+}
+
+func NewRateLimiter(limits map[string]RateLimit, store RateLimitStore, apiKeys []string) *RateLimiter {
+	l := &RateLimiter{limits: limits, store: store, apiKeys: map[string]bool{}}
+	for _, key := range apiKeys {
+		l.apiKeys[hashAPIKey(key)] = true
+	}
+	return l
+}
+
+// The key is hashed so it isn't stored with the buckets
+func hashAPIKey(apiKey string) string {
+	sum := sha256.Sum256([]byte(apiKey))
+	return hex.EncodeToString(sum[:16])
+}
+
+// Clients are identified by their API key when it is a known one, by their IP otherwise. Any other
+// key is ignored, a client sending a new random key on each request would get a full bucket each
+// time.
+func (l *RateLimiter) clientKey(c *gin.Context) string {
+	if apiKey := c.GetHeader("X-API-Key"); apiKey != "" {
+		if hash := hashAPIKey(apiKey); l.apiKeys[hash] {
+			return "key:" + hash
+		}
+	}
+	return "ip:" + c.ClientIP()
+}
+
+func (l *RateLimiter) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := c.FullPath()
+		limit, ok := l.limits[route]
+		if !ok {
+			limit, ok = l.limits[defaultRoute]
+		}
+		if !ok || limit.Rate == 0 {
+			c.Next()
+			return
+		}
+
+		// The limiter fails open, an unavailable store must not take the API down
+		result, err := l.store.Take(c.Request.Context(), route+" "+l.clientKey(c), limit)
+		if err != nil {
+			log.Println("Error taking a rate limit token: ", err)
+			c.Next()
+			return
+		}
+
+		header := c.Writer.Header()
+		header.Set("X-RateLimit-Limit", strconv.Itoa(limit.Burst))
+		header.Set("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		header.Set("X-RateLimit-Reset", strconv.Itoa(seconds(result.Reset)))
+		if !result.Allowed {
+			header.Set("Retry-After", strconv.Itoa(seconds(result.RetryAfter)))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, ErrorResponse{Error: "Too many requests"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// Whole seconds, rounded up so a client waiting that long is never limited again
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,136 @@
+package middleware
+
+import (
+	"backend/internal/repository"
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// STORES ==========================================================================================
+// Both stores implement the buckets with GCRA: instead of counting tokens they keep the theoretical
+// arrival time (tat) of the next request. The bucket is full when tat is in the past, and a request
+// is allowed when moving tat by one interval keeps it within the window of the bucket.
+
+type RateLimitResult struct {
+	Allowed   bool
+	Remaining int
+	// Time until the bucket is full again
+	Reset time.Duration
+	// Time until the next token, when not allowed
+	RetryAfter time.Duration
+}
+
+type RateLimitStore interface {
+	Take(ctx context.Context, key string, limit RateLimit) (RateLimitResult, error)
+}
+
+// Result from how far ahead of now the bucket is
+func gcraResult(allowed bool, ahead time.Duration, limit RateLimit) RateLimitResult {
+	ahead = max(ahead, 0)
+	result := RateLimitResult{
+		Allowed:   allowed,
+		Remaining: int((limit.window() - ahead) / limit.interval()),
+		Reset:     ahead,
+	}
+	if !allowed {
+		result.RetryAfter = ahead + limit.interval() - limit.window()
+	}
+	return result
+}
+
+// How often the full buckets are dropped
+const sweepInterval = time.Minute
+
+// Memory ------------------------------------------------------------------------------------------
+// Buckets of this process only, each replica limits on its own
+type MemoryRateLimitStore struct {
+	mu      sync.Mutex
+	tats    map[string]time.Time
+	sweptAt time.Time
+}
+
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{tats: map[string]time.Time{}, sweptAt: time.Now()}
+}
+
+func (s *MemoryRateLimitStore) Take(_ context.Context, key string, limit RateLimit) (RateLimitResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(s.sweptAt) > sweepInterval {
+		for k, tat := range s.tats {
+			if tat.Before(now) {
+				delete(s.tats, k)
+			}
+		}
+		s.sweptAt = now
+	}
+
+	tat := now
+	if stored, ok := s.tats[key]; ok && stored.After(now) {
+		tat = stored
+	}
+	next := tat.Add(limit.interval())
+	if next.Sub(now) > limit.window() {
+		return gcraResult(false, tat.Sub(now), limit), nil
+	}
+	s.tats[key] = next
+	return gcraResult(true, next.Sub(now), limit), nil
+}
+
+// Postgres ----------------------------------------------------------------------------------------
+// Buckets in the database, shared by every replica. Each request costs a round trip.
+type PostgresRateLimitStore struct {
+	repo repository.Repository
+
+	mu      sync.Mutex
+	sweptAt time.Time
+}
+
+func NewPostgresRateLimitStore(r repository.Repository) *PostgresRateLimitStore {
+	return &PostgresRateLimitStore{repo: r, sweptAt: time.Now()}
+}
+
+func (s *PostgresRateLimitStore) Take(ctx context.Context, key string, limit RateLimit) (RateLimitResult, error) {
+	s.sweep()
+
+	ahead, err := s.repo.TakeRateLimitToken(ctx, repository.TakeRateLimitTokenParams{
+		Key:        key,
+		IntervalUs: limit.interval().Microseconds(),
+		WindowUs:   limit.window().Microseconds(),
+	})
+	if err == nil {
+		return gcraResult(true, time.Duration(ahead)*time.Microsecond, limit), nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return RateLimitResult{}, err
+	}
+
+	// Empty bucket, read how long until the next token
+	ahead, err = s.repo.GetRateLimitBucket(ctx, key)
+	if err != nil {
+		return RateLimitResult{}, err
+	}
+	return gcraResult(false, time.Duration(ahead)*time.Microsecond, limit), nil
+}
+
+// Drop the full buckets in the background, at most once per sweepInterval per replica
+func (s *PostgresRateLimitStore) sweep() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if time.Since(s.sweptAt) < sweepInterval {
+		return
+	}
+	s.sweptAt = time.Now()
+	go func() {
+		if err := s.repo.DeleteFullRateLimitBuckets(context.Background()); err != nil {
+			log.Println("Error deleting full rate limit buckets: ", err)
+		}
+	}()
+}
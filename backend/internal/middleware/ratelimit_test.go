@@ -0,0 +1,117 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestParseRateLimits(t *testing.T) {
+	cases := []struct {
+		config  string
+		want    map[string]RateLimit
+		wantErr bool
+	}{
+		{"", map[string]RateLimit{}, false},
+		{
+			" *=10:20 , /v1/stock_ratings/export=0.2:2,/metrics=0:1",
+			map[string]RateLimit{
+				"*":                        {Rate: 10, Burst: 20},
+				"/v1/stock_ratings/export": {Rate: 0.2, Burst: 2},
+				"/metrics":                 {Rate: 0, Burst: 1},
+			},
+			false,
+		},
+		{"*", nil, true},
+		{"*=10", nil, true},
+		{"*=ten:20", nil, true},
+		{"*=-1:20", nil, true},
+		{"*=10:0", nil, true},
+		{"*=10:1.5", nil, true},
+	}
+	for _, tc := range cases {
+		got, err := ParseRateLimits(tc.config)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("ParseRateLimits(%q) error = %v, wantErr %v", tc.config, err, tc.wantErr)
+			continue
+		}
+		if !tc.wantErr && !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("ParseRateLimits(%q) = %v, want %v", tc.config, got, tc.want)
+		}
+	}
+}
+
+func TestMemoryRateLimitStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryRateLimitStore()
+	// A token every 50ms, up to 2
+	limit := RateLimit{Rate: 20, Burst: 2}
+
+	for i, remaining := range []int{1, 0} {
+		result, err := store.Take(ctx, "a", limit)
+		if err != nil || !result.Allowed || result.Remaining != remaining {
+			t.Fatalf("Take() #%d = %+v, %v, want allowed with %d remaining", i, result, err, remaining)
+		}
+	}
+	result, err := store.Take(ctx, "a", limit)
+	if err != nil || result.Allowed || result.RetryAfter <= 0 || result.RetryAfter > limit.interval() {
+		t.Fatalf("Take() on an empty bucket = %+v, %v, want denied until the next token", result, err)
+	}
+	if result, _ := store.Take(ctx, "b", limit); !result.Allowed {
+		t.Errorf("Take() of another key = %+v, want its own bucket", result)
+	}
+
+	time.Sleep(result.RetryAfter)
+	if result, err := store.Take(ctx, "a", limit); err != nil || !result.Allowed || result.Remaining != 0 {
+		t.Errorf("Take() after the retry delay = %+v, %v, want a single token refilled", result, err)
+	}
+}
+
+func TestRateLimiterKeysOnKnownAPIKeys(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	limits := map[string]RateLimit{defaultRoute: {Rate: 0.001, Burst: 1}}
+	limiter := NewRateLimiter(limits, NewMemoryRateLimitStore(), ParseAPIKeys(" known, ,other "))
+	router := gin.New()
+	router.Use(limiter.Middleware())
+	router.GET("/ping", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	request := func(apiKey string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+		req.RemoteAddr = "192.0.2.1:1234"
+		req.Header.Set("X-Forwarded-For", "198.51.100.1")
+		if apiKey != "" {
+			req.Header.Set("X-API-Key", apiKey)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := request("unknown-1"); w.Code != http.StatusNoContent {
+		t.Fatalf("first request = %d, want 204", w.Code)
+	}
+	// Unknown keys share the bucket of the IP
+	w := request("unknown-2")
+	var resp ErrorResponse
+	if w.Code != http.StatusTooManyRequests || json.Unmarshal(w.Body.Bytes(), &resp) != nil || resp.Error == "" || w.Header().Get("Retry-After") == "" {
+		t.Errorf("request with another unknown key = %d %s, want a 429 with an error and Retry-After", w.Code, w.Body)
+	}
+	if w := request(""); w.Code != http.StatusTooManyRequests {
+		t.Errorf("request without a key = %d, want 429", w.Code)
+	}
+	// Known keys have their own buckets
+	for _, key := range []string{"known", "other"} {
+		if w := request(key); w.Code != http.StatusNoContent {
+			t.Errorf("request with the key %s = %d, want 204", key, w.Code)
+		}
+	}
+	if w := request("known"); w.Code != http.StatusTooManyRequests {
+		t.Errorf("second request with a known key = %d, want 429", w.Code)
+	}
+}
@@ -0,0 +1,42 @@
+package openapi
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Swagger UI, loaded from a CDN, pointing at the spec served next to it
+const docsPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+	<meta charset="utf-8" />
+	<title>Stock ratings API</title>
+	<link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css" />
+</head>
+<body>
+	<div id="swagger-ui"></div>
+	<script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+	<script>
+		window.ui = SwaggerUIBundle({ url: "openapi.json", dom_id: "#swagger-ui" });
+	</script>
+</body>
+</html>`
+
+func GetSpec(c *gin.Context) {
+	spec, err := SpecJSON()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Data(http.StatusOK, "application/json", spec)
+}
+
+func GetDocs(c *gin.Context) {
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(docsPage))
+}
+
+func AddOpenAPIRoutes(rg *gin.RouterGroup) {
+	rg.GET("/openapi.json", GetSpec)
+	rg.GET("/docs", GetDocs)
+}
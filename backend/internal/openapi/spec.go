@@ -0,0 +1,507 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+
+	"backend/internal/features/alerts"
+	"backend/internal/features/events"
+	"backend/internal/features/graphql"
+	"backend/internal/features/ingestion"
+	"backend/internal/features/search"
+	"backend/internal/features/stockratings"
+	"backend/internal/features/stream"
+	"backend/internal/features/webhooks"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3gen"
+)
+
+// SPEC ============================================================================================
+// The paths are declared below, while the schemas are generated from the response types of the
+// handlers. The spec_test.go drift test checks every gin route against it.
+
+var sortByValues = []any{
+	"score", "target_from", "target_to", "target_delta",
+	"ticker", "company", "brokerage", "action", "rating_from", "rating_to",
+}
+
+// Get the OpenAPI document of the API, built once
+var Spec = sync.OnceValues(func() (*openapi3.T, error) {
+	doc := &openapi3.T{
+		OpenAPI: "3.0.3",
+		Info: &openapi3.Info{
+			Title:   "Stock ratings API",
+			Version: "1.0.0",
+		},
+		Servers:    openapi3.Servers{{URL: "/"}},
+		Paths:      openapi3.NewPaths(),
+		Components: &openapi3.Components{Schemas: openapi3.Schemas{}},
+	}
+
+	// Schemas generated from the handler types
+	schemas := map[string]any{
+		"StockRating":       stockratings.GetStockRatingsResponse{},
+		"StockRatingList":   stockratings.GetStockRatingsListResponse{},
+		"StockRatingV2":     stockratings.GetStockRatingsV2Response{},
+		"StockRatingV2List": stockratings.GetStockRatingsV2ListResponse{},
+		"SearchResult":      search.SearchResponse{},
+		"AlertRule":         alerts.AlertRuleResponse{},
+		"AlertRuleList":     alerts.AlertRuleListResponse{},
+		"AlertRuleInput":    alerts.CreateAlertRuleRequest{},
+		"AlertList":         alerts.AlertListResponse{},
+		"Webhook":           webhooks.WebhookResponse{},
+		"WebhookList":       webhooks.WebhookListResponse{},
+		"WebhookInput":      webhooks.CreateWebhookRequest{},
+		"CreatedWebhook":    webhooks.CreatedWebhookResponse{},
+		"DeliveryList":      webhooks.DeliveryListResponse{},
+		"StreamEvent":       stream.Event{},
+		"StreamReset":       stream.ResetMessage{},
+		"IngestionRunList":  ingestion.RunListResponse{},
+		"StockRatingDiff":   stockratings.StockRatingDiffResponse{},
+		"EventList":         events.EventListResponse{},
+		"Error":             stockratings.ErrorResponse{},
+	}
+	for name, value := range schemas {
+		if err := addSchema(doc, name, value); err != nil {
+			return nil, err
+		}
+	}
+
+	// Paths
+	doc.AddOperation("/ping/", "GET", &openapi3.Operation{
+		OperationID: "ping",
+		Summary:     "Health check",
+		Responses: openapi3.NewResponses(
+			openapi3.WithStatus(200, jsonResponse("Pong", openapi3.NewStringSchema().NewRef())),
+		),
+	})
+	doc.AddOperation("/v1/stock_ratings/", "GET", &openapi3.Operation{
+		OperationID: "getStockRatings",
+		Summary:     "List the stock ratings, scored, sorted and paginated",
+		Parameters:  append(append(filterParams(), pageParams()...), asOfParam()),
+		Responses: openapi3.NewResponses(
+			openapi3.WithStatus(200, jsonResponse("Stock ratings page", schemaRef(doc, "StockRatingList"))),
+			openapi3.WithStatus(304, notModifiedResponse()),
+			openapi3.WithStatus(400, jsonResponse("Invalid parameters", schemaRef(doc, "Error"))),
+			openapi3.WithStatus(500, jsonResponse("Unexpected error", schemaRef(doc, "Error"))),
+		),
+	})
+	doc.AddOperation("/v2/stock_ratings/", "GET", &openapi3.Operation{
+		OperationID: "getStockRatingsV2",
+		Summary:     "List the stock ratings with typed numbers and RFC3339 timestamps",
+		Parameters:  append(append(filterParams(), pageParams()...), asOfParam()),
+		Responses: openapi3.NewResponses(
+			openapi3.WithStatus(200, jsonResponse("Stock ratings page", schemaRef(doc, "StockRatingV2List"))),
+			openapi3.WithStatus(304, notModifiedResponse()),
+			openapi3.WithStatus(400, jsonResponse("Invalid parameters", schemaRef(doc, "Error"))),
+			openapi3.WithStatus(500, jsonResponse("Unexpected error", schemaRef(doc, "Error"))),
+		),
+	})
+
+	doc.AddOperation("/v2/stock_ratings/{ticker}", "GET", &openapi3.Operation{
+		OperationID: "getStockRatingV2",
+		Summary:     "Rating of a ticker, as in the v2 list",
+		Parameters: openapi3.Parameters{
+			&openapi3.ParameterRef{Value: openapi3.NewPathParameter("ticker").WithSchema(openapi3.NewStringSchema())},
+			asOfParam(),
+		},
+		Responses: openapi3.NewResponses(
+			openapi3.WithStatus(200, jsonResponse("Stock rating", schemaRef(doc, "StockRatingV2"))),
+			openapi3.WithStatus(304, notModifiedResponse()),
+			openapi3.WithStatus(400, jsonResponse("Invalid as_of", schemaRef(doc, "Error"))),
+			openapi3.WithStatus(404, jsonResponse("Ticker without rating", schemaRef(doc, "Error"))),
+			openapi3.WithStatus(500, jsonResponse("Unexpected error", schemaRef(doc, "Error"))),
+		),
+	})
+
+	doc.AddOperation("/v1/stock_ratings/export", "GET", &openapi3.Operation{
+		OperationID: "exportStockRatings",
+		Summary:     "Export every stock rating matching the filters and sort of the list",
+		Parameters: append(openapi3.Parameters{
+			queryParam("format", "File format", openapi3.NewStringSchema().WithEnum("csv", "ndjson", "xlsx").WithDefault("csv")),
+			queryParam("columns", "Comma separated columns to export, all by default", openapi3.NewStringSchema()),
+		}, filterParams()...),
+		Responses: openapi3.NewResponses(
+			openapi3.WithStatus(200, &openapi3.ResponseRef{Value: openapi3.NewResponse().
+				WithDescription("Stock ratings file").
+				WithContent(openapi3.Content{
+					"text/csv":             openapi3.NewMediaType().WithSchema(openapi3.NewStringSchema()),
+					"application/x-ndjson": openapi3.NewMediaType().WithSchema(openapi3.NewStringSchema()),
+					"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet": openapi3.NewMediaType().
+						WithSchema(openapi3.NewStringSchema().WithFormat("binary")),
+				})}),
+			openapi3.WithStatus(304, notModifiedResponse()),
+			openapi3.WithStatus(400, jsonResponse("Invalid parameters", schemaRef(doc, "Error"))),
+			openapi3.WithStatus(500, jsonResponse("Unexpected error", schemaRef(doc, "Error"))),
+		),
+	})
+
+	doc.AddOperation("/v1/stock_ratings/diff", "GET", &openapi3.Operation{
+		OperationID: "diffStockRatings",
+		Summary:     "Tickers added, removed, or with another rating, target or score between two points of the history",
+		Description: "A point is an RFC3339 time, seeing the events at or before it as as_of does, or an ingestion " +
+			"run ID, seeing the events recorded by the end of the run. A point only has the tickers loaded by the last " +
+			"run finished at it, from can't be after to. Only the history kept by the retention is seen.",
+		Parameters: openapi3.Parameters{
+			diffPointParam("from", "Point before the changes"),
+			diffPointParam("to", "Point after the changes"),
+		},
+		Responses: openapi3.NewResponses(
+			openapi3.WithStatus(200, jsonResponse("Changes by ticker", schemaRef(doc, "StockRatingDiff"))),
+			openapi3.WithStatus(304, notModifiedResponse()),
+			openapi3.WithStatus(400, jsonResponse("Invalid parameters", schemaRef(doc, "Error"))),
+			openapi3.WithStatus(404, jsonResponse("Ingestion run not found", schemaRef(doc, "Error"))),
+			openapi3.WithStatus(409, jsonResponse("Ingestion run not finished", schemaRef(doc, "Error"))),
+			openapi3.WithStatus(500, jsonResponse("Unexpected error", schemaRef(doc, "Error"))),
+		),
+	})
+
+	doc.AddOperation("/v1/search", "GET", &openapi3.Operation{
+		OperationID: "search",
+		Summary:     "Typeahead suggestions of tickers, companies and brokerages, ranked by similarity",
+		Parameters: openapi3.Parameters{
+			{Value: openapi3.NewQueryParameter("q").
+				WithDescription("Text typed so far").
+				WithSchema(openapi3.NewStringSchema().WithMinLength(1)).
+				WithRequired(true)},
+			queryParam("limit", "Suggestions per type", openapi3.NewInt32Schema().WithMin(1).WithMax(20).WithDefault(5)),
+		},
+		Responses: openapi3.NewResponses(
+			openapi3.WithStatus(200, jsonResponse("Suggestions grouped by type", schemaRef(doc, "SearchResult"))),
+			openapi3.WithStatus(304, notModifiedResponse()),
+			openapi3.WithStatus(400, jsonResponse("Invalid parameters", schemaRef(doc, "Error"))),
+			openapi3.WithStatus(500, jsonResponse("Unexpected error", schemaRef(doc, "Error"))),
+		),
+	})
+
+	doc.AddOperation("/v1/alerts/", "GET", &openapi3.Operation{
+		OperationID: "listAlerts",
+		Summary:     "List the alerts triggered by the loaded ratings, newest first",
+		Parameters: append(openapi3.Parameters{
+			queryParam("ticker", "Only the alerts of this ticker", openapi3.NewStringSchema()),
+		}, pageParams()...),
+		Responses: openapi3.NewResponses(
+			openapi3.WithStatus(200, jsonResponse("Alerts page", schemaRef(doc, "AlertList"))),
+			openapi3.WithStatus(400, jsonResponse("Invalid parameters", schemaRef(doc, "Error"))),
+			openapi3.WithStatus(500, jsonResponse("Unexpected error", schemaRef(doc, "Error"))),
+		),
+	})
+	doc.AddOperation("/v1/alerts/rules/", "GET", &openapi3.Operation{
+		OperationID: "listAlertRules",
+		Summary:     "List the alert rules",
+		Responses: openapi3.NewResponses(
+			openapi3.WithStatus(200, jsonResponse("Alert rules", schemaRef(doc, "AlertRuleList"))),
+			openapi3.WithStatus(500, jsonResponse("Unexpected error", schemaRef(doc, "Error"))),
+		),
+	})
+	doc.AddOperation("/v1/alerts/rules/", "POST", &openapi3.Operation{
+		OperationID: "createAlertRule",
+		Summary:     "Create an alert rule, evaluated against every batch of the next loads",
+		RequestBody: &openapi3.RequestBodyRef{Value: openapi3.NewRequestBody().
+			WithRequired(true).
+			WithJSONSchemaRef(schemaRef(doc, "AlertRuleInput"))},
+		Responses: openapi3.NewResponses(
+			openapi3.WithStatus(201, jsonResponse("Created rule", schemaRef(doc, "AlertRule"))),
+			openapi3.WithStatus(400, jsonResponse("Invalid rule", schemaRef(doc, "Error"))),
+			openapi3.WithStatus(500, jsonResponse("Unexpected error", schemaRef(doc, "Error"))),
+		),
+	})
+	doc.AddOperation("/v1/alerts/rules/{id}", "DELETE", &openapi3.Operation{
+		OperationID: "deleteAlertRule",
+		Summary:     "Delete an alert rule and its alerts",
+		Parameters:  openapi3.Parameters{uuidPathParam("id")},
+		Responses: openapi3.NewResponses(
+			openapi3.WithStatus(204, &openapi3.ResponseRef{Value: openapi3.NewResponse().WithDescription("Deleted")}),
+			openapi3.WithStatus(400, jsonResponse("Invalid id", schemaRef(doc, "Error"))),
+			openapi3.WithStatus(404, jsonResponse("Rule not found", schemaRef(doc, "Error"))),
+			openapi3.WithStatus(500, jsonResponse("Unexpected error", schemaRef(doc, "Error"))),
+		),
+	})
+
+	doc.AddOperation("/v1/webhooks/", "GET", &openapi3.Operation{
+		OperationID: "listWebhooks",
+		Summary:     "List the webhook subscriptions",
+		Responses: openapi3.NewResponses(
+			openapi3.WithStatus(200, jsonResponse("Webhooks", schemaRef(doc, "WebhookList"))),
+			openapi3.WithStatus(500, jsonResponse("Unexpected error", schemaRef(doc, "Error"))),
+		),
+	})
+	doc.AddOperation("/v1/webhooks/", "POST", &openapi3.Operation{
+		OperationID: "createWebhook",
+		Summary:     "Subscribe a URL to the rating events, the response holds the signing secret",
+		Description: "Deliveries are POSTed with the X-Webhook-Signature header " +
+			"t=<unix seconds>,v1=<hex HMAC-SHA256 of \"<t>.<body>\" keyed by the secret>. " +
+			"Event types: " + strings.Join(webhooks.EventTypes, ", ") + ", all of them when left out.",
+		RequestBody: &openapi3.RequestBodyRef{Value: openapi3.NewRequestBody().
+			WithRequired(true).
+			WithJSONSchemaRef(schemaRef(doc, "WebhookInput"))},
+		Responses: openapi3.NewResponses(
+			openapi3.WithStatus(201, jsonResponse("Created webhook", schemaRef(doc, "CreatedWebhook"))),
+			openapi3.WithStatus(400, jsonResponse("Invalid webhook", schemaRef(doc, "Error"))),
+			openapi3.WithStatus(500, jsonResponse("Unexpected error", schemaRef(doc, "Error"))),
+		),
+	})
+	doc.AddOperation("/v1/webhooks/{id}", "DELETE", &openapi3.Operation{
+		OperationID: "deleteWebhook",
+		Summary:     "Delete a webhook and its deliveries",
+		Parameters:  openapi3.Parameters{uuidPathParam("id")},
+		Responses: openapi3.NewResponses(
+			openapi3.WithStatus(204, &openapi3.ResponseRef{Value: openapi3.NewResponse().WithDescription("Deleted")}),
+			openapi3.WithStatus(400, jsonResponse("Invalid id", schemaRef(doc, "Error"))),
+			openapi3.WithStatus(404, jsonResponse("Webhook not found", schemaRef(doc, "Error"))),
+			openapi3.WithStatus(500, jsonResponse("Unexpected error", schemaRef(doc, "Error"))),
+		),
+	})
+	doc.AddOperation("/v1/webhooks/{id}/deliveries/", "GET", &openapi3.Operation{
+		OperationID: "listWebhookDeliveries",
+		Summary:     "Delivery log of a webhook, newest first",
+		Parameters: openapi3.Parameters{
+			uuidPathParam("id"),
+			queryParam("status", "Only the deliveries in this status", openapi3.NewStringSchema().WithEnum("pending", "succeeded", "failed")),
+			queryParam("offset", "Deliveries to skip", openapi3.NewInt32Schema().WithMin(0).WithDefault(0)),
+			queryParam("limit", "Deliveries to return", openapi3.NewInt32Schema().WithMin(1).WithMax(1000).WithDefault(50)),
+		},
+		Responses: openapi3.NewResponses(
+			openapi3.WithStatus(200, jsonResponse("Deliveries page", schemaRef(doc, "DeliveryList"))),
+			openapi3.WithStatus(400, jsonResponse("Invalid parameters", schemaRef(doc, "Error"))),
+			openapi3.WithStatus(500, jsonResponse("Unexpected error", schemaRef(doc, "Error"))),
+		),
+	})
+	doc.AddOperation("/v1/webhooks/{id}/deliveries/{delivery_id}/replay", "POST", &openapi3.Operation{
+		OperationID: "replayWebhookDelivery",
+		Summary:     "Queue a delivery again, with a fresh count of attempts",
+		Parameters:  openapi3.Parameters{uuidPathParam("id"), uuidPathParam("delivery_id")},
+		Responses: openapi3.NewResponses(
+			openapi3.WithStatus(202, &openapi3.ResponseRef{Value: openapi3.NewResponse().WithDescription("Queued")}),
+			openapi3.WithStatus(400, jsonResponse("Invalid id", schemaRef(doc, "Error"))),
+			openapi3.WithStatus(404, jsonResponse("Delivery not found", schemaRef(doc, "Error"))),
+			openapi3.WithStatus(500, jsonResponse("Unexpected error", schemaRef(doc, "Error"))),
+		),
+	})
+
+	streamParams := openapi3.Parameters{
+		queryParam("ticker_like", "Case insensitive ticker substring", openapi3.NewStringSchema()),
+		queryParam("company_like", "Case insensitive company substring", openapi3.NewStringSchema()),
+		queryParam("last_event_id", "Resume after this event, the Last-Event-ID header takes precedence", openapi3.NewStringSchema()),
+		&openapi3.ParameterRef{Value: openapi3.NewHeaderParameter("Last-Event-ID").
+			WithDescription("Resume after this event, sent by EventSource when it reconnects").
+			WithSchema(openapi3.NewStringSchema())},
+	}
+	doc.AddOperation("/v1/stream", "GET", &openapi3.Operation{
+		OperationID: "streamRatings",
+		Summary:     "Server-sent events of the ratings created, changed or removed by each load",
+		Description: "Each event has the id, the type (" + strings.Join([]string{stream.EventCreated, stream.EventChanged, stream.EventRemoved}, ", ") +
+			") and a StreamEvent as data. A reset event is sent first when the missed events are no longer kept, " +
+			"the client must then reload the list. A comment is sent every 15 seconds to keep the connection open.",
+		Parameters: streamParams,
+		Responses: openapi3.NewResponses(
+			openapi3.WithStatus(200, &openapi3.ResponseRef{Value: openapi3.NewResponse().
+				WithDescription("Event stream").
+				WithContent(openapi3.NewContentWithSchema(openapi3.NewStringSchema(), []string{"text/event-stream"}))}),
+			openapi3.WithStatus(400, jsonResponse("Invalid last event id", schemaRef(doc, "Error"))),
+		),
+	})
+	doc.AddOperation("/v1/stream/ws", "GET", &openapi3.Operation{
+		OperationID: "streamRatingsWebSocket",
+		Summary:     "WebSocket with the events of /v1/stream, one StreamEvent or StreamReset JSON per message",
+		Parameters:  streamParams,
+		Responses: openapi3.NewResponses(
+			openapi3.WithStatus(101, &openapi3.ResponseRef{Value: openapi3.NewResponse().WithDescription("Switched to WebSocket")}),
+			openapi3.WithStatus(400, jsonResponse("Invalid last event id or not a WebSocket request", schemaRef(doc, "Error"))),
+		),
+	})
+
+	doc.AddOperation("/v1/ingestion/runs/", "GET", &openapi3.Operation{
+		OperationID: "listIngestionRuns",
+		Summary:     "Most recent loads from the API and replays of the archive",
+		Parameters: openapi3.Parameters{
+			queryParam("limit", "Runs to return", openapi3.NewInt32Schema().WithMin(1).WithMax(100).WithDefault(10)),
+		},
+		Responses: openapi3.NewResponses(
+			openapi3.WithStatus(200, jsonResponse("Runs", schemaRef(doc, "IngestionRunList"))),
+			openapi3.WithStatus(400, jsonResponse("Invalid limit", schemaRef(doc, "Error"))),
+			openapi3.WithStatus(500, jsonResponse("Unexpected error", schemaRef(doc, "Error"))),
+		),
+	})
+
+	doc.AddOperation("/v1/events", "GET", &openapi3.Operation{
+		OperationID: "listEvents",
+		Summary:     "Every recorded event in the order it was recorded, resuming after a cursor",
+		Description: "The cursors are opaque and increase with the order of the events. Pass the next_cursor of a " +
+			"response as the since_cursor of the next request, it stays the same until new events are recorded. " +
+			"The retention only rolls up the events recorded before its cutoff, they are then no longer in the feed.",
+		Parameters: openapi3.Parameters{
+			queryParam("since_cursor", "Cursor of the last event read, the start of the feed when absent", openapi3.NewStringSchema()),
+			queryParam("limit", "Events to return", openapi3.NewInt32Schema().WithMin(1).WithMax(1000).WithDefault(100)),
+		},
+		Responses: openapi3.NewResponses(
+			openapi3.WithStatus(200, jsonResponse("Events", schemaRef(doc, "EventList"))),
+			openapi3.WithStatus(400, jsonResponse("Invalid parameters", schemaRef(doc, "Error"))),
+			openapi3.WithStatus(500, jsonResponse("Unexpected error", schemaRef(doc, "Error"))),
+		),
+	})
+
+	// The GraphQL schema is the contract of /graphql, only the envelope is described here
+	doc.Components.Schemas["GraphQLRequest"] = openapi3.NewSchemaRef("", openapi3.NewObjectSchema().
+		WithProperty("query", openapi3.NewStringSchema()).
+		WithProperty("operationName", openapi3.NewStringSchema()).
+		WithProperty("variables", openapi3.NewObjectSchema().WithAnyAdditionalProperties()).
+		WithRequired([]string{"query"}))
+	doc.Components.Schemas["GraphQLResponse"] = openapi3.NewSchemaRef("", openapi3.NewObjectSchema().
+		WithProperty("data", openapi3.NewObjectSchema().WithAnyAdditionalProperties().WithNullable()).
+		WithProperty("errors", openapi3.NewArraySchema().WithItems(openapi3.NewObjectSchema().
+			WithProperty("message", openapi3.NewStringSchema()).
+			WithAnyAdditionalProperties())).
+		WithProperty("extensions", openapi3.NewObjectSchema().WithAnyAdditionalProperties()))
+	graphQLDescription := "Query the ratings, tickers, brokerages and aggregates with the schema below. " +
+		"The nested loads of a query are batched, a list of tickers with their events costs two queries.\n\n" +
+		"```graphql\n" + graphql.Schema + "```"
+	doc.AddOperation("/graphql", "GET", &openapi3.Operation{
+		OperationID: "graphqlQuery",
+		Summary:     "Run a GraphQL query given in the parameters",
+		Description: graphQLDescription,
+		Parameters: openapi3.Parameters{
+			&openapi3.ParameterRef{Value: openapi3.NewQueryParameter("query").WithRequired(true).WithSchema(openapi3.NewStringSchema())},
+			queryParam("operationName", "Operation to run when the query has several", openapi3.NewStringSchema()),
+			queryParam("variables", "Variables as a JSON object", openapi3.NewStringSchema()),
+		},
+		Responses: openapi3.NewResponses(
+			openapi3.WithStatus(200, jsonResponse("Result, with the errors of the query if any", schemaRef(doc, "GraphQLResponse"))),
+			openapi3.WithStatus(400, jsonResponse("Missing query or invalid variables", schemaRef(doc, "Error"))),
+		),
+	})
+	doc.AddOperation("/graphql", "POST", &openapi3.Operation{
+		OperationID: "graphqlQueryBody",
+		Summary:     "Run a GraphQL query given in the body",
+		Description: graphQLDescription,
+		RequestBody: &openapi3.RequestBodyRef{Value: openapi3.NewRequestBody().
+			WithRequired(true).
+			WithJSONSchemaRef(schemaRef(doc, "GraphQLRequest"))},
+		Responses: openapi3.NewResponses(
+			openapi3.WithStatus(200, jsonResponse("Result, with the errors of the query if any", schemaRef(doc, "GraphQLResponse"))),
+			openapi3.WithStatus(400, jsonResponse("Missing query or invalid body", schemaRef(doc, "Error"))),
+		),
+	})
+
+	// Every route is rate limited
+	for _, item := range doc.Paths.Map() {
+		for _, operation := range item.Operations() {
+			operation.Responses.Set("429", jsonResponse("Rate limit exceeded, retry after the Retry-After seconds", schemaRef(doc, "Error")))
+		}
+	}
+
+	return doc, nil
+})
+
+// Get the OpenAPI document serialized as JSON
+var SpecJSON = sync.OnceValues(func() ([]byte, error) {
+	doc, err := Spec()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(doc)
+})
+
+// utils ===========================================================================================
+
+// Filter and sort parameters of the list endpoints
+func filterParams() openapi3.Parameters {
+	return openapi3.Parameters{
+		queryParam("sort_order", "Sort direction", openapi3.NewStringSchema().WithEnum("asc", "desc").WithDefault("desc")),
+		queryParam("sort_by", "Sort column", openapi3.NewStringSchema().WithEnum(sortByValues...).WithDefault("score")),
+		queryParam("ticker_like", "Case insensitive ticker substring", openapi3.NewStringSchema()),
+		queryParam("company_like", "Case insensitive company substring", openapi3.NewStringSchema()),
+	}
+}
+
+// Time travel of the list and detail endpoints, evaluated from the history. The history rolled up
+// by the retention is no longer there to evaluate.
+func asOfParam() *openapi3.ParameterRef {
+	return queryParam("as_of",
+		"RFC3339 time to evaluate the ratings at, from the latest event of each ticker at or before it. "+
+			"Only the history kept by the retention is seen, the current ratings when absent",
+		openapi3.NewDateTimeSchema())
+}
+
+// A time or an ingestion run ID, both strings the handler tells apart
+func diffPointParam(name string, description string) *openapi3.ParameterRef {
+	param := queryParam(name, description, openapi3.NewOneOfSchema(openapi3.NewDateTimeSchema(), openapi3.NewUUIDSchema()))
+	param.Value.Required = true
+	return param
+}
+
+func pageParams() openapi3.Parameters {
+	return openapi3.Parameters{
+		queryParam("offset", "Rows to skip", openapi3.NewInt32Schema().WithMin(0).WithDefault(0)),
+		queryParam("limit", "Rows to return", openapi3.NewInt32Schema().WithMin(0).WithDefault(10)),
+	}
+}
+
+// Generate the schema of a Go type into the components of the document. Objects are strict, every
+// property is required and nothing else is allowed, so a field added to or removed from a handler
+// response without updating the spec is caught by the drift test.
+func addSchema(doc *openapi3.T, name string, value any) error {
+	ref, err := openapi3gen.NewSchemaRefForValue(value, nil, openapi3gen.SchemaCustomizer(customize))
+	if err != nil {
+		return fmt.Errorf("failed to generate schema %s: %w", name, err)
+	}
+	strict(ref)
+	doc.Components.Schemas[name] = ref
+	return nil
+}
+
+// Decimals are serialized as json.Number, a string type for reflection but a number on the wire
+func customize(_ string, t reflect.Type, _ reflect.StructTag, schema *openapi3.Schema) error {
+	if t == reflect.TypeOf(json.Number("")) {
+		*schema = *openapi3.NewFloat64Schema()
+	}
+	return nil
+}
+
+func strict(ref *openapi3.SchemaRef) {
+	if ref == nil || ref.Value == nil {
+		return
+	}
+	schema := ref.Value
+	if schema.Type.Is(openapi3.TypeObject) {
+		schema.Required = slices.Sorted(maps.Keys(schema.Properties))
+		for _, propRef := range schema.Properties {
+			strict(propRef)
+		}
+		no := false
+		schema.AdditionalProperties = openapi3.AdditionalProperties{Has: &no}
+	}
+	strict(schema.Items)
+}
+
+func schemaRef(doc *openapi3.T, name string) *openapi3.SchemaRef {
+	return openapi3.NewSchemaRef("#/components/schemas/"+name, doc.Components.Schemas[name].Value)
+}
+
+func jsonResponse(description string, schema *openapi3.SchemaRef) *openapi3.ResponseRef {
+	return &openapi3.ResponseRef{Value: openapi3.NewResponse().
+		WithDescription(description).
+		WithJSONSchemaRef(schema)}
+}
+
+// The dataset routes answer conditional requests against the ETag of the dataset version
+func notModifiedResponse() *openapi3.ResponseRef {
+	return &openapi3.ResponseRef{Value: openapi3.NewResponse().
+		WithDescription("Not modified since the ETag or date of the request")}
+}
+
+func uuidPathParam(name string) *openapi3.ParameterRef {
+	return &openapi3.ParameterRef{Value: openapi3.NewPathParameter(name).WithSchema(openapi3.NewUUIDSchema())}
+}
+
+func queryParam(name string, description string, schema *openapi3.Schema) *openapi3.ParameterRef {
+	return &openapi3.ParameterRef{Value: openapi3.NewQueryParameter(name).
+		WithDescription(description).
+		WithSchema(schema)}
+}
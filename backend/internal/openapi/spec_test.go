@@ -0,0 +1,311 @@
+package openapi_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"backend/internal/features/alerts"
+	"backend/internal/features/events"
+	"backend/internal/features/graphql"
+	"backend/internal/features/ingestion"
+	"backend/internal/features/search"
+	"backend/internal/features/stockratings"
+	"backend/internal/features/stream"
+	"backend/internal/features/webhooks"
+	"backend/internal/openapi"
+	"backend/internal/repository"
+	"backend/internal/routes"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func init() {
+	// NDJSON bodies are validated line by line, each line must be a JSON object
+	openapi3filter.RegisterBodyDecoder("application/x-ndjson", func(body io.Reader, _ http.Header, _ *openapi3.SchemaRef, _ openapi3filter.EncodingFn) (any, error) {
+		data, err := io.ReadAll(body)
+		if err != nil {
+			return nil, err
+		}
+		for _, line := range strings.Split(strings.TrimSuffix(string(data), "\n"), "\n") {
+			var object map[string]any
+			if err := json.Unmarshal([]byte(line), &object); err != nil {
+				return nil, fmt.Errorf("invalid NDJSON line %q: %w", line, err)
+			}
+		}
+		return string(data), nil
+	})
+}
+
+// Routes that are not part of the API contract
+var undocumented = map[string]bool{
+	"GET /metrics":         true,
+	"GET /v1/openapi.json": true,
+	"GET /v1/docs":         true,
+}
+
+func TestSpecIsValid(t *testing.T) {
+	doc, err := openapi.Spec()
+	if err != nil {
+		t.Fatalf("failed to build spec: %v", err)
+	}
+	if err := doc.Validate(context.Background()); err != nil {
+		t.Fatalf("invalid spec: %v", err)
+	}
+}
+
+func TestEveryRouteIsDocumented(t *testing.T) {
+	doc, err := openapi.Spec()
+	if err != nil {
+		t.Fatalf("failed to build spec: %v", err)
+	}
+	router := newRouter(&fakeDB{})
+
+	param := regexp.MustCompile(`[:*]([^/]+)`)
+	for _, route := range router.Routes() {
+		if undocumented[route.Method+" "+route.Path] {
+			continue
+		}
+		path := param.ReplaceAllString(route.Path, "{$1}")
+		item := doc.Paths.Find(path)
+		if item == nil || item.GetOperation(route.Method) == nil {
+			t.Errorf("route %s %s is missing from the spec", route.Method, route.Path)
+		}
+	}
+}
+
+func TestResponsesMatchSpec(t *testing.T) {
+	doc, err := openapi.Spec()
+	if err != nil {
+		t.Fatalf("failed to build spec: %v", err)
+	}
+	specRouter, err := gorillamux.NewRouter(doc)
+	if err != nil {
+		t.Fatalf("failed to build spec router: %v", err)
+	}
+
+	rows := [][]any{
+		{"AAPL", "Apple Inc.", "Goldman Sachs", "150.00", "180.00", "up", "upgraded by", "hold", "Neutral", "buy", "Buy", time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC), "30.00", "20.00", int32(4000)},
+		{"TSLA", "Tesla, Inc.", "UBS", "300.00", "250.00", "down", "downgraded by", "buy", "Buy", "sell", "Sell", time.Date(2025, 1, 3, 3, 4, 5, 0, time.UTC), "-50.00", "-16.67", int32(-4666)},
+	}
+	suggestions := [][]any{
+		{"brokerage", "Apex Partners", 1.25},
+		{"company", "Apple Inc.", 1.4},
+		{"company", "Applied Materials, Inc.", 1.2},
+		{"ticker", "AAPL", 0.33},
+	}
+	ruleID := uuid.MustParse("6f1c2a3e-7d4b-4e2a-9c1f-2b3d4e5f6a7b")
+	rules := [][]any{
+		{ruleID, "Downgrades to sell", []string{"AAPL", "TSLA"}, repository.NullStockActionType{StockActionType: "down", Valid: true}, repository.NullStockRatingType{StockRatingType: "sell", Valid: true}, pgtype.Float8{}, pgtype.Int4{}, true, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{uuid.New(), "Score crosses 5000", []string{}, repository.NullStockActionType{}, repository.NullStockRatingType{}, pgtype.Float8{Float64: 20, Valid: true}, pgtype.Int4{Int32: 5000, Valid: true}, true, time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)},
+	}
+	alertEvents := [][]any{
+		{uuid.New(), ruleID, "Downgrades to sell", "TSLA", "Tesla, Inc.", "UBS", "down", "buy", "sell", "300.00", "250.00", int32(-4666), "on watchlist, action down, rating to sell", time.Date(2025, 1, 3, 3, 4, 5, 0, time.UTC), time.Date(2025, 1, 3, 4, 0, 0, 0, time.UTC)},
+	}
+	webhookID := uuid.MustParse("0b5d8c1e-2f3a-4b6c-8d7e-9f0a1b2c3d4e")
+	webhookRows := [][]any{
+		{webhookID, "https://example.com/hooks/ratings", "whsec_0123", []string{"rating.down"}, true, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	deliveries := [][]any{
+		{uuid.New(), webhookID, "rating.down", "TSLA@2025-01-03T03:04:05Z", []byte(`{"type":"rating.down","ticker":"TSLA","company":"Tesla, Inc.","brokerage":"UBS","action":"down","rating_from":"buy","rating_to":"sell","target_from":300.00,"target_to":250.00,"score":-4666,"at":"2025-01-03T03:04:05Z"}`), repository.WebhookDeliveryStatusPending, int32(2), time.Date(2025, 1, 3, 4, 0, 20, 0, time.UTC), pgtype.Int4{Int32: 503, Valid: true}, pgtype.Text{String: "status code: 503", Valid: true}, time.Date(2025, 1, 3, 4, 0, 0, 0, time.UTC)},
+	}
+	runs := [][]any{
+		{uuid.New(), repository.IngestionRunSourceApi, repository.IngestionRunStatusRunning, pgtype.Text{}, time.Date(2025, 1, 4, 0, 0, 0, 0, time.UTC), pgtype.Timestamptz{}, int64(3)},
+		{uuid.New(), repository.IngestionRunSourceApi, repository.IngestionRunStatusFailed, pgtype.Text{String: "status code: 502", Valid: true}, time.Date(2025, 1, 3, 0, 0, 0, 0, time.UTC), pgtype.Timestamptz{Time: time.Date(2025, 1, 3, 0, 1, 0, 0, time.UTC), Valid: true}, int64(12)},
+	}
+	before := []any{pgtype.Text{String: "Goldman Sachs", Valid: true}, repository.NullStockActionType{StockActionType: "up", Valid: true}, repository.NullStockRatingType{StockRatingType: "buy", Valid: true}, pgtype.Text{String: "180.00", Valid: true}, pgtype.Int4{Int32: 4000, Valid: true}, pgtype.Timestamptz{Time: time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC), Valid: true}}
+	after := []any{pgtype.Text{String: "UBS", Valid: true}, repository.NullStockActionType{StockActionType: "down", Valid: true}, repository.NullStockRatingType{StockRatingType: "hold", Valid: true}, pgtype.Text{String: "150.00", Valid: true}, pgtype.Int4{Int32: -2666, Valid: true}, pgtype.Timestamptz{Time: time.Date(2025, 1, 3, 3, 4, 5, 0, time.UTC), Valid: true}}
+	none := []any{pgtype.Text{}, repository.NullStockActionType{}, repository.NullStockRatingType{}, pgtype.Text{}, pgtype.Int4{}, pgtype.Timestamptz{}}
+	diffRows := [][]any{
+		slices.Concat([]any{"AAPL", "Apple Inc."}, before, after),
+		slices.Concat([]any{"NVDA", "NVIDIA Corporation"}, none, after),
+		slices.Concat([]any{"TSLA", "Tesla, Inc."}, before, none),
+	}
+	feed := [][]any{
+		{int64(41), "AAPL", "Apple Inc.", "Goldman Sachs", "150.00", "180.00", repository.StockActionTypeUp, "upgraded by", repository.StockRatingTypeHold, "Neutral", repository.StockRatingTypeBuy, "Buy", time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC), time.Date(2025, 1, 4, 0, 0, 1, 0, time.UTC)},
+		{int64(42), "TSLA", "Tesla, Inc.", "UBS", "300.00", "250.00", repository.StockActionTypeDown, "downgraded by", repository.StockRatingTypeBuy, "Buy", repository.StockRatingTypeSell, "Sell", time.Date(2025, 1, 1, 3, 4, 5, 0, time.UTC), time.Date(2025, 1, 4, 0, 0, 1, 0, time.UTC)},
+	}
+	cases := []struct {
+		name   string
+		db     *fakeDB
+		target string
+		status int
+	}{
+		{"ping", &fakeDB{}, "/ping/", http.StatusOK},
+		{"list", &fakeDB{rows: rows}, "/v1/stock_ratings/?sort_by=score&sort_order=desc&limit=2", http.StatusOK},
+		{"empty list", &fakeDB{}, "/v1/stock_ratings/?ticker_like=ZZZZ", http.StatusOK},
+		{"invalid offset", &fakeDB{}, "/v1/stock_ratings/?offset=abc", http.StatusBadRequest},
+		{"database error", &fakeDB{err: errors.New("connection refused")}, "/v1/stock_ratings/", http.StatusInternalServerError},
+		{"list as of", &fakeDB{rows: rows}, "/v1/stock_ratings/?as_of=2025-01-02T00:00:00Z", http.StatusOK},
+		{"invalid as of", &fakeDB{}, "/v1/stock_ratings/?as_of=yesterday", http.StatusBadRequest},
+		{"list v2", &fakeDB{rows: rows}, "/v2/stock_ratings/?sort_by=target_delta&sort_order=asc", http.StatusOK},
+		{"v2 invalid limit", &fakeDB{}, "/v2/stock_ratings/?limit=ten", http.StatusBadRequest},
+		{"detail v2", &fakeDB{rows: rows[:1]}, "/v2/stock_ratings/AAPL", http.StatusOK},
+		{"detail v2 not found", &fakeDB{}, "/v2/stock_ratings/ZZZZ", http.StatusNotFound},
+		{"detail v2 as of", &fakeDB{rows: rows[:1]}, "/v2/stock_ratings/AAPL?as_of=2025-01-02T00:00:00%2B01:00", http.StatusOK},
+		{"detail v2 invalid as of", &fakeDB{}, "/v2/stock_ratings/AAPL?as_of=2025-01-02", http.StatusBadRequest},
+		{"export csv", &fakeDB{rows: rows}, "/v1/stock_ratings/export?format=csv&columns=ticker,score", http.StatusOK},
+		{"export ndjson", &fakeDB{rows: rows}, "/v1/stock_ratings/export?format=ndjson", http.StatusOK},
+		{"export xlsx", &fakeDB{rows: rows}, "/v1/stock_ratings/export?format=xlsx", http.StatusOK},
+		{"export unknown column", &fakeDB{}, "/v1/stock_ratings/export?columns=ticker,nope", http.StatusBadRequest},
+		{"export database error", &fakeDB{err: errors.New("connection refused")}, "/v1/stock_ratings/export", http.StatusInternalServerError},
+		{"diff", &fakeDB{rows: diffRows}, "/v1/stock_ratings/diff?from=2025-01-01T00:00:00Z&to=2025-01-04T00:00:00Z", http.StatusOK},
+		{"diff no changes", &fakeDB{}, "/v1/stock_ratings/diff?from=2025-01-01T00:00:00Z&to=2025-01-01T00:00:00Z", http.StatusOK},
+		{"diff invalid from", &fakeDB{}, "/v1/stock_ratings/diff?from=yesterday&to=2025-01-04T00:00:00Z", http.StatusBadRequest},
+		{"diff missing to", &fakeDB{}, "/v1/stock_ratings/diff?from=2025-01-01T00:00:00Z", http.StatusBadRequest},
+		{"diff unknown run", &fakeDB{}, "/v1/stock_ratings/diff?from=" + uuid.NewString() + "&to=2025-01-04T00:00:00Z", http.StatusNotFound},
+		{"search", &fakeDB{rows: suggestions}, "/v1/search?q=ap&limit=3", http.StatusOK},
+		{"search no results", &fakeDB{}, "/v1/search?q=zzzz", http.StatusOK},
+		{"search missing query", &fakeDB{}, "/v1/search?q=%20", http.StatusBadRequest},
+		{"search invalid limit", &fakeDB{}, "/v1/search?q=ap&limit=100", http.StatusBadRequest},
+		{"alerts", &fakeDB{rows: alertEvents}, "/v1/alerts/?ticker=tsla", http.StatusOK},
+		{"alerts invalid limit", &fakeDB{}, "/v1/alerts/?limit=all", http.StatusBadRequest},
+		{"alert rules", &fakeDB{rows: rules}, "/v1/alerts/rules/", http.StatusOK},
+		{"alert rules database error", &fakeDB{err: errors.New("connection refused")}, "/v1/alerts/rules/", http.StatusInternalServerError},
+		{"webhooks", &fakeDB{rows: webhookRows}, "/v1/webhooks/", http.StatusOK},
+		{"webhook deliveries", &fakeDB{rows: deliveries}, "/v1/webhooks/" + webhookID.String() + "/deliveries/?status=pending", http.StatusOK},
+		{"webhook deliveries unknown status", &fakeDB{}, "/v1/webhooks/" + webhookID.String() + "/deliveries/?status=lost", http.StatusBadRequest},
+		{"webhook deliveries invalid id", &fakeDB{}, "/v1/webhooks/nope/deliveries/", http.StatusBadRequest},
+		{"stream invalid last event id", &fakeDB{}, "/v1/stream?last_event_id=abc", http.StatusBadRequest},
+		{"stream websocket invalid last event id", &fakeDB{}, "/v1/stream/ws?last_event_id=abc", http.StatusBadRequest},
+		{"ingestion runs", &fakeDB{rows: runs}, "/v1/ingestion/runs/?limit=2", http.StatusOK},
+		{"ingestion runs invalid limit", &fakeDB{}, "/v1/ingestion/runs/?limit=0", http.StatusBadRequest},
+		{"events", &fakeDB{rows: feed}, "/v1/events?since_cursor=0000000000000028&limit=1", http.StatusOK},
+		{"events from the start", &fakeDB{}, "/v1/events", http.StatusOK},
+		{"events invalid cursor", &fakeDB{}, "/v1/events?since_cursor=abc", http.StatusBadRequest},
+		{"events invalid limit", &fakeDB{}, "/v1/events?limit=5000", http.StatusBadRequest},
+		{"graphql", &fakeDB{rows: rows}, "/graphql?query=" + url.QueryEscape("{tickers(limit: 2) { ticker events { score ratingTo at } }}"), http.StatusOK},
+		{"graphql invalid query", &fakeDB{}, "/graphql?query=" + url.QueryEscape("{nope}"), http.StatusOK},
+		{"graphql missing query", &fakeDB{}, "/graphql", http.StatusBadRequest},
+		{"graphql invalid variables", &fakeDB{}, "/graphql?query=%7Bratings%7Bscore%7D%7D&variables=nope", http.StatusBadRequest},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tc.target, nil)
+			rec := httptest.NewRecorder()
+			newRouter(tc.db).ServeHTTP(rec, req)
+			if rec.Code != tc.status {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tc.status, rec.Body.String())
+			}
+
+			route, pathParams, err := specRouter.FindRoute(req)
+			if err != nil {
+				t.Fatalf("request not in the spec: %v", err)
+			}
+			input := &openapi3filter.ResponseValidationInput{
+				RequestValidationInput: &openapi3filter.RequestValidationInput{
+					Request:    req,
+					PathParams: pathParams,
+					Route:      route,
+				},
+				Status:  rec.Code,
+				Header:  rec.Header(),
+				Body:    io.NopCloser(rec.Body),
+				Options: &openapi3filter.Options{IncludeResponseStatus: true},
+			}
+			if err := openapi3filter.ValidateResponse(context.Background(), input); err != nil {
+				t.Fatalf("response drifted from the spec: %v", err)
+			}
+		})
+	}
+}
+
+func newRouter(db *fakeDB) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	repo := repository.New(db)
+	routes.GetRoutes(router, routes.Handlers{
+		StockRatings: stockratings.NewHandler(stockratings.NewService(repo)),
+		Search:       search.NewHandler(search.NewService(repo)),
+		Alerts:       alerts.NewHandler(alerts.NewService(repo)),
+		Webhooks:     webhooks.NewHandler(webhooks.NewService(repo)),
+		Stream:       stream.NewHandler(stream.NewBroker(repo)),
+		GraphQL:      graphql.NewHandler(graphql.NewService(repo)),
+		Ingestion:    ingestion.NewHandler(ingestion.NewService(repo)),
+		Events:       events.NewHandler(events.NewService(repo)),
+	})
+	return router
+}
+
+// FAKE DATABASE ===================================================================================
+// Answers every query with the same rows, scanned in the column order of the sqlc query
+
+type fakeDB struct {
+	rows [][]any
+	err  error
+}
+
+func (db *fakeDB) Exec(context.Context, string, ...interface{}) (pgconn.CommandTag, error) {
+	return pgconn.CommandTag{}, db.err
+}
+
+func (db *fakeDB) Query(context.Context, string, ...interface{}) (pgx.Rows, error) {
+	if db.err != nil {
+		return nil, db.err
+	}
+	return &fakeRows{rows: db.rows, index: -1}, nil
+}
+
+func (db *fakeDB) QueryRow(context.Context, string, ...interface{}) pgx.Row {
+	rows := &fakeRows{rows: db.rows, index: -1}
+	rows.Next()
+	return rows
+}
+
+func (db *fakeDB) CopyFrom(context.Context, pgx.Identifier, []string, pgx.CopyFromSource) (int64, error) {
+	return 0, db.err
+}
+
+type fakeRows struct {
+	rows  [][]any
+	index int
+}
+
+func (r *fakeRows) Close()                                       {}
+func (r *fakeRows) Err() error                                   { return nil }
+func (r *fakeRows) CommandTag() pgconn.CommandTag                { return pgconn.CommandTag{} }
+func (r *fakeRows) FieldDescriptions() []pgconn.FieldDescription { return nil }
+func (r *fakeRows) RawValues() [][]byte                          { return nil }
+func (r *fakeRows) Conn() *pgx.Conn                              { return nil }
+
+func (r *fakeRows) Next() bool {
+	r.index++
+	return r.index < len(r.rows)
+}
+
+func (r *fakeRows) Values() ([]any, error) {
+	return r.rows[r.index], nil
+}
+
+func (r *fakeRows) Scan(dest ...any) error {
+	if r.index >= len(r.rows) {
+		return pgx.ErrNoRows
+	}
+	row := r.rows[r.index]
+	if len(dest) != len(row) {
+		return fmt.Errorf("scan of %d columns into %d destinations", len(row), len(dest))
+	}
+	for i, d := range dest {
+		target := reflect.ValueOf(d).Elem()
+		target.Set(reflect.ValueOf(row[i]).Convert(target.Type()))
+	}
+	return nil
+}
@@ -0,0 +1,4 @@
+package repository
+
+// Text of the list query, for the benchmarks to explain its plans
+const GetStockRatingsQuery = getStockRatings
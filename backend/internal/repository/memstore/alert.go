@@ -0,0 +1,166 @@
+package memstore
+
+import (
+	"backend/internal/repository"
+	"cmp"
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// ALERTS ==========================================================================================
+
+// Rules -------------------------------------------------------------------------------------------
+
+func (s *Store) CreateAlertRule(ctx context.Context, arg repository.CreateAlertRuleParams) (repository.AlertRule, error) {
+	if arg.Tickers == nil {
+		return repository.AlertRule{}, notNullViolation("alert_rule", "tickers")
+	}
+	if arg.Action.Valid {
+		if err := checkAction(arg.Action.StockActionType); err != nil {
+			return repository.AlertRule{}, err
+		}
+	}
+	if arg.RatingTo.Valid {
+		if err := checkRating(arg.RatingTo.StockRatingType); err != nil {
+			return repository.AlertRule{}, err
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rule := repository.AlertRule{
+		ID:                 uuid.New(),
+		Name:               arg.Name,
+		Tickers:            slices.Clone(arg.Tickers),
+		Action:             arg.Action,
+		RatingTo:           arg.RatingTo,
+		MinTargetChangePct: arg.MinTargetChangePct,
+		ScoreCrosses:       arg.ScoreCrosses,
+		Enabled:            true,
+		CreatedAt:          s.timestamp(),
+	}
+	s.alertRules = append(s.alertRules, rule)
+	return rule, nil
+}
+
+// The rules matching the predicate, the oldest first
+func (s *Store) listAlertRules(match func(repository.AlertRule) bool) []repository.AlertRule {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var items []repository.AlertRule
+	for _, r := range s.alertRules {
+		if match(r) {
+			items = append(items, r)
+		}
+	}
+	slices.SortStableFunc(items, func(a, b repository.AlertRule) int { return a.CreatedAt.Compare(b.CreatedAt) })
+	return items
+}
+
+func (s *Store) ListAlertRules(ctx context.Context) ([]repository.AlertRule, error) {
+	return s.listAlertRules(func(repository.AlertRule) bool { return true }), nil
+}
+
+func (s *Store) ListEnabledAlertRules(ctx context.Context) ([]repository.AlertRule, error) {
+	return s.listAlertRules(func(r repository.AlertRule) bool { return r.Enabled }), nil
+}
+
+func (s *Store) DeleteAlertRule(ctx context.Context, id uuid.UUID) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	before := len(s.alertRules)
+	s.alertRules = slices.DeleteFunc(s.alertRules, func(r repository.AlertRule) bool { return r.ID == id })
+	if len(s.alertRules) == before {
+		return 0, nil
+	}
+	// ON DELETE CASCADE
+	s.alertEvents = slices.DeleteFunc(s.alertEvents, func(e repository.AlertEvent) bool { return e.RuleID == id })
+	return 1, nil
+}
+
+// Events ------------------------------------------------------------------------------------------
+
+func (s *Store) AddAlertEvent(ctx context.Context, arg repository.AddAlertEventParams) (int64, error) {
+	for _, err := range []error{checkAction(arg.Action), checkRating(arg.RatingFrom), checkRating(arg.RatingTo)} {
+		if err != nil {
+			return 0, err
+		}
+	}
+	targetFrom, err := toColumn(arg.TargetFrom, 10, 2)
+	if err != nil {
+		return 0, err
+	}
+	targetTo, err := toColumn(arg.TargetTo, 10, 2)
+	if err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if find(s.alertRules, func(r repository.AlertRule) bool { return r.ID == arg.RuleID }) < 0 {
+		return 0, foreignKeyViolation("alert_event", "alert_event_rule_id_fkey")
+	}
+	if !hasRun(s.ingestionRuns, arg.RunID) {
+		return 0, foreignKeyViolation("alert_event", "alert_event_run_id_fkey")
+	}
+	at := arg.At.Truncate(timestampPrecision)
+	conflict := func(e repository.AlertEvent) bool {
+		return e.RuleID == arg.RuleID && e.Ticker == arg.Ticker && e.At.Equal(at)
+	}
+	if find(s.alertEvents, conflict) >= 0 {
+		return 0, nil
+	}
+	s.alertEvents = append(s.alertEvents, repository.AlertEvent{
+		ID:          uuid.New(),
+		RuleID:      arg.RuleID,
+		RunID:       arg.RunID,
+		Ticker:      arg.Ticker,
+		Company:     arg.Company,
+		Brokerage:   arg.Brokerage,
+		Action:      arg.Action,
+		RatingFrom:  arg.RatingFrom,
+		RatingTo:    arg.RatingTo,
+		TargetFrom:  targetFrom,
+		TargetTo:    targetTo,
+		Score:       arg.Score,
+		Reason:      arg.Reason,
+		At:          at,
+		TriggeredAt: s.timestamp(),
+	})
+	return 1, nil
+}
+
+func (s *Store) ListAlertEvents(ctx context.Context, arg repository.ListAlertEventsParams) ([]repository.ListAlertEventsRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var rows []repository.ListAlertEventsRow
+	for _, e := range s.alertEvents {
+		if arg.Ticker.Valid && e.Ticker != arg.Ticker.String {
+			continue
+		}
+		rule := s.alertRules[find(s.alertRules, func(r repository.AlertRule) bool { return r.ID == e.RuleID })]
+		rows = append(rows, repository.ListAlertEventsRow{
+			ID:          e.ID,
+			RuleID:      e.RuleID,
+			RuleName:    rule.Name,
+			Ticker:      e.Ticker,
+			Company:     e.Company,
+			Brokerage:   e.Brokerage,
+			Action:      e.Action,
+			RatingFrom:  e.RatingFrom,
+			RatingTo:    e.RatingTo,
+			TargetFrom:  format(toRat(e.TargetFrom), 2),
+			TargetTo:    format(toRat(e.TargetTo), 2),
+			Score:       e.Score,
+			Reason:      e.Reason,
+			At:          e.At,
+			TriggeredAt: e.TriggeredAt,
+		})
+	}
+	slices.SortStableFunc(rows, func(a, b repository.ListAlertEventsRow) int {
+		return cmp.Or(b.TriggeredAt.Compare(a.TriggeredAt), strings.Compare(a.Ticker, b.Ticker))
+	})
+	return page(rows, arg.Offset, arg.Limit), nil
+}
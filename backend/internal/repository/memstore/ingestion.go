@@ -0,0 +1,164 @@
+package memstore
+
+import (
+	"backend/internal/repository"
+	"context"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// INGESTION =======================================================================================
+
+func (s *Store) CreateIngestionRun(ctx context.Context, source repository.IngestionRunSource) (uuid.UUID, error) {
+	if source != repository.IngestionRunSourceApi && source != repository.IngestionRunSourceReplay {
+		return uuid.Nil, invalidEnumValue("ingestion_run_source", string(source))
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	run := repository.IngestionRun{
+		ID:        uuid.New(),
+		Source:    source,
+		Status:    repository.IngestionRunStatusRunning,
+		StartedAt: s.timestamp(),
+	}
+	s.ingestionRuns = append(s.ingestionRuns, run)
+	return run.ID, nil
+}
+
+func (s *Store) FinishIngestionRun(ctx context.Context, arg repository.FinishIngestionRunParams) error {
+	switch arg.Status {
+	case repository.IngestionRunStatusRunning, repository.IngestionRunStatusSucceeded, repository.IngestionRunStatusFailed:
+	default:
+		return invalidEnumValue("ingestion_run_status", string(arg.Status))
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := find(s.ingestionRuns, func(r repository.IngestionRun) bool { return r.ID == arg.ID })
+	if i < 0 {
+		return nil
+	}
+	s.ingestionRuns[i].Status = arg.Status
+	s.ingestionRuns[i].Error = arg.Error
+	s.ingestionRuns[i].FinishedAt = pgtype.Timestamptz{Time: s.timestamp(), Valid: true}
+	return nil
+}
+
+func (s *Store) AddIngestionRunTickers(ctx context.Context, arg repository.AddIngestionRunTickersParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !hasRun(s.ingestionRuns, arg.RunID) {
+		return foreignKeyViolation("ingestion_run_ticker", "ingestion_run_ticker_run_id_fkey")
+	}
+	for _, ticker := range arg.Tickers {
+		t := repository.IngestionRunTicker{RunID: arg.RunID, Ticker: ticker}
+		if !slices.Contains(s.runTickers, t) {
+			s.runTickers = append(s.runTickers, t)
+		}
+	}
+	return nil
+}
+
+// The runs, the most recent first
+func (s *Store) sortedIngestionRuns() []repository.IngestionRun {
+	runs := slices.Clone(s.ingestionRuns)
+	slices.SortStableFunc(runs, func(a, b repository.IngestionRun) int { return b.StartedAt.Compare(a.StartedAt) })
+	return runs
+}
+
+func (s *Store) GetIngestionRun(ctx context.Context, id uuid.UUID) (repository.IngestionRun, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := find(s.ingestionRuns, func(r repository.IngestionRun) bool { return r.ID == id })
+	if i < 0 {
+		return repository.IngestionRun{}, pgx.ErrNoRows
+	}
+	return s.ingestionRuns[i], nil
+}
+
+func (s *Store) GetLatestIngestionRun(ctx context.Context) (repository.IngestionRun, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, r := range s.sortedIngestionRuns() {
+		if r.Source == repository.IngestionRunSourceApi && r.Status == repository.IngestionRunStatusSucceeded {
+			return r, nil
+		}
+	}
+	return repository.IngestionRun{}, pgx.ErrNoRows
+}
+
+func (s *Store) ListIngestionRuns(ctx context.Context, limit int32) ([]repository.ListIngestionRunsRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var items []repository.ListIngestionRunsRow
+	for _, r := range page(s.sortedIngestionRuns(), 0, limit) {
+		var pages int64
+		for _, p := range s.rawPages {
+			if p.RunID == r.ID {
+				pages++
+			}
+		}
+		items = append(items, repository.ListIngestionRunsRow{
+			ID:         r.ID,
+			Source:     r.Source,
+			Status:     r.Status,
+			Error:      r.Error,
+			StartedAt:  r.StartedAt,
+			FinishedAt: r.FinishedAt,
+			Pages:      pages,
+		})
+	}
+	return items, nil
+}
+
+// Archive -----------------------------------------------------------------------------------------
+
+func (s *Store) AddRawPage(ctx context.Context, arg repository.AddRawPageParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !hasRun(s.ingestionRuns, arg.RunID) {
+		return foreignKeyViolation("raw_page", "raw_page_run_id_fkey")
+	}
+	if find(s.rawPages, func(p repository.RawPage) bool { return p.RunID == arg.RunID && p.Page == arg.Page }) >= 0 {
+		return uniqueViolation("raw_page_pkey")
+	}
+	if arg.Payload == nil {
+		return notNullViolation("raw_page", "payload")
+	}
+	s.rawPages = append(s.rawPages, repository.RawPage{
+		RunID:     arg.RunID,
+		Page:      arg.Page,
+		Cursor:    arg.Cursor,
+		FetchedAt: arg.FetchedAt.Truncate(timestampPrecision),
+		Payload:   slices.Clone(arg.Payload),
+	})
+	return nil
+}
+
+func (s *Store) GetRawPage(ctx context.Context, arg repository.GetRawPageParams) (repository.RawPage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := find(s.rawPages, func(p repository.RawPage) bool { return p.RunID == arg.RunID && p.Page == arg.Page })
+	if i < 0 {
+		return repository.RawPage{}, pgx.ErrNoRows
+	}
+	return s.rawPages[i], nil
+}
+
+// DATASET VERSION =================================================================================
+
+func (s *Store) BumpDatasetVersion(ctx context.Context) (repository.BumpDatasetVersionRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.datasetVersion.Version++
+	s.datasetVersion.UpdatedAt = s.timestamp()
+	return repository.BumpDatasetVersionRow{Version: s.datasetVersion.Version, UpdatedAt: s.datasetVersion.UpdatedAt}, nil
+}
+
+func (s *Store) GetDatasetVersion(ctx context.Context) (repository.GetDatasetVersionRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return repository.GetDatasetVersionRow{Version: s.datasetVersion.Version, UpdatedAt: s.datasetVersion.UpdatedAt}, nil
+}
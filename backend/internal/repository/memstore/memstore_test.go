@@ -0,0 +1,11 @@
+package memstore
+
+import (
+	"backend/internal/repository"
+	"backend/internal/repository/repositorytest"
+	"testing"
+)
+
+func TestConformance(t *testing.T) {
+	repositorytest.Run(t, func(t *testing.T) repository.Repository { return New() })
+}
@@ -0,0 +1,78 @@
+package memstore
+
+import (
+	"math/big"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// DECIMALS ========================================================================================
+// NUMERIC columns and expressions computed with exact rationals, rounded as the database rounds
+
+var ten = big.NewInt(10)
+
+func pow10(n int32) *big.Int {
+	return new(big.Int).Exp(ten, big.NewInt(int64(n)), nil)
+}
+
+func toRat(n pgtype.Numeric) *big.Rat {
+	if !n.Valid || n.Int == nil {
+		return new(big.Rat)
+	}
+	r := new(big.Rat).SetInt(n.Int)
+	if n.Exp > 0 {
+		return r.Mul(r, new(big.Rat).SetInt(pow10(n.Exp)))
+	}
+	return r.Quo(r, new(big.Rat).SetInt(pow10(-n.Exp)))
+}
+
+// Scaled integer of r with scale decimals, rounded half away from zero as a NUMERIC cast, or
+// truncated toward zero as TRUNC
+func scaled(r *big.Rat, scale int32, truncate bool) *big.Int {
+	x := new(big.Rat).Mul(r, new(big.Rat).SetInt(pow10(scale)))
+	q, m := new(big.Int).QuoRem(x.Num(), x.Denom(), new(big.Int))
+	if truncate || m.Sign() == 0 {
+		return q
+	}
+	// Away from zero when the remainder is at least half of the denominator
+	if new(big.Int).Mul(new(big.Int).Abs(m), big.NewInt(2)).Cmp(x.Denom()) >= 0 {
+		q.Add(q, big.NewInt(int64(x.Sign())))
+	}
+	return q
+}
+
+// Value stored in a NUMERIC(precision, scale) column
+func toColumn(n pgtype.Numeric, precision int32, scale int32) (pgtype.Numeric, error) {
+	if !n.Valid {
+		return n, nil
+	}
+	return fromRat(toRat(n), precision, scale)
+}
+
+// Value of an expression stored in a NUMERIC(precision, scale) column
+func fromRat(r *big.Rat, precision int32, scale int32) (pgtype.Numeric, error) {
+	i := scaled(r, scale, false)
+	if new(big.Int).Abs(i).Cmp(pow10(precision)) >= 0 {
+		return pgtype.Numeric{}, numericOverflow
+	}
+	return pgtype.Numeric{Int: i, Exp: -scale, Valid: true}, nil
+}
+
+// Text of a NUMERIC with scale decimals, as a ::text cast prints it
+func format(r *big.Rat, scale int32) string {
+	i := scaled(r, scale, false)
+	neg := i.Sign() < 0
+	digits := new(big.Int).Abs(i).String()
+	if len(digits) <= int(scale) {
+		digits = strings.Repeat("0", int(scale)-len(digits)+1) + digits
+	}
+	out := digits
+	if scale > 0 {
+		out = digits[:len(digits)-int(scale)] + "." + digits[len(digits)-int(scale):]
+	}
+	if neg {
+		out = "-" + out
+	}
+	return out
+}
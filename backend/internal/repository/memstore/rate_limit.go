@@ -0,0 +1,62 @@
+package memstore
+
+import (
+	"backend/internal/repository"
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// RATE LIMIT ======================================================================================
+// GCRA buckets, stored as the theoretical arrival time of the next request
+
+func (s *Store) TakeRateLimitToken(ctx context.Context, arg repository.TakeRateLimitTokenParams) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.timestamp()
+	interval := time.Duration(arg.IntervalUs) * time.Microsecond
+	window := time.Duration(arg.WindowUs) * time.Microsecond
+
+	tat, ok := s.rateLimitBuckets[arg.Key]
+	if !ok {
+		tat = now.Add(interval)
+	} else {
+		tat = later(tat, now).Add(interval)
+		// The update is skipped, no row is returned
+		if tat.After(now.Add(window)) {
+			return 0, pgx.ErrNoRows
+		}
+	}
+	s.rateLimitBuckets[arg.Key] = tat
+	return tat.Sub(now).Microseconds(), nil
+}
+
+func (s *Store) GetRateLimitBucket(ctx context.Context, key string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tat, ok := s.rateLimitBuckets[key]
+	if !ok {
+		return 0, pgx.ErrNoRows
+	}
+	return tat.Sub(s.timestamp()).Microseconds(), nil
+}
+
+func (s *Store) DeleteFullRateLimitBuckets(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.timestamp()
+	for key, tat := range s.rateLimitBuckets {
+		if tat.Before(now) {
+			delete(s.rateLimitBuckets, key)
+		}
+	}
+	return nil
+}
+
+func later(a time.Time, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
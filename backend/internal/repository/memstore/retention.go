@@ -0,0 +1,171 @@
+package memstore
+
+import (
+	"backend/internal/repository"
+	"bytes"
+	"cmp"
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// RETENTION =======================================================================================
+
+type monthlyKey struct {
+	ticker string
+	month  time.Time
+}
+
+// First day of the month of t in UTC, as date_trunc('month', at AT TIME ZONE 'UTC')::DATE
+func monthOf(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// Events before the cutoff and recorded before recordedBefore, but the latest of each ticker which
+// is kept
+func (s *Store) expiredEvents(before time.Time, recordedBefore time.Time) []repository.StockRatingEvent {
+	latest := map[string]repository.StockRatingEvent{}
+	for _, e := range s.stockRatingEvents {
+		if l, ok := latest[e.Ticker]; !ok || after(e, l) {
+			latest[e.Ticker] = e
+		}
+	}
+	var expired []repository.StockRatingEvent
+	for _, e := range s.stockRatingEvents {
+		if e.At.Before(before) && e.RecordedAt.Before(recordedBefore) && latest[e.Ticker].ID != e.ID {
+			expired = append(expired, e)
+		}
+	}
+	return expired
+}
+
+func (s *Store) GetExpiredStockRatingEvents(ctx context.Context, arg repository.GetExpiredStockRatingEventsParams) ([]repository.GetExpiredStockRatingEventsRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	events := map[time.Time]int64{}
+	tickers := map[time.Time]map[string]bool{}
+	for _, e := range s.expiredEvents(arg.Before, arg.RecordedBefore) {
+		m := monthOf(e.At)
+		if tickers[m] == nil {
+			tickers[m] = map[string]bool{}
+		}
+		events[m]++
+		tickers[m][e.Ticker] = true
+	}
+	var items []repository.GetExpiredStockRatingEventsRow
+	for m, n := range events {
+		items = append(items, repository.GetExpiredStockRatingEventsRow{
+			Month:   pgtype.Date{Time: m, Valid: true},
+			Events:  n,
+			Tickers: int64(len(tickers[m])),
+		})
+	}
+	slices.SortFunc(items, func(a, b repository.GetExpiredStockRatingEventsRow) int { return a.Month.Time.Compare(b.Month.Time) })
+	return items, nil
+}
+
+// Summary of the events of a ticker and month, sorted by time and brokerage
+func summarize(key monthlyKey, events []repository.StockRatingEvent) repository.StockRatingMonthly {
+	first, last := events[0], events[len(events)-1]
+	m := repository.StockRatingMonthly{
+		Ticker:          key.ticker,
+		Month:           pgtype.Date{Time: key.month, Valid: true},
+		Events:          int64(len(events)),
+		FirstTargetFrom: first.TargetFrom,
+		LastTargetTo:    last.TargetTo,
+		MinTarget:       least(first.TargetFrom, first.TargetTo),
+		MaxTarget:       greatest(first.TargetFrom, first.TargetTo),
+		LastRatingTo:    last.RatingTo,
+		FirstAt:         first.At,
+		LastAt:          last.At,
+	}
+	for _, e := range events {
+		switch e.Action {
+		case repository.StockActionTypeUp:
+			m.Upgrades++
+		case repository.StockActionTypeDown:
+			m.Downgrades++
+		}
+		m.MinTarget = least(m.MinTarget, least(e.TargetFrom, e.TargetTo))
+		m.MaxTarget = greatest(m.MaxTarget, greatest(e.TargetFrom, e.TargetTo))
+	}
+	return m
+}
+
+// Merge the summary of a later batch into a summary, as ON CONFLICT DO UPDATE does
+func merge(m repository.StockRatingMonthly, excluded repository.StockRatingMonthly) repository.StockRatingMonthly {
+	m.Events += excluded.Events
+	m.Upgrades += excluded.Upgrades
+	m.Downgrades += excluded.Downgrades
+	if excluded.FirstAt.Before(m.FirstAt) {
+		m.FirstTargetFrom, m.FirstAt = excluded.FirstTargetFrom, excluded.FirstAt
+	}
+	if !excluded.LastAt.Before(m.LastAt) {
+		m.LastTargetTo, m.LastRatingTo, m.LastAt = excluded.LastTargetTo, excluded.LastRatingTo, excluded.LastAt
+	}
+	m.MinTarget = least(m.MinTarget, excluded.MinTarget)
+	m.MaxTarget = greatest(m.MaxTarget, excluded.MaxTarget)
+	return m
+}
+
+func least(a, b pgtype.Numeric) pgtype.Numeric {
+	if toRat(b).Cmp(toRat(a)) < 0 {
+		return b
+	}
+	return a
+}
+
+func greatest(a, b pgtype.Numeric) pgtype.Numeric {
+	if toRat(b).Cmp(toRat(a)) > 0 {
+		return b
+	}
+	return a
+}
+
+func (s *Store) RollUpStockRatingEvents(ctx context.Context, arg repository.RollUpStockRatingEventsParams) (repository.RollUpStockRatingEventsRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	expired := s.expiredEvents(arg.Before, arg.RecordedBefore)
+	slices.SortFunc(expired, func(a, b repository.StockRatingEvent) int {
+		return cmp.Or(a.At.Compare(b.At), bytes.Compare(a.ID[:], b.ID[:]))
+	})
+	expired = page(expired, 0, arg.Lim)
+	s.stockRatingEvents = slices.DeleteFunc(s.stockRatingEvents, func(e repository.StockRatingEvent) bool {
+		return slices.ContainsFunc(expired, func(x repository.StockRatingEvent) bool { return x.ID == e.ID })
+	})
+
+	groups := map[monthlyKey][]repository.StockRatingEvent{}
+	for _, e := range expired {
+		key := monthlyKey{e.Ticker, monthOf(e.At)}
+		groups[key] = append(groups[key], e)
+	}
+	for key, events := range groups {
+		slices.SortFunc(events, func(a, b repository.StockRatingEvent) int {
+			return cmp.Or(a.At.Compare(b.At), strings.Compare(a.Brokerage, b.Brokerage))
+		})
+		m := summarize(key, events)
+		if existing, ok := s.monthly[key]; ok {
+			m = merge(existing, m)
+		}
+		s.monthly[key] = m
+	}
+	return repository.RollUpStockRatingEventsRow{Events: int64(len(expired)), Summaries: int64(len(groups))}, nil
+}
+
+func (s *Store) GetStockRatingMonthly(ctx context.Context, ticker string) ([]repository.StockRatingMonthly, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var items []repository.StockRatingMonthly
+	for key, m := range s.monthly {
+		if key.ticker == ticker {
+			items = append(items, m)
+		}
+	}
+	slices.SortFunc(items, func(a, b repository.StockRatingMonthly) int { return a.Month.Time.Compare(b.Month.Time) })
+	return items, nil
+}
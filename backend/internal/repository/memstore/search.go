@@ -0,0 +1,90 @@
+package memstore
+
+import (
+	"backend/internal/repository"
+	"cmp"
+	"context"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// SEARCH ==========================================================================================
+// Trigram similarity of pg_trgm, the % operator matching above its default threshold
+
+const similarityThreshold = 0.3
+
+// Trigrams of the words of s, lowercased and padded with two spaces before and one after
+func trigrams(s string) map[string]bool {
+	set := map[string]bool{}
+	words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, w := range words {
+		padded := []rune("  " + w + " ")
+		for i := 0; i+3 <= len(padded); i++ {
+			set[string(padded[i:i+3])] = true
+		}
+	}
+	return set
+}
+
+func similarity(a string, b string) float64 {
+	ta, tb := trigrams(a), trigrams(b)
+	shared := 0
+	for t := range ta {
+		if tb[t] {
+			shared++
+		}
+	}
+	all := len(ta) + len(tb) - shared
+	if all == 0 {
+		return 0
+	}
+	return float64(shared) / float64(all)
+}
+
+func (s *Store) Search(ctx context.Context, arg repository.SearchParams) ([]repository.SearchRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	kinds := []struct {
+		kind   string
+		value  func(repository.StockRating) string
+		anchor string
+	}{
+		{"ticker", func(r repository.StockRating) string { return r.Ticker }, ""},
+		{"company", func(r repository.StockRating) string { return r.Company }, "%"},
+		{"brokerage", func(r repository.StockRating) string { return r.Brokerage }, "%"},
+	}
+	var items []repository.SearchRow
+	for _, k := range kinds {
+		seen := map[string]bool{}
+		var ranked []repository.SearchRow
+		for _, r := range s.stockRatings {
+			value := k.value(r)
+			if seen[value] {
+				continue
+			}
+			sim := similarity(value, arg.Q)
+			if sim < similarityThreshold && !ilike(value, k.anchor+arg.Prefix+"%") {
+				continue
+			}
+			seen[value] = true
+			rank := sim
+			switch {
+			case ilike(value, arg.Prefix+"%"):
+				rank += 1
+			case ilike(value, "% "+arg.Prefix+"%"):
+				rank += 0.5
+			}
+			ranked = append(ranked, repository.SearchRow{Kind: k.kind, Value: value, Rank: rank})
+		}
+		slices.SortFunc(ranked, func(a, b repository.SearchRow) int {
+			return cmp.Or(cmp.Compare(b.Rank, a.Rank), strings.Compare(a.Value, b.Value))
+		})
+		items = append(items, ranked[:min(len(ranked), max(int(arg.Limit), 0))]...)
+	}
+	// ORDER BY kind
+	slices.SortStableFunc(items, func(a, b repository.SearchRow) int { return strings.Compare(a.Kind, b.Kind) })
+	return items, nil
+}
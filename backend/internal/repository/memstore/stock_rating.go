@@ -0,0 +1,931 @@
+package memstore
+
+import (
+	"backend/internal/repository"
+	"cmp"
+	"context"
+	"math/big"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// STOCK RATINGS ===================================================================================
+
+// Values of the enums, in the order they were declared, which is the order they sort in
+var stockRatingTypes = []repository.StockRatingType{
+	repository.StockRatingTypeBuy,
+	repository.StockRatingTypeHold,
+	repository.StockRatingTypeSell,
+	repository.StockRatingTypePending,
+}
+
+var stockActionTypes = []repository.StockActionType{
+	repository.StockActionTypeUp,
+	repository.StockActionTypeDown,
+	repository.StockActionTypeReiterated,
+}
+
+func checkRating(r repository.StockRatingType) error {
+	if !slices.Contains(stockRatingTypes, r) {
+		return invalidEnumValue("stock_rating_type", string(r))
+	}
+	return nil
+}
+
+func checkAction(a repository.StockActionType) error {
+	if !slices.Contains(stockActionTypes, a) {
+		return invalidEnumValue("stock_action_type", string(a))
+	}
+	return nil
+}
+
+// A row as the stock_rating table stores it, with its targets rounded to NUMERIC(10,2). The
+// computed columns are left to withComputedColumns, the history doesn't store them.
+func toStockRating(p repository.AddStockRatingsParams) (repository.StockRating, error) {
+	r := repository.StockRating{
+		Ticker:        p.Ticker,
+		Company:       p.Company,
+		Brokerage:     p.Brokerage,
+		TargetFrom:    p.TargetFrom,
+		TargetTo:      p.TargetTo,
+		Action:        p.Action,
+		RawAction:     p.RawAction,
+		RatingFrom:    p.RatingFrom,
+		RawRatingFrom: p.RawRatingFrom,
+		RatingTo:      p.RatingTo,
+		RawRatingTo:   p.RawRatingTo,
+		At:            p.At,
+	}
+	for _, err := range []error{checkAction(r.Action), checkRating(r.RatingFrom), checkRating(r.RatingTo)} {
+		if err != nil {
+			return r, err
+		}
+	}
+	var err error
+	if r.TargetFrom, err = toColumn(r.TargetFrom, 10, 2); err != nil {
+		return r, err
+	}
+	if r.TargetTo, err = toColumn(r.TargetTo, 10, 2); err != nil {
+		return r, err
+	}
+	if !r.TargetFrom.Valid {
+		return r, notNullViolation("stock_rating", "target_from")
+	}
+	if !r.TargetTo.Valid {
+		return r, notNullViolation("stock_rating", "target_to")
+	}
+	r.At = r.At.Truncate(timestampPrecision)
+	return r, nil
+}
+
+func (s *Store) AddStockRatings(ctx context.Context, arg []repository.AddStockRatingsParams) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// A copy inserts all the rows or none
+	rows := map[string]repository.StockRating{}
+	for _, p := range arg {
+		r, err := toStockRating(p)
+		if err != nil {
+			return 0, err
+		}
+		if r, err = withComputedColumns(r); err != nil {
+			return 0, err
+		}
+		if _, ok := s.stockRatings[r.Ticker]; ok {
+			return 0, uniqueViolation("stock_rating_pkey")
+		}
+		if _, ok := rows[r.Ticker]; ok {
+			return 0, uniqueViolation("stock_rating_pkey")
+		}
+		rows[r.Ticker] = r
+	}
+	for ticker, r := range rows {
+		s.stockRatings[ticker] = r
+	}
+	return int64(len(rows)), nil
+}
+
+func (s *Store) ClearStockRating(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stockRatings = map[string]repository.StockRating{}
+	return nil
+}
+
+// The ratings, by ticker as the primary key orders them
+func (s *Store) sortedStockRatings() []repository.StockRating {
+	var out []repository.StockRating
+	for _, r := range s.stockRatings {
+		out = append(out, r)
+	}
+	slices.SortFunc(out, func(a, b repository.StockRating) int { return strings.Compare(a.Ticker, b.Ticker) })
+	return out
+}
+
+// Scores ------------------------------------------------------------------------------------------
+
+func ratingWeight(r repository.StockRatingType) int64 {
+	switch r {
+	case repository.StockRatingTypeBuy:
+		return 1
+	case repository.StockRatingTypeSell:
+		return -1
+	default:
+		return 0
+	}
+}
+
+func actionWeight(a repository.StockActionType) int64 {
+	switch a {
+	case repository.StockActionTypeUp:
+		return 1
+	case repository.StockActionTypeDown:
+		return -1
+	default:
+		return 0
+	}
+}
+
+// Compute the stored columns of a rating, on every write as the database does. Without a previous
+// target the change is 0, as NULLIF and COALESCE make it.
+func withComputedColumns(r repository.StockRating) (repository.StockRating, error) {
+	from, to := toRat(r.TargetFrom), toRat(r.TargetTo)
+	delta := new(big.Rat).Sub(to, from)
+	change := new(big.Rat)
+	if from.Sign() != 0 {
+		change.Quo(delta, from)
+	}
+
+	// TRUNC(10 * change + 2 * rating + action, 3) * 1000
+	x := new(big.Rat).Mul(change, big.NewRat(10, 1))
+	x.Add(x, big.NewRat(2*ratingWeight(r.RatingTo)+actionWeight(r.Action), 1))
+	points := scaled(x, 3, true)
+	if !points.IsInt64() || points.Int64() != int64(int32(points.Int64())) {
+		return r, integerOutOfRange
+	}
+
+	var err error
+	if r.TargetDelta, err = fromRat(delta, 10, 2); err != nil {
+		return r, err
+	}
+	if r.TargetDeltaPct, err = fromRat(new(big.Rat).Mul(change, big.NewRat(100, 1)), 10, 2); err != nil {
+		return r, err
+	}
+	r.Score = int32(points.Int64())
+	return r, nil
+}
+
+// A rating with the columns of the list, and the decimals they sort by
+type scoredStockRating struct {
+	row         repository.GetStockRatingsRow
+	targetFrom  *big.Rat
+	targetTo    *big.Rat
+	targetDelta *big.Rat
+	score       *big.Rat
+}
+
+func score(r repository.StockRating) scoredStockRating {
+	return scoredStockRating{
+		row: repository.GetStockRatingsRow{
+			Ticker:         r.Ticker,
+			Company:        r.Company,
+			Brokerage:      r.Brokerage,
+			TargetFrom:     format(toRat(r.TargetFrom), 2),
+			TargetTo:       format(toRat(r.TargetTo), 2),
+			Action:         r.Action,
+			RawAction:      r.RawAction,
+			RatingFrom:     r.RatingFrom,
+			RawRatingFrom:  r.RawRatingFrom,
+			RatingTo:       r.RatingTo,
+			RawRatingTo:    r.RawRatingTo,
+			At:             r.At,
+			TargetDelta:    format(toRat(r.TargetDelta), 2),
+			TargetDeltaPct: format(toRat(r.TargetDeltaPct), 2),
+			Score:          r.Score,
+		},
+		targetFrom:  toRat(r.TargetFrom),
+		targetTo:    toRat(r.TargetTo),
+		targetDelta: toRat(r.TargetDelta),
+		score:       big.NewRat(int64(r.Score), 1),
+	}
+}
+
+func scoreAll(rows []repository.StockRating) []scoredStockRating {
+	var out []scoredStockRating
+	for _, r := range rows {
+		out = append(out, score(r))
+	}
+	return out
+}
+
+// Filters -----------------------------------------------------------------------------------------
+
+// ILIKE match of s, with the % and _ wildcards and the \ escapes
+func ilike(s string, pattern string) bool {
+	return like([]rune(strings.ToLower(s)), []rune(strings.ToLower(pattern)))
+}
+
+func like(s []rune, p []rune) bool {
+	for len(p) > 0 {
+		switch p[0] {
+		case '%':
+			for len(p) > 0 && p[0] == '%' {
+				p = p[1:]
+			}
+			if len(p) == 0 {
+				return true
+			}
+			for i := range len(s) + 1 {
+				if like(s[i:], p) {
+					return true
+				}
+			}
+			return false
+		case '_':
+			if len(s) == 0 {
+				return false
+			}
+		case '\\':
+			if len(p) > 1 {
+				p = p[1:]
+			}
+			fallthrough
+		default:
+			if len(s) == 0 || s[0] != p[0] {
+				return false
+			}
+		}
+		s, p = s[1:], p[1:]
+	}
+	return len(s) == 0
+}
+
+// The ratings of the list filters, ticker and company containing their filter
+func (s *Store) filterStockRatings(tickerLike string, companyLike string) []repository.StockRating {
+	var out []repository.StockRating
+	for _, r := range s.sortedStockRatings() {
+		if ilike(r.Ticker, "%"+tickerLike+"%") && ilike(r.Company, "%"+companyLike+"%") {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// List --------------------------------------------------------------------------------------------
+
+var numericSortKeys = map[string]func(scoredStockRating) *big.Rat{
+	"target_from":  func(r scoredStockRating) *big.Rat { return r.targetFrom },
+	"target_to":    func(r scoredStockRating) *big.Rat { return r.targetTo },
+	"target_delta": func(r scoredStockRating) *big.Rat { return r.targetDelta },
+	"score":        func(r scoredStockRating) *big.Rat { return r.score },
+}
+
+var textSortKeys = map[string]func(scoredStockRating) string{
+	"ticker":      func(r scoredStockRating) string { return r.row.Ticker },
+	"company":     func(r scoredStockRating) string { return r.row.Company },
+	"brokerage":   func(r scoredStockRating) string { return r.row.Brokerage },
+	"action":      func(r scoredStockRating) string { return string(r.row.Action) },
+	"rating_from": func(r scoredStockRating) string { return string(r.row.RatingFrom) },
+	"rating_to":   func(r scoredStockRating) string { return string(r.row.RatingTo) },
+}
+
+// Order of the list: the sort column in the sort order, then the ticker. Unknown columns and orders
+// only sort by ticker.
+func compareStockRatings(sortBy string, sortOrder string) func(a, b scoredStockRating) int {
+	return func(a, b scoredStockRating) int {
+		c := 0
+		if key, ok := numericSortKeys[sortBy]; ok {
+			c = key(a).Cmp(key(b))
+		} else if key, ok := textSortKeys[sortBy]; ok {
+			c = strings.Compare(key(a), key(b))
+		}
+		switch sortOrder {
+		case "asc":
+		case "desc":
+			c = -c
+		default:
+			c = 0
+		}
+		return cmp.Or(c, strings.Compare(a.row.Ticker, b.row.Ticker))
+	}
+}
+
+func (s *Store) listStockRatings(arg repository.GetStockRatingsParams) []scoredStockRating {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rows := scoreAll(s.filterStockRatings(arg.TickerLike, arg.CompanyLike))
+	slices.SortFunc(rows, compareStockRatings(arg.SortBy, arg.SortOrder))
+	return rows
+}
+
+func (s *Store) GetStockRatings(ctx context.Context, arg repository.GetStockRatingsParams) ([]repository.GetStockRatingsRow, error) {
+	rows := s.listStockRatings(arg)
+	var items []repository.GetStockRatingsRow
+	for _, r := range page(rows, arg.Offset, arg.Limit) {
+		items = append(items, r.row)
+	}
+	return items, nil
+}
+
+func (s *Store) StreamStockRatings(ctx context.Context, arg repository.GetStockRatingsParams, fn func(repository.GetStockRatingsRow) error) error {
+	for _, r := range s.listStockRatings(arg) {
+		if err := fn(r.row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Store) GetStockRating(ctx context.Context, ticker string) (repository.GetStockRatingRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r, ok := s.stockRatings[ticker]
+	if !ok {
+		return repository.GetStockRatingRow{}, pgx.ErrNoRows
+	}
+	return repository.GetStockRatingRow(score(r).row), nil
+}
+
+// Ratings of the tickers or brokerages given, in the order of the queries
+func (s *Store) stockRatingsWhere(match func(repository.StockRating) bool, compare func(a, b repository.StockRating) int) []repository.GetStockRatingsRow {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var rows []repository.StockRating
+	for _, r := range s.stockRatings {
+		if match(r) {
+			rows = append(rows, r)
+		}
+	}
+	slices.SortFunc(rows, compare)
+	var items []repository.GetStockRatingsRow
+	for _, r := range scoreAll(rows) {
+		items = append(items, r.row)
+	}
+	return items
+}
+
+func (s *Store) GetStockRatingsByBrokerages(ctx context.Context, brokerages []string) ([]repository.GetStockRatingsByBrokeragesRow, error) {
+	rows := s.stockRatingsWhere(
+		func(r repository.StockRating) bool { return slices.Contains(brokerages, r.Brokerage) },
+		func(a, b repository.StockRating) int {
+			return cmp.Or(strings.Compare(a.Brokerage, b.Brokerage), b.At.Compare(a.At), strings.Compare(a.Ticker, b.Ticker))
+		},
+	)
+	var items []repository.GetStockRatingsByBrokeragesRow
+	for _, r := range rows {
+		items = append(items, repository.GetStockRatingsByBrokeragesRow(r))
+	}
+	return items, nil
+}
+
+// Aggregates --------------------------------------------------------------------------------------
+
+func (s *Store) GetStockRatingCounts(ctx context.Context, arg repository.GetStockRatingCountsParams) ([]repository.GetStockRatingCountsRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	counts := map[repository.GetStockRatingCountsRow]int64{}
+	for _, r := range s.filterStockRatings(arg.TickerLike, arg.CompanyLike) {
+		counts[repository.GetStockRatingCountsRow{RatingTo: r.RatingTo, Action: r.Action}]++
+	}
+	var items []repository.GetStockRatingCountsRow
+	for key, count := range counts {
+		key.Count = count
+		items = append(items, key)
+	}
+	// Enums sort in the order of their values
+	slices.SortFunc(items, func(a, b repository.GetStockRatingCountsRow) int {
+		return cmp.Or(
+			cmp.Compare(slices.Index(stockRatingTypes, a.RatingTo), slices.Index(stockRatingTypes, b.RatingTo)),
+			cmp.Compare(slices.Index(stockActionTypes, a.Action), slices.Index(stockActionTypes, b.Action)),
+		)
+	})
+	return items, nil
+}
+
+func (s *Store) GetBrokerages(ctx context.Context, arg repository.GetBrokeragesParams) ([]repository.GetBrokeragesRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	byName := map[string]*repository.GetBrokeragesRow{}
+	var rows []*repository.GetBrokeragesRow
+	for _, r := range s.stockRatings {
+		b, ok := byName[r.Brokerage]
+		if !ok {
+			b = &repository.GetBrokeragesRow{Brokerage: r.Brokerage}
+			byName[r.Brokerage] = b
+			rows = append(rows, b)
+		}
+		b.Ratings++
+		switch r.Action {
+		case repository.StockActionTypeUp:
+			b.Upgrades++
+		case repository.StockActionTypeDown:
+			b.Downgrades++
+		}
+	}
+	slices.SortFunc(rows, func(a, b *repository.GetBrokeragesRow) int {
+		return cmp.Or(cmp.Compare(b.Ratings, a.Ratings), strings.Compare(a.Brokerage, b.Brokerage))
+	})
+	var items []repository.GetBrokeragesRow
+	for _, b := range page(rows, arg.Offset, arg.Limit) {
+		items = append(items, *b)
+	}
+	return items, nil
+}
+
+// Count the ratings by a column, the most frequent first
+func countBy[K comparable](rows []repository.StockRating, key func(repository.StockRating) K, order []K) ([]K, map[K]int64) {
+	counts := map[K]int64{}
+	var keys []K
+	for _, r := range rows {
+		k := key(r)
+		if counts[k] == 0 {
+			keys = append(keys, k)
+		}
+		counts[k]++
+	}
+	slices.SortFunc(keys, func(a, b K) int {
+		return cmp.Or(cmp.Compare(counts[b], counts[a]), cmp.Compare(slices.Index(order, a), slices.Index(order, b)))
+	})
+	return keys, counts
+}
+
+func (s *Store) GetOverallMarketStockRatings(ctx context.Context) ([]repository.GetOverallMarketStockRatingsRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	keys, counts := countBy(s.sortedStockRatings(), func(r repository.StockRating) repository.StockRatingType { return r.RatingTo }, stockRatingTypes)
+	var items []repository.GetOverallMarketStockRatingsRow
+	for _, k := range keys {
+		items = append(items, repository.GetOverallMarketStockRatingsRow{Rating: k, Count: counts[k]})
+	}
+	return items, nil
+}
+
+func (s *Store) GetOverallAnalystActions(ctx context.Context) ([]repository.GetOverallAnalystActionsRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	keys, counts := countBy(s.sortedStockRatings(), func(r repository.StockRating) repository.StockActionType { return r.Action }, stockActionTypes)
+	var items []repository.GetOverallAnalystActionsRow
+	for _, k := range keys {
+		items = append(items, repository.GetOverallAnalystActionsRow{Action: k, Count: counts[k]})
+	}
+	return items, nil
+}
+
+// Before a load -----------------------------------------------------------------------------------
+
+func (s *Store) GetStockRatingScores(ctx context.Context) ([]repository.GetStockRatingScoresRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var items []repository.GetStockRatingScoresRow
+	for _, r := range s.sortedStockRatings() {
+		items = append(items, repository.GetStockRatingScoresRow{Ticker: r.Ticker, At: r.At, Score: r.Score})
+	}
+	return items, nil
+}
+
+func (s *Store) GetStockRatingTimes(ctx context.Context) ([]repository.GetStockRatingTimesRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var items []repository.GetStockRatingTimesRow
+	for _, r := range s.sortedStockRatings() {
+		items = append(items, repository.GetStockRatingTimesRow{Ticker: r.Ticker, At: r.At})
+	}
+	return items, nil
+}
+
+// Data migrations ---------------------------------------------------------------------------------
+
+func (s *Store) ListStockRatingsAfter(ctx context.Context, arg repository.ListStockRatingsAfterParams) ([]repository.ListStockRatingsAfterRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var rows []repository.ListStockRatingsAfterRow
+	for _, r := range s.sortedStockRatings() {
+		if r.Ticker > arg.After {
+			rows = append(rows, repository.ListStockRatingsAfterRow{
+				Ticker:        r.Ticker,
+				Company:       r.Company,
+				Brokerage:     r.Brokerage,
+				TargetFrom:    r.TargetFrom,
+				TargetTo:      r.TargetTo,
+				Action:        r.Action,
+				RawAction:     r.RawAction,
+				RatingFrom:    r.RatingFrom,
+				RawRatingFrom: r.RawRatingFrom,
+				RatingTo:      r.RatingTo,
+				RawRatingTo:   r.RawRatingTo,
+				At:            r.At,
+			})
+		}
+	}
+	return page(rows, 0, arg.Lim), nil
+}
+
+func (s *Store) UpdateStockRatingNormalization(ctx context.Context, arg repository.UpdateStockRatingNormalizationParams) error {
+	for _, err := range []error{checkAction(arg.Action), checkRating(arg.RatingFrom), checkRating(arg.RatingTo)} {
+		if err != nil {
+			return err
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r, ok := s.stockRatings[arg.Ticker]
+	if !ok {
+		return nil
+	}
+	r.Action, r.RatingFrom, r.RatingTo = arg.Action, arg.RatingFrom, arg.RatingTo
+	r, err := withComputedColumns(r)
+	if err != nil {
+		return err
+	}
+	s.stockRatings[arg.Ticker] = r
+	return nil
+}
+
+// HISTORY =========================================================================================
+
+// unnest pads the shorter arrays with NULL, refused by the NOT NULL columns
+func checkEventColumns(arg repository.AddStockRatingEventsParams) (int, error) {
+	lengths := []struct {
+		column string
+		length int
+	}{
+		{"ticker", len(arg.Ticker)},
+		{"company", len(arg.Company)},
+		{"brokerage", len(arg.Brokerage)},
+		{"target_from", len(arg.TargetFrom)},
+		{"target_to", len(arg.TargetTo)},
+		{"action", len(arg.Action)},
+		{"raw_action", len(arg.RawAction)},
+		{"rating_from", len(arg.RatingFrom)},
+		{"raw_rating_from", len(arg.RawRatingFrom)},
+		{"rating_to", len(arg.RatingTo)},
+		{"raw_rating_to", len(arg.RawRatingTo)},
+		{"at", len(arg.At)},
+	}
+	n := 0
+	for _, l := range lengths {
+		n = max(n, l.length)
+	}
+	for _, l := range lengths {
+		if l.length < n {
+			return 0, notNullViolation("stock_rating_event", l.column)
+		}
+	}
+	return n, nil
+}
+
+func (s *Store) AddStockRatingEvents(ctx context.Context, arg repository.AddStockRatingEventsParams) (int64, error) {
+	n, err := checkEventColumns(arg)
+	if err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// The statement inserts all the rows or none
+	var events []repository.StockRatingEvent
+	for i := range n {
+		r, err := toStockRating(repository.AddStockRatingsParams{
+			Ticker:        arg.Ticker[i],
+			Company:       arg.Company[i],
+			Brokerage:     arg.Brokerage[i],
+			TargetFrom:    arg.TargetFrom[i],
+			TargetTo:      arg.TargetTo[i],
+			Action:        repository.StockActionType(arg.Action[i]),
+			RawAction:     arg.RawAction[i],
+			RatingFrom:    repository.StockRatingType(arg.RatingFrom[i]),
+			RawRatingFrom: arg.RawRatingFrom[i],
+			RatingTo:      repository.StockRatingType(arg.RatingTo[i]),
+			RawRatingTo:   arg.RawRatingTo[i],
+			At:            arg.At[i],
+		})
+		if err != nil {
+			return 0, err
+		}
+		e := repository.StockRatingEvent{
+			ID:            uuid.New(),
+			Ticker:        r.Ticker,
+			Company:       r.Company,
+			Brokerage:     r.Brokerage,
+			TargetFrom:    r.TargetFrom,
+			TargetTo:      r.TargetTo,
+			Action:        r.Action,
+			RawAction:     r.RawAction,
+			RatingFrom:    r.RatingFrom,
+			RawRatingFrom: r.RawRatingFrom,
+			RatingTo:      r.RatingTo,
+			RawRatingTo:   r.RawRatingTo,
+			At:            r.At,
+			RecordedAt:    s.timestamp(),
+		}
+		events = append(events, e)
+	}
+	return s.insertEvents(events, s.rolledUp), nil
+}
+
+// An event the retention rolled up, its month of the ticker is summarized up to its time
+func (s *Store) rolledUp(e repository.StockRatingEvent) bool {
+	m, ok := s.monthly[monthlyKey{e.Ticker, monthOf(e.At)}]
+	return ok && !e.At.After(m.LastAt)
+}
+
+// Insert the events, skipping the ones already recorded as ON CONFLICT DO NOTHING does, and the
+// ones skip matches
+func (s *Store) insertEvents(events []repository.StockRatingEvent, skip func(repository.StockRatingEvent) bool) int64 {
+	// Every row takes a position of stock_rating_event_position, the skipped ones leave theirs unused
+	start := s.eventSeq
+	s.eventSeq += int64(len(events))
+	var inserted []repository.StockRatingEvent
+	for i, e := range events {
+		match := func(o repository.StockRatingEvent) bool {
+			return o.Ticker == e.Ticker && o.Brokerage == e.Brokerage && o.At.Equal(e.At)
+		}
+		if find(s.stockRatingEvents, match) < 0 && find(inserted, match) < 0 && !skip(e) {
+			e.Seq = start + int64(i) + 1
+			inserted = append(inserted, e)
+		}
+	}
+	s.stockRatingEvents = append(s.stockRatingEvents, inserted...)
+	return int64(len(inserted))
+}
+
+func (s *Store) BackfillStockRatingEvents(ctx context.Context, tickers []string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var events []repository.StockRatingEvent
+	for _, r := range s.sortedStockRatings() {
+		if !slices.Contains(tickers, r.Ticker) {
+			continue
+		}
+		// The end of the first successful run started after the rating, or the rating itself
+		recordedAt := r.At
+		var loadedBy *repository.IngestionRun
+		for _, run := range s.ingestionRuns {
+			if run.Status == repository.IngestionRunStatusSucceeded && !run.StartedAt.Before(r.At) &&
+				(loadedBy == nil || run.FinishedAt.Time.Before(loadedBy.FinishedAt.Time)) {
+				loadedBy = &run
+			}
+		}
+		if loadedBy != nil {
+			recordedAt = loadedBy.FinishedAt.Time
+		}
+		e := repository.StockRatingEvent{
+			ID:            uuid.New(),
+			Ticker:        r.Ticker,
+			Company:       r.Company,
+			Brokerage:     r.Brokerage,
+			TargetFrom:    r.TargetFrom,
+			TargetTo:      r.TargetTo,
+			Action:        r.Action,
+			RawAction:     r.RawAction,
+			RatingFrom:    r.RatingFrom,
+			RawRatingFrom: r.RawRatingFrom,
+			RatingTo:      r.RatingTo,
+			RawRatingTo:   r.RawRatingTo,
+			At:            r.At,
+			RecordedAt:    recordedAt,
+		}
+		events = append(events, e)
+	}
+	return s.insertEvents(events, func(repository.StockRatingEvent) bool { return false }), nil
+}
+
+// Change feed -------------------------------------------------------------------------------------
+
+func (s *Store) ListStockRatingEventsAfter(ctx context.Context, arg repository.ListStockRatingEventsAfterParams) ([]repository.ListStockRatingEventsAfterRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var rows []repository.ListStockRatingEventsAfterRow
+	for _, e := range s.stockRatingEvents {
+		if e.Seq > arg.After {
+			rows = append(rows, repository.ListStockRatingEventsAfterRow{
+				Seq:           e.Seq,
+				Ticker:        e.Ticker,
+				Company:       e.Company,
+				Brokerage:     e.Brokerage,
+				TargetFrom:    format(toRat(e.TargetFrom), 2),
+				TargetTo:      format(toRat(e.TargetTo), 2),
+				Action:        e.Action,
+				RawAction:     e.RawAction,
+				RatingFrom:    e.RatingFrom,
+				RawRatingFrom: e.RawRatingFrom,
+				RatingTo:      e.RatingTo,
+				RawRatingTo:   e.RawRatingTo,
+				At:            e.At,
+				RecordedAt:    e.RecordedAt,
+			})
+		}
+	}
+	slices.SortFunc(rows, func(a, b repository.ListStockRatingEventsAfterRow) int { return cmp.Compare(a.Seq, b.Seq) })
+	return page(rows, 0, arg.Lim), nil
+}
+
+// History of tickers ------------------------------------------------------------------------------
+
+// The rating an event made
+func eventStockRating(e repository.StockRatingEvent) repository.StockRating {
+	return repository.StockRating{
+		Ticker:        e.Ticker,
+		Company:       e.Company,
+		Brokerage:     e.Brokerage,
+		TargetFrom:    e.TargetFrom,
+		TargetTo:      e.TargetTo,
+		Action:        e.Action,
+		RawAction:     e.RawAction,
+		RatingFrom:    e.RatingFrom,
+		RawRatingFrom: e.RawRatingFrom,
+		RatingTo:      e.RatingTo,
+		RawRatingTo:   e.RawRatingTo,
+		At:            e.At,
+	}
+}
+
+func (s *Store) GetStockRatingEventsByTickers(ctx context.Context, tickers []string) ([]repository.GetStockRatingEventsByTickersRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var events []repository.StockRatingEvent
+	for _, e := range s.stockRatingEvents {
+		if slices.Contains(tickers, e.Ticker) {
+			events = append(events, e)
+		}
+	}
+	slices.SortFunc(events, func(a, b repository.StockRatingEvent) int {
+		return cmp.Or(strings.Compare(a.Ticker, b.Ticker), b.At.Compare(a.At), b.RecordedAt.Compare(a.RecordedAt), strings.Compare(a.Brokerage, b.Brokerage))
+	})
+	var items []repository.GetStockRatingEventsByTickersRow
+	for _, e := range events {
+		r, err := withComputedColumns(eventStockRating(e))
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, repository.GetStockRatingEventsByTickersRow(score(r).row))
+	}
+	return items, nil
+}
+
+// As of a past time -------------------------------------------------------------------------------
+
+// Whether e comes before l in ORDER BY at DESC, recorded_at DESC, brokerage, the order picking the
+// latest event of a ticker
+func after(e repository.StockRatingEvent, l repository.StockRatingEvent) bool {
+	return cmp.Or(e.At.Compare(l.At), e.RecordedAt.Compare(l.RecordedAt), strings.Compare(l.Brokerage, e.Brokerage)) > 0
+}
+
+// The latest visible event of each ticker, as the rating it was, with its computed columns. The
+// filters match the latest event, a renamed company is found by its name at the time.
+func (s *Store) latestStockRatings(visible func(repository.StockRatingEvent) bool, match func(repository.StockRatingEvent) bool) ([]repository.StockRating, error) {
+	latest := map[string]repository.StockRatingEvent{}
+	for _, e := range s.stockRatingEvents {
+		if !visible(e) {
+			continue
+		}
+		if l, ok := latest[e.Ticker]; !ok || after(e, l) {
+			latest[e.Ticker] = e
+		}
+	}
+	var rows []repository.StockRating
+	for _, e := range latest {
+		if !match(e) {
+			continue
+		}
+		r, err := withComputedColumns(eventStockRating(e))
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, r)
+	}
+	return rows, nil
+}
+
+// The ratings at or before asOf
+func (s *Store) stockRatingsAsOf(asOf time.Time, match func(repository.StockRatingEvent) bool) ([]repository.StockRating, error) {
+	asOf = asOf.Truncate(timestampPrecision)
+	return s.latestStockRatings(func(e repository.StockRatingEvent) bool { return !e.At.After(asOf) }, match)
+}
+
+func (s *Store) GetStockRatingsAsOf(ctx context.Context, arg repository.GetStockRatingsAsOfParams) ([]repository.GetStockRatingsAsOfRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rows, err := s.stockRatingsAsOf(arg.AsOf, func(e repository.StockRatingEvent) bool {
+		return ilike(e.Ticker, "%"+arg.TickerLike+"%") && ilike(e.Company, "%"+arg.CompanyLike+"%")
+	})
+	if err != nil {
+		return nil, err
+	}
+	scored := scoreAll(rows)
+	slices.SortFunc(scored, compareStockRatings(arg.SortBy, arg.SortOrder))
+	var items []repository.GetStockRatingsAsOfRow
+	for _, r := range page(scored, arg.Offset, arg.Limit) {
+		items = append(items, repository.GetStockRatingsAsOfRow(r.row))
+	}
+	return items, nil
+}
+
+func (s *Store) GetStockRatingAsOf(ctx context.Context, arg repository.GetStockRatingAsOfParams) (repository.GetStockRatingAsOfRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rows, err := s.stockRatingsAsOf(arg.AsOf, func(e repository.StockRatingEvent) bool { return e.Ticker == arg.Ticker })
+	if err != nil {
+		return repository.GetStockRatingAsOfRow{}, err
+	}
+	if len(rows) == 0 {
+		return repository.GetStockRatingAsOfRow{}, pgx.ErrNoRows
+	}
+	return repository.GetStockRatingAsOfRow(score(rows[0]).row), nil
+}
+
+// Between two points of the history ---------------------------------------------------------------
+
+// Events of a point of the diff, bounded by their time and the time they were recorded
+func visibleAt(at pgtype.Timestamptz, recordedAt pgtype.Timestamptz) func(repository.StockRatingEvent) bool {
+	return func(e repository.StockRatingEvent) bool {
+		return (!at.Valid || !e.At.After(at.Time.Truncate(timestampPrecision))) &&
+			(!recordedAt.Valid || !e.RecordedAt.After(recordedAt.Time.Truncate(timestampPrecision)))
+	}
+}
+
+// The tickers loaded by the last successful run finished at a point of the history, every ticker
+// when the run recorded none
+func (s *Store) loadedAt(at pgtype.Timestamptz, recordedAt pgtype.Timestamptz) func(repository.StockRatingEvent) bool {
+	bound := at
+	if !bound.Valid {
+		bound = recordedAt
+	}
+	var last *repository.IngestionRun
+	for i, r := range s.ingestionRuns {
+		if !bound.Valid || r.Status != repository.IngestionRunStatusSucceeded || !r.FinishedAt.Valid ||
+			r.FinishedAt.Time.After(bound.Time.Truncate(timestampPrecision)) {
+			continue
+		}
+		if last == nil || r.FinishedAt.Time.After(last.FinishedAt.Time) {
+			last = &s.ingestionRuns[i]
+		}
+	}
+	tickers := map[string]bool{}
+	for _, t := range s.runTickers {
+		if last != nil && t.RunID == last.ID {
+			tickers[t.Ticker] = true
+		}
+	}
+	return func(e repository.StockRatingEvent) bool { return len(tickers) == 0 || tickers[e.Ticker] }
+}
+
+func (s *Store) GetStockRatingsDiff(ctx context.Context, arg repository.GetStockRatingsDiffParams) ([]repository.GetStockRatingsDiffRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	before, err := s.latestStockRatings(visibleAt(arg.FromAt, arg.FromRecordedAt), s.loadedAt(arg.FromAt, arg.FromRecordedAt))
+	if err != nil {
+		return nil, err
+	}
+	after, err := s.latestStockRatings(visibleAt(arg.ToAt, arg.ToRecordedAt), s.loadedAt(arg.ToAt, arg.ToRecordedAt))
+	if err != nil {
+		return nil, err
+	}
+
+	rows := map[string]*repository.GetStockRatingsDiffRow{}
+	row := func(r repository.StockRating) *repository.GetStockRatingsDiffRow {
+		if rows[r.Ticker] == nil {
+			rows[r.Ticker] = &repository.GetStockRatingsDiffRow{Ticker: r.Ticker}
+		}
+		return rows[r.Ticker]
+	}
+	for _, r := range before {
+		d := row(r)
+		d.Company = r.Company
+		d.BeforeBrokerage = pgtype.Text{String: r.Brokerage, Valid: true}
+		d.BeforeAction = repository.NullStockActionType{StockActionType: r.Action, Valid: true}
+		d.BeforeRatingTo = repository.NullStockRatingType{StockRatingType: r.RatingTo, Valid: true}
+		d.BeforeTargetTo = pgtype.Text{String: format(toRat(r.TargetTo), 2), Valid: true}
+		d.BeforeScore = pgtype.Int4{Int32: r.Score, Valid: true}
+		d.BeforeAt = pgtype.Timestamptz{Time: r.At, Valid: true}
+	}
+	for _, r := range after {
+		// The company after the change, as COALESCE(a.company, b.company)
+		d := row(r)
+		d.Company = r.Company
+		d.AfterBrokerage = pgtype.Text{String: r.Brokerage, Valid: true}
+		d.AfterAction = repository.NullStockActionType{StockActionType: r.Action, Valid: true}
+		d.AfterRatingTo = repository.NullStockRatingType{StockRatingType: r.RatingTo, Valid: true}
+		d.AfterTargetTo = pgtype.Text{String: format(toRat(r.TargetTo), 2), Valid: true}
+		d.AfterScore = pgtype.Int4{Int32: r.Score, Valid: true}
+		d.AfterAt = pgtype.Timestamptz{Time: r.At, Valid: true}
+	}
+
+	var items []repository.GetStockRatingsDiffRow
+	for _, d := range rows {
+		changed := !d.BeforeAt.Valid || !d.AfterAt.Valid ||
+			d.BeforeRatingTo != d.AfterRatingTo || d.BeforeTargetTo != d.AfterTargetTo || d.BeforeScore != d.AfterScore
+		if changed {
+			items = append(items, *d)
+		}
+	}
+	slices.SortFunc(items, func(a, b repository.GetStockRatingsDiffRow) int { return strings.Compare(a.Ticker, b.Ticker) })
+	return items, nil
+}
@@ -0,0 +1,131 @@
+// In-memory implementation of repository.Repository, for the tests of the services. It reproduces
+// the queries of sqlc as CockroachDB runs them: the filters, the sort orders, the pagination, the
+// scores computed with decimals, and the errors of the constraints and of the queries returning no
+// row. The conformance suite of repositorytest runs against both.
+package memstore
+
+import (
+	"backend/internal/repository"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type Store struct {
+	mu  sync.Mutex
+	now func() time.Time
+
+	stockRatings      map[string]repository.StockRating
+	stockRatingEvents []repository.StockRatingEvent
+	eventSeq          int64
+	monthly           map[monthlyKey]repository.StockRatingMonthly
+	datasetVersion    repository.DatasetVersion
+	ingestionRuns     []repository.IngestionRun
+	runTickers        []repository.IngestionRunTicker
+	rawPages          []repository.RawPage
+	rateLimitBuckets  map[string]time.Time
+	alertRules        []repository.AlertRule
+	alertEvents       []repository.AlertEvent
+	webhooks          []repository.Webhook
+	deliveries        []repository.WebhookDelivery
+	deliveryAttempts  []repository.WebhookDeliveryAttempt
+}
+
+var _ repository.Repository = (*Store)(nil)
+
+// Empty store, in the state left by the migrations
+func New() *Store {
+	s := &Store{
+		now:              time.Now,
+		stockRatings:     map[string]repository.StockRating{},
+		monthly:          map[monthlyKey]repository.StockRatingMonthly{},
+		rateLimitBuckets: map[string]time.Time{},
+	}
+	s.datasetVersion = repository.DatasetVersion{ID: 1, Version: 1, UpdatedAt: s.timestamp()}
+	return s
+}
+
+// Use another clock, e.g. to step through the rate limits
+func (s *Store) SetClock(now func() time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.now = now
+}
+
+// Precision of the TIMESTAMPTZ columns
+const timestampPrecision = time.Microsecond
+
+func (s *Store) timestamp() time.Time {
+	return s.now().Truncate(timestampPrecision)
+}
+
+// ERRORS ==========================================================================================
+// The errors of the driver, for the callers checking the SQLSTATE
+
+func uniqueViolation(constraint string) error {
+	return &pgconn.PgError{
+		Severity:       "ERROR",
+		Code:           "23505",
+		Message:        fmt.Sprintf("duplicate key value violates unique constraint %q", constraint),
+		ConstraintName: constraint,
+	}
+}
+
+func foreignKeyViolation(table string, constraint string) error {
+	return &pgconn.PgError{
+		Severity:       "ERROR",
+		Code:           "23503",
+		Message:        fmt.Sprintf("insert on table %q violates foreign key constraint %q", table, constraint),
+		TableName:      table,
+		ConstraintName: constraint,
+	}
+}
+
+func notNullViolation(table string, column string) error {
+	return &pgconn.PgError{
+		Severity:   "ERROR",
+		Code:       "23502",
+		Message:    fmt.Sprintf("null value in column %q violates not-null constraint", column),
+		TableName:  table,
+		ColumnName: column,
+	}
+}
+
+func invalidEnumValue(enum string, value string) error {
+	return &pgconn.PgError{
+		Severity: "ERROR",
+		Code:     "22P02",
+		Message:  fmt.Sprintf("invalid input value for enum %s: %q", enum, value),
+	}
+}
+
+var numericOverflow = &pgconn.PgError{Severity: "ERROR", Code: "22003", Message: "numeric field overflow"}
+
+var integerOutOfRange = &pgconn.PgError{Severity: "ERROR", Code: "22003", Message: "integer out of range for type int4"}
+
+// Index of the row matching the predicate, -1 when none does
+func find[T any](rows []T, match func(T) bool) int {
+	for i, r := range rows {
+		if match(r) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Page of rows, as OFFSET and LIMIT do
+func page[T any](rows []T, offset int32, limit int32) []T {
+	start := min(max(int(offset), 0), len(rows))
+	end := min(start+max(int(limit), 0), len(rows))
+	if start == end {
+		return nil
+	}
+	return rows[start:end]
+}
+
+func hasRun(runs []repository.IngestionRun, id uuid.UUID) bool {
+	return find(runs, func(r repository.IngestionRun) bool { return r.ID == id }) >= 0
+}
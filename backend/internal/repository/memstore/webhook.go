@@ -0,0 +1,215 @@
+package memstore
+
+import (
+	"backend/internal/repository"
+	"cmp"
+	"context"
+	"encoding/json"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// WEBHOOKS ========================================================================================
+
+// Subscriptions -----------------------------------------------------------------------------------
+
+func (s *Store) CreateWebhook(ctx context.Context, arg repository.CreateWebhookParams) (repository.Webhook, error) {
+	if arg.EventTypes == nil {
+		return repository.Webhook{}, notNullViolation("webhook", "event_types")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	webhook := repository.Webhook{
+		ID:         uuid.New(),
+		Url:        arg.Url,
+		Secret:     arg.Secret,
+		EventTypes: slices.Clone(arg.EventTypes),
+		Enabled:    true,
+		CreatedAt:  s.timestamp(),
+	}
+	s.webhooks = append(s.webhooks, webhook)
+	return webhook, nil
+}
+
+// The webhooks matching the predicate, the oldest first
+func (s *Store) listWebhooks(match func(repository.Webhook) bool) []repository.Webhook {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var items []repository.Webhook
+	for _, w := range s.webhooks {
+		if match(w) {
+			items = append(items, w)
+		}
+	}
+	slices.SortStableFunc(items, func(a, b repository.Webhook) int { return a.CreatedAt.Compare(b.CreatedAt) })
+	return items
+}
+
+func (s *Store) ListWebhooks(ctx context.Context) ([]repository.Webhook, error) {
+	return s.listWebhooks(func(repository.Webhook) bool { return true }), nil
+}
+
+func (s *Store) ListEnabledWebhooks(ctx context.Context) ([]repository.Webhook, error) {
+	return s.listWebhooks(func(w repository.Webhook) bool { return w.Enabled }), nil
+}
+
+func (s *Store) DeleteWebhook(ctx context.Context, id uuid.UUID) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	before := len(s.webhooks)
+	s.webhooks = slices.DeleteFunc(s.webhooks, func(w repository.Webhook) bool { return w.ID == id })
+	if len(s.webhooks) == before {
+		return 0, nil
+	}
+	// ON DELETE CASCADE, down to the attempts of the deliveries
+	deleted := map[uuid.UUID]bool{}
+	s.deliveries = slices.DeleteFunc(s.deliveries, func(d repository.WebhookDelivery) bool {
+		deleted[d.ID] = d.WebhookID == id
+		return deleted[d.ID]
+	})
+	s.deliveryAttempts = slices.DeleteFunc(s.deliveryAttempts, func(a repository.WebhookDeliveryAttempt) bool {
+		return deleted[a.DeliveryID]
+	})
+	return 1, nil
+}
+
+// Queue -------------------------------------------------------------------------------------------
+
+// Lease of the claimed deliveries
+const claimLease = time.Minute
+
+func (s *Store) AddWebhookDelivery(ctx context.Context, arg repository.AddWebhookDeliveryParams) (int64, error) {
+	if !json.Valid(arg.Payload) {
+		return 0, &pgconn.PgError{Severity: "ERROR", Code: "22P02", Message: "could not parse JSON"}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if find(s.webhooks, func(w repository.Webhook) bool { return w.ID == arg.WebhookID }) < 0 {
+		return 0, foreignKeyViolation("webhook_delivery", "webhook_delivery_webhook_id_fkey")
+	}
+	conflict := func(d repository.WebhookDelivery) bool {
+		return d.WebhookID == arg.WebhookID && d.EventKey == arg.EventKey
+	}
+	if find(s.deliveries, conflict) >= 0 {
+		return 0, nil
+	}
+	now := s.timestamp()
+	s.deliveries = append(s.deliveries, repository.WebhookDelivery{
+		ID:            uuid.New(),
+		WebhookID:     arg.WebhookID,
+		EventType:     arg.EventType,
+		EventKey:      arg.EventKey,
+		Payload:       slices.Clone(arg.Payload),
+		Status:        repository.WebhookDeliveryStatusPending,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+	})
+	return 1, nil
+}
+
+func (s *Store) ClaimWebhookDeliveries(ctx context.Context, limit int32) ([]repository.ClaimWebhookDeliveriesRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.timestamp()
+	var due []int
+	for i, d := range s.deliveries {
+		if d.Status == repository.WebhookDeliveryStatusPending && !d.NextAttemptAt.After(now) {
+			due = append(due, i)
+		}
+	}
+	slices.SortStableFunc(due, func(a, b int) int {
+		return s.deliveries[a].NextAttemptAt.Compare(s.deliveries[b].NextAttemptAt)
+	})
+	due = due[:min(len(due), max(int(limit), 0))]
+
+	var items []repository.ClaimWebhookDeliveriesRow
+	for _, i := range due {
+		d := &s.deliveries[i]
+		d.Attempts++
+		d.NextAttemptAt = now.Add(claimLease)
+		w := s.webhooks[find(s.webhooks, func(w repository.Webhook) bool { return w.ID == d.WebhookID })]
+		items = append(items, repository.ClaimWebhookDeliveriesRow{
+			ID:        d.ID,
+			WebhookID: d.WebhookID,
+			EventType: d.EventType,
+			Payload:   slices.Clone(d.Payload),
+			Attempts:  d.Attempts,
+			Url:       w.Url,
+			Secret:    w.Secret,
+		})
+	}
+	return items, nil
+}
+
+func (s *Store) FinishWebhookDeliveryAttempt(ctx context.Context, arg repository.FinishWebhookDeliveryAttemptParams) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := find(s.deliveries, func(d repository.WebhookDelivery) bool {
+		return d.ID == arg.ID && d.Attempts == arg.Attempts
+	})
+	if i < 0 {
+		return 0, nil
+	}
+	d := &s.deliveries[i]
+	d.Status = arg.Status
+	d.NextAttemptAt = arg.NextAttemptAt.Truncate(timestampPrecision)
+	d.ResponseStatus = arg.ResponseStatus
+	d.LastError = arg.LastError
+	return 1, nil
+}
+
+func (s *Store) AddWebhookDeliveryAttempt(ctx context.Context, arg repository.AddWebhookDeliveryAttemptParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if find(s.deliveries, func(d repository.WebhookDelivery) bool { return d.ID == arg.DeliveryID }) < 0 {
+		return foreignKeyViolation("webhook_delivery_attempt", "webhook_delivery_attempt_delivery_id_fkey")
+	}
+	s.deliveryAttempts = append(s.deliveryAttempts, repository.WebhookDeliveryAttempt{
+		ID:             uuid.New(),
+		DeliveryID:     arg.DeliveryID,
+		Attempt:        arg.Attempt,
+		ResponseStatus: arg.ResponseStatus,
+		Error:          arg.Error,
+		DurationMs:     arg.DurationMs,
+		AttemptedAt:    s.timestamp(),
+	})
+	return nil
+}
+
+// Log ---------------------------------------------------------------------------------------------
+
+func (s *Store) ListWebhookDeliveries(ctx context.Context, arg repository.ListWebhookDeliveriesParams) ([]repository.WebhookDelivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var items []repository.WebhookDelivery
+	for _, d := range s.deliveries {
+		if d.WebhookID != arg.WebhookID || arg.Status.Valid && d.Status != arg.Status.WebhookDeliveryStatus {
+			continue
+		}
+		d.Payload = slices.Clone(d.Payload)
+		items = append(items, d)
+	}
+	slices.SortStableFunc(items, func(a, b repository.WebhookDelivery) int {
+		return cmp.Or(b.CreatedAt.Compare(a.CreatedAt), slices.Compare(a.ID[:], b.ID[:]))
+	})
+	return page(items, arg.Offset, arg.Limit), nil
+}
+
+func (s *Store) ReplayWebhookDelivery(ctx context.Context, arg repository.ReplayWebhookDeliveryParams) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := find(s.deliveries, func(d repository.WebhookDelivery) bool {
+		return d.ID == arg.ID && d.WebhookID == arg.WebhookID
+	})
+	if i < 0 {
+		return 0, nil
+	}
+	d := &s.deliveries[i]
+	d.Status = repository.WebhookDeliveryStatusPending
+	d.Attempts = 0
+	d.NextAttemptAt = s.timestamp()
+	return 1, nil
+}
@@ -9,9 +9,95 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type IngestionRunSource string
+
+const (
+	IngestionRunSourceApi    IngestionRunSource = "api"
+	IngestionRunSourceReplay IngestionRunSource = "replay"
+)
+
+func (e *IngestionRunSource) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = IngestionRunSource(s)
+	case string:
+		*e = IngestionRunSource(s)
+	default:
+		return fmt.Errorf("unsupported scan type for IngestionRunSource: %T", src)
+	}
+	return nil
+}
+
+type NullIngestionRunSource struct {
+	IngestionRunSource IngestionRunSource
+	Valid              bool // Valid is true if IngestionRunSource is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullIngestionRunSource) Scan(value interface{}) error {
+	if value == nil {
+		ns.IngestionRunSource, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.IngestionRunSource.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullIngestionRunSource) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.IngestionRunSource), nil
+}
+
+type IngestionRunStatus string
+
+const (
+	IngestionRunStatusRunning   IngestionRunStatus = "running"
+	IngestionRunStatusSucceeded IngestionRunStatus = "succeeded"
+	IngestionRunStatusFailed    IngestionRunStatus = "failed"
+)
+
+func (e *IngestionRunStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = IngestionRunStatus(s)
+	case string:
+		*e = IngestionRunStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for IngestionRunStatus: %T", src)
+	}
+	return nil
+}
+
+type NullIngestionRunStatus struct {
+	IngestionRunStatus IngestionRunStatus
+	Valid              bool // Valid is true if IngestionRunStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullIngestionRunStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.IngestionRunStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.IngestionRunStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullIngestionRunStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.IngestionRunStatus), nil
+}
+
 type StockActionType string
 
 const (
@@ -99,7 +185,132 @@ func (ns NullStockRatingType) Value() (driver.Value, error) {
 	return string(ns.StockRatingType), nil
 }
 
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryStatusPending   WebhookDeliveryStatus = "pending"
+	WebhookDeliveryStatusSucceeded WebhookDeliveryStatus = "succeeded"
+	WebhookDeliveryStatusFailed    WebhookDeliveryStatus = "failed"
+)
+
+func (e *WebhookDeliveryStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = WebhookDeliveryStatus(s)
+	case string:
+		*e = WebhookDeliveryStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for WebhookDeliveryStatus: %T", src)
+	}
+	return nil
+}
+
+type NullWebhookDeliveryStatus struct {
+	WebhookDeliveryStatus WebhookDeliveryStatus
+	Valid                 bool // Valid is true if WebhookDeliveryStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullWebhookDeliveryStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.WebhookDeliveryStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.WebhookDeliveryStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullWebhookDeliveryStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.WebhookDeliveryStatus), nil
+}
+
+type AlertEvent struct {
+	ID          uuid.UUID
+	RuleID      uuid.UUID
+	RunID       uuid.UUID
+	Ticker      string
+	Company     string
+	Brokerage   string
+	Action      StockActionType
+	RatingFrom  StockRatingType
+	RatingTo    StockRatingType
+	TargetFrom  pgtype.Numeric
+	TargetTo    pgtype.Numeric
+	Score       int32
+	Reason      string
+	At          time.Time
+	TriggeredAt time.Time
+}
+
+type AlertRule struct {
+	ID                 uuid.UUID
+	Name               string
+	Tickers            []string
+	Action             NullStockActionType
+	RatingTo           NullStockRatingType
+	MinTargetChangePct pgtype.Float8
+	ScoreCrosses       pgtype.Int4
+	Enabled            bool
+	CreatedAt          time.Time
+}
+
+type DatasetVersion struct {
+	ID        int32
+	Version   int64
+	UpdatedAt time.Time
+}
+
+type IngestionRun struct {
+	ID         uuid.UUID
+	Source     IngestionRunSource
+	Status     IngestionRunStatus
+	Error      pgtype.Text
+	StartedAt  time.Time
+	FinishedAt pgtype.Timestamptz
+}
+
+type IngestionRunTicker struct {
+	RunID  uuid.UUID
+	Ticker string
+}
+
+type RateLimitBucket struct {
+	Key string
+	Tat time.Time
+}
+
+type RawPage struct {
+	RunID     uuid.UUID
+	Page      int32
+	Cursor    string
+	FetchedAt time.Time
+	Payload   []byte
+}
+
 type StockRating struct {
+	Ticker         string
+	Company        string
+	Brokerage      string
+	TargetFrom     pgtype.Numeric
+	TargetTo       pgtype.Numeric
+	Action         StockActionType
+	RawAction      string
+	RatingFrom     StockRatingType
+	RawRatingFrom  string
+	RatingTo       StockRatingType
+	RawRatingTo    string
+	At             time.Time
+	TargetDelta    pgtype.Numeric
+	TargetDeltaPct pgtype.Numeric
+	Score          int32
+}
+
+type StockRatingEvent struct {
+	ID            uuid.UUID
 	Ticker        string
 	Company       string
 	Brokerage     string
@@ -112,4 +323,59 @@ type StockRating struct {
 	RatingTo      StockRatingType
 	RawRatingTo   string
 	At            time.Time
+	RecordedAt    time.Time
+	Seq           int64
+}
+
+type StockRatingEventPosition struct {
+	ID  int32
+	Seq int64
+}
+
+type StockRatingMonthly struct {
+	Ticker          string
+	Month           pgtype.Date
+	Events          int64
+	Upgrades        int64
+	Downgrades      int64
+	FirstTargetFrom pgtype.Numeric
+	LastTargetTo    pgtype.Numeric
+	MinTarget       pgtype.Numeric
+	MaxTarget       pgtype.Numeric
+	LastRatingTo    StockRatingType
+	FirstAt         time.Time
+	LastAt          time.Time
+}
+
+type Webhook struct {
+	ID         uuid.UUID
+	Url        string
+	Secret     string
+	EventTypes []string
+	Enabled    bool
+	CreatedAt  time.Time
+}
+
+type WebhookDelivery struct {
+	ID             uuid.UUID
+	WebhookID      uuid.UUID
+	EventType      string
+	EventKey       string
+	Payload        []byte
+	Status         WebhookDeliveryStatus
+	Attempts       int32
+	NextAttemptAt  time.Time
+	ResponseStatus pgtype.Int4
+	LastError      pgtype.Text
+	CreatedAt      time.Time
+}
+
+type WebhookDeliveryAttempt struct {
+	ID             uuid.UUID
+	DeliveryID     uuid.UUID
+	Attempt        int32
+	ResponseStatus pgtype.Int4
+	Error          pgtype.Text
+	DurationMs     int32
+	AttemptedAt    time.Time
 }
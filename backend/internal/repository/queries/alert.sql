@@ -0,0 +1,64 @@
+-- Rules
+
+-- name: CreateAlertRule :one
+INSERT INTO alert_rule (
+    name, tickers, action, rating_to, min_target_change_pct, score_crosses
+) VALUES (
+    $1, $2, $3, $4, $5, $6
+)
+RETURNING *;
+
+-- name: ListAlertRules :many
+SELECT * FROM alert_rule
+ORDER BY created_at ASC;
+
+-- name: ListEnabledAlertRules :many
+SELECT * FROM alert_rule
+WHERE enabled
+ORDER BY created_at ASC;
+
+-- name: DeleteAlertRule :execrows
+DELETE FROM alert_rule
+WHERE id = $1;
+
+
+-- Events
+
+-- name: AddAlertEvent :execrows
+INSERT INTO alert_event (
+    rule_id, run_id, ticker, company, brokerage, action, rating_from, rating_to, target_from, target_to, score, reason, at
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13
+)
+ON CONFLICT (rule_id, ticker, at) DO NOTHING;
+
+-- name: ListAlertEvents :many
+SELECT
+    e.id,
+    e.rule_id,
+    r.name AS rule_name,
+    e.ticker,
+    e.company,
+    e.brokerage,
+    e.action,
+    e.rating_from,
+    e.rating_to,
+    e.target_from::text AS target_from,
+    e.target_to::text AS target_to,
+    e.score,
+    e.reason,
+    e.at,
+    e.triggered_at
+FROM alert_event e
+JOIN alert_rule r ON r.id = e.rule_id
+WHERE sqlc.narg('ticker')::text IS NULL OR e.ticker = sqlc.narg('ticker')::text
+ORDER BY e.triggered_at DESC, e.ticker ASC
+LIMIT sqlc.arg('limit')
+OFFSET sqlc.arg('offset');
+
+
+-- Scores of the current ratings and when they were made, before a load replaces them
+
+-- name: GetStockRatingScores :many
+SELECT ticker, at, score
+FROM stock_rating;
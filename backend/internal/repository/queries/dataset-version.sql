@@ -0,0 +1,9 @@
+-- name: BumpDatasetVersion :one
+UPDATE dataset_version
+SET version = version + 1, updated_at = now()
+WHERE id = 1
+RETURNING version, updated_at;
+
+-- name: GetDatasetVersion :one
+SELECT version, updated_at FROM dataset_version
+WHERE id = 1;
@@ -0,0 +1,55 @@
+-- name: CreateIngestionRun :one
+INSERT INTO ingestion_run (
+    source
+) VALUES (
+    $1
+)
+RETURNING id;
+
+-- name: FinishIngestionRun :exec
+UPDATE ingestion_run
+SET status = $2, error = $3, finished_at = now()
+WHERE id = $1;
+
+-- Tickers loaded by a run, the diff between two points of the history takes the others as removed
+-- name: AddIngestionRunTickers :exec
+INSERT INTO ingestion_run_ticker (run_id, ticker)
+SELECT DISTINCT sqlc.arg('run_id')::UUID, unnest(sqlc.arg('tickers')::text[])
+ON CONFLICT DO NOTHING;
+
+-- name: GetIngestionRun :one
+SELECT * FROM ingestion_run
+WHERE id = $1;
+
+-- name: GetLatestIngestionRun :one
+SELECT * FROM ingestion_run
+WHERE source = 'api' AND status = 'succeeded'
+ORDER BY started_at DESC
+LIMIT 1;
+
+-- Most recent runs first, with the number of pages they archived
+-- name: ListIngestionRuns :many
+SELECT
+    id,
+    source,
+    status,
+    error,
+    started_at,
+    finished_at,
+    (SELECT COUNT(*) FROM raw_page WHERE raw_page.run_id = ingestion_run.id) AS pages
+FROM ingestion_run
+ORDER BY started_at DESC
+LIMIT sqlc.arg('limit');
+
+-- Archive
+
+-- name: AddRawPage :exec
+INSERT INTO raw_page (
+    run_id, page, cursor, fetched_at, payload
+) VALUES (
+    $1, $2, $3, $4, $5
+);
+
+-- name: GetRawPage :one
+SELECT * FROM raw_page
+WHERE run_id = $1 AND page = $2;
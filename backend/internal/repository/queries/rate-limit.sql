@@ -0,0 +1,19 @@
+-- Take a token, returning how far ahead of now the bucket is in microseconds. No row is returned
+-- when the bucket is empty.
+-- name: TakeRateLimitToken :one
+INSERT INTO rate_limit_bucket AS b (key, tat)
+VALUES (sqlc.arg('key')::text, now() + sqlc.arg('interval_us')::INT8 * INTERVAL '1 microsecond')
+ON CONFLICT (key) DO UPDATE
+SET tat = GREATEST(b.tat, now()) + sqlc.arg('interval_us')::INT8 * INTERVAL '1 microsecond'
+WHERE GREATEST(b.tat, now()) + sqlc.arg('interval_us')::INT8 * INTERVAL '1 microsecond'
+    <= now() + sqlc.arg('window_us')::INT8 * INTERVAL '1 microsecond'
+RETURNING (EXTRACT(EPOCH FROM tat - now()) * 1000000)::INT8 AS ahead_us;
+
+-- name: GetRateLimitBucket :one
+SELECT (EXTRACT(EPOCH FROM tat - now()) * 1000000)::INT8 AS ahead_us
+FROM rate_limit_bucket
+WHERE key = $1;
+
+-- name: DeleteFullRateLimitBuckets :exec
+DELETE FROM rate_limit_bucket
+WHERE tat < now();
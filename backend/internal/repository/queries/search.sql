@@ -0,0 +1,41 @@
+-- Typeahead suggestions, ranked by trigram similarity plus a boost when the value starts with the
+-- query (1) or one of its words does (0.5). The prefix must have its LIKE wildcards escaped.
+-- name: Search :many
+WITH candidates AS (
+    SELECT DISTINCT 'ticker' AS kind, ticker AS value
+    FROM stock_rating
+    WHERE ticker % sqlc.arg('q')::text OR ticker ILIKE sqlc.arg('prefix')::text || '%'
+    UNION ALL
+    SELECT DISTINCT 'company' AS kind, company AS value
+    FROM stock_rating
+    WHERE company % sqlc.arg('q')::text OR company ILIKE '%' || sqlc.arg('prefix')::text || '%'
+    UNION ALL
+    SELECT DISTINCT 'brokerage' AS kind, brokerage AS value
+    FROM stock_rating
+    WHERE brokerage % sqlc.arg('q')::text OR brokerage ILIKE '%' || sqlc.arg('prefix')::text || '%'
+), ranked AS (
+    SELECT
+        kind,
+        value,
+        similarity(value, sqlc.arg('q')::text)
+        + CASE
+            WHEN value ILIKE sqlc.arg('prefix')::text || '%' THEN 1
+            WHEN value ILIKE '% ' || sqlc.arg('prefix')::text || '%' THEN 0.5
+            ELSE 0
+        END AS rank
+    FROM candidates
+), numbered AS (
+    SELECT
+        kind,
+        value,
+        rank,
+        ROW_NUMBER() OVER (PARTITION BY kind ORDER BY rank DESC, value ASC) AS position
+    FROM ranked
+)
+SELECT
+    kind::text,
+    value::text,
+    rank::FLOAT8
+FROM numbered
+WHERE position <= sqlc.arg('limit')::INT
+ORDER BY kind, rank DESC, value ASC;
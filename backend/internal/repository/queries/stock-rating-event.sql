@@ -0,0 +1,375 @@
+-- History of the ratings, appended by every load
+
+-- The events already recorded are skipped, a load reingests the whole history, and so are the
+-- events the retention rolled up. The events take the next positions of the change feed, the update
+-- of stock_rating_event_position holding its lock until the statement commits (migration 0013).
+-- name: AddStockRatingEvents :execrows
+WITH position AS (
+    UPDATE stock_rating_event_position
+    SET seq = seq + cardinality(sqlc.arg(ticker)::text[])
+    WHERE id = 1
+    RETURNING seq - cardinality(sqlc.arg(ticker)::text[]) AS start
+)
+INSERT INTO stock_rating_event (
+    ticker, company, brokerage, target_from, target_to, action, raw_action, rating_from, raw_rating_from, rating_to, raw_rating_to, at, seq
+)
+SELECT
+    e.ticker, e.company, e.brokerage, e.target_from, e.target_to, e.action::stock_action_type, e.raw_action,
+    e.rating_from::stock_rating_type, e.raw_rating_from, e.rating_to::stock_rating_type, e.raw_rating_to, e.at,
+    position.start + e.n
+FROM position, (
+    SELECT
+        unnest(sqlc.arg(ticker)::text[]) AS ticker,
+        unnest(sqlc.arg(company)::text[]) AS company,
+        unnest(sqlc.arg(brokerage)::text[]) AS brokerage,
+        unnest(sqlc.arg(target_from)::numeric[]) AS target_from,
+        unnest(sqlc.arg(target_to)::numeric[]) AS target_to,
+        unnest(sqlc.arg(action)::text[]) AS action,
+        unnest(sqlc.arg(raw_action)::text[]) AS raw_action,
+        unnest(sqlc.arg(rating_from)::text[]) AS rating_from,
+        unnest(sqlc.arg(raw_rating_from)::text[]) AS raw_rating_from,
+        unnest(sqlc.arg(rating_to)::text[]) AS rating_to,
+        unnest(sqlc.arg(raw_rating_to)::text[]) AS raw_rating_to,
+        unnest(sqlc.arg(at)::timestamptz[]) AS at,
+        generate_series(1, cardinality(sqlc.arg(ticker)::text[])) AS n
+) AS e
+WHERE NOT EXISTS (
+    SELECT 1 FROM stock_rating_monthly m
+    WHERE m.ticker = e.ticker AND m.month = date_trunc('month', e.at AT TIME ZONE 'UTC')::DATE AND e.at <= m.last_at
+)
+ON CONFLICT (ticker, brokerage, at) DO NOTHING;
+
+-- Record the ratings of the tickers, loaded before the history, as their first events. They are
+-- recorded at the end of the first successful ingestion run started after them, the one that loaded
+-- them, or at their own time without one, for the diffs between runs to not see them added now.
+-- They take the next positions of the change feed as AddStockRatingEvents.
+-- name: BackfillStockRatingEvents :execrows
+WITH position AS (
+    UPDATE stock_rating_event_position
+    SET seq = seq + (SELECT count(*) FROM stock_rating WHERE ticker = ANY(sqlc.arg('tickers')::text[]))
+    WHERE id = 1
+    RETURNING seq - (SELECT count(*) FROM stock_rating WHERE ticker = ANY(sqlc.arg('tickers')::text[])) AS start
+)
+INSERT INTO stock_rating_event (
+    ticker, company, brokerage, target_from, target_to, action, raw_action, rating_from, raw_rating_from, rating_to, raw_rating_to, at, recorded_at, seq
+)
+SELECT
+    s.ticker, s.company, s.brokerage, s.target_from, s.target_to, s.action, s.raw_action, s.rating_from, s.raw_rating_from, s.rating_to, s.raw_rating_to, s.at,
+    COALESCE((
+        SELECT min(r.finished_at)
+        FROM ingestion_run r
+        WHERE r.status = 'succeeded' AND r.started_at >= s.at
+    ), s.at),
+    position.start + row_number() OVER (ORDER BY s.ticker)
+FROM stock_rating s, position
+WHERE s.ticker = ANY(sqlc.arg('tickers')::text[])
+ON CONFLICT (ticker, brokerage, at) DO NOTHING;
+
+-- As of a past time
+
+-- The list as it was at as_of: the latest event of each ticker at or before it, with the columns
+-- stock_rating computes (migration 0009) and the filters and sort of GetStockRatings
+-- name: GetStockRatingsAsOf :many
+WITH latest AS (
+    SELECT DISTINCT ON (ticker)
+        ticker, company, brokerage, target_from, target_to, action, raw_action, rating_from, raw_rating_from, rating_to, raw_rating_to, at
+    FROM stock_rating_event
+    WHERE at <= sqlc.arg('as_of')
+    ORDER BY ticker, at DESC, recorded_at DESC, brokerage
+), scored AS (
+    SELECT
+        *,
+        (target_to - target_from)::NUMERIC(10,2) AS target_delta,
+        COALESCE((target_to - target_from) / NULLIF(target_from, 0) * 100, 0)::NUMERIC(10,2) AS target_delta_pct,
+        (TRUNC((10 * COALESCE((target_to - target_from) / NULLIF(target_from, 0), 0))
+        + (2 * (CASE rating_to
+            WHEN 'buy' THEN 1
+            WHEN 'hold' THEN 0
+            WHEN 'pending' THEN 0
+            WHEN 'sell' THEN -1
+        END))
+        + (1 * (CASE action
+            WHEN 'up' THEN 1
+            WHEN 'down' THEN -1
+            WHEN 'reiterated' THEN 0
+        END)), 3) * 1000)::INT4 AS score
+    FROM latest
+    WHERE
+        (sqlc.arg('ticker_like')::text IS NULL OR ticker ILIKE '%' || sqlc.arg('ticker_like')::text || '%')
+        AND (sqlc.arg('company_like')::text IS NULL OR company ILIKE '%' || sqlc.arg('company_like')::text || '%')
+)
+SELECT
+    ticker,
+    company,
+    brokerage,
+    target_from::text,
+    target_to::text,
+    action,
+    raw_action,
+    rating_from,
+    raw_rating_from,
+    rating_to,
+    raw_rating_to,
+    at,
+    target_delta::text,
+    target_delta_pct::text,
+    score
+FROM scored
+ORDER BY
+    -- Numeric ordering
+    CASE WHEN sqlc.arg('sort_order')::text = 'desc' THEN
+        CASE sqlc.arg('sort_by')::text
+            WHEN 'target_from' THEN target_from
+            WHEN 'target_to' THEN target_to
+            WHEN 'target_delta' THEN target_delta
+            ELSE NULl
+        END
+    END DESC,
+    CASE WHEN sqlc.arg('sort_order')::text = 'asc' THEN
+        CASE sqlc.arg('sort_by')::text
+            WHEN 'target_from' THEN target_from
+            WHEN 'target_to' THEN target_to
+            WHEN 'target_delta' THEN target_delta
+            ELSE NULl
+        END
+    END ASC,
+    -- Score ordering, an integer the numeric cases can't mix with
+    CASE WHEN sqlc.arg('sort_order')::text = 'desc' AND sqlc.arg('sort_by')::text = 'score' THEN score END DESC,
+    CASE WHEN sqlc.arg('sort_order')::text = 'asc' AND sqlc.arg('sort_by')::text = 'score' THEN score END ASC,
+    -- String Ordering
+    CASE WHEN sqlc.arg('sort_order')::text = 'desc' THEN
+        CASE sqlc.arg('sort_by')::text
+            WHEN 'ticker' THEN ticker::text
+            WHEN 'company' THEN company::text
+            WHEN 'brokerage' THEN brokerage::text
+            WHEN 'action' THEN action::text
+            WHEN 'rating_from' THEN rating_from::text
+            WHEN 'rating_to' THEN rating_to::text
+            ELSE NULl
+        END
+    END DESC,
+    CASE WHEN sqlc.arg('sort_order')::text = 'asc' THEN
+        CASE sqlc.arg('sort_by')::text
+            WHEN 'ticker' THEN ticker::text
+            WHEN 'company' THEN company::text
+            WHEN 'brokerage' THEN brokerage::text
+            WHEN 'action' THEN action::text
+            WHEN 'rating_from' THEN rating_from::text
+            WHEN 'rating_to' THEN rating_to::text
+            ELSE NULl
+        END
+    END,
+    ticker ASC
+
+LIMIT sqlc.arg('limit')
+OFFSET sqlc.arg('offset');
+
+-- Rating of a ticker as it was at as_of, with the columns of the list
+-- name: GetStockRatingAsOf :one
+SELECT
+    ticker,
+    company,
+    brokerage,
+    target_from::text,
+    target_to::text,
+    action,
+    raw_action,
+    rating_from,
+    raw_rating_from,
+    rating_to,
+    raw_rating_to,
+    at,
+    (target_to - target_from)::NUMERIC(10,2)::text AS target_delta,
+    COALESCE((target_to - target_from) / NULLIF(target_from, 0) * 100, 0)::NUMERIC(10,2)::text AS target_delta_pct,
+    (TRUNC((10 * COALESCE((target_to - target_from) / NULLIF(target_from, 0), 0))
+    + (2 * (CASE rating_to
+        WHEN 'buy' THEN 1
+        WHEN 'hold' THEN 0
+        WHEN 'pending' THEN 0
+        WHEN 'sell' THEN -1
+    END))
+    + (1 * (CASE action
+        WHEN 'up' THEN 1
+        WHEN 'down' THEN -1
+        WHEN 'reiterated' THEN 0
+    END)), 3) * 1000)::INT4 AS score
+FROM stock_rating_event
+WHERE ticker = sqlc.arg('ticker') AND at <= sqlc.arg('as_of')
+ORDER BY at DESC, recorded_at DESC, brokerage
+LIMIT 1;
+
+-- History of tickers
+
+-- Events of the given tickers, the most recent first, with the columns of the list
+-- name: GetStockRatingEventsByTickers :many
+SELECT
+    ticker,
+    company,
+    brokerage,
+    target_from::text,
+    target_to::text,
+    action,
+    raw_action,
+    rating_from,
+    raw_rating_from,
+    rating_to,
+    raw_rating_to,
+    at,
+    (target_to - target_from)::NUMERIC(10,2)::text AS target_delta,
+    COALESCE((target_to - target_from) / NULLIF(target_from, 0) * 100, 0)::NUMERIC(10,2)::text AS target_delta_pct,
+    (TRUNC((10 * COALESCE((target_to - target_from) / NULLIF(target_from, 0), 0))
+    + (2 * (CASE rating_to
+        WHEN 'buy' THEN 1
+        WHEN 'hold' THEN 0
+        WHEN 'pending' THEN 0
+        WHEN 'sell' THEN -1
+    END))
+    + (1 * (CASE action
+        WHEN 'up' THEN 1
+        WHEN 'down' THEN -1
+        WHEN 'reiterated' THEN 0
+    END)), 3) * 1000)::INT4 AS score
+FROM stock_rating_event
+WHERE ticker = ANY(sqlc.arg('tickers')::text[])
+ORDER BY ticker, at DESC, recorded_at DESC, brokerage;
+
+-- Between two points of the history
+
+-- Tickers added, removed, or with another rating, target or score between two points of the history.
+-- A point is bounded by the time of the events, as GetStockRatingsAsOf, or by the time they were
+-- recorded, for the end of an ingestion run. It only has the tickers loaded by the last successful
+-- run finished at that time, when the run recorded them, the others were dropped upstream.
+-- name: GetStockRatingsDiff :many
+WITH
+before_tickers AS (
+    SELECT ticker FROM ingestion_run_ticker
+    WHERE run_id = (
+        SELECT id FROM ingestion_run
+        WHERE status = 'succeeded' AND finished_at <= COALESCE(sqlc.narg('from_at')::TIMESTAMPTZ, sqlc.narg('from_recorded_at')::TIMESTAMPTZ)
+        ORDER BY finished_at DESC
+        LIMIT 1
+    )
+),
+after_tickers AS (
+    SELECT ticker FROM ingestion_run_ticker
+    WHERE run_id = (
+        SELECT id FROM ingestion_run
+        WHERE status = 'succeeded' AND finished_at <= COALESCE(sqlc.narg('to_at')::TIMESTAMPTZ, sqlc.narg('to_recorded_at')::TIMESTAMPTZ)
+        ORDER BY finished_at DESC
+        LIMIT 1
+    )
+),
+before AS (
+    SELECT DISTINCT ON (ticker) *
+    FROM stock_rating_event
+    WHERE
+        (sqlc.narg('from_at')::TIMESTAMPTZ IS NULL OR at <= sqlc.narg('from_at'))
+        AND (sqlc.narg('from_recorded_at')::TIMESTAMPTZ IS NULL OR recorded_at <= sqlc.narg('from_recorded_at'))
+        AND (NOT EXISTS (SELECT 1 FROM before_tickers) OR ticker IN (SELECT ticker FROM before_tickers))
+    ORDER BY ticker, at DESC, recorded_at DESC, brokerage
+),
+after AS (
+    SELECT DISTINCT ON (ticker) *
+    FROM stock_rating_event
+    WHERE
+        (sqlc.narg('to_at')::TIMESTAMPTZ IS NULL OR at <= sqlc.narg('to_at'))
+        AND (sqlc.narg('to_recorded_at')::TIMESTAMPTZ IS NULL OR recorded_at <= sqlc.narg('to_recorded_at'))
+        AND (NOT EXISTS (SELECT 1 FROM after_tickers) OR ticker IN (SELECT ticker FROM after_tickers))
+    ORDER BY ticker, at DESC, recorded_at DESC, brokerage
+),
+before_scored AS (
+    SELECT
+        ticker,
+        company,
+        brokerage,
+        action,
+        rating_to,
+        target_to,
+        target_to::text AS target_to_text,
+        (TRUNC((10 * COALESCE((target_to - target_from) / NULLIF(target_from, 0), 0))
+        + (2 * (CASE rating_to
+            WHEN 'buy' THEN 1
+            WHEN 'hold' THEN 0
+            WHEN 'pending' THEN 0
+            WHEN 'sell' THEN -1
+        END))
+        + (1 * (CASE action
+            WHEN 'up' THEN 1
+            WHEN 'down' THEN -1
+            WHEN 'reiterated' THEN 0
+        END)), 3) * 1000)::INT4 AS score,
+        at
+    FROM before
+),
+after_scored AS (
+    SELECT
+        ticker,
+        company,
+        brokerage,
+        action,
+        rating_to,
+        target_to,
+        target_to::text AS target_to_text,
+        (TRUNC((10 * COALESCE((target_to - target_from) / NULLIF(target_from, 0), 0))
+        + (2 * (CASE rating_to
+            WHEN 'buy' THEN 1
+            WHEN 'hold' THEN 0
+            WHEN 'pending' THEN 0
+            WHEN 'sell' THEN -1
+        END))
+        + (1 * (CASE action
+            WHEN 'up' THEN 1
+            WHEN 'down' THEN -1
+            WHEN 'reiterated' THEN 0
+        END)), 3) * 1000)::INT4 AS score,
+        at
+    FROM after
+)
+SELECT
+    COALESCE(a.ticker, b.ticker)::text AS ticker,
+    COALESCE(a.company, b.company)::text AS company,
+    b.brokerage AS before_brokerage,
+    b.action AS before_action,
+    b.rating_to AS before_rating_to,
+    b.target_to_text AS before_target_to,
+    b.score AS before_score,
+    b.at AS before_at,
+    a.brokerage AS after_brokerage,
+    a.action AS after_action,
+    a.rating_to AS after_rating_to,
+    a.target_to_text AS after_target_to,
+    a.score AS after_score,
+    a.at AS after_at
+FROM before_scored AS b
+FULL OUTER JOIN after_scored AS a ON a.ticker = b.ticker
+WHERE
+    b.ticker IS NULL
+    OR a.ticker IS NULL
+    OR a.rating_to <> b.rating_to
+    OR a.target_to <> b.target_to
+    OR a.score <> b.score
+ORDER BY 1;
+
+-- Change feed
+
+-- The events recorded after a position of the feed, in the order they were recorded
+-- name: ListStockRatingEventsAfter :many
+SELECT
+    seq,
+    ticker,
+    company,
+    brokerage,
+    target_from::text,
+    target_to::text,
+    action,
+    raw_action,
+    rating_from,
+    raw_rating_from,
+    rating_to,
+    raw_rating_to,
+    at,
+    recorded_at
+FROM stock_rating_event
+WHERE seq > sqlc.arg('after')
+ORDER BY seq
+LIMIT sqlc.arg('lim');
@@ -0,0 +1,82 @@
+-- Retention of the history, the events past the cutoff are rolled into monthly summaries. The
+-- latest event of each ticker is kept whatever its age: it is the current rating, the one as_of
+-- reads and every load sends again. So are the events recorded since recorded_before, the change
+-- feed serves them in the order they were recorded and its readers may not have read them yet. An
+-- event expires once its ticker has a newer one in the order of as_of, looked up in the
+-- (ticker, at DESC) index rather than ranking the whole history on every batch.
+
+-- Events older than the cutoff per month, what a roll-up would delete and summarize
+-- name: GetExpiredStockRatingEvents :many
+SELECT
+    date_trunc('month', at AT TIME ZONE 'UTC')::DATE AS month,
+    COUNT(*) AS events,
+    COUNT(DISTINCT ticker) AS tickers
+FROM stock_rating_event e
+WHERE e.at < sqlc.arg('before') AND e.recorded_at < sqlc.arg('recorded_before') AND EXISTS (
+    SELECT 1 FROM stock_rating_event n
+    WHERE n.ticker = e.ticker AND n.at >= e.at
+        AND (n.at > e.at OR n.recorded_at > e.recorded_at OR (n.recorded_at = e.recorded_at AND n.brokerage < e.brokerage))
+)
+GROUP BY month
+ORDER BY month;
+
+-- Delete the oldest events before the cutoff, up to lim, and merge them into the summaries of
+-- their month in the same statement, so a batch is either rolled up whole or not at all
+-- name: RollUpStockRatingEvents :one
+WITH expired AS (
+    DELETE FROM stock_rating_event
+    WHERE id IN (
+        SELECT e.id FROM stock_rating_event e
+        WHERE e.at < sqlc.arg('before') AND e.recorded_at < sqlc.arg('recorded_before') AND EXISTS (
+            SELECT 1 FROM stock_rating_event n
+            WHERE n.ticker = e.ticker AND n.at >= e.at
+                AND (n.at > e.at OR n.recorded_at > e.recorded_at OR (n.recorded_at = e.recorded_at AND n.brokerage < e.brokerage))
+        )
+        ORDER BY e.at, e.id
+        LIMIT sqlc.arg('lim')
+    )
+    RETURNING ticker, brokerage, target_from, target_to, action, rating_to, at
+), summaries AS (
+    INSERT INTO stock_rating_monthly (
+        ticker, month, events, upgrades, downgrades, first_target_from, last_target_to, min_target, max_target, last_rating_to, first_at, last_at
+    )
+    SELECT
+        ticker,
+        date_trunc('month', at AT TIME ZONE 'UTC')::DATE AS month,
+        COUNT(*),
+        COUNT(*) FILTER (WHERE action = 'up'),
+        COUNT(*) FILTER (WHERE action = 'down'),
+        (array_agg(target_from ORDER BY at, brokerage))[1],
+        (array_agg(target_to ORDER BY at DESC, brokerage DESC))[1],
+        MIN(LEAST(target_from, target_to)),
+        MAX(GREATEST(target_from, target_to)),
+        (array_agg(rating_to ORDER BY at DESC, brokerage DESC))[1],
+        MIN(at),
+        MAX(at)
+    FROM expired
+    GROUP BY ticker, month
+    ON CONFLICT (ticker, month) DO UPDATE SET
+        events = stock_rating_monthly.events + excluded.events,
+        upgrades = stock_rating_monthly.upgrades + excluded.upgrades,
+        downgrades = stock_rating_monthly.downgrades + excluded.downgrades,
+        first_target_from = CASE WHEN excluded.first_at < stock_rating_monthly.first_at
+            THEN excluded.first_target_from ELSE stock_rating_monthly.first_target_from END,
+        last_target_to = CASE WHEN excluded.last_at >= stock_rating_monthly.last_at
+            THEN excluded.last_target_to ELSE stock_rating_monthly.last_target_to END,
+        min_target = LEAST(stock_rating_monthly.min_target, excluded.min_target),
+        max_target = GREATEST(stock_rating_monthly.max_target, excluded.max_target),
+        last_rating_to = CASE WHEN excluded.last_at >= stock_rating_monthly.last_at
+            THEN excluded.last_rating_to ELSE stock_rating_monthly.last_rating_to END,
+        first_at = LEAST(stock_rating_monthly.first_at, excluded.first_at),
+        last_at = GREATEST(stock_rating_monthly.last_at, excluded.last_at)
+    RETURNING ticker
+)
+SELECT
+    (SELECT COUNT(*) FROM expired) AS events,
+    (SELECT COUNT(*) FROM summaries) AS summaries;
+
+-- Summaries of a ticker, the oldest first
+-- name: GetStockRatingMonthly :many
+SELECT * FROM stock_rating_monthly
+WHERE ticker = sqlc.arg('ticker')
+ORDER BY month;
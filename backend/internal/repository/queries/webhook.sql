@@ -0,0 +1,93 @@
+-- Subscriptions
+
+-- name: CreateWebhook :one
+INSERT INTO webhook (
+    url, secret, event_types
+) VALUES (
+    $1, $2, $3
+)
+RETURNING *;
+
+-- name: ListWebhooks :many
+SELECT * FROM webhook
+ORDER BY created_at ASC;
+
+-- name: ListEnabledWebhooks :many
+SELECT * FROM webhook
+WHERE enabled
+ORDER BY created_at ASC;
+
+-- name: DeleteWebhook :execrows
+DELETE FROM webhook
+WHERE id = $1;
+
+
+-- Queue
+
+-- name: AddWebhookDelivery :execrows
+INSERT INTO webhook_delivery (
+    webhook_id, event_type, event_key, payload
+) VALUES (
+    $1, $2, $3, $4
+)
+ON CONFLICT (webhook_id, event_key) DO NOTHING;
+
+-- Take the due deliveries, leasing them for a minute so another dispatcher doesn't send them too
+-- name: ClaimWebhookDeliveries :many
+WITH claimed AS (
+    UPDATE webhook_delivery
+    SET attempts = attempts + 1, next_attempt_at = now() + INTERVAL '1 minute'
+    WHERE id IN (
+        SELECT id FROM webhook_delivery
+        WHERE status = 'pending' AND next_attempt_at <= now()
+        ORDER BY next_attempt_at ASC
+        LIMIT sqlc.arg('limit')
+    )
+    RETURNING id, webhook_id, event_type, payload, attempts
+)
+SELECT
+    c.id,
+    c.webhook_id,
+    c.event_type,
+    c.payload,
+    c.attempts,
+    w.url,
+    w.secret
+FROM claimed c
+JOIN webhook w ON w.id = c.webhook_id;
+
+-- Only while the claim holds: once its lease ran out another dispatcher may have claimed it again
+-- name: FinishWebhookDeliveryAttempt :execrows
+UPDATE webhook_delivery
+SET status = $2, next_attempt_at = $3, response_status = $4, last_error = $5
+WHERE id = $1 AND attempts = $6;
+
+-- name: AddWebhookDeliveryAttempt :exec
+INSERT INTO webhook_delivery_attempt (
+    delivery_id, attempt, response_status, error, duration_ms
+) VALUES (
+    $1, $2, $3, $4, $5
+);
+
+
+-- Log
+
+-- name: ListWebhookDeliveries :many
+SELECT * FROM webhook_delivery
+WHERE
+    webhook_id = sqlc.arg('webhook_id')
+    AND (sqlc.narg('status')::WEBHOOK_DELIVERY_STATUS IS NULL OR status = sqlc.narg('status')::WEBHOOK_DELIVERY_STATUS)
+ORDER BY created_at DESC, id ASC
+LIMIT sqlc.arg('limit')
+OFFSET sqlc.arg('offset');
+
+-- name: ReplayWebhookDelivery :execrows
+UPDATE webhook_delivery
+SET status = 'pending', attempts = 0, next_attempt_at = now()
+WHERE id = $1 AND webhook_id = $2;
+
+
+-- Ratings already known before a load, only the others are delivered
+
+-- name: GetStockRatingTimes :many
+SELECT ticker, at FROM stock_rating;
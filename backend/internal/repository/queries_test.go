@@ -0,0 +1,46 @@
+package repository_test
+
+import (
+	"backend/internal/repository"
+	"backend/internal/repository/repositorytest"
+	"backend/pkg/db"
+	"context"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Tables emptied before each test, the dataset version, the position of the change feed and the
+// migrations are kept
+const truncate = `TRUNCATE TABLE
+	stock_rating, stock_rating_event, stock_rating_monthly, ingestion_run, ingestion_run_ticker, raw_page,
+	rate_limit_bucket, alert_rule, alert_event, webhook, webhook_delivery, webhook_delivery_attempt
+CASCADE`
+
+// Runs the conformance suite against the queries of sqlc, on the database of TEST_DATABASE_URL. The
+// database is migrated and its tables are emptied, never point it at a database with data to keep.
+func TestConformance(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	t.Setenv("DATABASE_URL", dsn)
+	if err := db.RunMigrations(io.Discard, []string{"up-last"}, false); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+
+	pool, err := pgxpool.New(context.Background(), dsn)
+	if err != nil {
+		t.Fatalf("failed to connect to database: %v", err)
+	}
+	t.Cleanup(pool.Close)
+
+	repositorytest.Run(t, func(t *testing.T) repository.Repository {
+		if _, err := pool.Exec(context.Background(), truncate); err != nil {
+			t.Fatalf("failed to empty the tables: %v", err)
+		}
+		return repository.New(pool)
+	})
+}
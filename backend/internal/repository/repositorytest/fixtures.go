@@ -0,0 +1,35 @@
+package repositorytest
+
+import (
+	"backend/internal/repository"
+	"math/big"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// FIXTURES ========================================================================================
+// Ratings for the tests of the features, loaded in the in-memory store. The tests change the fields
+// they are about and leave the rest to these defaults.
+
+// Time of the ratings of the tests that don't care about it
+var RatedAt = time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+// Upgrade of the ticker's company from hold to buy by Goldman Sachs, its target moved from
+// targetFrom to targetTo
+func NewStockRating(ticker string, targetFrom int64, targetTo int64, at time.Time) repository.AddStockRatingsParams {
+	return repository.AddStockRatingsParams{
+		Ticker:        ticker,
+		Company:       ticker + " Inc.",
+		Brokerage:     "Goldman Sachs",
+		TargetFrom:    pgtype.Numeric{Int: big.NewInt(targetFrom), Valid: true},
+		TargetTo:      pgtype.Numeric{Int: big.NewInt(targetTo), Valid: true},
+		Action:        repository.StockActionTypeUp,
+		RawAction:     "upgraded by",
+		RatingFrom:    repository.StockRatingTypeHold,
+		RawRatingFrom: "Neutral",
+		RatingTo:      repository.StockRatingTypeBuy,
+		RawRatingTo:   "Buy",
+		At:            at,
+	}
+}
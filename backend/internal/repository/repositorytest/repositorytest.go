@@ -0,0 +1,1215 @@
+// Conformance suite of repository.Repository, run against the in-memory store and, when a database
+// is available, against the queries of sqlc. The expected scores, deltas and orders are computed by
+// hand from the fixtures, so both implementations must agree with the queries as written.
+package repositorytest
+
+import (
+	"backend/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Opens an empty repository for a test
+type Open func(t *testing.T) repository.Repository
+
+func Run(t *testing.T, open Open) {
+	t.Run("List", func(t *testing.T) { testList(t, open(t)) })
+	t.Run("Sort", func(t *testing.T) { testSort(t, open(t)) })
+	t.Run("Filter", func(t *testing.T) { testFilter(t, open(t)) })
+	t.Run("Page", func(t *testing.T) { testPage(t, open(t)) })
+	t.Run("Get", func(t *testing.T) { testGet(t, open(t)) })
+	t.Run("Aggregates", func(t *testing.T) { testAggregates(t, open(t)) })
+	t.Run("Duplicate", func(t *testing.T) { testDuplicate(t, open(t)) })
+	t.Run("Computed", func(t *testing.T) { testComputed(t, open(t)) })
+	t.Run("Events", func(t *testing.T) { testEvents(t, open(t)) })
+	t.Run("Backfill", func(t *testing.T) { testBackfill(t, open(t)) })
+	t.Run("AsOf", func(t *testing.T) { testAsOf(t, open(t)) })
+	t.Run("Diff", func(t *testing.T) { testDiff(t, open(t)) })
+	t.Run("Feed", func(t *testing.T) { testFeed(t, open(t)) })
+	t.Run("FeedWriters", func(t *testing.T) { testFeedWriters(t, open(t)) })
+	t.Run("Retention", func(t *testing.T) { testRetention(t, open(t)) })
+	t.Run("Ingestion", func(t *testing.T) { testIngestion(t, open(t)) })
+	t.Run("DatasetVersion", func(t *testing.T) { testDatasetVersion(t, open(t)) })
+	t.Run("RateLimit", func(t *testing.T) { testRateLimit(t, open(t)) })
+	t.Run("Alerts", func(t *testing.T) { testAlerts(t, open(t)) })
+	t.Run("Webhooks", func(t *testing.T) { testWebhooks(t, open(t)) })
+	t.Run("Search", func(t *testing.T) { testSearch(t, open(t)) })
+}
+
+// FIXTURES ========================================================================================
+
+var at = time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+func numeric(s string) pgtype.Numeric {
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		panic("invalid numeric " + s)
+	}
+	// Six decimals are enough for the fixtures
+	i := new(big.Int).Quo(new(big.Int).Mul(r.Num(), big.NewInt(1_000_000)), r.Denom())
+	return pgtype.Numeric{Int: i, Exp: -6, Valid: true}
+}
+
+// Four ratings covering every rating and action, a rounded target and a truncated score
+var fixtures = []repository.AddStockRatingsParams{
+	{
+		Ticker: "AAPL", Company: "Apple Inc.", Brokerage: "Goldman Sachs",
+		TargetFrom: numeric("150"), TargetTo: numeric("180"),
+		Action: repository.StockActionTypeUp, RawAction: "upgraded by",
+		RatingFrom: repository.StockRatingTypeHold, RawRatingFrom: "Neutral",
+		RatingTo: repository.StockRatingTypeBuy, RawRatingTo: "Buy",
+		At: at,
+	},
+	{
+		Ticker: "AMZN", Company: "Amazon.com, Inc.", Brokerage: "Goldman Sachs",
+		TargetFrom: numeric("3"), TargetTo: numeric("4"),
+		Action: repository.StockActionTypeReiterated, RawAction: "reiterated by",
+		RatingFrom: repository.StockRatingTypeSell, RawRatingFrom: "Sell",
+		RatingTo: repository.StockRatingTypeSell, RawRatingTo: "Sell",
+		At: at.Add(time.Hour),
+	},
+	{
+		Ticker: "BRK.B", Company: "Berkshire Hathaway", Brokerage: "Morgan Stanley",
+		TargetFrom: numeric("410.555"), TargetTo: numeric("420"),
+		Action: repository.StockActionTypeReiterated, RawAction: "target raised by",
+		RatingFrom: repository.StockRatingTypePending, RawRatingFrom: "",
+		RatingTo: repository.StockRatingTypePending, RawRatingTo: "",
+		At: at.Add(2 * time.Hour),
+	},
+	{
+		Ticker: "MSFT", Company: "Microsoft Corp", Brokerage: "Morgan Stanley",
+		TargetFrom: numeric("400"), TargetTo: numeric("360"),
+		Action: repository.StockActionTypeDown, RawAction: "downgraded by",
+		RatingFrom: repository.StockRatingTypeBuy, RawRatingFrom: "Outperform",
+		RatingTo: repository.StockRatingTypeHold, RawRatingTo: "Hold",
+		At: at.Add(3 * time.Hour),
+	},
+}
+
+// Computed columns of the fixtures:
+//
+//	AAPL   30 / 150 = 20%, score TRUNC(10 * 0.2 + 2 + 1, 3) = 5
+//	AMZN   1 / 3 = 33.33%, score TRUNC(10 * 0.3333… - 2, 3) = 1.333
+//	BRK.B  410.555 is stored as 410.56, 9.44 / 410.56 = 2.30%, score TRUNC(0.2299…, 3) = 0.229
+//	MSFT   -40 / 400 = -10%, score TRUNC(10 * -0.1 + 0 - 1, 3) = -2
+type computed struct {
+	targetFrom     string
+	targetTo       string
+	targetDelta    string
+	targetDeltaPct string
+	score          int32
+}
+
+var want = map[string]computed{
+	"AAPL":  {"150.00", "180.00", "30.00", "20.00", 5000},
+	"AMZN":  {"3.00", "4.00", "1.00", "33.33", 1333},
+	"BRK.B": {"410.56", "420.00", "9.44", "2.30", 229},
+	"MSFT":  {"400.00", "360.00", "-40.00", "-10.00", -2000},
+}
+
+func seed(t *testing.T, repo repository.Repository) {
+	t.Helper()
+	n, err := repo.AddStockRatings(context.Background(), fixtures)
+	if err != nil {
+		t.Fatalf("AddStockRatings() error = %v", err)
+	}
+	if n != int64(len(fixtures)) {
+		t.Fatalf("AddStockRatings() = %d, want %d", n, len(fixtures))
+	}
+}
+
+func list(t *testing.T, repo repository.Repository, arg repository.GetStockRatingsParams) []string {
+	t.Helper()
+	rows, err := repo.GetStockRatings(context.Background(), arg)
+	if err != nil {
+		t.Fatalf("GetStockRatings(%+v) error = %v", arg, err)
+	}
+	var tickers []string
+	for _, r := range rows {
+		tickers = append(tickers, r.Ticker)
+	}
+	return tickers
+}
+
+func all(sortBy string, sortOrder string) repository.GetStockRatingsParams {
+	return repository.GetStockRatingsParams{SortBy: sortBy, SortOrder: sortOrder, Limit: 100}
+}
+
+func pgCode(err error) string {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code
+	}
+	return ""
+}
+
+// STOCK RATINGS ===================================================================================
+
+func testList(t *testing.T, repo repository.Repository) {
+	seed(t, repo)
+	rows, err := repo.GetStockRatings(context.Background(), all("ticker", "asc"))
+	if err != nil {
+		t.Fatalf("GetStockRatings() error = %v", err)
+	}
+	if len(rows) != len(fixtures) {
+		t.Fatalf("GetStockRatings() returned %d rows, want %d", len(rows), len(fixtures))
+	}
+	for i, r := range rows {
+		f := fixtures[i]
+		w := want[r.Ticker]
+		got := computed{r.TargetFrom, r.TargetTo, r.TargetDelta, r.TargetDeltaPct, r.Score}
+		if got != w {
+			t.Errorf("%s computed columns = %+v, want %+v", r.Ticker, got, w)
+		}
+		if r.Ticker != f.Ticker || r.Company != f.Company || r.Brokerage != f.Brokerage ||
+			r.Action != f.Action || r.RawAction != f.RawAction ||
+			r.RatingFrom != f.RatingFrom || r.RawRatingFrom != f.RawRatingFrom ||
+			r.RatingTo != f.RatingTo || r.RawRatingTo != f.RawRatingTo || !r.At.Equal(f.At) {
+			t.Errorf("row %d = %+v, want the columns of %+v", i, r, f)
+		}
+	}
+}
+
+func testSort(t *testing.T, repo repository.Repository) {
+	seed(t, repo)
+	cases := []struct {
+		sortBy string
+		asc    []string
+		desc   []string
+	}{
+		{"ticker", []string{"AAPL", "AMZN", "BRK.B", "MSFT"}, []string{"MSFT", "BRK.B", "AMZN", "AAPL"}},
+		{"company", []string{"AMZN", "AAPL", "BRK.B", "MSFT"}, []string{"MSFT", "BRK.B", "AAPL", "AMZN"}},
+		// Ties are broken by ticker, ascending in both orders
+		{"brokerage", []string{"AAPL", "AMZN", "BRK.B", "MSFT"}, []string{"BRK.B", "MSFT", "AAPL", "AMZN"}},
+		{"action", []string{"MSFT", "AMZN", "BRK.B", "AAPL"}, []string{"AAPL", "AMZN", "BRK.B", "MSFT"}},
+		{"rating_from", []string{"MSFT", "AAPL", "BRK.B", "AMZN"}, []string{"AMZN", "BRK.B", "AAPL", "MSFT"}},
+		{"rating_to", []string{"AAPL", "MSFT", "BRK.B", "AMZN"}, []string{"AMZN", "BRK.B", "MSFT", "AAPL"}},
+		{"target_from", []string{"AMZN", "AAPL", "MSFT", "BRK.B"}, []string{"BRK.B", "MSFT", "AAPL", "AMZN"}},
+		{"target_to", []string{"AMZN", "AAPL", "MSFT", "BRK.B"}, []string{"BRK.B", "MSFT", "AAPL", "AMZN"}},
+		{"target_delta", []string{"MSFT", "AMZN", "BRK.B", "AAPL"}, []string{"AAPL", "BRK.B", "AMZN", "MSFT"}},
+		{"score", []string{"MSFT", "BRK.B", "AMZN", "AAPL"}, []string{"AAPL", "AMZN", "BRK.B", "MSFT"}},
+		// Unknown columns only sort by ticker
+		{"unknown", []string{"AAPL", "AMZN", "BRK.B", "MSFT"}, []string{"AAPL", "AMZN", "BRK.B", "MSFT"}},
+	}
+	for _, c := range cases {
+		if got := list(t, repo, all(c.sortBy, "asc")); !slices.Equal(got, c.asc) {
+			t.Errorf("sort by %s asc = %v, want %v", c.sortBy, got, c.asc)
+		}
+		if got := list(t, repo, all(c.sortBy, "desc")); !slices.Equal(got, c.desc) {
+			t.Errorf("sort by %s desc = %v, want %v", c.sortBy, got, c.desc)
+		}
+	}
+}
+
+func testFilter(t *testing.T, repo repository.Repository) {
+	seed(t, repo)
+	cases := []struct {
+		tickerLike  string
+		companyLike string
+		want        []string
+	}{
+		{"", "", []string{"AAPL", "AMZN", "BRK.B", "MSFT"}},
+		{"a", "", []string{"AAPL", "AMZN"}},
+		{"", "INC", []string{"AAPL", "AMZN"}},
+		{"a", "apple", []string{"AAPL"}},
+		// The LIKE wildcards of the filters are not escaped by the query
+		{"A_P", "", []string{"AAPL"}},
+		{"", "micro%corp", []string{"MSFT"}},
+		{`A\_P`, "", nil},
+		{"nope", "", nil},
+	}
+	for _, c := range cases {
+		arg := all("ticker", "asc")
+		arg.TickerLike, arg.CompanyLike = c.tickerLike, c.companyLike
+		if got := list(t, repo, arg); !slices.Equal(got, c.want) {
+			t.Errorf("filter %q, %q = %v, want %v", c.tickerLike, c.companyLike, got, c.want)
+		}
+	}
+}
+
+func testPage(t *testing.T, repo repository.Repository) {
+	seed(t, repo)
+	cases := []struct {
+		offset int32
+		limit  int32
+		want   []string
+	}{
+		{0, 2, []string{"AAPL", "AMZN"}},
+		{1, 2, []string{"AMZN", "BRK.B"}},
+		{3, 2, []string{"MSFT"}},
+		{4, 2, nil},
+		{0, 0, nil},
+	}
+	for _, c := range cases {
+		arg := all("ticker", "asc")
+		arg.Offset, arg.Limit = c.offset, c.limit
+		if got := list(t, repo, arg); !slices.Equal(got, c.want) {
+			t.Errorf("page %d, %d = %v, want %v", c.offset, c.limit, got, c.want)
+		}
+	}
+
+	// The stream returns the rows of the list
+	arg := all("score", "desc")
+	var streamed []string
+	err := repo.StreamStockRatings(context.Background(), arg, func(r repository.GetStockRatingsRow) error {
+		streamed = append(streamed, r.Ticker)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("StreamStockRatings() error = %v", err)
+	}
+	if listed := list(t, repo, arg); !slices.Equal(streamed, listed) {
+		t.Errorf("StreamStockRatings() = %v, want the list %v", streamed, listed)
+	}
+
+	after, err := repo.ListStockRatingsAfter(context.Background(), repository.ListStockRatingsAfterParams{After: "AMZN", Lim: 2})
+	if err != nil {
+		t.Fatalf("ListStockRatingsAfter() error = %v", err)
+	}
+	var tickers []string
+	for _, r := range after {
+		tickers = append(tickers, r.Ticker)
+	}
+	if want := []string{"BRK.B", "MSFT"}; !slices.Equal(tickers, want) {
+		t.Errorf("ListStockRatingsAfter(AMZN) = %v, want %v", tickers, want)
+	}
+}
+
+func testGet(t *testing.T, repo repository.Repository) {
+	seed(t, repo)
+	ctx := context.Background()
+
+	r, err := repo.GetStockRating(ctx, "BRK.B")
+	if err != nil {
+		t.Fatalf("GetStockRating() error = %v", err)
+	}
+	if got := (computed{r.TargetFrom, r.TargetTo, r.TargetDelta, r.TargetDeltaPct, r.Score}); got != want["BRK.B"] {
+		t.Errorf("GetStockRating(BRK.B) computed columns = %+v, want %+v", got, want["BRK.B"])
+	}
+	if _, err := repo.GetStockRating(ctx, "NOPE"); !errors.Is(err, pgx.ErrNoRows) {
+		t.Errorf("GetStockRating(NOPE) error = %v, want %v", err, pgx.ErrNoRows)
+	}
+
+	// By brokerage, then the most recent first
+	byBrokerages, err := repo.GetStockRatingsByBrokerages(ctx, []string{"Morgan Stanley", "Goldman Sachs"})
+	if err != nil {
+		t.Fatalf("GetStockRatingsByBrokerages() error = %v", err)
+	}
+	var tickers []string
+	for _, r := range byBrokerages {
+		tickers = append(tickers, r.Ticker)
+	}
+	if want := []string{"AMZN", "AAPL", "MSFT", "BRK.B"}; !slices.Equal(tickers, want) {
+		t.Errorf("GetStockRatingsByBrokerages() = %v, want %v", tickers, want)
+	}
+}
+
+func testAggregates(t *testing.T, repo repository.Repository) {
+	seed(t, repo)
+	ctx := context.Background()
+
+	counts, err := repo.GetStockRatingCounts(ctx, repository.GetStockRatingCountsParams{})
+	if err != nil {
+		t.Fatalf("GetStockRatingCounts() error = %v", err)
+	}
+	// In the order of the enums
+	wantCounts := []repository.GetStockRatingCountsRow{
+		{RatingTo: repository.StockRatingTypeBuy, Action: repository.StockActionTypeUp, Count: 1},
+		{RatingTo: repository.StockRatingTypeHold, Action: repository.StockActionTypeDown, Count: 1},
+		{RatingTo: repository.StockRatingTypeSell, Action: repository.StockActionTypeReiterated, Count: 1},
+		{RatingTo: repository.StockRatingTypePending, Action: repository.StockActionTypeReiterated, Count: 1},
+	}
+	if !slices.Equal(counts, wantCounts) {
+		t.Errorf("GetStockRatingCounts() = %v, want %v", counts, wantCounts)
+	}
+	counts, err = repo.GetStockRatingCounts(ctx, repository.GetStockRatingCountsParams{CompanyLike: "inc"})
+	if err != nil {
+		t.Fatalf("GetStockRatingCounts() error = %v", err)
+	}
+	if !slices.Equal(counts, []repository.GetStockRatingCountsRow{wantCounts[0], wantCounts[2]}) {
+		t.Errorf("GetStockRatingCounts(inc) = %v, want the buy and sell counts", counts)
+	}
+
+	brokerages, err := repo.GetBrokerages(ctx, repository.GetBrokeragesParams{Limit: 10})
+	if err != nil {
+		t.Fatalf("GetBrokerages() error = %v", err)
+	}
+	wantBrokerages := []repository.GetBrokeragesRow{
+		{Brokerage: "Goldman Sachs", Ratings: 2, Upgrades: 1, Downgrades: 0},
+		{Brokerage: "Morgan Stanley", Ratings: 2, Upgrades: 0, Downgrades: 1},
+	}
+	if !slices.Equal(brokerages, wantBrokerages) {
+		t.Errorf("GetBrokerages() = %v, want %v", brokerages, wantBrokerages)
+	}
+}
+
+func testDuplicate(t *testing.T, repo repository.Repository) {
+	seed(t, repo)
+	_, err := repo.AddStockRatings(context.Background(), fixtures[:1])
+	if code := pgCode(err); code != "23505" {
+		t.Errorf("AddStockRatings() of a duplicate ticker error = %v, want a unique violation", err)
+	}
+	if got := list(t, repo, all("ticker", "asc")); len(got) != len(fixtures) {
+		t.Errorf("ratings after the duplicate = %v, want the fixtures only", got)
+	}
+
+	if err := repo.ClearStockRating(context.Background()); err != nil {
+		t.Fatalf("ClearStockRating() error = %v", err)
+	}
+	if got := list(t, repo, all("ticker", "asc")); got != nil {
+		t.Errorf("ratings after ClearStockRating() = %v, want none", got)
+	}
+}
+
+// The computed columns are stored, computed again when the normalization changes and refused
+// when the score can't be computed
+func testComputed(t *testing.T, repo repository.Repository) {
+	seed(t, repo)
+	ctx := context.Background()
+
+	// MSFT upgraded instead: TRUNC(10 * -0.1 + 0 + 1, 3) = 0
+	err := repo.UpdateStockRatingNormalization(ctx, repository.UpdateStockRatingNormalizationParams{
+		Ticker:     "MSFT",
+		Action:     repository.StockActionTypeUp,
+		RatingFrom: repository.StockRatingTypeBuy,
+		RatingTo:   repository.StockRatingTypeHold,
+	})
+	if err != nil {
+		t.Fatalf("UpdateStockRatingNormalization() error = %v", err)
+	}
+	r, err := repo.GetStockRating(ctx, "MSFT")
+	if err != nil {
+		t.Fatalf("GetStockRating() error = %v", err)
+	}
+	if r.Score != 0 || r.TargetDelta != "-40.00" {
+		t.Errorf("GetStockRating(MSFT) after the update = %+v, want a score of 0 and the same delta", r)
+	}
+	if got := list(t, repo, all("score", "desc")); !slices.Equal(got, []string{"AAPL", "AMZN", "BRK.B", "MSFT"}) {
+		t.Errorf("sorted by score after the update = %v", got)
+	}
+
+	// Without a previous target there is no change, score TRUNC(0 + 2 + 1, 3) = 3
+	zero := fixtures[0]
+	zero.Ticker, zero.TargetFrom = "ZERO", numeric("0")
+	if _, err := repo.AddStockRatings(ctx, []repository.AddStockRatingsParams{zero}); err != nil {
+		t.Fatalf("AddStockRatings() of a zero target error = %v", err)
+	}
+	r, err = repo.GetStockRating(ctx, "ZERO")
+	if err != nil {
+		t.Fatalf("GetStockRating(ZERO) error = %v", err)
+	}
+	if got := (computed{r.TargetFrom, r.TargetTo, r.TargetDelta, r.TargetDeltaPct, r.Score}); got != (computed{"0.00", "180.00", "180.00", "0.00", 3000}) {
+		t.Errorf("GetStockRating(ZERO) computed columns = %+v", got)
+	}
+	// The queries of the history compute it alike
+	if _, err := repo.AddStockRatingEvents(ctx, toEvents([]repository.AddStockRatingsParams{zero})); err != nil {
+		t.Fatalf("AddStockRatingEvents() error = %v", err)
+	}
+	asOf, err := repo.GetStockRatingAsOf(ctx, repository.GetStockRatingAsOfParams{Ticker: "ZERO", AsOf: at})
+	if err != nil {
+		t.Fatalf("GetStockRatingAsOf(ZERO) error = %v", err)
+	}
+	if got := (computed{asOf.TargetFrom, asOf.TargetTo, asOf.TargetDelta, asOf.TargetDeltaPct, asOf.Score}); got != (computed{"0.00", "180.00", "180.00", "0.00", 3000}) {
+		t.Errorf("GetStockRatingAsOf(ZERO) computed columns = %+v", got)
+	}
+}
+
+// The columns of AddStockRatingEvents of the ratings
+func toEvents(rows []repository.AddStockRatingsParams) repository.AddStockRatingEventsParams {
+	var events repository.AddStockRatingEventsParams
+	for _, r := range rows {
+		events.Ticker = append(events.Ticker, r.Ticker)
+		events.Company = append(events.Company, r.Company)
+		events.Brokerage = append(events.Brokerage, r.Brokerage)
+		events.TargetFrom = append(events.TargetFrom, r.TargetFrom)
+		events.TargetTo = append(events.TargetTo, r.TargetTo)
+		events.Action = append(events.Action, string(r.Action))
+		events.RawAction = append(events.RawAction, r.RawAction)
+		events.RatingFrom = append(events.RatingFrom, string(r.RatingFrom))
+		events.RawRatingFrom = append(events.RawRatingFrom, r.RawRatingFrom)
+		events.RatingTo = append(events.RatingTo, string(r.RatingTo))
+		events.RawRatingTo = append(events.RawRatingTo, r.RawRatingTo)
+		events.At = append(events.At, r.At)
+	}
+	return events
+}
+
+func testEvents(t *testing.T, repo repository.Repository) {
+	events := toEvents(fixtures)
+	ctx := context.Background()
+	if n, err := repo.AddStockRatingEvents(ctx, events); err != nil || n != int64(len(fixtures)) {
+		t.Fatalf("AddStockRatingEvents() = %d, %v, want %d", n, err, len(fixtures))
+	}
+	// The same ratings loaded again are already in the history
+	if n, err := repo.AddStockRatingEvents(ctx, events); err != nil || n != 0 {
+		t.Errorf("AddStockRatingEvents() again = %d, %v, want 0", n, err)
+	}
+
+	// A later event of AAPL comes first in its history, every event computed as the stock ratings are
+	later := fixtures[0]
+	later.TargetFrom, later.TargetTo = numeric("180"), numeric("150")
+	later.At = at.Add(5 * time.Hour)
+	if _, err := repo.AddStockRatingEvents(ctx, toEvents([]repository.AddStockRatingsParams{later})); err != nil {
+		t.Fatalf("AddStockRatingEvents() error = %v", err)
+	}
+	rows, err := repo.GetStockRatingEventsByTickers(ctx, []string{"MSFT", "AAPL", "NOPE"})
+	if err != nil {
+		t.Fatalf("GetStockRatingEventsByTickers() error = %v", err)
+	}
+	var got []string
+	for _, r := range rows {
+		got = append(got, r.Ticker+" "+r.TargetTo)
+	}
+	if want := []string{"AAPL 150.00", "AAPL 180.00", "MSFT 360.00"}; !slices.Equal(got, want) {
+		t.Errorf("GetStockRatingEventsByTickers() = %v, want %v", got, want)
+	}
+	for _, r := range rows[1:] {
+		if got := (computed{r.TargetFrom, r.TargetTo, r.TargetDelta, r.TargetDeltaPct, r.Score}); got != want[r.Ticker] {
+			t.Errorf("GetStockRatingEventsByTickers() of %s = %+v, want %+v", r.Ticker, got, want[r.Ticker])
+		}
+	}
+}
+
+func testBackfill(t *testing.T, repo repository.Repository) {
+	seed(t, repo)
+	ctx := context.Background()
+	// A rating after the runs, that none of them loaded
+	later := fixtures[0]
+	later.Ticker, later.At = "NVDA", time.Now().Add(time.Hour).Truncate(time.Microsecond)
+	if _, err := repo.AddStockRatings(ctx, []repository.AddStockRatingsParams{later}); err != nil {
+		t.Fatalf("AddStockRatings() error = %v", err)
+	}
+	var runs []uuid.UUID
+	for _, status := range []repository.IngestionRunStatus{
+		repository.IngestionRunStatusFailed, repository.IngestionRunStatusSucceeded, repository.IngestionRunStatusSucceeded,
+	} {
+		id, err := repo.CreateIngestionRun(ctx, repository.IngestionRunSourceApi)
+		if err != nil {
+			t.Fatalf("CreateIngestionRun() error = %v", err)
+		}
+		if err := repo.FinishIngestionRun(ctx, repository.FinishIngestionRunParams{ID: id, Status: status}); err != nil {
+			t.Fatalf("FinishIngestionRun() error = %v", err)
+		}
+		runs = append(runs, id)
+	}
+	loadedBy, err := repo.GetIngestionRun(ctx, runs[1])
+	if err != nil {
+		t.Fatalf("GetIngestionRun() error = %v", err)
+	}
+
+	if n, err := repo.BackfillStockRatingEvents(ctx, []string{"NVDA", "AAPL", "NOPE"}); err != nil || n != 2 {
+		t.Fatalf("BackfillStockRatingEvents() = %d, %v, want 2", n, err)
+	}
+	if n, err := repo.BackfillStockRatingEvents(ctx, []string{"AAPL"}); err != nil || n != 0 {
+		t.Errorf("BackfillStockRatingEvents() again = %d, %v, want 0", n, err)
+	}
+
+	// Recorded at the end of the first successful run, or at their time without one
+	events, err := repo.ListStockRatingEventsAfter(ctx, repository.ListStockRatingEventsAfterParams{After: 0, Lim: 10})
+	if err != nil {
+		t.Fatalf("ListStockRatingEventsAfter() error = %v", err)
+	}
+	recordedAt := map[string]time.Time{}
+	for _, e := range events {
+		recordedAt[e.Ticker] = e.RecordedAt
+	}
+	if len(events) != 2 || !recordedAt["AAPL"].Equal(loadedBy.FinishedAt.Time) || !recordedAt["NVDA"].Equal(later.At) {
+		t.Errorf("backfilled events recorded at %v, want AAPL at %v and NVDA at %v", recordedAt, loadedBy.FinishedAt.Time, later.At)
+	}
+}
+
+func testAsOf(t *testing.T, repo repository.Repository) {
+	ctx := context.Background()
+	// AAPL downgraded after the fixtures: -30 / 180 = -16.67%, score TRUNC(10 * -0.1666… + 0 - 1, 3) = -2.666
+	downgrade := fixtures[0]
+	downgrade.TargetFrom, downgrade.TargetTo = numeric("180"), numeric("150")
+	downgrade.Action, downgrade.RawAction = repository.StockActionTypeDown, "downgraded by"
+	downgrade.RatingFrom, downgrade.RawRatingFrom = repository.StockRatingTypeBuy, "Buy"
+	downgrade.RatingTo, downgrade.RawRatingTo = repository.StockRatingTypeHold, "Hold"
+	downgrade.At = at.Add(5 * time.Hour)
+	if _, err := repo.AddStockRatingEvents(ctx, toEvents(append(slices.Clone(fixtures), downgrade))); err != nil {
+		t.Fatalf("AddStockRatingEvents() error = %v", err)
+	}
+
+	listAsOf := func(asOf time.Time, arg repository.GetStockRatingsParams) []repository.GetStockRatingsAsOfRow {
+		t.Helper()
+		rows, err := repo.GetStockRatingsAsOf(ctx, repository.GetStockRatingsAsOfParams{
+			SortOrder: arg.SortOrder, SortBy: arg.SortBy, Offset: arg.Offset, Limit: arg.Limit,
+			TickerLike: arg.TickerLike, CompanyLike: arg.CompanyLike, AsOf: asOf,
+		})
+		if err != nil {
+			t.Fatalf("GetStockRatingsAsOf(%v) error = %v", asOf, err)
+		}
+		return rows
+	}
+	tickers := func(rows []repository.GetStockRatingsAsOfRow) []string {
+		var out []string
+		for _, r := range rows {
+			out = append(out, r.Ticker)
+		}
+		return out
+	}
+
+	// Before the downgrade the history gives the fixtures, computed as the stock ratings are
+	rows := listAsOf(at.Add(4*time.Hour), all("score", "desc"))
+	if got := tickers(rows); !slices.Equal(got, []string{"AAPL", "AMZN", "BRK.B", "MSFT"}) {
+		t.Errorf("GetStockRatingsAsOf() before the downgrade = %v", got)
+	}
+	for _, r := range rows {
+		got := computed{r.TargetFrom, r.TargetTo, r.TargetDelta, r.TargetDeltaPct, r.Score}
+		if got != want[r.Ticker] {
+			t.Errorf("GetStockRatingsAsOf() of %s = %+v, want %+v", r.Ticker, got, want[r.Ticker])
+		}
+	}
+	if got := tickers(listAsOf(at.Add(5*time.Hour), all("score", "desc"))); !slices.Equal(got, []string{"AMZN", "BRK.B", "MSFT", "AAPL"}) {
+		t.Errorf("GetStockRatingsAsOf() after the downgrade = %v", got)
+	}
+	// At the time of an event it is included, the later ones are not
+	if got := tickers(listAsOf(at.Add(time.Hour), all("ticker", "asc"))); !slices.Equal(got, []string{"AAPL", "AMZN"}) {
+		t.Errorf("GetStockRatingsAsOf() an hour after the first = %v", got)
+	}
+	filter := all("ticker", "desc")
+	filter.TickerLike, filter.Limit, filter.Offset = "a", 1, 1
+	if got := tickers(listAsOf(at.Add(5*time.Hour), filter)); !slices.Equal(got, []string{"AAPL"}) {
+		t.Errorf("GetStockRatingsAsOf() filtered and paged = %v", got)
+	}
+
+	r, err := repo.GetStockRatingAsOf(ctx, repository.GetStockRatingAsOfParams{Ticker: "AAPL", AsOf: at.Add(5 * time.Hour)})
+	if err != nil {
+		t.Fatalf("GetStockRatingAsOf() error = %v", err)
+	}
+	if got := (computed{r.TargetFrom, r.TargetTo, r.TargetDelta, r.TargetDeltaPct, r.Score}); got != (computed{"180.00", "150.00", "-30.00", "-16.67", -2666}) {
+		t.Errorf("GetStockRatingAsOf(AAPL) after the downgrade = %+v", got)
+	}
+	if r.RatingTo != repository.StockRatingTypeHold || !r.At.Equal(downgrade.At) {
+		t.Errorf("GetStockRatingAsOf(AAPL) = %+v, want the downgrade", r)
+	}
+	if _, err := repo.GetStockRatingAsOf(ctx, repository.GetStockRatingAsOfParams{Ticker: "MSFT", AsOf: at}); !errors.Is(err, pgx.ErrNoRows) {
+		t.Errorf("GetStockRatingAsOf(MSFT) before its first event error = %v, want no rows", err)
+	}
+}
+
+func testDiff(t *testing.T, repo repository.Repository) {
+	ctx := context.Background()
+	// AAPL downgraded and NVDA initiated after the fixtures
+	downgrade := fixtures[0]
+	downgrade.TargetFrom, downgrade.TargetTo = numeric("180"), numeric("150")
+	downgrade.Action, downgrade.RawAction = repository.StockActionTypeDown, "downgraded by"
+	downgrade.RatingTo, downgrade.RawRatingTo = repository.StockRatingTypeHold, "Hold"
+	downgrade.At = at.Add(5 * time.Hour)
+	initiated := fixtures[3]
+	initiated.Ticker, initiated.Company = "NVDA", "NVIDIA Corporation"
+	initiated.At = at.Add(4 * time.Hour)
+	if _, err := repo.AddStockRatingEvents(ctx, toEvents(append(slices.Clone(fixtures), downgrade, initiated))); err != nil {
+		t.Fatalf("AddStockRatingEvents() error = %v", err)
+	}
+
+	type change struct {
+		ticker string
+		before string
+		after  string
+	}
+	side := func(ratingTo repository.NullStockRatingType, targetTo pgtype.Text, score pgtype.Int4) string {
+		if !ratingTo.Valid {
+			return ""
+		}
+		return fmt.Sprintf("%s %s %d", ratingTo.StockRatingType, targetTo.String, score.Int32)
+	}
+	diff := func(arg repository.GetStockRatingsDiffParams) []change {
+		t.Helper()
+		rows, err := repo.GetStockRatingsDiff(ctx, arg)
+		if err != nil {
+			t.Fatalf("GetStockRatingsDiff(%+v) error = %v", arg, err)
+		}
+		var out []change
+		for _, r := range rows {
+			out = append(out, change{
+				r.Ticker,
+				side(r.BeforeRatingTo, r.BeforeTargetTo, r.BeforeScore),
+				side(r.AfterRatingTo, r.AfterTargetTo, r.AfterScore),
+			})
+		}
+		return out
+	}
+	point := func(t time.Time) pgtype.Timestamptz { return pgtype.Timestamptz{Time: t, Valid: true} }
+
+	got := diff(repository.GetStockRatingsDiffParams{FromAt: point(at.Add(3 * time.Hour)), ToAt: point(at.Add(5 * time.Hour))})
+	wantChanges := []change{{"AAPL", "buy 180.00 5000", "hold 150.00 -2666"}, {"NVDA", "", "hold 360.00 -2000"}}
+	if !slices.Equal(got, wantChanges) {
+		t.Errorf("GetStockRatingsDiff() forward = %v, want %v", got, wantChanges)
+	}
+	got = diff(repository.GetStockRatingsDiffParams{FromAt: point(at.Add(5 * time.Hour)), ToAt: point(at.Add(time.Hour))})
+	wantChanges = []change{
+		{"AAPL", "hold 150.00 -2666", "buy 180.00 5000"},
+		{"BRK.B", "pending 420.00 229", ""},
+		{"MSFT", "hold 360.00 -2000", ""},
+		{"NVDA", "hold 360.00 -2000", ""},
+	}
+	if !slices.Equal(got, wantChanges) {
+		t.Errorf("GetStockRatingsDiff() backward = %v, want %v", got, wantChanges)
+	}
+	if got := diff(repository.GetStockRatingsDiffParams{FromAt: point(at), ToAt: point(at.Add(30 * time.Minute))}); len(got) != 0 {
+		t.Errorf("GetStockRatingsDiff() without events between = %v, want none", got)
+	}
+	// Nothing was recorded before the fixtures were written, every ticker is added since
+	got = diff(repository.GetStockRatingsDiffParams{FromRecordedAt: point(at)})
+	if len(got) != 5 || slices.ContainsFunc(got, func(c change) bool { return c.before != "" }) {
+		t.Errorf("GetStockRatingsDiff() since nothing was recorded = %v, want the 5 tickers added", got)
+	}
+
+	// A run that did not load MSFT, dropped upstream, only its last event is left. A page can repeat a
+	// ticker.
+	id, err := repo.CreateIngestionRun(ctx, repository.IngestionRunSourceApi)
+	if err != nil {
+		t.Fatalf("CreateIngestionRun() error = %v", err)
+	}
+	loaded := repository.AddIngestionRunTickersParams{RunID: id, Tickers: []string{"AAPL", "AMZN", "BRK.B", "NVDA", "AAPL"}}
+	if err := repo.AddIngestionRunTickers(ctx, loaded); err != nil {
+		t.Fatalf("AddIngestionRunTickers() error = %v", err)
+	}
+	err = repo.AddIngestionRunTickers(ctx, repository.AddIngestionRunTickersParams{RunID: uuid.New(), Tickers: []string{"AAPL"}})
+	if code := pgCode(err); code != "23503" {
+		t.Errorf("AddIngestionRunTickers() of an unknown run error = %v, want a foreign key violation", err)
+	}
+	if err := repo.FinishIngestionRun(ctx, repository.FinishIngestionRunParams{ID: id, Status: repository.IngestionRunStatusSucceeded}); err != nil {
+		t.Fatalf("FinishIngestionRun() error = %v", err)
+	}
+	run, err := repo.GetIngestionRun(ctx, id)
+	if err != nil {
+		t.Fatalf("GetIngestionRun() error = %v", err)
+	}
+	got = diff(repository.GetStockRatingsDiffParams{FromAt: point(at.Add(5 * time.Hour)), ToRecordedAt: run.FinishedAt})
+	wantChanges = []change{{"MSFT", "hold 360.00 -2000", ""}}
+	if !slices.Equal(got, wantChanges) {
+		t.Errorf("GetStockRatingsDiff() to the run = %v, want %v", got, wantChanges)
+	}
+}
+
+func testFeed(t *testing.T, repo repository.Repository) {
+	ctx := context.Background()
+	if _, err := repo.AddStockRatingEvents(ctx, toEvents(fixtures[2:])); err != nil {
+		t.Fatalf("AddStockRatingEvents() error = %v", err)
+	}
+	// Older events loaded later, after the ones already recorded, and a duplicate left out
+	if _, err := repo.AddStockRatingEvents(ctx, toEvents(fixtures[:3])); err != nil {
+		t.Fatalf("AddStockRatingEvents() error = %v", err)
+	}
+
+	var tickers []string
+	var after int64
+	for range 3 {
+		rows, err := repo.ListStockRatingEventsAfter(ctx, repository.ListStockRatingEventsAfterParams{After: after, Lim: 2})
+		if err != nil {
+			t.Fatalf("ListStockRatingEventsAfter(%d) error = %v", after, err)
+		}
+		for _, r := range rows {
+			if r.Seq <= after {
+				t.Errorf("ListStockRatingEventsAfter() position %d of %s, want after %d", r.Seq, r.Ticker, after)
+			}
+			tickers = append(tickers, r.Ticker)
+			after = r.Seq
+		}
+	}
+	if want := []string{"BRK.B", "MSFT", "AAPL", "AMZN"}; !slices.Equal(tickers, want) {
+		t.Errorf("ListStockRatingEventsAfter() pages = %v, want %v", tickers, want)
+	}
+
+	rows, err := repo.ListStockRatingEventsAfter(ctx, repository.ListStockRatingEventsAfterParams{Lim: 1})
+	if err != nil || len(rows) != 1 {
+		t.Fatalf("ListStockRatingEventsAfter() = %v, %v, want the first event", rows, err)
+	}
+	if r := rows[0]; r.TargetFrom != "410.56" || r.TargetTo != "420.00" || !r.At.Equal(fixtures[2].At) {
+		t.Errorf("ListStockRatingEventsAfter() first event = %+v, want BRK.B as recorded", r)
+	}
+}
+
+// Writers appending at once, as a load, the seed and the backfill can: a reader following the feed
+// meanwhile must see every event once, a position committed after the reader went past it would be
+// skipped for good
+func testFeedWriters(t *testing.T, repo repository.Repository) {
+	ctx := context.Background()
+	if _, err := repo.AddStockRatings(ctx, fixtures); err != nil {
+		t.Fatalf("AddStockRatings() error = %v", err)
+	}
+	const writers, pages, perPage = 4, 10, 5
+	var wg sync.WaitGroup
+	for w := range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for p := range pages {
+				var rows []repository.AddStockRatingsParams
+				for i := range perPage {
+					r := fixtures[0]
+					r.Ticker, r.At = fmt.Sprintf("W%d", w), at.Add(time.Duration(p*perPage+i)*time.Minute)
+					rows = append(rows, r)
+				}
+				if _, err := repo.AddStockRatingEvents(ctx, toEvents(rows)); err != nil {
+					t.Errorf("AddStockRatingEvents() of writer %d error = %v", w, err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if _, err := repo.BackfillStockRatingEvents(ctx, []string{"AAPL", "AMZN", "BRK.B", "MSFT"}); err != nil {
+			t.Errorf("BackfillStockRatingEvents() error = %v", err)
+		}
+	}()
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	seen := map[string]bool{}
+	var after int64
+	read := func() int {
+		rows, err := repo.ListStockRatingEventsAfter(ctx, repository.ListStockRatingEventsAfterParams{After: after, Lim: 7})
+		if err != nil {
+			<-done
+			t.Fatalf("ListStockRatingEventsAfter(%d) error = %v", after, err)
+		}
+		for _, r := range rows {
+			key := r.Ticker + " " + r.At.Format(time.RFC3339)
+			if r.Seq <= after || seen[key] {
+				t.Errorf("ListStockRatingEventsAfter() position %d of %s, want it once after %d", r.Seq, key, after)
+			}
+			seen[key] = true
+			after = r.Seq
+		}
+		return len(rows)
+	}
+	for running := true; running; {
+		select {
+		case <-done:
+			running = false
+		default:
+			read()
+		}
+	}
+	for read() > 0 {
+	}
+	if want := writers*pages*perPage + 4; len(seen) != want {
+		t.Errorf("ListStockRatingEventsAfter() read %d events while they were written, want %d", len(seen), want)
+	}
+}
+
+// INGESTION =======================================================================================
+
+// Events of AAPL over two months and a later one kept, and one of MSFT
+func retentionEvents() []repository.AddStockRatingsParams {
+	event := func(ticker string, at time.Time, brokerage string, from string, to string, action repository.StockActionType, ratingTo repository.StockRatingType) repository.AddStockRatingsParams {
+		return repository.AddStockRatingsParams{
+			Ticker: ticker, Company: ticker + " Inc.", Brokerage: brokerage,
+			TargetFrom: numeric(from), TargetTo: numeric(to),
+			Action: action, RawAction: string(action),
+			RatingFrom: repository.StockRatingTypeHold, RawRatingFrom: "Hold",
+			RatingTo: ratingTo, RawRatingTo: string(ratingTo),
+			At: at,
+		}
+	}
+	day := func(month time.Month, d int) time.Time { return time.Date(2024, month, d, 12, 0, 0, 0, time.UTC) }
+	return []repository.AddStockRatingsParams{
+		event("AAPL", day(time.January, 10), "Goldman Sachs", "100", "110", repository.StockActionTypeUp, repository.StockRatingTypeBuy),
+		event("MSFT", day(time.January, 15), "Morgan Stanley", "400", "360", repository.StockActionTypeDown, repository.StockRatingTypeSell),
+		event("AAPL", day(time.January, 20), "Morgan Stanley", "110", "90", repository.StockActionTypeDown, repository.StockRatingTypeHold),
+		event("AAPL", day(time.February, 5), "Goldman Sachs", "90", "95", repository.StockActionTypeReiterated, repository.StockRatingTypeHold),
+		event("AAPL", day(time.December, 1), "Goldman Sachs", "95", "120", repository.StockActionTypeUp, repository.StockRatingTypeBuy),
+	}
+}
+
+// The events before the cutoff are rolled up in batches, the summaries of a month rolled up over
+// several batches are merged. The latest event of each ticker is kept, MSFT's in January, and so are
+// the events recorded since the cutoff of the change feed.
+func testRetention(t *testing.T, repo repository.Repository) {
+	ctx := context.Background()
+	if _, err := repo.AddStockRatingEvents(ctx, toEvents(retentionEvents())); err != nil {
+		t.Fatalf("AddStockRatingEvents() error = %v", err)
+	}
+	before := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+	if expired, err := repo.GetExpiredStockRatingEvents(ctx, repository.GetExpiredStockRatingEventsParams{Before: before, RecordedBefore: before}); err != nil || len(expired) != 0 {
+		t.Errorf("GetExpiredStockRatingEvents() of the events recorded since the cutoff = %v, %v, want none", expired, err)
+	}
+	// Recorded now, past the cutoff of the feed
+	expiredArg := repository.GetExpiredStockRatingEventsParams{Before: before, RecordedBefore: time.Now().Add(time.Hour)}
+
+	expired, err := repo.GetExpiredStockRatingEvents(ctx, expiredArg)
+	if err != nil {
+		t.Fatalf("GetExpiredStockRatingEvents() error = %v", err)
+	}
+	type month struct {
+		month   time.Time
+		events  int64
+		tickers int64
+	}
+	var months []month
+	for _, e := range expired {
+		months = append(months, month{e.Month.Time, e.Events, e.Tickers})
+	}
+	wantMonths := []month{
+		{time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC), 2, 1},
+		{time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC), 1, 1},
+	}
+	if !slices.Equal(months, wantMonths) {
+		t.Errorf("GetExpiredStockRatingEvents() = %v, want %v", months, wantMonths)
+	}
+
+	// The oldest first: AAPL twice in January, then in February
+	arg := repository.RollUpStockRatingEventsParams{Before: before, RecordedBefore: expiredArg.RecordedBefore, Lim: 1}
+	for i, want := range []repository.RollUpStockRatingEventsRow{{Events: 1, Summaries: 1}, {Events: 1, Summaries: 1}, {Events: 1, Summaries: 1}, {}} {
+		got, err := repo.RollUpStockRatingEvents(ctx, arg)
+		if err != nil {
+			t.Fatalf("RollUpStockRatingEvents() error = %v", err)
+		}
+		if got != want {
+			t.Errorf("RollUpStockRatingEvents() batch %d = %+v, want %+v", i, got, want)
+		}
+	}
+	if expired, err := repo.GetExpiredStockRatingEvents(ctx, expiredArg); err != nil || len(expired) != 0 {
+		t.Errorf("GetExpiredStockRatingEvents() after the roll-up = %v, %v, want none", expired, err)
+	}
+
+	summaries, err := repo.GetStockRatingMonthly(ctx, "AAPL")
+	if err != nil {
+		t.Fatalf("GetStockRatingMonthly() error = %v", err)
+	}
+	type summary struct {
+		events, upgrades, downgrades int64
+		first, last, low, high       string
+		rating                       repository.StockRatingType
+	}
+	var got []summary
+	for _, m := range summaries {
+		got = append(got, summary{
+			m.Events, m.Upgrades, m.Downgrades,
+			text(m.FirstTargetFrom), text(m.LastTargetTo), text(m.MinTarget), text(m.MaxTarget),
+			m.LastRatingTo,
+		})
+	}
+	wantSummaries := []summary{
+		{2, 1, 1, "100.00", "90.00", "90.00", "110.00", repository.StockRatingTypeHold},
+		{1, 0, 0, "90.00", "95.00", "90.00", "95.00", repository.StockRatingTypeHold},
+	}
+	if !slices.Equal(got, wantSummaries) {
+		t.Errorf("GetStockRatingMonthly(AAPL) = %+v, want %+v", got, wantSummaries)
+	}
+
+	// A load sends the whole history again: the current ratings are still recorded, the events
+	// rolled up are skipped, and the next roll-up has nothing to count twice
+	if n, err := repo.AddStockRatingEvents(ctx, toEvents(retentionEvents())); err != nil || n != 0 {
+		t.Errorf("AddStockRatingEvents() of the history = %d, %v, want 0", n, err)
+	}
+	if got, err := repo.RollUpStockRatingEvents(ctx, arg); err != nil || got != (repository.RollUpStockRatingEventsRow{}) {
+		t.Errorf("RollUpStockRatingEvents() after a load = %+v, %v, want nothing", got, err)
+	}
+	for ticker, want := range map[string][]int64{"AAPL": {2, 1}, "MSFT": nil} {
+		summaries, err := repo.GetStockRatingMonthly(ctx, ticker)
+		var events []int64
+		for _, m := range summaries {
+			events = append(events, m.Events)
+		}
+		if err != nil || !slices.Equal(events, want) {
+			t.Errorf("GetStockRatingMonthly(%s) events after a load = %v, %v, want %v", ticker, events, err, want)
+		}
+	}
+	if r, err := repo.GetStockRatingAsOf(ctx, repository.GetStockRatingAsOfParams{Ticker: "MSFT", AsOf: before}); err != nil || r.TargetTo != "360.00" {
+		t.Errorf("GetStockRatingAsOf(MSFT) after the roll-up = %+v, %v, want its latest event", r, err)
+	}
+}
+
+// Text of a NUMERIC(10,2), as the ::text casts of the queries print it
+func text(n pgtype.Numeric) string {
+	f, _ := n.Float64Value()
+	return strconv.FormatFloat(f.Float64, 'f', 2, 64)
+}
+
+func testIngestion(t *testing.T, repo repository.Repository) {
+	ctx := context.Background()
+	if _, err := repo.GetLatestIngestionRun(ctx); !errors.Is(err, pgx.ErrNoRows) {
+		t.Errorf("GetLatestIngestionRun() of no run error = %v, want %v", err, pgx.ErrNoRows)
+	}
+
+	id, err := repo.CreateIngestionRun(ctx, repository.IngestionRunSourceApi)
+	if err != nil {
+		t.Fatalf("CreateIngestionRun() error = %v", err)
+	}
+	payload := []byte(`{"items": []}`)
+	for page := range int32(2) {
+		err := repo.AddRawPage(ctx, repository.AddRawPageParams{RunID: id, Page: page, Cursor: "", FetchedAt: at, Payload: payload})
+		if err != nil {
+			t.Fatalf("AddRawPage(%d) error = %v", page, err)
+		}
+	}
+	err = repo.AddRawPage(ctx, repository.AddRawPageParams{RunID: uuid.New(), Page: 0, FetchedAt: at, Payload: payload})
+	if code := pgCode(err); code != "23503" {
+		t.Errorf("AddRawPage() of an unknown run error = %v, want a foreign key violation", err)
+	}
+
+	runs, err := repo.ListIngestionRuns(ctx, 10)
+	if err != nil {
+		t.Fatalf("ListIngestionRuns() error = %v", err)
+	}
+	if len(runs) != 1 || runs[0].ID != id || runs[0].Pages != 2 || runs[0].Status != repository.IngestionRunStatusRunning {
+		t.Errorf("ListIngestionRuns() = %+v, want the running run with 2 pages", runs)
+	}
+
+	err = repo.FinishIngestionRun(ctx, repository.FinishIngestionRunParams{ID: id, Status: repository.IngestionRunStatusSucceeded})
+	if err != nil {
+		t.Fatalf("FinishIngestionRun() error = %v", err)
+	}
+	latest, err := repo.GetLatestIngestionRun(ctx)
+	if err != nil {
+		t.Fatalf("GetLatestIngestionRun() error = %v", err)
+	}
+	if latest.ID != id || latest.Status != repository.IngestionRunStatusSucceeded || !latest.FinishedAt.Valid {
+		t.Errorf("GetLatestIngestionRun() = %+v, want the finished run", latest)
+	}
+
+	page, err := repo.GetRawPage(ctx, repository.GetRawPageParams{RunID: id, Page: 1})
+	if err != nil {
+		t.Fatalf("GetRawPage() error = %v", err)
+	}
+	if string(page.Payload) != string(payload) {
+		t.Errorf("GetRawPage() payload = %s, want %s", page.Payload, payload)
+	}
+	if _, err := repo.GetRawPage(ctx, repository.GetRawPageParams{RunID: id, Page: 2}); !errors.Is(err, pgx.ErrNoRows) {
+		t.Errorf("GetRawPage() of a missing page error = %v, want %v", err, pgx.ErrNoRows)
+	}
+}
+
+func testDatasetVersion(t *testing.T, repo repository.Repository) {
+	ctx := context.Background()
+	before, err := repo.GetDatasetVersion(ctx)
+	if err != nil {
+		t.Fatalf("GetDatasetVersion() error = %v", err)
+	}
+	bumped, err := repo.BumpDatasetVersion(ctx)
+	if err != nil {
+		t.Fatalf("BumpDatasetVersion() error = %v", err)
+	}
+	if bumped.Version != before.Version+1 {
+		t.Errorf("BumpDatasetVersion() = %d, want %d", bumped.Version, before.Version+1)
+	}
+	after, err := repo.GetDatasetVersion(ctx)
+	if err != nil {
+		t.Fatalf("GetDatasetVersion() error = %v", err)
+	}
+	if after.Version != bumped.Version {
+		t.Errorf("GetDatasetVersion() after the bump = %d, want %d", after.Version, bumped.Version)
+	}
+}
+
+// RATE LIMIT ======================================================================================
+
+func testRateLimit(t *testing.T, repo repository.Repository) {
+	ctx := context.Background()
+	hour := time.Hour.Microseconds()
+	// A burst of two requests an hour
+	arg := repository.TakeRateLimitTokenParams{Key: "client", IntervalUs: hour, WindowUs: 2 * hour}
+	for i := range 2 {
+		ahead, err := repo.TakeRateLimitToken(ctx, arg)
+		if err != nil {
+			t.Fatalf("TakeRateLimitToken() %d error = %v", i, err)
+		}
+		if low, high := int64(i+1)*hour-time.Minute.Microseconds(), int64(i+1)*hour; ahead < low || ahead > high {
+			t.Errorf("TakeRateLimitToken() %d = %dµs ahead, want about %dµs", i, ahead, high)
+		}
+	}
+	if _, err := repo.TakeRateLimitToken(ctx, arg); !errors.Is(err, pgx.ErrNoRows) {
+		t.Errorf("TakeRateLimitToken() of an empty bucket error = %v, want %v", err, pgx.ErrNoRows)
+	}
+	if _, err := repo.GetRateLimitBucket(ctx, "client"); err != nil {
+		t.Errorf("GetRateLimitBucket() error = %v", err)
+	}
+	if _, err := repo.GetRateLimitBucket(ctx, "other"); !errors.Is(err, pgx.ErrNoRows) {
+		t.Errorf("GetRateLimitBucket() of an unknown key error = %v, want %v", err, pgx.ErrNoRows)
+	}
+
+	// Buckets ahead of now are kept
+	if err := repo.DeleteFullRateLimitBuckets(ctx); err != nil {
+		t.Fatalf("DeleteFullRateLimitBuckets() error = %v", err)
+	}
+	if _, err := repo.GetRateLimitBucket(ctx, "client"); err != nil {
+		t.Errorf("GetRateLimitBucket() after the cleanup error = %v", err)
+	}
+}
+
+// ALERTS ==========================================================================================
+
+func testAlerts(t *testing.T, repo repository.Repository) {
+	ctx := context.Background()
+	rule, err := repo.CreateAlertRule(ctx, repository.CreateAlertRuleParams{Name: "upgrades", Tickers: []string{"AAPL"}})
+	if err != nil {
+		t.Fatalf("CreateAlertRule() error = %v", err)
+	}
+	if !rule.Enabled {
+		t.Errorf("CreateAlertRule() = %+v, want an enabled rule", rule)
+	}
+	if _, err := repo.CreateAlertRule(ctx, repository.CreateAlertRuleParams{Name: "none"}); pgCode(err) != "23502" {
+		t.Errorf("CreateAlertRule() without tickers error = %v, want a not-null violation", err)
+	}
+
+	runID, err := repo.CreateIngestionRun(ctx, repository.IngestionRunSourceApi)
+	if err != nil {
+		t.Fatalf("CreateIngestionRun() error = %v", err)
+	}
+	f := fixtures[0]
+	event := repository.AddAlertEventParams{
+		RuleID: rule.ID, RunID: runID,
+		Ticker: f.Ticker, Company: f.Company, Brokerage: f.Brokerage,
+		Action: f.Action, RatingFrom: f.RatingFrom, RatingTo: f.RatingTo,
+		TargetFrom: f.TargetFrom, TargetTo: f.TargetTo,
+		Score: 5000, Reason: "upgraded", At: f.At,
+	}
+	for i, want := range []int64{1, 0} {
+		if n, err := repo.AddAlertEvent(ctx, event); err != nil || n != want {
+			t.Errorf("AddAlertEvent() %d = %d, %v, want %d", i, n, err, want)
+		}
+	}
+	events, err := repo.ListAlertEvents(ctx, repository.ListAlertEventsParams{Limit: 10})
+	if err != nil {
+		t.Fatalf("ListAlertEvents() error = %v", err)
+	}
+	if len(events) != 1 || events[0].RuleName != "upgrades" || events[0].TargetTo != "180.00" {
+		t.Errorf("ListAlertEvents() = %+v, want the event of the rule", events)
+	}
+
+	// The events go with their rule
+	if n, err := repo.DeleteAlertRule(ctx, rule.ID); err != nil || n != 1 {
+		t.Fatalf("DeleteAlertRule() = %d, %v, want 1", n, err)
+	}
+	if events, err := repo.ListAlertEvents(ctx, repository.ListAlertEventsParams{Limit: 10}); err != nil || len(events) != 0 {
+		t.Errorf("ListAlertEvents() after the delete = %+v, %v, want none", events, err)
+	}
+	if n, err := repo.DeleteAlertRule(ctx, rule.ID); err != nil || n != 0 {
+		t.Errorf("DeleteAlertRule() again = %d, %v, want 0", n, err)
+	}
+}
+
+// WEBHOOKS ========================================================================================
+
+func testWebhooks(t *testing.T, repo repository.Repository) {
+	ctx := context.Background()
+	webhook, err := repo.CreateWebhook(ctx, repository.CreateWebhookParams{
+		Url: "https://example.com/hook", Secret: "secret", EventTypes: []string{"rating.changed"},
+	})
+	if err != nil {
+		t.Fatalf("CreateWebhook() error = %v", err)
+	}
+
+	delivery := repository.AddWebhookDeliveryParams{
+		WebhookID: webhook.ID, EventType: "rating.changed", EventKey: "AAPL", Payload: []byte(`{"ticker": "AAPL"}`),
+	}
+	for i, want := range []int64{1, 0} {
+		if n, err := repo.AddWebhookDelivery(ctx, delivery); err != nil || n != want {
+			t.Errorf("AddWebhookDelivery() %d = %d, %v, want %d", i, n, err, want)
+		}
+	}
+
+	claimed, err := repo.ClaimWebhookDeliveries(ctx, 10)
+	if err != nil {
+		t.Fatalf("ClaimWebhookDeliveries() error = %v", err)
+	}
+	if len(claimed) != 1 || claimed[0].Attempts != 1 || claimed[0].Url != webhook.Url {
+		t.Fatalf("ClaimWebhookDeliveries() = %+v, want the delivery at its first attempt", claimed)
+	}
+	// Leased, another dispatcher doesn't take it
+	if again, err := repo.ClaimWebhookDeliveries(ctx, 10); err != nil || len(again) != 0 {
+		t.Errorf("ClaimWebhookDeliveries() again = %+v, %v, want none", again, err)
+	}
+
+	id := claimed[0].ID
+	err = repo.AddWebhookDeliveryAttempt(ctx, repository.AddWebhookDeliveryAttemptParams{
+		DeliveryID: id, Attempt: 1, ResponseStatus: pgtype.Int4{Int32: 200, Valid: true}, DurationMs: 12,
+	})
+	if err != nil {
+		t.Fatalf("AddWebhookDeliveryAttempt() error = %v", err)
+	}
+	finish := repository.FinishWebhookDeliveryAttemptParams{
+		ID: id, Status: repository.WebhookDeliveryStatusSucceeded, NextAttemptAt: at,
+		ResponseStatus: pgtype.Int4{Int32: 200, Valid: true}, Attempts: claimed[0].Attempts,
+	}
+	// Claimed again by another dispatcher after the lease ran out, the stale claim changes nothing
+	stale := finish
+	stale.Attempts--
+	if n, err := repo.FinishWebhookDeliveryAttempt(ctx, stale); err != nil || n != 0 {
+		t.Errorf("FinishWebhookDeliveryAttempt() of a stale claim = %d, %v, want 0", n, err)
+	}
+	if n, err := repo.FinishWebhookDeliveryAttempt(ctx, finish); err != nil || n != 1 {
+		t.Fatalf("FinishWebhookDeliveryAttempt() = %d, %v, want 1", n, err)
+	}
+
+	succeeded := repository.NullWebhookDeliveryStatus{WebhookDeliveryStatus: repository.WebhookDeliveryStatusSucceeded, Valid: true}
+	deliveries, err := repo.ListWebhookDeliveries(ctx, repository.ListWebhookDeliveriesParams{WebhookID: webhook.ID, Status: succeeded, Limit: 10})
+	if err != nil {
+		t.Fatalf("ListWebhookDeliveries() error = %v", err)
+	}
+	if len(deliveries) != 1 || deliveries[0].ID != id || deliveries[0].Attempts != 1 {
+		t.Errorf("ListWebhookDeliveries(succeeded) = %+v, want the delivery", deliveries)
+	}
+
+	// A replay makes the delivery due again, counting its attempts from zero
+	if n, err := repo.ReplayWebhookDelivery(ctx, repository.ReplayWebhookDeliveryParams{ID: id, WebhookID: webhook.ID}); err != nil || n != 1 {
+		t.Fatalf("ReplayWebhookDelivery() = %d, %v, want 1", n, err)
+	}
+	if claimed, err := repo.ClaimWebhookDeliveries(ctx, 10); err != nil || len(claimed) != 1 || claimed[0].Attempts != 1 {
+		t.Errorf("ClaimWebhookDeliveries() after the replay = %+v, %v, want the delivery at its first attempt", claimed, err)
+	}
+
+	if n, err := repo.DeleteWebhook(ctx, webhook.ID); err != nil || n != 1 {
+		t.Fatalf("DeleteWebhook() = %d, %v, want 1", n, err)
+	}
+	if n, err := repo.AddWebhookDelivery(ctx, delivery); pgCode(err) != "23503" {
+		t.Errorf("AddWebhookDelivery() of a deleted webhook = %d, %v, want a foreign key violation", n, err)
+	}
+}
+
+// SEARCH ==========================================================================================
+
+func testSearch(t *testing.T, repo repository.Repository) {
+	seed(t, repo)
+	rows, err := repo.Search(context.Background(), repository.SearchParams{Q: "gold", Prefix: "gold", Limit: 5})
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if len(rows) != 1 || rows[0].Kind != "brokerage" || rows[0].Value != "Goldman Sachs" || rows[0].Rank < 1 {
+		t.Errorf("Search(gold) = %+v, want the brokerage boosted by its prefix", rows)
+	}
+
+	// A word of the value starting with the query is half as relevant as the value starting with it
+	rows, err = repo.Search(context.Background(), repository.SearchParams{Q: "stanley", Prefix: "stanley", Limit: 5})
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if len(rows) != 1 || rows[0].Value != "Morgan Stanley" || rows[0].Rank < 0.5 {
+		t.Errorf("Search(stanley) = %+v, want the brokerage boosted by its second word", rows)
+	}
+
+	// The limit applies per kind
+	rows, err = repo.Search(context.Background(), repository.SearchParams{Q: "a", Prefix: "a", Limit: 1})
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	var kinds []string
+	for _, r := range rows {
+		kinds = append(kinds, r.Kind)
+	}
+	if want := []string{"brokerage", "company", "ticker"}; !slices.Equal(kinds, want) {
+		t.Errorf("Search(a) kinds = %v, want %v", kinds, want)
+	}
+}
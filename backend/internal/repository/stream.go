@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"context"
+	"math"
+)
+
+// Hand written companions of the sqlc queries, for results too large to be loaded in a slice
+
+// The sqlc queries and their companions, implemented by Queries and by the in-memory store of
+// memstore, so the services can be tested without a database
+type Repository interface {
+	Querier
+	StreamStockRatings(ctx context.Context, arg GetStockRatingsParams, fn func(GetStockRatingsRow) error) error
+}
+
+var _ Repository = (*Queries)(nil)
+
+// Stream every row matching the filters and sort of GetStockRatings, one at a time, so the memory
+// use doesn't grow with the result. Offset and Limit are ignored.
+func (q *Queries) StreamStockRatings(ctx context.Context, arg GetStockRatingsParams, fn func(GetStockRatingsRow) error) error {
+	rows, err := q.db.Query(ctx, getStockRatings,
+		arg.TickerLike,
+		arg.CompanyLike,
+		arg.SortOrder,
+		arg.SortBy,
+		int32(0),
+		int32(math.MaxInt32),
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var i GetStockRatingsRow
+		if err := rows.Scan(
+			&i.Ticker,
+			&i.Company,
+			&i.Brokerage,
+			&i.TargetFrom,
+			&i.TargetTo,
+			&i.Action,
+			&i.RawAction,
+			&i.RatingFrom,
+			&i.RawRatingFrom,
+			&i.RatingTo,
+			&i.RawRatingTo,
+			&i.At,
+			&i.TargetDelta,
+			&i.TargetDeltaPct,
+			&i.Score,
+		); err != nil {
+			return err
+		}
+		if err := fn(i); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
@@ -1,19 +1,59 @@
 package routes
 
 import (
+	"backend/internal/features/alerts"
+	"backend/internal/features/events"
+	"backend/internal/features/graphql"
+	"backend/internal/features/ingestion"
+	"backend/internal/features/search"
 	stockratings "backend/internal/features/stockratings"
+	"backend/internal/features/stream"
+	"backend/internal/features/webhooks"
+	"backend/internal/openapi"
+	"backend/pkg/metrics"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 )
 
-func GetRoutes(rg *gin.Engine, h stockratings.HandlerInterface) {
+// Handlers of every feature, built in the dependency injection of the app
+type Handlers struct {
+	StockRatings stockratings.HandlerInterface
+	Search       search.HandlerInterface
+	Alerts       alerts.HandlerInterface
+	Webhooks     webhooks.HandlerInterface
+	Stream       stream.HandlerInterface
+	GraphQL      graphql.HandlerInterface
+	Ingestion    ingestion.HandlerInterface
+	Events       events.HandlerInterface
+
+	// Middlewares of the routes serving the dataset, e.g. the response cache
+	Dataset gin.HandlersChain
+}
+
+func GetRoutes(rg *gin.Engine, h Handlers) {
 	ping := rg.Group("/ping")
 	ping.GET("/", func(c *gin.Context) {
 		c.JSON(http.StatusOK, "pong")
 	})
 
+	rg.GET("/metrics", gin.WrapH(metrics.Handler()))
+
+	graphql.AddGraphQLRoutes(&rg.RouterGroup, h.GraphQL)
+
 	v1 := rg.Group("/v1")
-	stockratings.AddStockRatingRoutes(v1, h)
+	v1Dataset := v1.Group("", h.Dataset...)
+	stockratings.AddStockRatingRoutes(v1Dataset, h.StockRatings)
+	search.AddSearchRoutes(v1Dataset, h.Search)
+	alerts.AddAlertRoutes(v1, h.Alerts)
+	webhooks.AddWebhookRoutes(v1, h.Webhooks)
+	stream.AddStreamRoutes(v1, h.Stream)
+	ingestion.AddIngestionRoutes(v1, h.Ingestion)
+	events.AddEventRoutes(v1, h.Events)
+	openapi.AddOpenAPIRoutes(v1)
+
+	v2 := rg.Group("/v2")
+	v2Dataset := v2.Group("", h.Dataset...)
+	stockratings.AddStockRatingV2Routes(v2Dataset, h.StockRatings)
 
 }
@@ -0,0 +1,32 @@
+package rpc
+
+import (
+	stockratingsv1 "backend/internal/gen/stockratings/v1"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+)
+
+// Services of every feature served over gRPC, built in the dependency injection of the app
+type Services struct {
+	StockRatings       stockratingsv1.StockRatingServiceServer
+	StockRatingsStream stockratingsv1.StockRatingStreamServiceServer
+}
+
+// Build the gRPC server, with the health service reporting every service as serving and the
+// reflection service so grpcurl and the like can list them
+func NewServer(s Services, opts ...grpc.ServerOption) *grpc.Server {
+	server := grpc.NewServer(opts...)
+	stockratingsv1.RegisterStockRatingServiceServer(server, s.StockRatings)
+	stockratingsv1.RegisterStockRatingStreamServiceServer(server, s.StockRatingsStream)
+
+	healthServer := health.NewServer()
+	for name := range server.GetServiceInfo() {
+		healthServer.SetServingStatus(name, healthpb.HealthCheckResponse_SERVING)
+	}
+	healthpb.RegisterHealthServer(server, healthServer)
+	reflection.Register(server)
+	return server
+}
@@ -0,0 +1,286 @@
+// Synthetic rating events for load tests, demos and bug reports, the same for the same seed. The
+// events are in the format of the upstream API and use its whole vocabulary of ratings and actions,
+// along with the edge cases the loader must accept: pending ratings, targets with thousands
+// separators, without cents or padded with spaces, and times with fractions and offsets.
+package seed
+
+import (
+	"backend/internal/features/stockratings"
+	"backend/internal/repository"
+	"cmp"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Options struct {
+	Seed    uint64
+	Tickers int
+	// Average number of events of a ticker
+	EventsPerTicker int
+	// Time of the last event, the events are spread over the days before it
+	Until time.Time
+	Days  int
+}
+
+// Options of the seed command, the until time is fixed so a seed always gives the same events
+var DefaultOptions = Options{
+	Seed:            1,
+	Tickers:         500,
+	EventsPerTicker: 4,
+	Until:           time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC),
+	Days:            90,
+}
+
+// Share of the events with an edge case in their target or time
+const edgeCaseRate = 0.05
+
+// Generate the events of the options, sorted by time. The targets of the events of a ticker follow
+// a path, each starting from the target of the previous one.
+func Generate(opts Options) []stockratings.RawStockEvent {
+	rng := rand.New(rand.NewPCG(opts.Seed, opts.Seed^0x9e3779b97f4a7c15))
+	g := generator{rng: rng, opts: opts}
+
+	var timed []timedEvent
+	for _, ticker := range g.tickers() {
+		timed = append(timed, g.path(ticker, g.company())...)
+	}
+	// The times are formatted with different offsets, they sort by instant
+	slices.SortStableFunc(timed, func(a, b timedEvent) int {
+		return cmp.Or(a.at.Compare(b.at), cmp.Compare(a.event.Ticker, b.event.Ticker))
+	})
+	events := make([]stockratings.RawStockEvent, len(timed))
+	for i, t := range timed {
+		events[i] = t.event
+	}
+	return events
+}
+
+// Keep the latest event of each ticker, the stock ratings hold one rating per ticker
+func Latest(rows []repository.AddStockRatingsParams) []repository.AddStockRatingsParams {
+	latest := map[string]int{}
+	var out []repository.AddStockRatingsParams
+	for _, r := range rows {
+		i, ok := latest[r.Ticker]
+		if !ok {
+			latest[r.Ticker] = len(out)
+			out = append(out, r)
+		} else if !r.At.Before(out[i].At) {
+			out[i] = r
+		}
+	}
+	return out
+}
+
+// GENERATOR =======================================================================================
+
+type timedEvent struct {
+	at    time.Time
+	event stockratings.RawStockEvent
+}
+
+type generator struct {
+	rng  *rand.Rand
+	opts Options
+}
+
+var (
+	syllables  = []string{"al", "ver", "to", "nex", "ra", "cor", "mi", "lu", "zen", "da", "ter", "qua", "bri", "son", "vo", "lé", "ki", "mon", "sa", "tri"}
+	suffixes   = []string{"Inc.", ", Inc.", "Corp", "Corporation", "Holdings Inc.", "Group Ltd", "plc", "Therapeutics", "Bancorp", "Technologies", "N.V.", "S.A."}
+	brokerages = []string{
+		"Goldman Sachs", "The Goldman Sachs Group", "Morgan Stanley", "JPMorgan Chase & Co.", "Barclays",
+		"Wells Fargo & Company", "Royal Bank of Canada", "Raymond James", "Piper Sandler", "Needham & Company LLC",
+		"Keefe, Bruyette & Woods", "D.A. Davidson", "HC Wainwright", "BTIG Research", "Wedbush", "Mizuho",
+		"Truist Financial", "Loop Capital", "Benchmark", "Stifel Nicolaus", "Cantor Fitzgerald", "Citigroup",
+	}
+	classes = []string{".A", ".B"}
+)
+
+// Distinct tickers of 1 to 5 letters, a few of them share classes like BRK.B
+func (g *generator) tickers() []string {
+	seen := map[string]bool{}
+	var out []string
+	for len(out) < g.opts.Tickers {
+		letters := make([]byte, 1+g.rng.IntN(5))
+		for i := range letters {
+			letters[i] = 'A' + byte(g.rng.IntN(26))
+		}
+		ticker := string(letters)
+		if g.rng.Float64() < 0.03 {
+			ticker += classes[g.rng.IntN(len(classes))]
+		}
+		if !seen[ticker] {
+			seen[ticker] = true
+			out = append(out, ticker)
+		}
+	}
+	return out
+}
+
+func (g *generator) company() string {
+	name := capitalize(g.word())
+	// Some companies are named after their two founders
+	if g.rng.Float64() < 0.1 {
+		name += " & " + capitalize(g.word())
+	}
+	suffix := g.pick(suffixes)
+	if strings.HasPrefix(suffix, ",") {
+		return name + suffix
+	}
+	return name + " " + suffix
+}
+
+// Word of 2 or 3 syllables
+func (g *generator) word() string {
+	var w strings.Builder
+	for range 2 + g.rng.IntN(2) {
+		w.WriteString(g.pick(syllables))
+	}
+	return w.String()
+}
+
+func capitalize(s string) string {
+	r := []rune(s)
+	return strings.ToUpper(string(r[:1])) + string(r[1:])
+}
+
+// Ratings from the most bearish to the most bullish, the upgrades and downgrades move along it
+var ladder = []repository.StockRatingType{
+	repository.StockRatingTypeSell,
+	repository.StockRatingTypeHold,
+	repository.StockRatingTypeBuy,
+}
+
+// Events of a ticker, from its first coverage to its latest rating
+func (g *generator) path(ticker string, company string) []timedEvent {
+	n := 1 + g.rng.IntN(2*max(g.opts.EventsPerTicker, 1)-1)
+	times := make([]time.Time, n)
+	window := time.Duration(max(g.opts.Days, 1)) * 24 * time.Hour
+	for i := range times {
+		times[i] = g.opts.Until.Add(-time.Duration(g.rng.Int64N(int64(window))))
+	}
+	slices.SortFunc(times, func(a, b time.Time) int { return a.Compare(b) })
+
+	// Prices from $1 to $1500, as many cheap stocks as expensive ones
+	target := cents(math.Exp(g.rng.Float64() * math.Log(1500)))
+	rating := []repository.StockRatingType{
+		repository.StockRatingTypeBuy,
+		repository.StockRatingTypeHold,
+		repository.StockRatingTypeSell,
+		repository.StockRatingTypePending,
+	}[g.rng.IntN(4)]
+
+	var events []timedEvent
+	for i, at := range times {
+		from, to := target, target
+		ratingFrom, ratingTo := rating, rating
+		var action string
+		switch p := g.rng.Float64(); {
+		case i == 0:
+			// The coverage starts with an initiation, the rating may still be pending
+			action = g.pick([]string{"initiated by", "target set by"})
+		case p < 0.35:
+			action = g.pick(stockratings.RawActions(repository.StockActionTypeUp))
+			to = cents(from * (1.02 + g.rng.Float64()*0.28))
+			if action == "upgraded by" {
+				ratingTo = g.move(rating, 1)
+			}
+		case p < 0.65:
+			action = g.pick(stockratings.RawActions(repository.StockActionTypeDown))
+			to = max(cents(from*(0.98-g.rng.Float64()*0.28)), 0.01)
+			if action == "downgraded by" {
+				ratingTo = g.move(rating, -1)
+			}
+		default:
+			action = g.pick(stockratings.RawActions(repository.StockActionTypeReiterated))
+		}
+
+		events = append(events, timedEvent{at, stockratings.RawStockEvent{
+			Ticker:     ticker,
+			TargetFrom: g.formatTarget(from),
+			TargetTo:   g.formatTarget(to),
+			Company:    company,
+			Action:     action,
+			Brokerage:  g.pick(brokerages),
+			RatingFrom: g.pick(stockratings.RawRatings(ratingFrom)),
+			RatingTo:   g.pick(stockratings.RawRatings(ratingTo)),
+			Time:       g.formatTime(at),
+		}})
+		target, rating = to, ratingTo
+	}
+	return events
+}
+
+// Rating steps up or down the ladder, a pending rating is taken as hold
+func (g *generator) move(rating repository.StockRatingType, steps int) repository.StockRatingType {
+	i := slices.Index(ladder, rating)
+	if i < 0 {
+		i = slices.Index(ladder, repository.StockRatingTypeHold)
+	}
+	return ladder[min(max(i+steps, 0), len(ladder)-1)]
+}
+
+func (g *generator) pick(values []string) string {
+	return values[g.rng.IntN(len(values))]
+}
+
+func cents(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// Target as the API writes it, e.g. $1,234.50, or one of its edge cases
+func (g *generator) formatTarget(v float64) string {
+	s := "$" + thousands(strconv.FormatFloat(v, 'f', 2, 64))
+	if g.rng.Float64() >= edgeCaseRate {
+		return s
+	}
+	switch g.rng.IntN(3) {
+	case 0:
+		// Whole dollars are written without cents
+		return strings.TrimSuffix(s, ".00")
+	case 1:
+		return " " + s + " "
+	default:
+		return strings.ReplaceAll(s, ",", "")
+	}
+}
+
+// Add the thousands separators of a number with 2 decimals
+func thousands(s string) string {
+	whole, frac, _ := strings.Cut(s, ".")
+	var grouped string
+	for len(whole) > 3 {
+		grouped = "," + whole[len(whole)-3:] + grouped
+		whole = whole[:len(whole)-3]
+	}
+	return whole + grouped + "." + frac
+}
+
+// Offsets of the edge cases, the instant is the same
+var zones = []*time.Location{time.FixedZone("EDT", -4*3600), time.FixedZone("CET", 3600)}
+
+// Time as the API writes it, in UTC with nanoseconds, or one of its edge cases
+func (g *generator) formatTime(t time.Time) string {
+	if g.rng.Float64() >= edgeCaseRate {
+		return t.UTC().Format(time.RFC3339Nano)
+	}
+	switch g.rng.IntN(2) {
+	case 0:
+		return t.Truncate(time.Second).UTC().Format(time.RFC3339)
+	default:
+		return t.In(zones[g.rng.IntN(len(zones))]).Format(time.RFC3339Nano)
+	}
+}
+
+// Summary of the events, for the logs of the command
+func Describe(events []stockratings.RawStockEvent) string {
+	tickers := map[string]bool{}
+	for _, e := range events {
+		tickers[e.Ticker] = true
+	}
+	return fmt.Sprintf("%d events of %d tickers", len(events), len(tickers))
+}
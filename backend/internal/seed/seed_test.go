@@ -0,0 +1,105 @@
+package seed
+
+import (
+	"backend/internal/features/stockratings"
+	"backend/internal/repository"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func value(t *testing.T, n pgtype.Numeric) float64 {
+	t.Helper()
+	f, err := n.Float64Value()
+	if err != nil {
+		t.Fatalf("Float64Value(%v) error = %v", n, err)
+	}
+	return f.Float64
+}
+
+var testOptions = Options{
+	Seed:            7,
+	Tickers:         300,
+	EventsPerTicker: 4,
+	Until:           DefaultOptions.Until,
+	Days:            30,
+}
+
+func TestGenerateIsDeterministic(t *testing.T) {
+	a, b := Generate(testOptions), Generate(testOptions)
+	if !slices.Equal(a, b) {
+		t.Errorf("Generate() differs for the same seed")
+	}
+	other := testOptions
+	other.Seed++
+	if slices.Equal(a, Generate(other)) {
+		t.Errorf("Generate() is the same for another seed")
+	}
+}
+
+func TestGenerateNormalizes(t *testing.T) {
+	events := Generate(testOptions)
+	rows, err := stockratings.Normalize(events)
+	if err != nil {
+		t.Fatalf("Normalize() error = %v", err)
+	}
+
+	// The whole vocabulary of the API is used
+	ratings, actions := map[string]bool{}, map[string]bool{}
+	edgeCases := 0
+	for _, e := range events {
+		ratings[e.RatingFrom], ratings[e.RatingTo], actions[e.Action] = true, true, true
+		if strings.HasPrefix(e.TargetTo, " ") || !strings.HasSuffix(e.Time, "Z") {
+			edgeCases++
+		}
+	}
+	for _, rating := range []repository.StockRatingType{
+		repository.StockRatingTypeBuy, repository.StockRatingTypeHold,
+		repository.StockRatingTypeSell, repository.StockRatingTypePending,
+	} {
+		for _, raw := range stockratings.RawRatings(rating) {
+			if !ratings[raw] {
+				t.Errorf("raw rating %q never generated", raw)
+			}
+		}
+	}
+	for _, action := range []repository.StockActionType{
+		repository.StockActionTypeUp, repository.StockActionTypeDown, repository.StockActionTypeReiterated,
+	} {
+		for _, raw := range stockratings.RawActions(action) {
+			if !actions[raw] {
+				t.Errorf("raw action %q never generated", raw)
+			}
+		}
+	}
+	if edgeCases == 0 {
+		t.Errorf("no padded target or offset time generated")
+	}
+
+	// Sorted by time, each target of a ticker starting from the previous one
+	previous := map[string]repository.AddStockRatingsParams{}
+	for i, r := range rows {
+		if i > 0 && r.At.Before(rows[i-1].At) {
+			t.Fatalf("event %d at %v is before the previous one at %v", i, r.At, rows[i-1].At)
+		}
+		if p, ok := previous[r.Ticker]; ok && value(t, p.TargetTo) != value(t, r.TargetFrom) {
+			t.Errorf("%s target from %v, want the previous target %v", r.Ticker, r.TargetFrom, p.TargetTo)
+		}
+		if value(t, r.TargetFrom) <= 0 {
+			t.Errorf("%s target from %v, want a positive target", r.Ticker, r.TargetFrom)
+		}
+		previous[r.Ticker] = r
+	}
+
+	latest := Latest(rows)
+	if len(latest) != testOptions.Tickers {
+		t.Errorf("Latest() = %d ratings, want one per ticker, %d", len(latest), testOptions.Tickers)
+	}
+	for _, r := range latest {
+		if !r.At.Equal(previous[r.Ticker].At) {
+			t.Errorf("Latest() of %s at %v, want its last event at %v", r.Ticker, r.At, previous[r.Ticker].At)
+		}
+	}
+}
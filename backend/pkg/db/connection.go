@@ -7,17 +7,18 @@ import (
 	"os"
 	"sync"
 
-	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/multitracer"
+	"github.com/jackc/pgx/v5/pgxpool"
 	_ "github.com/jackc/pgx/v5/stdlib"
 )
 
 var (
 	once         sync.Once
-	instance     *pgx.Conn
+	instance     *pgxpool.Pool
 	raw_instance *sql.DB
 )
 
-func Get() *pgx.Conn {
+func Get() *pgxpool.Pool {
 	once.Do(func() {
 		// Get the database connection string from .env
 		dsn := os.Getenv("DATABASE_URL")
@@ -25,9 +26,15 @@ func Get() *pgx.Conn {
 			log.Fatal("DATABASE_URL not set")
 		}
 
+		// Configure the pool, tracing every query
+		config, err := pgxpool.ParseConfig(dsn)
+		if err != nil {
+			log.Fatalf("invalid DATABASE_URL: %v", err)
+		}
+		config.ConnConfig.Tracer = multitracer.New(metricsTracer{}, spanTracer{})
+
 		// Connect to the database
-		var err error
-		instance, err = pgx.Connect(context.Background(), dsn)
+		instance, err = pgxpool.NewWithConfig(context.Background(), config)
 		if err != nil {
 			log.Fatalf("failed to connect to database: %v", err)
 		}
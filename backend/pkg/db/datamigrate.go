@@ -0,0 +1,172 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// DATA MIGRATIONS =================================================================================
+// Migrations written in Go, for the changes SQL can't express, e.g. reusing the normalization of
+// the loader. Each one runs once the SQL migration of its version is applied, before the next SQL
+// migration, and is tracked in the data_migrations table next to the schema_migrations table of
+// golang-migrate.
+//
+// Large tables are processed in batches, each committed in its own transaction along with the
+// cursor of the next one, so an interrupted migration resumes where it stopped.
+
+type DataMigration struct {
+	// Version of the SQL migration it runs after
+	Version uint
+	// Unique name, the key of the tracking table
+	Name string
+	// Process the batch starting at cursor, empty for the first batch, and return the cursor of
+	// the next one, empty once done
+	Batch func(ctx context.Context, tx pgx.Tx, cursor string) (string, error)
+}
+
+var dataMigrations []DataMigration
+
+// Register a data migration, from the init of the package defining it. The migrations of a version
+// run in the order they are registered.
+func RegisterDataMigration(m DataMigration) {
+	if m.Name == "" || m.Batch == nil {
+		panic("db: data migration without a name or a batch")
+	}
+	for _, registered := range dataMigrations {
+		if registered.Name == m.Name {
+			panic("db: data migration registered twice: " + m.Name)
+		}
+	}
+	dataMigrations = append(dataMigrations, m)
+	slices.SortStableFunc(dataMigrations, func(a, b DataMigration) int { return int(a.Version) - int(b.Version) })
+}
+
+const createDataMigrationsTable = `
+CREATE TABLE IF NOT EXISTS data_migrations (
+    name TEXT PRIMARY KEY NOT NULL,
+    version BIGINT NOT NULL,
+    cursor TEXT NOT NULL DEFAULT '',
+    done BOOL NOT NULL DEFAULT false,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+)`
+
+// Progress of a data migration, the zero value for one never started
+type dataMigrationState struct {
+	cursor string
+	done   bool
+}
+
+// Connection of the data migrations, pgx so they can use the queries of the repository
+func openDataMigrations(ctx context.Context) (*pgx.Conn, error) {
+	conn, err := pgx.Connect(ctx, os.Getenv("DATABASE_URL"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+	return conn, nil
+}
+
+// Read the progress of the data migrations. Nothing is created, for the dry runs: a database
+// without the tracking table has not started any.
+func readDataMigrations(ctx context.Context, conn *pgx.Conn) (map[string]dataMigrationState, error) {
+	rows, err := conn.Query(ctx, `SELECT name, cursor, done FROM data_migrations`)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "42P01" {
+			return map[string]dataMigrationState{}, nil
+		}
+		return nil, fmt.Errorf("failed to read the data migrations: %w", err)
+	}
+	defer rows.Close()
+	states := map[string]dataMigrationState{}
+	for rows.Next() {
+		var name string
+		var state dataMigrationState
+		if err := rows.Scan(&name, &state.cursor, &state.done); err != nil {
+			return nil, err
+		}
+		states[name] = state
+	}
+	return states, rows.Err()
+}
+
+// The data migrations left to run once the SQL migrations are at version
+func pendingDataMigrations(states map[string]dataMigrationState, version uint) []DataMigration {
+	var out []DataMigration
+	for _, m := range dataMigrations {
+		if m.Version <= version && !states[m.Name].done {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+// Run the data migrations left to run at version, resuming the interrupted ones
+func runDataMigrations(ctx context.Context, conn *pgx.Conn, w io.Writer, version uint) error {
+	if _, err := conn.Exec(ctx, createDataMigrationsTable); err != nil {
+		return fmt.Errorf("failed to create the data_migrations table: %w", err)
+	}
+	states, err := readDataMigrations(ctx, conn)
+	if err != nil {
+		return err
+	}
+	for _, m := range pendingDataMigrations(states, version) {
+		cursor := states[m.Name].cursor
+		if cursor != "" {
+			fmt.Fprintf(w, "Resuming data migration %s after %q\n", m.Name, cursor)
+		}
+		batches := 0
+		for {
+			next, err := runBatch(ctx, conn, m, cursor)
+			if err != nil {
+				return fmt.Errorf("data migration %s failed after %q, run it again to resume: %w", m.Name, cursor, err)
+			}
+			batches++
+			if next == "" {
+				break
+			}
+			cursor = next
+		}
+		fmt.Fprintf(w, "✅ Data migration %s succeeded, in %d batches\n", m.Name, batches)
+	}
+	return nil
+}
+
+// Run a batch and record its progress in the same transaction
+func runBatch(ctx context.Context, conn *pgx.Conn, m DataMigration, cursor string) (string, error) {
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback(ctx)
+
+	next, err := m.Batch(ctx, tx, cursor)
+	if err != nil {
+		return "", err
+	}
+	_, err = tx.Exec(ctx, `
+        INSERT INTO data_migrations (name, version, cursor, done, updated_at) VALUES ($1, $2, $3, $4, now())
+        ON CONFLICT (name) DO UPDATE SET cursor = excluded.cursor, done = excluded.done, updated_at = excluded.updated_at`,
+		m.Name, int64(m.Version), next, next == "",
+	)
+	if err != nil {
+		return "", err
+	}
+	return next, tx.Commit(ctx)
+}
+
+// Forget the data migrations of the reverted SQL migrations, they run again when migrating up
+func resetDataMigrations(ctx context.Context, conn *pgx.Conn, version uint) error {
+	_, err := conn.Exec(ctx, `DELETE FROM data_migrations WHERE version > $1`, int64(version))
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "42P01" {
+		return nil
+	}
+	return err
+}
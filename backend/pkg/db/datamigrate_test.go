@@ -0,0 +1,52 @@
+package db
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func TestPendingDataMigrations(t *testing.T) {
+	registered := dataMigrations
+	t.Cleanup(func() { dataMigrations = registered })
+	dataMigrations = nil
+
+	batch := func(context.Context, pgx.Tx, string) (string, error) { return "", nil }
+	RegisterDataMigration(DataMigration{Version: 9, Name: "c", Batch: batch})
+	RegisterDataMigration(DataMigration{Version: 8, Name: "b", Batch: batch})
+	RegisterDataMigration(DataMigration{Version: 8, Name: "a", Batch: batch})
+
+	names := func(ms []DataMigration) []string {
+		var out []string
+		for _, m := range ms {
+			out = append(out, m.Name)
+		}
+		return out
+	}
+	cases := []struct {
+		states  map[string]dataMigrationState
+		version uint
+		want    []string
+	}{
+		{nil, 7, nil},
+		// Registration order within a version
+		{nil, 8, []string{"b", "a"}},
+		{nil, 9, []string{"b", "a", "c"}},
+		// Interrupted migrations are still pending, to be resumed
+		{map[string]dataMigrationState{"b": {done: true}, "a": {cursor: "AAPL"}}, 9, []string{"a", "c"}},
+	}
+	for _, c := range cases {
+		if got := names(pendingDataMigrations(c.states, c.version)); !slices.Equal(got, c.want) {
+			t.Errorf("pendingDataMigrations(%v, %d) = %v, want %v", c.states, c.version, got, c.want)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("RegisterDataMigration() of a duplicate name didn't panic")
+		}
+	}()
+	RegisterDataMigration(DataMigration{Version: 10, Name: "a", Batch: batch})
+}
@@ -1,77 +1,461 @@
 package db
 
 import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
 	"fmt"
-	"log"
+	"io"
+	"io/fs"
 	"os"
+	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
 
 	"github.com/golang-migrate/migrate/v4"
 	"github.com/golang-migrate/migrate/v4/database/cockroachdb"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/jackc/pgx/v5"
 )
 
-func RunMigrations(command string) {
+// The migrations are part of the binary, the commands work from any directory
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+const MigrationsDir = "pkg/db/migrations"
+
+var (
+	// The command line is invalid
+	ErrMigrationUsage = errors.New("invalid migration command")
+	// A migration failed half way, the database has to be fixed by hand and forced to a version
+	ErrDirtyDatabase = errors.New("dirty database")
+)
+
+const migrationUsage = `commands:
+  up            apply the next migration
+  up-last       apply all the pending migrations
+  down          revert the last migration
+  down-first    revert all the migrations
+  goto N        migrate up or down to version N
+  force N       set the version without running migrations
+  status        list the migrations, applied or pending
+  version       print the current version
+  create NAME   add empty up and down migrations to ` + MigrationsDir
+
+// Open the database of DATABASE_URL without the pool, returning the errors of the connection
+func openStd() (*sql.DB, error) {
 	dsn := os.Getenv("DATABASE_URL")
 	if dsn == "" {
-		log.Fatal("DATABASE_URL not set")
+		return nil, errors.New("DATABASE_URL not set")
 	}
+	db, err := sql.Open("pgx", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("ping error: %w", err)
+	}
+	return db, nil
+}
 
-	db := GetStd()
+func newMigrate() (*migrate.Migrate, source.Driver, error) {
+	src, err := iofs.New(migrationFiles, "migrations")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read the migrations: %w", err)
+	}
+	db, err := openStd()
+	if err != nil {
+		return nil, nil, err
+	}
 	driver, err := cockroachdb.WithInstance(db, &cockroachdb.Config{})
 	if err != nil {
-		log.Fatalf("failed to create migration driver: %v", err)
+		return nil, nil, fmt.Errorf("failed to create migration driver: %w", err)
+	}
+	m, err := migrate.NewWithInstance("iofs", src, "postgres", driver)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create migrate instance: %w", err)
+	}
+	return m, src, nil
+}
+
+// Run a migration command, printing to w. With dryRun the SQL of the migrations the command would
+// run is printed instead, nothing is applied.
+func RunMigrations(w io.Writer, args []string, dryRun bool) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%w: no command provided\n%s", ErrMigrationUsage, migrationUsage)
 	}
+	command, args := args[0], args[1:]
 
-	m, err := migrate.NewWithDatabaseInstance(
-		"file://pkg/db/migrations",
-		"postgres",
-		driver,
-	)
+	// Commands without the database
+	if command == "create" {
+		if len(args) != 1 {
+			return fmt.Errorf("%w: create requires a name", ErrMigrationUsage)
+		}
+		files, err := CreateMigration(MigrationsDir, args[0])
+		if err != nil {
+			return err
+		}
+		for _, f := range files {
+			fmt.Fprintln(w, "Created", f)
+		}
+		return nil
+	}
+
+	// The commands taking a version
+	var target uint
+	switch command {
+	case "goto", "force":
+		if len(args) != 1 {
+			return fmt.Errorf("%w: %s requires a version argument", ErrMigrationUsage, command)
+		}
+		version, err := strconv.ParseUint(args[0], 10, 32)
+		if err != nil {
+			return fmt.Errorf("%w: invalid version %q", ErrMigrationUsage, args[0])
+		}
+		target = uint(version)
+	case "up", "up-last", "down", "down-first", "status", "version":
+		if len(args) != 0 {
+			return fmt.Errorf("%w: %s takes no argument", ErrMigrationUsage, command)
+		}
+	default:
+		return fmt.Errorf("%w: unknown command %q\n%s", ErrMigrationUsage, command, migrationUsage)
+	}
+
+	m, src, err := newMigrate()
 	if err != nil {
-		log.Fatalf("failed to create migrate instance: %v", err)
+		return err
 	}
-	commands := strings.Fields(command)
-	if len(commands) == 0 {
-		log.Fatal("no migration command provided")
+	defer m.Close()
+	current, dirty, err := m.Version()
+	if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+		return fmt.Errorf("failed to read the version: %w", err)
+	}
+	if command == "version" {
+		if errors.Is(err, migrate.ErrNilVersion) {
+			fmt.Fprintln(w, "No migration applied")
+			return nil
+		}
+		fmt.Fprint(w, current)
+		if dirty {
+			fmt.Fprint(w, " (dirty)")
+		}
+		fmt.Fprintln(w)
+		return nil
 	}
 
-	switch commands[0] {
-	case "up":
-		if err := m.Steps(1); err != nil && err != migrate.ErrNoChange {
-			log.Fatalf("migration up failed: %v", err)
+	ctx := context.Background()
+	conn, err := openDataMigrations(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(ctx)
+	states, err := readDataMigrations(ctx, conn)
+	if err != nil {
+		return err
+	}
+	if command == "status" {
+		return printStatus(w, src, current, dirty, states)
+	}
+
+	if dryRun {
+		if command == "force" {
+			fmt.Fprintf(w, "-- Would force version %d, without running any migration\n", target)
+			return nil
 		}
-		fmt.Println("✅ 1 Migration applied successfully")
-	case "up-last":
-		if err := m.Up(); err != nil {
-			log.Fatalf("migration up-last failed: %v", err)
+		if dirty {
+			return fmt.Errorf("%w: version %d, fix it and force a version", ErrDirtyDatabase, current)
 		}
-		fmt.Println("✅ All Mmgrations applied successfully")
-	case "down":
-		if err := m.Steps(-1); err != nil {
-			log.Fatalf("migration down failed: %v", err)
+		steps, err := plan(src, command, current, target)
+		if err != nil {
+			return err
 		}
-		fmt.Println("✅ 1 Migration reverted successfully")
+		return printSteps(w, src, current, steps, states)
+	}
+
+	switch command {
+	case "up":
+		err = migrateUp(ctx, m, conn, w, current, 1)
+	case "up-last":
+		err = migrateUp(ctx, m, conn, w, current, -1)
+	case "down":
+		err = m.Steps(-1)
 	case "down-first":
-		if err := m.Down(); err != nil {
-			log.Fatalf("migration down-first failed: %v", err)
+		err = m.Down()
+	case "goto":
+		if target < current {
+			err = m.Migrate(target)
+			break
+		}
+		// Step up to the target, for its data migrations to run in order
+		var steps []step
+		steps, err = plan(src, command, current, target)
+		if err == nil && len(steps) > 0 && steps[len(steps)-1].version != target {
+			err = fmt.Errorf("no migration with version %d", target)
+		}
+		if err == nil {
+			err = migrateUp(ctx, m, conn, w, current, len(steps))
 		}
-		fmt.Println("✅ All migrations reverted successfully")
 	case "force":
-		if len(commands) < 2 {
-			log.Fatalf("force requires a version argument")
+		err = m.Force(int(target))
+	}
+
+	var dirtyErr migrate.ErrDirty
+	switch {
+	case isNoChange(err):
+		fmt.Fprintln(w, "No migration to run")
+		return nil
+	case errors.As(err, &dirtyErr):
+		return fmt.Errorf("%w: version %d, fix it and force a version", ErrDirtyDatabase, dirtyErr.Version)
+	case err != nil:
+		return fmt.Errorf("migration %s failed: %w", command, err)
+	}
+
+	version, _, _ := m.Version()
+	if version < current {
+		if err := resetDataMigrations(ctx, conn, version); err != nil {
+			return fmt.Errorf("failed to reset the data migrations: %w", err)
 		}
-		version, err := strconv.Atoi(commands[1])
+	}
+	fmt.Fprintf(w, "✅ Migration %s succeeded, at version %d\n", command, version)
+	return nil
+}
+
+// The errors of golang-migrate meaning there was nothing to run
+func isNoChange(err error) bool {
+	var shortErr migrate.ErrShortLimit
+	return errors.Is(err, migrate.ErrNoChange) || errors.Is(err, migrate.ErrNilVersion) || errors.As(err, &shortErr)
+}
+
+// Apply up to n SQL migrations, all of them when n is negative, each one followed by its data
+// migrations. The data migrations left by an interrupted run are resumed first.
+func migrateUp(ctx context.Context, m *migrate.Migrate, conn *pgx.Conn, w io.Writer, current uint, n int) error {
+	if err := runDataMigrations(ctx, conn, w, current); err != nil {
+		return err
+	}
+	for i := 0; n < 0 || i < n; i++ {
+		err := m.Steps(1)
+		// A step past the last migration has no file to read
+		if errors.Is(err, fs.ErrNotExist) {
+			err = migrate.ErrNoChange
+		}
+		if isNoChange(err) && i > 0 {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		version, _, err := m.Version()
 		if err != nil {
-			log.Fatalf("invalid force version: %v", err)
+			return err
 		}
-		if err := m.Force(version); err != nil {
-			log.Fatalf("migration force failed: %v", err)
+		if err := runDataMigrations(ctx, conn, w, version); err != nil {
+			return err
 		}
-		fmt.Println("✅ Migrations forced successfully")
+	}
+	return nil
+}
 
-	default:
-		log.Fatalf("unknown direction: %s (use 'up' or 'down')", command)
+// STATUS ==========================================================================================
+
+// The versions of the migrations, in order
+func versions(src source.Driver) ([]uint, error) {
+	version, err := src.First()
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	out := []uint{version}
+	for {
+		version, err = src.Next(version)
+		if errors.Is(err, fs.ErrNotExist) {
+			return out, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, version)
+	}
+}
+
+// The identifier of a migration, the name of its file without the version and the direction
+func identifier(src source.Driver, version uint) string {
+	r, identifier, err := src.ReadUp(version)
+	if err != nil {
+		return ""
+	}
+	r.Close()
+	return identifier
+}
+
+func printStatus(w io.Writer, src source.Driver, current uint, dirty bool, states map[string]dataMigrationState) error {
+	all, err := versions(src)
+	if err != nil {
+		return fmt.Errorf("failed to read the migrations: %w", err)
+	}
+	for _, version := range all {
+		state := "pending"
+		if version <= current {
+			state = "applied"
+		}
+		if version == current && dirty {
+			state = "dirty"
+		}
+		fmt.Fprintf(w, "%04d  %-8s %s\n", version, state, identifier(src, version))
+
+		// The data migrations run after it
+		for _, m := range dataMigrations {
+			if m.Version != version {
+				continue
+			}
+			s := states[m.Name]
+			switch {
+			case s.done:
+				state = "applied"
+			case s.cursor != "":
+				state = fmt.Sprintf("running, after %q", s.cursor)
+			default:
+				state = "pending"
+			}
+			fmt.Fprintf(w, "      %-8s %s (go)\n", state, m.Name)
+		}
+	}
+	return nil
+}
+
+// DRY RUN =========================================================================================
+
+type step struct {
+	version uint
+	up      bool
+}
+
+// The migrations a command would run from the current version, as migrate runs them
+func plan(src source.Driver, command string, current uint, target uint) ([]step, error) {
+	all, err := versions(src)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the migrations: %w", err)
+	}
+	var pending, applied []step
+	for _, version := range all {
+		if version > current {
+			pending = append(pending, step{version: version, up: true})
+		}
+	}
+	for i := len(all) - 1; i >= 0; i-- {
+		if all[i] <= current {
+			applied = append(applied, step{version: all[i]})
+		}
+	}
+
+	switch command {
+	case "up":
+		return pending[:min(1, len(pending))], nil
+	case "up-last":
+		return pending, nil
+	case "down":
+		return applied[:min(1, len(applied))], nil
+	case "down-first":
+		return applied, nil
+	}
+	// goto
+	var out []step
+	for _, s := range pending {
+		if s.version <= target {
+			out = append(out, s)
+		}
+	}
+	for _, s := range applied {
+		if s.version > target {
+			out = append(out, s)
+		}
+	}
+	return out, nil
+}
+
+func printSteps(w io.Writer, src source.Driver, current uint, steps []step, states map[string]dataMigrationState) error {
+	// The data migrations left by an interrupted run, and those following each SQL migration
+	printDataMigrations := func(version uint) {
+		for _, m := range pendingDataMigrations(states, version) {
+			if m.Version == version || version == current {
+				fmt.Fprintf(w, "-- data migration %s, in Go\n\n", m.Name)
+			}
+		}
+	}
+	if len(steps) == 0 || steps[0].up {
+		printDataMigrations(current)
+	}
+	if len(steps) == 0 {
+		fmt.Fprintln(w, "-- No migration to run")
+		return nil
+	}
+	for _, s := range steps {
+		read, direction := src.ReadUp, "up"
+		if !s.up {
+			read, direction = src.ReadDown, "down"
+		}
+		r, identifier, err := read(s.version)
+		if errors.Is(err, fs.ErrNotExist) {
+			fmt.Fprintf(w, "-- %04d_%s.%s.sql: missing\n\n", s.version, identifier, direction)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		body, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "-- %04d_%s.%s.sql\n%s\n", s.version, identifier, direction, strings.TrimSpace(string(body)))
+		fmt.Fprintln(w)
+		if s.up {
+			printDataMigrations(s.version)
+		}
+	}
+	return nil
+}
+
+// CREATE ==========================================================================================
+
+var migrationName = regexp.MustCompile(`^(\d+)_.*\.(up|down)\.sql$`)
+var nonIdentifier = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Add empty up and down migrations to dir, numbered after the last one
+func CreateMigration(dir string, name string) ([]string, error) {
+	name = strings.Trim(nonIdentifier.ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if name == "" {
+		return nil, fmt.Errorf("%w: invalid migration name", ErrMigrationUsage)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s, run create from the backend directory: %w", dir, err)
+	}
+	next := 1
+	for _, e := range entries {
+		if match := migrationName.FindStringSubmatch(e.Name()); match != nil {
+			version, _ := strconv.Atoi(match[1])
+			next = max(next, version+1)
+		}
+	}
+
+	var files []string
+	for _, direction := range []string{"up", "down"} {
+		path := filepath.Join(dir, fmt.Sprintf("%04d_%s.%s.sql", next, name, direction))
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if err != nil {
+			return files, err
+		}
+		if err := f.Close(); err != nil {
+			return files, err
+		}
+		files = append(files, path)
 	}
+	return files, nil
 }
@@ -0,0 +1,110 @@
+package db
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+)
+
+func TestEmbeddedMigrationsHaveUpAndDown(t *testing.T) {
+	src, err := iofs.New(migrationFiles, "migrations")
+	if err != nil {
+		t.Fatalf("iofs.New() failed: %v", err)
+	}
+	all, err := versions(src)
+	if err != nil || len(all) == 0 {
+		t.Fatalf("versions() = %v, %v", all, err)
+	}
+	for _, version := range all {
+		if _, _, err := src.ReadUp(version); err != nil {
+			t.Errorf("migration %d has no up file: %v", version, err)
+		}
+		if _, _, err := src.ReadDown(version); err != nil {
+			t.Errorf("migration %d has no down file: %v", version, err)
+		}
+	}
+}
+
+func TestPlan(t *testing.T) {
+	src, err := iofs.New(migrationFiles, "migrations")
+	if err != nil {
+		t.Fatalf("iofs.New() failed: %v", err)
+	}
+	all, _ := versions(src)
+	last := all[len(all)-1]
+
+	cases := []struct {
+		command string
+		current uint
+		target  uint
+		want    []step
+	}{
+		{"up", 0, 0, []step{{1, true}}},
+		{"up", last, 0, nil},
+		{"down", 3, 0, []step{{3, false}}},
+		{"down-first", 2, 0, []step{{2, false}, {1, false}}},
+		{"goto", 1, 3, []step{{2, true}, {3, true}}},
+		{"goto", 4, 2, []step{{4, false}, {3, false}}},
+		{"goto", 2, 2, nil},
+	}
+	for _, c := range cases {
+		got, err := plan(src, c.command, c.current, c.target)
+		if err != nil {
+			t.Fatalf("plan(%s, %d, %d) failed: %v", c.command, c.current, c.target, err)
+		}
+		if !slices.Equal(got, c.want) {
+			t.Errorf("plan(%s, %d, %d) = %v, want %v", c.command, c.current, c.target, got, c.want)
+		}
+	}
+	if got, _ := plan(src, "up-last", 0, 0); len(got) != len(all) {
+		t.Errorf("plan(up-last, 0) has %d steps, want %d", len(got), len(all))
+	}
+}
+
+func TestIsNoChange(t *testing.T) {
+	cases := []struct {
+		err  error
+		want bool
+	}{
+		{migrate.ErrNoChange, true},
+		{migrate.ErrNilVersion, true},
+		{migrate.ErrShortLimit{Short: 1}, true},
+		{fmt.Errorf("step: %w", migrate.ErrNoChange), true},
+		// A missing migration, e.g. the target of goto, is a failure
+		{fs.ErrNotExist, false},
+		{&fs.PathError{Op: "read", Path: "0042", Err: fs.ErrNotExist}, false},
+		{nil, false},
+	}
+	for _, c := range cases {
+		if got := isNoChange(c.err); got != c.want {
+			t.Errorf("isNoChange(%v) = %v, want %v", c.err, got, c.want)
+		}
+	}
+}
+
+func TestCreateMigration(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "0009_create_things.up.sql"), nil, 0o644)
+	os.WriteFile(filepath.Join(dir, "0009_create_things.down.sql"), nil, 0o644)
+
+	files, err := CreateMigration(dir, "Add Index on things")
+	if err != nil {
+		t.Fatalf("CreateMigration() failed: %v", err)
+	}
+	want := []string{
+		filepath.Join(dir, "0010_add_index_on_things.up.sql"),
+		filepath.Join(dir, "0010_add_index_on_things.down.sql"),
+	}
+	if !slices.Equal(files, want) {
+		t.Fatalf("CreateMigration() = %v, want %v", files, want)
+	}
+	if _, err := CreateMigration(dir, "!!"); err == nil {
+		t.Fatalf("CreateMigration() with an invalid name succeeded")
+	}
+}
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS raw_page;
+DROP TABLE IF EXISTS ingestion_run;
+DROP TYPE IF EXISTS INGESTION_RUN_STATUS;
+DROP TYPE IF EXISTS INGESTION_RUN_SOURCE;
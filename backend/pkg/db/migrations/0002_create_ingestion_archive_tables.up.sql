@@ -0,0 +1,20 @@
+CREATE TYPE INGESTION_RUN_SOURCE AS ENUM ( 'api', 'replay');
+CREATE TYPE INGESTION_RUN_STATUS AS ENUM ( 'running', 'succeeded', 'failed');
+CREATE TABLE IF NOT EXISTS ingestion_run (
+    id UUID PRIMARY KEY NOT NULL DEFAULT gen_random_uuid(),
+    source INGESTION_RUN_SOURCE NOT NULL,
+    status INGESTION_RUN_STATUS NOT NULL DEFAULT 'running',
+    error TEXT,
+    started_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    finished_at TIMESTAMPTZ
+);
+
+-- Every page fetched from the upstream API, as received, gzip compressed
+CREATE TABLE IF NOT EXISTS raw_page (
+    run_id UUID NOT NULL REFERENCES ingestion_run (id) ON DELETE CASCADE,
+    page INT4 NOT NULL,
+    cursor TEXT NOT NULL,
+    fetched_at TIMESTAMPTZ NOT NULL,
+    payload BYTEA NOT NULL,
+    PRIMARY KEY (run_id, page)
+);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS stock_rating@stock_rating_brokerage_trgm_idx;
+DROP INDEX IF EXISTS stock_rating@stock_rating_company_trgm_idx;
+DROP INDEX IF EXISTS stock_rating@stock_rating_ticker_trgm_idx;
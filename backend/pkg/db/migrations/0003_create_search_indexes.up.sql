@@ -0,0 +1,4 @@
+-- Trigram indexes backing the typeahead search, they serve both similarity and ILIKE matches
+CREATE INDEX IF NOT EXISTS stock_rating_ticker_trgm_idx ON stock_rating USING GIN (ticker gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS stock_rating_company_trgm_idx ON stock_rating USING GIN (company gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS stock_rating_brokerage_trgm_idx ON stock_rating USING GIN (brokerage gin_trgm_ops);
@@ -0,0 +1,7 @@
+-- Single row, bumped by every successful load, the HTTP caches are keyed by it
+CREATE TABLE IF NOT EXISTS dataset_version (
+    id INT4 PRIMARY KEY NOT NULL DEFAULT 1 CHECK (id = 1),
+    version INT8 NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL
+);
+INSERT INTO dataset_version (id, version, updated_at) VALUES (1, 1, now()) ON CONFLICT (id) DO NOTHING;
@@ -0,0 +1,6 @@
+-- Token buckets shared by the replicas, stored as the theoretical arrival time of the next request
+-- (GCRA). A bucket whose tat is in the past is full and can be deleted.
+CREATE TABLE IF NOT EXISTS rate_limit_bucket (
+    key TEXT PRIMARY KEY NOT NULL,
+    tat TIMESTAMPTZ NOT NULL
+);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS alert_event;
+DROP TABLE IF EXISTS alert_rule;
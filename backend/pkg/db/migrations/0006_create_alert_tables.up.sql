@@ -0,0 +1,33 @@
+-- Conditions left NULL are ignored, the others must all match an event for the rule to trigger
+CREATE TABLE IF NOT EXISTS alert_rule (
+    id UUID PRIMARY KEY NOT NULL DEFAULT gen_random_uuid(),
+    name TEXT NOT NULL,
+    tickers TEXT[] NOT NULL DEFAULT '{}',
+    action STOCK_ACTION_TYPE,
+    rating_to STOCK_RATING_TYPE,
+    min_target_change_pct FLOAT8,
+    score_crosses INT4,
+    enabled BOOL NOT NULL DEFAULT true,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- Every load reingests the whole history, an event triggers each rule once
+CREATE TABLE IF NOT EXISTS alert_event (
+    id UUID PRIMARY KEY NOT NULL DEFAULT gen_random_uuid(),
+    rule_id UUID NOT NULL REFERENCES alert_rule (id) ON DELETE CASCADE,
+    run_id UUID NOT NULL REFERENCES ingestion_run (id) ON DELETE CASCADE,
+    ticker TEXT NOT NULL,
+    company TEXT NOT NULL,
+    brokerage TEXT NOT NULL,
+    action STOCK_ACTION_TYPE NOT NULL,
+    rating_from STOCK_RATING_TYPE NOT NULL,
+    rating_to STOCK_RATING_TYPE NOT NULL,
+    target_from NUMERIC(10,2) NOT NULL,
+    target_to NUMERIC(10,2) NOT NULL,
+    score INT4 NOT NULL,
+    reason TEXT NOT NULL,
+    at TIMESTAMPTZ NOT NULL,
+    triggered_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (rule_id, ticker, at)
+);
+CREATE INDEX IF NOT EXISTS alert_event_triggered_at_idx ON alert_event (triggered_at DESC);
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS webhook_delivery_attempt;
+DROP TABLE IF EXISTS webhook_delivery;
+DROP TABLE IF EXISTS webhook;
+DROP TYPE IF EXISTS WEBHOOK_DELIVERY_STATUS;
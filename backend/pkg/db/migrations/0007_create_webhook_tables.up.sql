@@ -0,0 +1,41 @@
+CREATE TYPE WEBHOOK_DELIVERY_STATUS AS ENUM ( 'pending', 'succeeded', 'failed');
+
+-- Subscriptions, an empty event_types receives every event
+CREATE TABLE IF NOT EXISTS webhook (
+    id UUID PRIMARY KEY NOT NULL DEFAULT gen_random_uuid(),
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    event_types TEXT[] NOT NULL DEFAULT '{}',
+    enabled BOOL NOT NULL DEFAULT true,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- Delivery queue, an event is delivered once per webhook
+CREATE TABLE IF NOT EXISTS webhook_delivery (
+    id UUID PRIMARY KEY NOT NULL DEFAULT gen_random_uuid(),
+    webhook_id UUID NOT NULL REFERENCES webhook (id) ON DELETE CASCADE,
+    event_type TEXT NOT NULL,
+    event_key TEXT NOT NULL,
+    payload JSONB NOT NULL,
+    status WEBHOOK_DELIVERY_STATUS NOT NULL DEFAULT 'pending',
+    attempts INT4 NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    response_status INT4,
+    last_error TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (webhook_id, event_key)
+);
+CREATE INDEX IF NOT EXISTS webhook_delivery_status_next_attempt_at_idx ON webhook_delivery (status, next_attempt_at);
+
+-- Log of every attempt of the deliveries, a replay starts counting the attempts again
+CREATE TABLE IF NOT EXISTS webhook_delivery_attempt (
+    id UUID PRIMARY KEY NOT NULL DEFAULT gen_random_uuid(),
+    delivery_id UUID NOT NULL REFERENCES webhook_delivery (id) ON DELETE CASCADE,
+    attempt INT4 NOT NULL,
+    response_status INT4,
+    error TEXT,
+    duration_ms INT4 NOT NULL,
+    attempted_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS webhook_delivery_attempt_delivery_id_attempted_at_idx
+    ON webhook_delivery_attempt (delivery_id, attempted_at);
@@ -0,0 +1,20 @@
+-- History of the ratings, stock_rating only keeps the latest event of each ticker. Every load
+-- reingests the whole history, an event is recorded once.
+CREATE TABLE IF NOT EXISTS stock_rating_event (
+    id UUID PRIMARY KEY NOT NULL DEFAULT gen_random_uuid(),
+    ticker TEXT NOT NULL,
+    company TEXT NOT NULL,
+    brokerage TEXT NOT NULL,
+    target_from NUMERIC(10,2) NOT NULL,
+    target_to NUMERIC(10,2) NOT NULL,
+    action STOCK_ACTION_TYPE NOT NULL,
+    raw_action TEXT NOT NULL,
+    rating_from STOCK_RATING_TYPE NOT NULL,
+    raw_rating_from TEXT NOT NULL,
+    rating_to STOCK_RATING_TYPE NOT NULL,
+    raw_rating_to TEXT NOT NULL,
+    at TIMESTAMPTZ NOT NULL,
+    recorded_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (ticker, brokerage, at)
+);
+CREATE INDEX IF NOT EXISTS stock_rating_event_ticker_at_idx ON stock_rating_event (ticker, at DESC);
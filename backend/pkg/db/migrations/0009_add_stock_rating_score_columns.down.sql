@@ -0,0 +1,15 @@
+DROP INDEX IF EXISTS stock_rating@stock_rating_score_desc_idx;
+DROP INDEX IF EXISTS stock_rating@stock_rating_score_asc_idx;
+DROP INDEX IF EXISTS stock_rating@stock_rating_target_delta_desc_idx;
+DROP INDEX IF EXISTS stock_rating@stock_rating_target_delta_asc_idx;
+DROP INDEX IF EXISTS stock_rating@stock_rating_target_to_desc_idx;
+DROP INDEX IF EXISTS stock_rating@stock_rating_target_to_asc_idx;
+DROP INDEX IF EXISTS stock_rating@stock_rating_target_from_desc_idx;
+DROP INDEX IF EXISTS stock_rating@stock_rating_target_from_asc_idx;
+DROP INDEX IF EXISTS stock_rating@stock_rating_brokerage_desc_idx;
+DROP INDEX IF EXISTS stock_rating@stock_rating_brokerage_asc_idx;
+DROP INDEX IF EXISTS stock_rating@stock_rating_company_desc_idx;
+DROP INDEX IF EXISTS stock_rating@stock_rating_company_asc_idx;
+ALTER TABLE stock_rating DROP COLUMN IF EXISTS score;
+ALTER TABLE stock_rating DROP COLUMN IF EXISTS target_delta_pct;
+ALTER TABLE stock_rating DROP COLUMN IF EXISTS target_delta;
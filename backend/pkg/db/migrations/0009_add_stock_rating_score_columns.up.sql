@@ -0,0 +1,37 @@
+-- Columns of the list computed once when a rating is written rather than for every row of every
+-- request, so they can be indexed. A rating without a previous target has no change, in the score
+-- as in target_delta_pct.
+ALTER TABLE stock_rating ADD COLUMN IF NOT EXISTS target_delta NUMERIC(10,2) NOT NULL
+    GENERATED ALWAYS AS ((target_to - target_from)::NUMERIC(10,2)) STORED;
+ALTER TABLE stock_rating ADD COLUMN IF NOT EXISTS target_delta_pct NUMERIC(10,2) NOT NULL
+    GENERATED ALWAYS AS (COALESCE((target_to - target_from) / NULLIF(target_from, 0) * 100, 0)::NUMERIC(10,2)) STORED;
+ALTER TABLE stock_rating ADD COLUMN IF NOT EXISTS score INT4 NOT NULL
+    GENERATED ALWAYS AS ((TRUNC((10 * COALESCE((target_to - target_from) / NULLIF(target_from, 0), 0))
+    + (2 * (CASE rating_to
+        WHEN 'buy' THEN 1
+        WHEN 'hold' THEN 0
+        WHEN 'pending' THEN 0
+        WHEN 'sell' THEN -1
+    END))
+    + (1 * (CASE action
+        WHEN 'up' THEN 1
+        WHEN 'down' THEN -1
+        WHEN 'reiterated' THEN 0
+    END)), 3) * 1000)::INT4) STORED;
+
+-- Two indexes per sortable column, one per direction, both breaking the ties by the ticker
+-- ascending as the list does. A reverse scan of a single index would break them by the ticker
+-- descending, and the integer scores tie often. The ticker sorts read the primary key. The enums
+-- sort by their label, not in the order of the index, and have too few values to be worth one.
+CREATE INDEX IF NOT EXISTS stock_rating_company_asc_idx ON stock_rating (company ASC, ticker ASC);
+CREATE INDEX IF NOT EXISTS stock_rating_company_desc_idx ON stock_rating (company DESC, ticker ASC);
+CREATE INDEX IF NOT EXISTS stock_rating_brokerage_asc_idx ON stock_rating (brokerage ASC, ticker ASC);
+CREATE INDEX IF NOT EXISTS stock_rating_brokerage_desc_idx ON stock_rating (brokerage DESC, ticker ASC);
+CREATE INDEX IF NOT EXISTS stock_rating_target_from_asc_idx ON stock_rating (target_from ASC, ticker ASC);
+CREATE INDEX IF NOT EXISTS stock_rating_target_from_desc_idx ON stock_rating (target_from DESC, ticker ASC);
+CREATE INDEX IF NOT EXISTS stock_rating_target_to_asc_idx ON stock_rating (target_to ASC, ticker ASC);
+CREATE INDEX IF NOT EXISTS stock_rating_target_to_desc_idx ON stock_rating (target_to DESC, ticker ASC);
+CREATE INDEX IF NOT EXISTS stock_rating_target_delta_asc_idx ON stock_rating (target_delta ASC, ticker ASC);
+CREATE INDEX IF NOT EXISTS stock_rating_target_delta_desc_idx ON stock_rating (target_delta DESC, ticker ASC);
+CREATE INDEX IF NOT EXISTS stock_rating_score_asc_idx ON stock_rating (score ASC, ticker ASC);
+CREATE INDEX IF NOT EXISTS stock_rating_score_desc_idx ON stock_rating (score DESC, ticker ASC);
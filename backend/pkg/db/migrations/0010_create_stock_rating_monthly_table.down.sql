@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS stock_rating_event@stock_rating_event_at_idx;
+DROP TABLE IF EXISTS stock_rating_monthly;
@@ -0,0 +1,21 @@
+-- Summaries of the history past the retention, one per ticker and month. The retention rolls the
+-- events older than its cutoff into them and deletes the events, a month rolled up over several
+-- batches is merged into the same summary.
+CREATE TABLE IF NOT EXISTS stock_rating_monthly (
+    ticker TEXT NOT NULL,
+    month DATE NOT NULL,
+    events INT8 NOT NULL,
+    upgrades INT8 NOT NULL,
+    downgrades INT8 NOT NULL,
+    first_target_from NUMERIC(10,2) NOT NULL,
+    last_target_to NUMERIC(10,2) NOT NULL,
+    min_target NUMERIC(10,2) NOT NULL,
+    max_target NUMERIC(10,2) NOT NULL,
+    last_rating_to STOCK_RATING_TYPE NOT NULL,
+    first_at TIMESTAMPTZ NOT NULL,
+    last_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (ticker, month)
+);
+
+-- The retention reads the history from its oldest events
+CREATE INDEX IF NOT EXISTS stock_rating_event_at_idx ON stock_rating_event (at);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS stock_rating_event@stock_rating_event_seq_idx;
+ALTER TABLE stock_rating_event DROP COLUMN IF EXISTS seq;
+DROP SEQUENCE IF EXISTS stock_rating_event_seq;
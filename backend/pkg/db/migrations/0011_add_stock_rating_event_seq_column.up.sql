@@ -0,0 +1,6 @@
+-- Insertion order of the history, the position of the change feed. The at column is the time of the
+-- event upstream, a load can bring events older than the ones already recorded. The events recorded
+-- before this migration are numbered in no particular order.
+CREATE SEQUENCE IF NOT EXISTS stock_rating_event_seq;
+ALTER TABLE stock_rating_event ADD COLUMN IF NOT EXISTS seq INT8 NOT NULL DEFAULT nextval('stock_rating_event_seq');
+CREATE UNIQUE INDEX IF NOT EXISTS stock_rating_event_seq_idx ON stock_rating_event (seq);
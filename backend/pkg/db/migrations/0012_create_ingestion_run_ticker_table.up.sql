@@ -0,0 +1,8 @@
+-- Tickers loaded by each ingestion run. The history is append only, a ticker dropped upstream keeps
+-- its last event, the diff between two points takes the tickers the last run did not load as removed.
+-- The runs finished before this migration have no tickers recorded.
+CREATE TABLE IF NOT EXISTS ingestion_run_ticker (
+    run_id UUID NOT NULL REFERENCES ingestion_run (id) ON DELETE CASCADE,
+    ticker TEXT NOT NULL,
+    PRIMARY KEY (run_id, ticker)
+);
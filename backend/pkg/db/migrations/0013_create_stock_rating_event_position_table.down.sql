@@ -0,0 +1,3 @@
+SELECT setval('stock_rating_event_seq', GREATEST((SELECT seq FROM stock_rating_event_position WHERE id = 1), 1));
+ALTER TABLE stock_rating_event ALTER COLUMN seq SET DEFAULT nextval('stock_rating_event_seq');
+DROP TABLE IF EXISTS stock_rating_event_position;
@@ -0,0 +1,13 @@
+-- Last position given in the change feed, a single row. nextval is not transactional: with several
+-- writers a page numbered lower could commit after a reader of the feed went past it. The writers
+-- now take the positions of their events from this row, in the statement inserting them, and the
+-- update holds the lock of the row until the statement commits. The writers number their events one
+-- after the other and the positions become visible in their order.
+CREATE TABLE IF NOT EXISTS stock_rating_event_position (
+    id INT4 PRIMARY KEY NOT NULL DEFAULT 1 CHECK (id = 1),
+    seq INT8 NOT NULL
+);
+INSERT INTO stock_rating_event_position (id, seq)
+SELECT 1, COALESCE(max(seq), 0) FROM stock_rating_event
+ON CONFLICT (id) DO NOTHING;
+ALTER TABLE stock_rating_event ALTER COLUMN seq DROP DEFAULT;
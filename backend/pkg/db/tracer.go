@@ -0,0 +1,106 @@
+package db
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"backend/pkg/metrics"
+	"backend/pkg/tracing"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Name of the sqlc query, taken from the "-- name: GetStockRatings :many" header sqlc puts in front
+// of every generated statement
+func queryName(sql string) string {
+	const prefix = "-- name: "
+	if !strings.HasPrefix(sql, prefix) {
+		return "unknown"
+	}
+	name := sql[len(prefix):]
+	if i := strings.IndexAny(name, " \n"); i >= 0 {
+		name = name[:i]
+	}
+	return name
+}
+
+// metricsTracer records the latency of every query and copy in the db_query_duration histogram
+type metricsTracer struct{}
+
+type metricsTraceKey struct{}
+type metricsTraceStart struct {
+	name string
+	at   time.Time
+}
+
+func (metricsTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	return context.WithValue(ctx, metricsTraceKey{}, metricsTraceStart{name: queryName(data.SQL), at: time.Now()})
+}
+
+func (metricsTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	if start, ok := ctx.Value(metricsTraceKey{}).(metricsTraceStart); ok {
+		metrics.ObserveDBQuery(start.name, data.Err, time.Since(start.at))
+	}
+}
+
+func (metricsTracer) TraceCopyFromStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceCopyFromStartData) context.Context {
+	name := "CopyFrom " + strings.Join(data.TableName, ".")
+	return context.WithValue(ctx, metricsTraceKey{}, metricsTraceStart{name: name, at: time.Now()})
+}
+
+func (metricsTracer) TraceCopyFromEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceCopyFromEndData) {
+	if start, ok := ctx.Value(metricsTraceKey{}).(metricsTraceStart); ok {
+		metrics.ObserveDBQuery(start.name, data.Err, time.Since(start.at))
+	}
+}
+
+// spanTracer opens an OpenTelemetry span for every query and copy, named after the sqlc query
+type spanTracer struct{}
+
+func (spanTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	name := queryName(data.SQL)
+	ctx, _ = tracing.Tracer().Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system.name", "postgresql"),
+			attribute.String("db.operation.name", name),
+			attribute.String("db.query.text", data.SQL),
+		),
+	)
+	return ctx
+}
+
+func (spanTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	endSpan(ctx, data.CommandTag.RowsAffected(), data.Err)
+}
+
+func (spanTracer) TraceCopyFromStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceCopyFromStartData) context.Context {
+	table := strings.Join(data.TableName, ".")
+	ctx, _ = tracing.Tracer().Start(ctx, "CopyFrom "+table,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system.name", "postgresql"),
+			attribute.String("db.operation.name", "CopyFrom"),
+			attribute.String("db.collection.name", table),
+		),
+	)
+	return ctx
+}
+
+func (spanTracer) TraceCopyFromEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceCopyFromEndData) {
+	endSpan(ctx, data.CommandTag.RowsAffected(), data.Err)
+}
+
+func endSpan(ctx context.Context, rows int64, err error) {
+	span := trace.SpanFromContext(ctx)
+	span.SetAttributes(attribute.Int64("db.response.returned_rows", rows))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,93 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/client_golang/prometheus/push"
+)
+
+// HTTP ============================================================================================
+var (
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "HTTP requests served, by method, route and status code.",
+	}, []string{"method", "route", "status"})
+
+	httpRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "HTTP request latency, by method, route and status code.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+)
+
+// Gin middleware recording the request count and latency of every request. The route label is the
+// gin route pattern (e.g. /v1/stock_ratings/) so path parameters don't blow up the cardinality.
+func GinMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		status := strconv.Itoa(c.Writer.Status())
+		httpRequests.WithLabelValues(c.Request.Method, route, status).Inc()
+		httpRequestDuration.WithLabelValues(c.Request.Method, route, status).Observe(time.Since(start).Seconds())
+	}
+}
+
+// Handler exposing every registered metric in the Prometheus text format
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// DATABASE ========================================================================================
+var dbQueryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "db_query_duration_seconds",
+	Help:    "Database query latency, by sqlc query name and outcome.",
+	Buckets: prometheus.DefBuckets,
+}, []string{"query", "status"})
+
+func ObserveDBQuery(query string, err error, d time.Duration) {
+	status := "ok"
+	if err != nil {
+		status = "error"
+	}
+	dbQueryDuration.WithLabelValues(query, status).Observe(d.Seconds())
+}
+
+// LOADER ==========================================================================================
+var (
+	LoaderPagesFetched = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "loader_pages_fetched_total",
+		Help: "Pages fetched from the upstream ratings API.",
+	})
+
+	LoaderEventsIngested = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "loader_events_ingested_total",
+		Help: "Rating events normalized and inserted into the database.",
+	})
+
+	LoaderEventsRejected = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "loader_events_rejected_total",
+		Help: "Rating events rejected during normalization or insertion, by error kind.",
+	}, []string{"kind"})
+
+	LoaderLastSuccess = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "loader_last_success_timestamp_seconds",
+		Help: "Unix time of the last successful data sync.",
+	})
+)
+
+// Push every registered metric to a Prometheus Pushgateway. Used by short-lived commands such as
+// init_data, which exit before any scrape could happen.
+func Push(url string, job string) error {
+	return push.New(url, job).Gatherer(prometheus.DefaultGatherer).Push()
+}
@@ -0,0 +1,97 @@
+package tracing
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// Stand-in for an OTLP collector, keeping the names of the spans it receives
+type collector struct {
+	mu    sync.Mutex
+	spans []string
+}
+
+func (c *collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var req coltracepb.ExportTraceServiceRequest
+	if err := proto.Unmarshal(body, &req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, rs := range req.ResourceSpans {
+		for _, ss := range rs.ScopeSpans {
+			for _, span := range ss.Spans {
+				c.spans = append(c.spans, span.Name)
+			}
+		}
+	}
+	w.Header().Set("Content-Type", "application/x-protobuf")
+	w.WriteHeader(http.StatusOK)
+}
+
+func TestSetupExportsOverOTLP(t *testing.T) {
+	col := &collector{}
+	srv := httptest.NewServer(col)
+	defer srv.Close()
+	t.Setenv("TRACING_EXPORTER", "otlp")
+	t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", srv.URL+"/v1/traces")
+	defer otel.SetTracerProvider(otel.GetTracerProvider())
+
+	shutdown, err := Setup(context.Background(), "backend-test")
+	if err != nil {
+		t.Fatalf("Setup() failed: %v", err)
+	}
+	_, span := Tracer().Start(context.Background(), "load")
+	span.End()
+
+	// The spans are batched, shutting down must flush them
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown() failed: %v", err)
+	}
+	col.mu.Lock()
+	defer col.mu.Unlock()
+	if len(col.spans) != 1 || col.spans[0] != "load" {
+		t.Errorf("collector received %v, want [load]", col.spans)
+	}
+}
+
+func TestSetupExporters(t *testing.T) {
+	defer otel.SetTracerProvider(otel.GetTracerProvider())
+	cases := []struct {
+		exporter string
+		wantErr  bool
+	}{
+		{"", false},
+		{"none", false},
+		{"stdout", false},
+		{"jaeger", true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.exporter, func(t *testing.T) {
+			t.Setenv("TRACING_EXPORTER", tc.exporter)
+			shutdown, err := Setup(context.Background(), "backend-test")
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Setup() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if err == nil {
+				if err := shutdown(context.Background()); err != nil {
+					t.Errorf("shutdown() failed: %v", err)
+				}
+			}
+		})
+	}
+}